@@ -0,0 +1,121 @@
+package main
+
+import (
+	"sync"
+	"time"
+)
+
+// AlertDebouncer keeps the per-monitor status transition state in memory, so that an alert is only sent
+// when a status change persists for a number of consecutive checks.
+type AlertDebouncer struct {
+	mu     sync.Mutex
+	states map[string]*alertState
+}
+
+type alertState struct {
+	// stable is the latest status that has been alerted, or the initial status of the monitor
+	stable MonitorStatus
+	// candidate is the status that differs from stable, and count is how many consecutive checks it has persisted
+	candidate MonitorStatus
+	count     int
+	// lastUp is the timestamp of the latest check that wasn't a failure
+	lastUp time.Time
+}
+
+// AlertEvent describes a stable status transition of a monitor.
+type AlertEvent struct {
+	Type AlertEventType
+	// Downtime is the time between the last known up check and the recovering check, only set on recovered events.
+	Downtime time.Duration
+}
+
+func NewAlertDebouncer() *AlertDebouncer {
+	return &AlertDebouncer{states: make(map[string]*alertState)}
+}
+
+// Known returns whether the debouncer has a state for the monitor.
+func (d *AlertDebouncer) Known(monitorId string) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	_, ok := d.states[monitorId]
+	return ok
+}
+
+// Seed sets the stable status of the monitor and the timestamp it was observed at,
+// if the monitor doesn't have a state yet.
+func (d *AlertDebouncer) Seed(monitorId string, status MonitorStatus, timestamp time.Time) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if _, ok := d.states[monitorId]; !ok {
+		state := &alertState{stable: status}
+		if status != MonitorStatusFailure {
+			state.lastUp = timestamp
+		}
+
+		d.states[monitorId] = state
+	}
+}
+
+// Observe records the status of a check and returns the event to alert with and true if an alert should be sent,
+// that is when the status has differed from the previously alerted status for alertAfter consecutive checks.
+// The very first observed status of a monitor never produces an alert, as there's nothing to compare to.
+func (d *AlertDebouncer) Observe(monitorId string, status MonitorStatus, timestamp time.Time, alertAfter int) (AlertEvent, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	state, ok := d.states[monitorId]
+	if !ok {
+		state = &alertState{stable: status}
+		if status != MonitorStatusFailure {
+			state.lastUp = timestamp
+		}
+
+		d.states[monitorId] = state
+		return AlertEvent{}, false
+	}
+
+	// The downtime is measured from the last up check before the monitor went down, so it has to be read
+	// before it's updated by the current check.
+	lastUp := state.lastUp
+	if status != MonitorStatusFailure && state.stable != MonitorStatusFailure {
+		state.lastUp = timestamp
+	}
+
+	if status == state.stable {
+		state.count = 0
+		return AlertEvent{}, false
+	}
+
+	if state.count > 0 && status == state.candidate {
+		state.count++
+	} else {
+		state.candidate = status
+		state.count = 1
+	}
+
+	if state.count < alertAfter {
+		return AlertEvent{}, false
+	}
+
+	previous := state.stable
+	state.stable = status
+	state.count = 0
+
+	switch {
+	case status == MonitorStatusFailure:
+		return AlertEvent{Type: AlertEventTypeDown}, true
+	case previous == MonitorStatusFailure:
+		state.lastUp = timestamp
+
+		event := AlertEvent{Type: AlertEventTypeRecovered}
+		if !lastUp.IsZero() {
+			event.Downtime = timestamp.Sub(lastUp)
+		}
+
+		return event, true
+	default:
+		return AlertEvent{Type: AlertEventTypeUp}, true
+	}
+}
@@ -0,0 +1,85 @@
+package main_test
+
+import (
+	"testing"
+	"time"
+
+	main "semyi"
+)
+
+func TestAlertDebouncer(t *testing.T) {
+	up := main.MonitorStatusSuccess
+	down := main.MonitorStatusFailure
+	start := time.Date(2024, 6, 1, 10, 0, 0, 0, time.UTC)
+
+	t.Run("Should ignore flapping", func(t *testing.T) {
+		debouncer := main.NewAlertDebouncer()
+
+		var alerts int
+		for i, status := range []main.MonitorStatus{up, down, up, down, up, down, up} {
+			if _, ok := debouncer.Observe("flapping", status, start.Add(time.Minute*time.Duration(i)), 2); ok {
+				alerts++
+			}
+		}
+
+		if alerts != 0 {
+			t.Errorf("expected no alerts, got %d", alerts)
+		}
+	})
+
+	t.Run("Should alert on stable transitions", func(t *testing.T) {
+		debouncer := main.NewAlertDebouncer()
+
+		var alerted []int
+		var events []main.AlertEvent
+		for i, status := range []main.MonitorStatus{up, down, down, down, up, down, up, up, up} {
+			if event, ok := debouncer.Observe("stable", status, start.Add(time.Minute*time.Duration(i)), 2); ok {
+				alerted = append(alerted, i)
+				events = append(events, event)
+			}
+		}
+
+		// Down is alerted on the second consecutive failure, recovery on the second consecutive success
+		if len(alerted) != 2 || alerted[0] != 2 || alerted[1] != 7 {
+			t.Fatalf("expected alerts at checks [2 7], got %v", alerted)
+		}
+
+		if events[0].Type != main.AlertEventTypeDown {
+			t.Errorf("expected down event, got %s", events[0].Type)
+		}
+
+		// The last up check is at minute 0, the recovering check is at minute 7
+		if events[1].Type != main.AlertEventTypeRecovered || events[1].Downtime != time.Minute*7 {
+			t.Errorf("expected recovered event with 7m downtime, got %+v", events[1])
+		}
+	})
+
+	t.Run("Should send a single recovered event", func(t *testing.T) {
+		debouncer := main.NewAlertDebouncer()
+
+		var events []main.AlertEvent
+		for i, status := range []main.MonitorStatus{up, up, down, down, up, up, up, up} {
+			if event, ok := debouncer.Observe("recovered", status, start.Add(time.Minute*time.Duration(i)), 1); ok {
+				events = append(events, event)
+			}
+		}
+
+		if len(events) != 2 {
+			t.Fatalf("expected 2 events, got %d", len(events))
+		}
+
+		if events[1].Type != main.AlertEventTypeRecovered || events[1].Downtime != time.Minute*3 {
+			t.Errorf("expected recovered event with 3m downtime, got %+v", events[1])
+		}
+	})
+
+	t.Run("Should alert from seeded status", func(t *testing.T) {
+		debouncer := main.NewAlertDebouncer()
+		debouncer.Seed("seeded", up, start)
+
+		event, ok := debouncer.Observe("seeded", down, start.Add(time.Minute), 1)
+		if !ok || event.Type != main.AlertEventTypeDown {
+			t.Errorf("expected a down alert on the first failure, got %+v", event)
+		}
+	})
+}
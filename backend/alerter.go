@@ -13,13 +13,33 @@ type Alerter interface {
 	Send(ctx context.Context, msg AlertMessage) error
 }
 
+// AlertEventType specifies the kind of status transition that an alert is sent for.
+type AlertEventType string
+
+const (
+	AlertEventTypeDown AlertEventType = "down"
+	AlertEventTypeUp   AlertEventType = "up"
+	// AlertEventTypeRecovered is sent once when a monitor goes back up after being down.
+	AlertEventTypeRecovered AlertEventType = "recovered"
+	// AlertEventTypeRepeat is sent every repeat_interval of the escalation policy while a monitor stays down.
+	AlertEventTypeRepeat AlertEventType = "repeat"
+	// AlertEventTypeEscalated is sent once to the escalation webhooks when a monitor stays down for escalate_after.
+	AlertEventTypeEscalated AlertEventType = "escalated"
+)
+
 type AlertMessage struct {
-	Success     bool
-	StatusCode  int
-	Timestamp   time.Time
-	MonitorID   string
-	MonitorName string
-	Latency     int64
+	Success   bool
+	Status    MonitorStatus
+	EventType AlertEventType
+	// Downtime specifies how long the monitor has been down, only set for recovered, repeat, and escalated events.
+	Downtime      time.Duration
+	StatusCode    int
+	Timestamp     time.Time
+	MonitorID     string
+	MonitorName   string
+	Latency       int64
+	TlsExpiryDays int
+	Error         string
 }
 
 type TelegramProvider struct {
@@ -48,6 +68,9 @@ func (t TelegramProvider) Send(ctx context.Context, msg AlertMessage) error {
 	if msg.Success {
 		title = "✅ Up"
 	}
+	if msg.Status == MonitorStatusDegraded {
+		title = "🟡 Degraded"
+	}
 	text := fmt.Sprintf(title+`
 
 	**MonitorID:** %s
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"sort"
 	"sync"
 
 	"github.com/google/uuid"
@@ -29,6 +30,9 @@ import (
 type BrokerCallbackHandler[T any] func(BrokerEvent[T]) error
 
 type BrokerMessage[T any] struct {
+	// ID is assigned by the broker on publish. It's increasing across every topic of the broker,
+	// so it can be used to resume a subscription.
+	ID     uint64
 	Header map[string]string
 	Body   T
 }
@@ -43,8 +47,31 @@ type BrokerEvent[T any] interface {
 type Broker[T any] struct {
 	sync.RWMutex
 	Subscribers map[string][]*BrokerSubscriber[T]
+
+	// history keeps the latest messages of each topic, up to historySize messages per topic
+	history     map[string][]*BrokerMessage[T]
+	historySize int
+	sequence    uint64
+
+	// unchanged drops the messages that are the same as the last published message of the topic, it's nil
+	// when every message is published
+	unchanged func(previous, current T) bool
+	published map[string]T
 }
 
+// BrokerOptions tunes the broker created by NewBrokerWithOptions.
+type BrokerOptions[T any] struct {
+	// HistorySize specifies how many recent messages are kept per topic for replay. Zero disables the replay.
+	HistorySize int
+	// Unchanged reports whether the message is the same as the last published message of the topic. Unchanged
+	// messages aren't sent to the subscribers, they only replace the latest message of the topic. Every message
+	// is published if it's nil.
+	Unchanged func(previous, current T) bool
+}
+
+// DefaultBrokerHistorySize is the amount of recent messages that are kept per topic for replay.
+const DefaultBrokerHistorySize = 100
+
 type memoryEvent[T any] struct {
 	topic   string
 	message *BrokerMessage[T]
@@ -58,10 +85,36 @@ type BrokerSubscriber[T any] struct {
 }
 
 func (m *Broker[T]) Publish(topic string, message *BrokerMessage[T]) error {
-	m.RLock()
+	m.Lock()
+	if m.unchanged != nil {
+		// Compared to the last published message rather than the previous one, so small changes still add up
+		if previous, ok := m.published[topic]; ok && m.unchanged(previous, message.Body) {
+			// The latest message is still replaced, so Latest doesn't report the first of a run of unchanged
+			// messages. It keeps the id of the published message, as the subscribers already have an equivalent one.
+			if history := m.history[topic]; len(history) > 0 {
+				message.ID = history[len(history)-1].ID
+				history[len(history)-1] = message
+			}
+			m.Unlock()
+			return nil
+		}
+
+		m.published[topic] = message.Body
+	}
+
+	m.sequence++
+	message.ID = m.sequence
+
+	if m.historySize > 0 {
+		history := append(m.history[topic], message)
+		if len(history) > m.historySize {
+			history = history[len(history)-m.historySize:]
+		}
+		m.history[topic] = history
+	}
 
 	subs, ok := m.Subscribers[topic]
-	m.RUnlock()
+	m.Unlock()
 	if !ok {
 		return nil
 	}
@@ -109,6 +162,41 @@ func (m *Broker[T]) Subscribe(topic string, callback BrokerCallbackHandler[T]) (
 	return sub, nil
 }
 
+// Replay returns the kept messages of the topics that were published after the message with the given id,
+// ordered by the id.
+func (m *Broker[T]) Replay(afterId uint64, topics ...string) []*BrokerMessage[T] {
+	m.RLock()
+	defer m.RUnlock()
+
+	var messages []*BrokerMessage[T]
+	for _, topic := range topics {
+		for _, message := range m.history[topic] {
+			if message.ID > afterId {
+				messages = append(messages, message)
+			}
+		}
+	}
+
+	sort.Slice(messages, func(i, j int) bool {
+		return messages[i].ID < messages[j].ID
+	})
+
+	return messages
+}
+
+// Latest returns the most recent kept message of the topic.
+func (m *Broker[T]) Latest(topic string) (*BrokerMessage[T], bool) {
+	m.RLock()
+	defer m.RUnlock()
+
+	history := m.history[topic]
+	if len(history) == 0 {
+		return nil, false
+	}
+
+	return history[len(history)-1], true
+}
+
 func (m *memoryEvent[T]) Topic() string {
 	return m.topic
 }
@@ -131,7 +219,22 @@ func (m *BrokerSubscriber[T]) Unsubscribe() error {
 }
 
 func NewBroker[T any]() *Broker[T] {
+	return NewBrokerWithHistory[T](DefaultBrokerHistorySize)
+}
+
+// NewBrokerWithHistory creates a broker that keeps up to historySize recent messages per topic for replay.
+// A zero historySize disables the replay.
+func NewBrokerWithHistory[T any](historySize int) *Broker[T] {
+	return NewBrokerWithOptions(BrokerOptions[T]{HistorySize: historySize})
+}
+
+// NewBrokerWithOptions creates a broker with the given options.
+func NewBrokerWithOptions[T any](options BrokerOptions[T]) *Broker[T] {
 	return &Broker[T]{
 		Subscribers: make(map[string][]*BrokerSubscriber[T]),
+		history:     make(map[string][]*BrokerMessage[T]),
+		historySize: options.HistorySize,
+		unchanged:   options.Unchanged,
+		published:   make(map[string]T),
 	}
 }
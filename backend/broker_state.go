@@ -0,0 +1,142 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// BrokerHeaderStale is set to "true" on the messages that were restored from a previous run, as they may be
+// outdated until the monitor is checked again.
+const BrokerHeaderStale = "stale"
+
+// brokerState is the persisted form of the latest message of every topic.
+type brokerState[T any] struct {
+	SavedAt  time.Time                    `json:"saved_at"`
+	Messages map[string]*BrokerMessage[T] `json:"messages"`
+}
+
+// SaveState writes the latest message of every topic as JSON.
+func (m *Broker[T]) SaveState(w io.Writer) error {
+	m.RLock()
+	state := brokerState[T]{SavedAt: time.Now(), Messages: make(map[string]*BrokerMessage[T], len(m.history))}
+	for topic, history := range m.history {
+		if len(history) > 0 {
+			state.Messages[topic] = history[len(history)-1]
+		}
+	}
+	m.RUnlock()
+
+	return json.NewEncoder(w).Encode(state)
+}
+
+// LoadState restores the messages written by SaveState, marked with the BrokerHeaderStale header. The topics
+// that already have a message are left untouched, and the subscribers are not notified.
+func (m *Broker[T]) LoadState(r io.Reader) error {
+	var state brokerState[T]
+	if err := json.NewDecoder(r).Decode(&state); err != nil {
+		return fmt.Errorf("failed to decode broker state: %w", err)
+	}
+
+	m.Lock()
+	defer m.Unlock()
+
+	if m.historySize <= 0 {
+		return nil
+	}
+
+	for topic, message := range state.Messages {
+		if message == nil || len(m.history[topic]) > 0 {
+			continue
+		}
+
+		header := make(map[string]string, len(message.Header)+1)
+		for key, value := range message.Header {
+			header[key] = value
+		}
+		header[BrokerHeaderStale] = "true"
+		message.Header = header
+
+		// Keep the ids increasing across restarts, so clients resuming with an old id don't miss new messages
+		if message.ID > m.sequence {
+			m.sequence = message.ID
+		}
+
+		m.history[topic] = []*BrokerMessage[T]{message}
+	}
+
+	return nil
+}
+
+// SaveBrokerState persists the state of the broker to the file at path uncompressed. The file is replaced
+// atomically, so a crash while saving won't leave a truncated state behind.
+func SaveBrokerState[T any](path string, broker *Broker[T]) error {
+	return SaveBrokerStateWithCompression(path, broker, CompressionNone)
+}
+
+// SaveBrokerStateWithCompression persists the state of the broker like SaveBrokerState, compressed with
+// the given compression.
+func SaveBrokerStateWithCompression[T any](path string, broker *Broker[T], compression Compression) error {
+	if err := compression.Validate(); err != nil {
+		return err
+	}
+
+	file, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create broker state file: %w", err)
+	}
+	defer os.Remove(file.Name())
+
+	writer, err := compressWriter(file, compression)
+	if err != nil {
+		file.Close()
+		return err
+	}
+
+	err = broker.SaveState(writer)
+	// The compressed stream is only complete once the writer is closed, which is also done when saving failed
+	if closeErr := writer.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("failed to write broker state: %w", err)
+	}
+
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("failed to close broker state file: %w", err)
+	}
+
+	if err := os.Rename(file.Name(), path); err != nil {
+		return fmt.Errorf("failed to replace broker state file: %w", err)
+	}
+
+	return nil
+}
+
+// LoadBrokerState restores the state of the broker from the file at path. A missing file is not an error,
+// as there's nothing to restore on the very first run. The compression is detected from the content, so
+// a state saved with a different compression, or without one, is still restored.
+func LoadBrokerState[T any](path string, broker *Broker[T]) error {
+	file, err := os.Open(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+
+		return fmt.Errorf("failed to open broker state file: %w", err)
+	}
+	defer file.Close()
+
+	reader, err := decompressReader(file)
+	if err != nil {
+		return fmt.Errorf("failed to read broker state: %w", err)
+	}
+	defer reader.Close()
+
+	return broker.LoadState(reader)
+}
@@ -0,0 +1,173 @@
+package main_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	main "semyi"
+)
+
+func TestBrokerState(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "broker_state.json")
+	now := time.Now().Truncate(time.Second).UTC()
+
+	broker := main.NewBroker[main.MonitorHistorical]()
+	for _, historical := range []main.MonitorHistorical{
+		{MonitorID: "state-a", Status: main.MonitorStatusSuccess, Latency: 10, Timestamp: now.Add(-time.Minute)},
+		{MonitorID: "state-a", Status: main.MonitorStatusFailure, Latency: 20, Timestamp: now, ErrorCategory: main.ErrorCategoryTimeout},
+		{MonitorID: "state-b", Status: main.MonitorStatusDegraded, Latency: 30, Timestamp: now},
+	} {
+		err := broker.Publish(historical.MonitorID, &main.BrokerMessage[main.MonitorHistorical]{Body: historical})
+		if err != nil {
+			t.Fatalf("unexpected error publishing: %v", err)
+		}
+	}
+
+	if err := main.SaveBrokerState(path, broker); err != nil {
+		t.Fatalf("unexpected error saving broker state: %v", err)
+	}
+
+	restored := main.NewBroker[main.MonitorHistorical]()
+	if err := main.LoadBrokerState(path, restored); err != nil {
+		t.Fatalf("unexpected error loading broker state: %v", err)
+	}
+
+	tests := []struct {
+		topic  string
+		id     uint64
+		status main.MonitorStatus
+		// latency tells the latest message apart from the previous ones
+		latency int64
+	}{
+		{topic: "state-a", id: 2, status: main.MonitorStatusFailure, latency: 20},
+		{topic: "state-b", id: 3, status: main.MonitorStatusDegraded, latency: 30},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.topic, func(t *testing.T) {
+			message, ok := restored.Latest(tt.topic)
+			if !ok {
+				t.Fatalf("expected the latest message of %s to be restored", tt.topic)
+			}
+
+			if message.ID != tt.id || message.Body.Status != tt.status || message.Body.Latency != tt.latency {
+				t.Errorf("expected id %d, status %s, and latency %d, got %+v", tt.id, tt.status, tt.latency, message)
+			}
+
+			if !message.Body.Timestamp.Equal(now) {
+				t.Errorf("expected timestamp %s, got %s", now, message.Body.Timestamp)
+			}
+
+			if message.Header[main.BrokerHeaderStale] != "true" {
+				t.Errorf("expected the message to be marked as stale, got headers %v", message.Header)
+			}
+		})
+	}
+
+	if messages := restored.Replay(0, "state-a"); len(messages) != 1 {
+		t.Errorf("expected only the latest message to be restored, got %d", len(messages))
+	}
+
+	// New messages are not stale, and keep increasing the ids
+	err := restored.Publish("state-a", &main.BrokerMessage[main.MonitorHistorical]{Body: main.MonitorHistorical{MonitorID: "state-a"}})
+	if err != nil {
+		t.Fatalf("unexpected error publishing: %v", err)
+	}
+
+	message, _ := restored.Latest("state-a")
+	if message.ID != 4 || message.Header[main.BrokerHeaderStale] != "" {
+		t.Errorf("expected a fresh message with id 4, got %+v", message)
+	}
+
+	t.Run("Missing file", func(t *testing.T) {
+		if err := main.LoadBrokerState(filepath.Join(t.TempDir(), "missing.json"), main.NewBroker[main.MonitorHistorical]()); err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+}
+
+func TestBrokerState_Compression(t *testing.T) {
+	now := time.Now().Truncate(time.Second).UTC()
+	broker := main.NewBroker[main.MonitorHistorical]()
+	for i := 0; i < 50; i++ {
+		historical := main.MonitorHistorical{
+			MonitorID: fmt.Sprintf("state-compression-%d", i),
+			Status:    main.MonitorStatusSuccess,
+			Latency:   int64(i),
+			Timestamp: now,
+			Protocol:  "HTTP/2.0",
+		}
+		err := broker.Publish(historical.MonitorID, &main.BrokerMessage[main.MonitorHistorical]{Body: historical})
+		if err != nil {
+			t.Fatalf("unexpected error publishing: %v", err)
+		}
+	}
+
+	dir := t.TempDir()
+	legacyPath := filepath.Join(dir, "legacy.json")
+	if err := main.SaveBrokerState(legacyPath, broker); err != nil {
+		t.Fatalf("unexpected error saving broker state: %v", err)
+	}
+
+	legacy, err := os.Stat(legacyPath)
+	if err != nil {
+		t.Fatalf("unexpected error reading broker state: %v", err)
+	}
+
+	tests := []struct {
+		name        string
+		compression main.Compression
+	}{
+		{name: "uncompressed", compression: main.CompressionNone},
+		{name: "gzip", compression: main.CompressionGzip},
+		{name: "zstd", compression: main.CompressionZstd},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(dir, tt.name+".json")
+			if err := main.SaveBrokerStateWithCompression(path, broker, tt.compression); err != nil {
+				t.Fatalf("unexpected error saving broker state: %v", err)
+			}
+
+			if tt.compression != main.CompressionNone {
+				saved, err := os.Stat(path)
+				if err != nil {
+					t.Fatalf("unexpected error reading broker state: %v", err)
+				}
+
+				if saved.Size() >= legacy.Size() {
+					t.Errorf("expected the compressed state to be smaller than %d bytes, got %d", legacy.Size(), saved.Size())
+				}
+			}
+
+			// The state saved by a previous version has no compression, it's restored along with the compressed one
+			for _, source := range []string{path, legacyPath} {
+				restored := main.NewBroker[main.MonitorHistorical]()
+				if err := main.LoadBrokerState(source, restored); err != nil {
+					t.Fatalf("unexpected error loading %s: %v", filepath.Base(source), err)
+				}
+
+				for i := 0; i < 50; i++ {
+					message, ok := restored.Latest(fmt.Sprintf("state-compression-%d", i))
+					if !ok {
+						t.Fatalf("expected monitor %d to be restored from %s", i, filepath.Base(source))
+					}
+
+					if message.Body.Latency != int64(i) || message.Body.Protocol != "HTTP/2.0" || !message.Body.Timestamp.Equal(now) {
+						t.Errorf("expected the snapshot of monitor %d to be restored, got %+v", i, message.Body)
+					}
+				}
+			}
+		})
+	}
+
+	t.Run("unknown compression", func(t *testing.T) {
+		if err := main.SaveBrokerStateWithCompression(filepath.Join(dir, "unknown.json"), broker, "brotli"); err == nil {
+			t.Error("expected an error for an unknown compression")
+		}
+	})
+}
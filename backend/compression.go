@@ -0,0 +1,125 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// Compression specifies how the persisted broker state and historical data are compressed.
+type Compression string
+
+const (
+	CompressionNone Compression = ""
+	CompressionGzip Compression = "gzip"
+	CompressionZstd Compression = "zstd"
+)
+
+// The magic numbers that the compressed data start with, so it can be told apart from the uncompressed data
+var (
+	gzipMagic = []byte{0x1f, 0x8b}
+	zstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+)
+
+// Validate checks that the compression is either empty, "gzip", or "zstd".
+func (c Compression) Validate() error {
+	switch c {
+	case CompressionNone, CompressionGzip, CompressionZstd:
+		return nil
+	}
+
+	return fmt.Errorf("compression must be either gzip or zstd, got %q", string(c))
+}
+
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error {
+	return nil
+}
+
+// compressWriter returns a writer that compresses to w. The compressed stream is only complete once the
+// writer is closed, closing it doesn't close w.
+func compressWriter(w io.Writer, compression Compression) (io.WriteCloser, error) {
+	switch compression {
+	case CompressionNone:
+		return nopWriteCloser{w}, nil
+	case CompressionGzip:
+		return gzip.NewWriter(w), nil
+	case CompressionZstd:
+		writer, err := zstd.NewWriter(w)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create zstd writer: %w", err)
+		}
+
+		return writer, nil
+	}
+
+	return nil, compression.Validate()
+}
+
+// decompressReader returns a reader of the decompressed r. The compression is detected from the content, the
+// content that doesn't start with a known magic number is read as is.
+func decompressReader(r io.Reader) (io.ReadCloser, error) {
+	reader := bufio.NewReader(r)
+	magic, _ := reader.Peek(len(zstdMagic))
+	switch {
+	case bytes.HasPrefix(magic, gzipMagic):
+		gzipReader, err := gzip.NewReader(reader)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read gzip data: %w", err)
+		}
+
+		return gzipReader, nil
+	case bytes.HasPrefix(magic, zstdMagic):
+		zstdReader, err := zstd.NewReader(reader)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read zstd data: %w", err)
+		}
+
+		return zstdReader.IOReadCloser(), nil
+	}
+
+	return io.NopCloser(reader), nil
+}
+
+// compress compresses data in memory.
+func compress(data []byte, compression Compression) ([]byte, error) {
+	var buffer bytes.Buffer
+	writer, err := compressWriter(&buffer, compression)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := writer.Write(data); err != nil {
+		writer.Close()
+		return nil, fmt.Errorf("failed to compress data: %w", err)
+	}
+
+	if err := writer.Close(); err != nil {
+		return nil, fmt.Errorf("failed to compress data: %w", err)
+	}
+
+	return buffer.Bytes(), nil
+}
+
+// decompress decompresses data compressed by compress with any compression.
+func decompress(data []byte) ([]byte, error) {
+	reader, err := decompressReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	decompressed, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress data: %w", err)
+	}
+
+	return decompressed, nil
+}
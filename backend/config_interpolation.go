@@ -0,0 +1,113 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+)
+
+// expandEnvironment substitutes every "${NAME}" reference in the value with the value of the environment
+// variable, and "$$" with a literal "$". It returns an error if a referenced variable is not set.
+func expandEnvironment(value string) (string, error) {
+	if !strings.Contains(value, "$") {
+		return value, nil
+	}
+
+	var builder strings.Builder
+	for i := 0; i < len(value); i++ {
+		if value[i] != '$' || i+1 >= len(value) {
+			builder.WriteByte(value[i])
+			continue
+		}
+
+		switch value[i+1] {
+		case '$':
+			builder.WriteByte('$')
+			i++
+		case '{':
+			end := strings.IndexByte(value[i+2:], '}')
+			if end < 0 {
+				return "", fmt.Errorf("unterminated environment variable reference in %q", value)
+			}
+
+			name := value[i+2 : i+2+end]
+			resolved, ok := os.LookupEnv(name)
+			if !ok {
+				return "", fmt.Errorf("environment variable %s is not set", name)
+			}
+
+			builder.WriteString(resolved)
+			i += end + 2
+		default:
+			builder.WriteByte('$')
+		}
+	}
+
+	return builder.String(), nil
+}
+
+// interpolateEnvironment expands the environment variable references of every string value reachable from v,
+// which must be a pointer. Struct fields tagged with `interpolate:"false"` are left as is.
+func interpolateEnvironment(v any) error {
+	return interpolateValue(reflect.ValueOf(v).Elem(), "")
+}
+
+func interpolateValue(v reflect.Value, path string) error {
+	switch v.Kind() {
+	case reflect.String:
+		expanded, err := expandEnvironment(v.String())
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+
+		v.SetString(expanded)
+	case reflect.Pointer:
+		if !v.IsNil() {
+			return interpolateValue(v.Elem(), path)
+		}
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if !field.IsExported() || field.Tag.Get("interpolate") == "false" {
+				continue
+			}
+
+			name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+			if name == "" {
+				name = field.Name
+			}
+
+			if path != "" {
+				name = path + "." + name
+			}
+
+			if err := interpolateValue(v.Field(i), name); err != nil {
+				return err
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			if err := interpolateValue(v.Index(i), fmt.Sprintf("%s[%d]", path, i)); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		if v.Type().Elem().Kind() != reflect.String {
+			return nil
+		}
+
+		iter := v.MapRange()
+		for iter.Next() {
+			expanded, err := expandEnvironment(iter.Value().String())
+			if err != nil {
+				return fmt.Errorf("%s.%v: %w", path, iter.Key(), err)
+			}
+
+			v.SetMapIndex(iter.Key(), reflect.ValueOf(expanded).Convert(v.Type().Elem()))
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,285 @@
+package main_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	main "semyi"
+)
+
+func TestValidateConfig(t *testing.T) {
+	valid := main.Monitor{UniqueID: "valid", Name: "Valid", Type: main.MonitorTypeHTTP, HttpEndpoint: "https://example.com", Interval: 30, Timeout: 10}
+
+	testCases := []struct {
+		name     string
+		monitors []main.Monitor
+		webhooks []main.Webhook
+		expect   []string
+	}{
+		{
+			name:     "Valid",
+			monitors: []main.Monitor{valid},
+		},
+		{
+			name: "Duplicate unique id",
+			monitors: []main.Monitor{
+				valid,
+				{UniqueID: "valid", Name: "Duplicate", Type: main.MonitorTypeHTTP, HttpEndpoint: "https://example.com"},
+			},
+			expect: []string{`monitors[1] (Duplicate): duplicate unique_id "valid"`},
+		},
+		{
+			name:     "Empty URL",
+			monitors: []main.Monitor{{UniqueID: "empty-url", Name: "Empty URL", Type: main.MonitorTypeHTTP}},
+			expect:   []string{"monitors[0] (Empty URL): http_endpoint is required for http monitors"},
+		},
+		{
+			name:     "Non-positive timeout",
+			monitors: []main.Monitor{{UniqueID: "timeout", Name: "Timeout", Type: main.MonitorTypeHTTP, HttpEndpoint: "https://example.com", Timeout: -1}},
+			expect:   []string{"monitors[0] (Timeout): timeout must be greater than 0"},
+		},
+		{
+			name:     "Non-positive interval",
+			monitors: []main.Monitor{{UniqueID: "interval", Name: "Interval", Type: main.MonitorTypeHTTP, HttpEndpoint: "https://example.com", Interval: -5, Timeout: 1}},
+			expect:   []string{"monitors[0] (Interval): interval must be greater than 0"},
+		},
+		{
+			name:     "Timeout greater than interval",
+			monitors: []main.Monitor{{UniqueID: "slow", Name: "Slow", Type: main.MonitorTypeHTTP, HttpEndpoint: "https://example.com", Interval: 5, Timeout: 10}},
+			expect:   []string{"monitors[0] (Slow): timeout (10s) must not be greater than interval (5s)"},
+		},
+		{
+			name:     "Unknown monitor type",
+			monitors: []main.Monitor{{UniqueID: "unknown", Name: "Unknown", Type: "smtp"}},
+			expect:   []string{`monitors[0] (Unknown): unknown monitor type "smtp"`},
+		},
+		{
+			name:     "Invalid webhook",
+			monitors: []main.Monitor{valid},
+			webhooks: []main.Webhook{{URL: "https://example.com"}},
+			expect:   []string{"webhooks[0]: failed_response and success_response cannot both be false"},
+		},
+		{
+			name: "Every problem is listed",
+			monitors: []main.Monitor{
+				{Name: "Nameless", Type: "smtp", Timeout: -1},
+				{UniqueID: "no-name", Type: main.MonitorTypeHTTP},
+			},
+			expect: []string{
+				"monitors[0] (Nameless): unique_id is required",
+				`monitors[0] (Nameless): unknown monitor type "smtp"`,
+				"monitors[0] (Nameless): timeout must be greater than 0",
+				"monitors[1]: name is required",
+				"monitors[1]: http_endpoint is required for http monitors",
+			},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			err := main.ValidateConfig(main.ConfigurationFile{Monitors: testCase.monitors, Webhooks: testCase.webhooks})
+			if len(testCase.expect) == 0 {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+
+			var validationError *main.ValidationError
+			if !errors.As(err, &validationError) {
+				t.Fatalf("expected an error of type ValidationError, got %T", err)
+			}
+
+			var issues []string
+			for _, issue := range validationError.Issues {
+				issues = append(issues, issue.Field+": "+issue.Message)
+			}
+
+			if strings.Join(issues, "\n") != strings.Join(testCase.expect, "\n") {
+				t.Errorf("expected issues:\n%s\ngot:\n%s", strings.Join(testCase.expect, "\n"), strings.Join(issues, "\n"))
+			}
+		})
+	}
+}
+
+func TestValidateConfig_ExampleConfig(t *testing.T) {
+	config, err := main.ReadConfigurationFile("../config.json")
+	if err != nil {
+		t.Fatalf("unexpected error reading config: %v", err)
+	}
+
+	if err := main.ValidateConfig(config); err != nil {
+		t.Errorf("expected the example config to be valid, got %v", err)
+	}
+}
+
+func TestReadConfigurationFile_YAML(t *testing.T) {
+	dir := t.TempDir()
+
+	jsonPath := filepath.Join(dir, "config.json")
+	err := os.WriteFile(jsonPath, []byte(`{
+		"monitors": [
+			{
+				"unique_id": "yaml-test",
+				"name": "YAML Test",
+				"type": "http",
+				"http_endpoint": "https://example.com",
+				"http_headers": {"Accept": "application/json"},
+				"http_expected_status_code": "2xx",
+				"interval": 30,
+				"alert_provider": "telegram",
+				"webhook": false
+			}
+		],
+		"webhooks": [{"url": "https://example.com/webhook", "failed_response": true, "max_retries": 5}]
+	}`), 0o644)
+	if err != nil {
+		t.Fatalf("unexpected error writing config: %v", err)
+	}
+
+	yamlPath := filepath.Join(dir, "config.yaml")
+	err = os.WriteFile(yamlPath, []byte(`# Comments are allowed in YAML
+monitors:
+  - unique_id: yaml-test
+    name: YAML Test
+    type: http
+    http_endpoint: https://example.com
+    http_headers:
+      Accept: application/json
+    http_expected_status_code: 2xx
+    interval: 30
+    alert_provider: telegram
+    webhook: false
+webhooks:
+  - url: https://example.com/webhook
+    failed_response: true
+    max_retries: 5
+`), 0o644)
+	if err != nil {
+		t.Fatalf("unexpected error writing config: %v", err)
+	}
+
+	jsonConfig, err := main.ReadConfigurationFile(jsonPath)
+	if err != nil {
+		t.Fatalf("unexpected error reading json config: %v", err)
+	}
+
+	yamlConfig, err := main.ReadConfigurationFile(yamlPath)
+	if err != nil {
+		t.Fatalf("unexpected error reading yaml config: %v", err)
+	}
+
+	if !reflect.DeepEqual(jsonConfig, yamlConfig) {
+		t.Errorf("expected identical configs, got\njson: %+v\nyaml: %+v", jsonConfig, yamlConfig)
+	}
+
+	if yamlConfig.Monitors[0].AlertProvider != main.AlertProviderTypeTelegram {
+		t.Errorf("expected alert provider telegram, got %q", yamlConfig.Monitors[0].AlertProvider)
+	}
+}
+
+func TestReadConfigurationFile_Ambiguous(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"config.json", "config.yaml"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("{}"), 0o644); err != nil {
+			t.Fatalf("unexpected error writing config: %v", err)
+		}
+	}
+
+	// The default configuration files are looked up in the parent of the working directory
+	workingDir := filepath.Join(dir, "backend")
+	if err := os.Mkdir(workingDir, 0o755); err != nil {
+		t.Fatalf("unexpected error creating directory: %v", err)
+	}
+
+	previousDir, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("unexpected error getting working directory: %v", err)
+	}
+
+	if err := os.Chdir(workingDir); err != nil {
+		t.Fatalf("unexpected error changing directory: %v", err)
+	}
+	defer os.Chdir(previousDir)
+
+	_, err = main.ReadConfigurationFile("")
+	if err == nil || !strings.Contains(err.Error(), "multiple configuration files found") {
+		t.Errorf("expected a multiple configuration files error, got %v", err)
+	}
+}
+
+func TestReadConfigurationFile_Interpolation(t *testing.T) {
+	t.Setenv("SEMYA_TEST_WEBHOOK_URL", "https://example.com/webhook")
+	t.Setenv("SEMYA_TEST_WEBHOOK_SECRET", "s3cret")
+	t.Setenv("SEMYA_TEST_HOST", "example.com")
+	t.Setenv("SEMYA_TEST_API_KEY", "key-from-env")
+
+	writeConfig := func(t *testing.T, content string) string {
+		configPath := filepath.Join(t.TempDir(), "config.json")
+		if err := os.WriteFile(configPath, []byte(content), 0o644); err != nil {
+			t.Fatalf("unexpected error writing config: %v", err)
+		}
+		return configPath
+	}
+
+	t.Run("Should substitute environment variables", func(t *testing.T) {
+		config, err := main.ReadConfigurationFile(writeConfig(t, `{
+			"monitors": [{
+				"unique_id": "interpolation-test",
+				"name": "Interpolation Test",
+				"type": "http",
+				"http_endpoint": "https://${SEMYA_TEST_HOST}/health",
+				"http_headers": {"X-Api-Key": "${SEMYA_TEST_API_KEY}"},
+				"bearer_token": "${SEMYA_TEST_API_KEY}"
+			}],
+			"webhooks": [{"url": "${SEMYA_TEST_WEBHOOK_URL}", "secret": "${SEMYA_TEST_WEBHOOK_SECRET}", "failed_response": true}]
+		}`))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		monitor := config.Monitors[0]
+		if monitor.HttpEndpoint != "https://example.com/health" {
+			t.Errorf("unexpected http_endpoint: %s", monitor.HttpEndpoint)
+		}
+
+		if monitor.HttpHeaders["X-Api-Key"] != "key-from-env" {
+			t.Errorf("unexpected header value: %s", monitor.HttpHeaders["X-Api-Key"])
+		}
+
+		// Authentication values are resolved by the worker
+		if monitor.HttpBearerToken != "${SEMYA_TEST_API_KEY}" {
+			t.Errorf("expected bearer_token to be left as is, got %s", monitor.HttpBearerToken)
+		}
+
+		if config.Webhooks[0].URL != "https://example.com/webhook" || config.Webhooks[0].Secret != "s3cret" {
+			t.Errorf("unexpected webhook: %+v", config.Webhooks[0])
+		}
+	})
+
+	t.Run("Should fail on missing environment variable", func(t *testing.T) {
+		_, err := main.ReadConfigurationFile(writeConfig(t, `{
+			"monitors": [{"unique_id": "a", "name": "A", "type": "http", "http_endpoint": "https://${SEMYA_TEST_UNSET_HOST}"}]
+		}`))
+		if err == nil || !strings.Contains(err.Error(), "monitors[0].http_endpoint: environment variable SEMYA_TEST_UNSET_HOST is not set") {
+			t.Errorf("expected a missing environment variable error, got %v", err)
+		}
+	})
+
+	t.Run("Should unescape double dollar signs", func(t *testing.T) {
+		config, err := main.ReadConfigurationFile(writeConfig(t, `{
+			"monitors": [{"unique_id": "a", "name": "Costs $$5 and $${SEMYA_TEST_HOST}", "type": "http", "http_endpoint": "https://example.com"}]
+		}`))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if config.Monitors[0].Name != "Costs $5 and ${SEMYA_TEST_HOST}" {
+			t.Errorf("unexpected name: %s", config.Monitors[0].Name)
+		}
+	})
+}
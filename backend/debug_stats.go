@@ -0,0 +1,174 @@
+package main
+
+import (
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// CheckStats keeps the live counters of the checks of every monitor, for the diagnostics endpoint.
+type CheckStats struct {
+	mu       sync.RWMutex
+	monitors map[string]MonitorCheckStats
+}
+
+// MonitorCheckStats is the state of the checks of a single monitor.
+type MonitorCheckStats struct {
+	// LastCheck specifies when the latest check completed, it's nil if the monitor hasn't been checked yet.
+	LastCheck *time.Time `json:"last_check"`
+	// ConsecutiveFailures specifies how many checks in a row have failed, including the ones that couldn't be
+	// made at all.
+	ConsecutiveFailures int    `json:"consecutive_failures"`
+	Checks              uint64 `json:"checks"`
+}
+
+func NewCheckStats() *CheckStats {
+	return &CheckStats{monitors: make(map[string]MonitorCheckStats)}
+}
+
+// Observe records a completed check of the monitor. A non-nil err means the check couldn't be made.
+func (s *CheckStats) Observe(monitorId string, response Response, err error) {
+	timestamp := response.Timestamp
+	if err != nil || timestamp.IsZero() {
+		timestamp = time.Now()
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stats := s.monitors[monitorId]
+	stats.LastCheck = &timestamp
+	stats.Checks++
+	if err != nil || !response.Success {
+		stats.ConsecutiveFailures++
+	} else {
+		stats.ConsecutiveFailures = 0
+	}
+
+	s.monitors[monitorId] = stats
+}
+
+// Get returns the counters of the monitor.
+func (s *CheckStats) Get(monitorId string) MonitorCheckStats {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.monitors[monitorId]
+}
+
+// SubscriberRegistry keeps track of the open subscribers, and of the events dropped by every subscriber
+// including the closed ones.
+type SubscriberRegistry struct {
+	mu          sync.Mutex
+	subscribers map[*Subscriber]struct{}
+	dropped     atomic.Uint64
+}
+
+// SubscriberStats summarizes the buffers of the open subscribers.
+type SubscriberStats struct {
+	Active int `json:"active"`
+	// Queued specifies the amount of events that are buffered across every open subscriber.
+	Queued int `json:"queued"`
+	// Dropped specifies the amount of events that were dropped since the start, including the events of the
+	// subscribers that are closed.
+	Dropped uint64                 `json:"dropped"`
+	Queues  []SubscriberQueueStats `json:"queues"`
+}
+
+// SubscriberQueueStats is the buffer of a single open subscriber.
+type SubscriberQueueStats struct {
+	Topics   int    `json:"topics"`
+	Queued   int    `json:"queued"`
+	Capacity int    `json:"capacity"`
+	Dropped  uint64 `json:"dropped"`
+}
+
+func NewSubscriberRegistry() *SubscriberRegistry {
+	return &SubscriberRegistry{subscribers: make(map[*Subscriber]struct{})}
+}
+
+func (r *SubscriberRegistry) add(subscriber *Subscriber) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.subscribers[subscriber] = struct{}{}
+}
+
+func (r *SubscriberRegistry) remove(subscriber *Subscriber) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.subscribers, subscriber)
+}
+
+// Stats returns the state of the open subscribers, the fullest queues first.
+func (r *SubscriberRegistry) Stats() SubscriberStats {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stats := SubscriberStats{
+		Active:  len(r.subscribers),
+		Dropped: r.dropped.Load(),
+		Queues:  make([]SubscriberQueueStats, 0, len(r.subscribers)),
+	}
+	for subscriber := range r.subscribers {
+		queue := SubscriberQueueStats{
+			Topics:   len(subscriber.subscribers),
+			Queued:   len(subscriber.ch),
+			Capacity: cap(subscriber.ch),
+			Dropped:  subscriber.Dropped(),
+		}
+		stats.Queued += queue.Queued
+		stats.Queues = append(stats.Queues, queue)
+	}
+
+	sort.Slice(stats.Queues, func(i, j int) bool {
+		return stats.Queues[i].Queued > stats.Queues[j].Queued
+	})
+
+	return stats
+}
+
+// BrokerTopicStats is the state of a single topic of the broker.
+type BrokerTopicStats struct {
+	Topic       string `json:"topic"`
+	Subscribers int    `json:"subscribers"`
+	// History specifies the amount of messages that are kept for replay.
+	History int `json:"history"`
+}
+
+// Stats returns the state of every topic that has subscribers or kept messages, sorted by the topic.
+func (m *Broker[T]) Stats() []BrokerTopicStats {
+	m.RLock()
+	defer m.RUnlock()
+
+	topics := make(map[string]*BrokerTopicStats)
+	for topic, subscribers := range m.Subscribers {
+		if len(subscribers) > 0 {
+			topics[topic] = &BrokerTopicStats{Topic: topic, Subscribers: len(subscribers)}
+		}
+	}
+
+	for topic, history := range m.history {
+		if len(history) == 0 {
+			continue
+		}
+
+		if _, ok := topics[topic]; !ok {
+			topics[topic] = &BrokerTopicStats{Topic: topic}
+		}
+		topics[topic].History = len(history)
+	}
+
+	stats := make([]BrokerTopicStats, 0, len(topics))
+	for _, topic := range topics {
+		stats = append(stats, *topic)
+	}
+
+	sort.Slice(stats, func(i, j int) bool {
+		return stats[i].Topic < stats[j].Topic
+	})
+
+	return stats
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// DigestConfig specifies the periodic summary of every monitor, for the teams that would rather read a digest
+// than an alert for every status change.
+type DigestConfig struct {
+	// Interval specifies how often the digest is sent, e.g. "1d" or "12h". Each digest covers the interval
+	// that just passed. Defaults to "1d".
+	Interval string `json:"interval" yaml:"interval" toml:"interval"`
+	// Webhooks specifies the destinations of the digest. The digest is always sent as a DigestPayload,
+	// regardless of the format of the webhook.
+	Webhooks []Webhook `json:"webhooks" yaml:"webhooks" toml:"webhooks"`
+}
+
+// ParseInterval parses the interval of the digest, with the same units as the retention durations.
+func (c DigestConfig) ParseInterval() (time.Duration, error) {
+	if c.Interval == "" {
+		return time.Hour * 24, nil
+	}
+
+	return ParseRetention(c.Interval)
+}
+
+// DigestPayload is the JSON body that is sent to the digest webhooks.
+type DigestPayload struct {
+	// Type is always "digest", to tell it apart from the alerts on a shared endpoint.
+	Type string `json:"type"`
+	// From and To specify the period of the digest in unix seconds.
+	From     int64                  `json:"from"`
+	To       int64                  `json:"to"`
+	Monitors []DigestMonitorSummary `json:"monitors"`
+	// WorstPerformer is the unique ID of the monitor with the lowest uptime. It's empty when there's no data.
+	WorstPerformer string `json:"worstPerformer,omitempty"`
+}
+
+// DigestMonitorSummary is the aggregate of a monitor within the period of the digest.
+type DigestMonitorSummary struct {
+	MonitorID string `json:"monitorId"`
+	Name      string `json:"name"`
+	// Uptime specifies the uptime percentage, rounded to two decimal places. It's nil when there's no data.
+	Uptime *float64 `json:"uptime"`
+	Checks int      `json:"checks"`
+	// Outages specifies the amount of detected outages within the period, including the ongoing one.
+	Outages int `json:"outages"`
+	// AverageLatency specifies the average latency of the checks in milliseconds.
+	AverageLatency int64 `json:"averageLatency"`
+}
+
+// DigestReporter periodically sends the digest of the monitors in the registry.
+type DigestReporter struct {
+	historicalReader *MonitorHistoricalReader
+	monitors         *MonitorRegistry
+	providers        []*WebhookProvider
+	interval         time.Duration
+}
+
+func NewDigestReporter(historicalReader *MonitorHistoricalReader, monitors *MonitorRegistry, config DigestConfig) (*DigestReporter, error) {
+	interval, err := config.ParseInterval()
+	if err != nil {
+		return nil, fmt.Errorf("invalid digest interval: %w", err)
+	}
+
+	return &DigestReporter{
+		historicalReader: historicalReader,
+		monitors:         monitors,
+		providers:        newWebhookProviders(config.Webhooks, WebhookDispatcherOptions{}),
+		interval:         interval,
+	}, nil
+}
+
+// Run sends the digest on every interval until the context is cancelled.
+func (d *DigestReporter) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			if err := d.Send(ctx, now); err != nil {
+				log.Error().Err(err).Msg("failed to send digest")
+			}
+		}
+	}
+}
+
+// Send sends the digest of the interval that ends at now to every webhook.
+func (d *DigestReporter) Send(ctx context.Context, now time.Time) error {
+	digest, err := d.Build(ctx, now)
+	if err != nil {
+		return err
+	}
+
+	payload, err := json.Marshal(digest)
+	if err != nil {
+		return fmt.Errorf("failed to marshal digest payload: %w", err)
+	}
+
+	var errs []error
+	for _, provider := range d.providers {
+		if err := provider.deliver(ctx, "", payload); err != nil {
+			errs = append(errs, fmt.Errorf("webhook %s: %w", provider.url, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// Build aggregates the raw historical data of every monitor within the interval that ends at now.
+func (d *DigestReporter) Build(ctx context.Context, now time.Time) (DigestPayload, error) {
+	from := now.Add(-d.interval)
+	digest := DigestPayload{
+		Type:     "digest",
+		From:     from.Unix(),
+		To:       now.Unix(),
+		Monitors: []DigestMonitorSummary{},
+	}
+
+	worst := -1
+	for _, monitor := range d.monitors.List() {
+		historical, err := d.historicalReader.ReadRawHistoricalRange(ctx, monitor.UniqueID, from, now)
+		if err != nil {
+			return DigestPayload{}, fmt.Errorf("failed to read historical data of %s: %w", monitor.UniqueID, err)
+		}
+
+		uptime := CalculateUptime(historical)
+		summary := DigestMonitorSummary{
+			MonitorID: monitor.UniqueID,
+			Name:      monitor.Name,
+			Uptime:    uptime.Uptime,
+			Checks:    uptime.Total,
+			Outages:   len(GroupOutages(historical, 0)),
+		}
+
+		var totalLatency int64
+		for _, h := range historical {
+			totalLatency += h.Latency
+		}
+		if len(historical) > 0 {
+			summary.AverageLatency = totalLatency / int64(len(historical))
+		}
+
+		// Ties are broken by the amount of outages
+		if summary.Uptime != nil {
+			if worst < 0 || *summary.Uptime < *digest.Monitors[worst].Uptime ||
+				(*summary.Uptime == *digest.Monitors[worst].Uptime && summary.Outages > digest.Monitors[worst].Outages) {
+				worst = len(digest.Monitors)
+			}
+		}
+
+		digest.Monitors = append(digest.Monitors, summary)
+	}
+
+	if worst >= 0 {
+		digest.WorstPerformer = digest.Monitors[worst].MonitorID
+	}
+
+	return digest, nil
+}
@@ -0,0 +1,113 @@
+package main_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	main "semyi"
+)
+
+func TestDigestReporter_Send(t *testing.T) {
+	writer := main.NewMonitorHistoricalWriter(database)
+	now := time.Now().Truncate(time.Second)
+	for _, historical := range []main.MonitorHistorical{
+		{MonitorID: "digest-stable", Status: main.MonitorStatusSuccess, Latency: 10, Timestamp: now.Add(-time.Hour * 4)},
+		{MonitorID: "digest-stable", Status: main.MonitorStatusDegraded, Latency: 30, Timestamp: now.Add(-time.Hour * 3)},
+		{MonitorID: "digest-flaky", Status: main.MonitorStatusFailure, Latency: 100, Timestamp: now.Add(-time.Hour * 5)},
+		{MonitorID: "digest-flaky", Status: main.MonitorStatusSuccess, Latency: 20, Timestamp: now.Add(-time.Hour * 4)},
+		{MonitorID: "digest-flaky", Status: main.MonitorStatusFailure, Latency: 100, Timestamp: now.Add(-time.Hour * 3)},
+		{MonitorID: "digest-flaky", Status: main.MonitorStatusSuccess, Latency: 20, Timestamp: now.Add(-time.Hour * 2)},
+		// Outside the period of the digest
+		{MonitorID: "digest-stable", Status: main.MonitorStatusFailure, Latency: 10, Timestamp: now.Add(-time.Hour * 30)},
+	} {
+		if err := writer.Write(context.Background(), historical); err != nil {
+			t.Fatalf("unexpected error writing historical data: %v", err)
+		}
+	}
+
+	received := make(chan main.DigestPayload, 1)
+	webhookServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload main.DigestPayload
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		received <- payload
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer webhookServer.Close()
+
+	registry := main.NewMonitorRegistry([]main.Monitor{
+		{UniqueID: "digest-stable", Name: "Stable"},
+		{UniqueID: "digest-flaky", Name: "Flaky"},
+		{UniqueID: "digest-empty", Name: "Empty"},
+	})
+	reporter, err := main.NewDigestReporter(main.NewMonitorHistoricalReader(database), registry, main.DigestConfig{
+		Interval: "1d",
+		Webhooks: []main.Webhook{{URL: webhookServer.URL}},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error creating digest reporter: %v", err)
+	}
+
+	if err := reporter.Send(context.Background(), now); err != nil {
+		t.Fatalf("unexpected error sending digest: %v", err)
+	}
+
+	var payload main.DigestPayload
+	select {
+	case payload = <-received:
+	case <-time.After(time.Second * 5):
+		t.Fatal("timed out waiting for the digest")
+	}
+
+	if payload.Type != "digest" || payload.To != now.Unix() || payload.From != now.Add(-time.Hour*24).Unix() {
+		t.Errorf("unexpected type or period: %+v", payload)
+	}
+
+	if payload.WorstPerformer != "digest-flaky" {
+		t.Errorf("expected the worst performer to be digest-flaky, got %q", payload.WorstPerformer)
+	}
+
+	summaries := map[string]main.DigestMonitorSummary{}
+	for _, summary := range payload.Monitors {
+		summaries[summary.MonitorID] = summary
+	}
+
+	tests := []struct {
+		monitorId      string
+		uptime         *float64
+		checks         int
+		outages        int
+		averageLatency int64
+	}{
+		{monitorId: "digest-stable", uptime: floatPointer(100), checks: 2, outages: 0, averageLatency: 20},
+		{monitorId: "digest-flaky", uptime: floatPointer(50), checks: 4, outages: 2, averageLatency: 60},
+		{monitorId: "digest-empty", uptime: nil, checks: 0, outages: 0, averageLatency: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.monitorId, func(t *testing.T) {
+			summary, ok := summaries[tt.monitorId]
+			if !ok {
+				t.Fatalf("expected a summary of %s, got %+v", tt.monitorId, payload.Monitors)
+			}
+
+			if (summary.Uptime == nil) != (tt.uptime == nil) || (summary.Uptime != nil && *summary.Uptime != *tt.uptime) {
+				t.Errorf("expected uptime %v, got %v", tt.uptime, summary.Uptime)
+			}
+
+			if summary.Checks != tt.checks || summary.Outages != tt.outages || summary.AverageLatency != tt.averageLatency {
+				t.Errorf("expected %d checks, %d outages, and %dms average latency, got %+v", tt.checks, tt.outages, tt.averageLatency, summary)
+			}
+		})
+	}
+}
+
+func floatPointer(value float64) *float64 {
+	return &value
+}
@@ -0,0 +1,188 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Downsampler periodically rolls the raw historical data up into the hourly and daily aggregates.
+type Downsampler struct {
+	registry *MonitorRegistry
+	reader   *MonitorHistoricalReader
+	writer   *MonitorHistoricalWriter
+	interval time.Duration
+	location *time.Location
+}
+
+// DownsamplerOptions specifies how the raw historical data is bucketed.
+type DownsamplerOptions struct {
+	// Location specifies the time zone of the hourly and daily buckets, so the daily buckets start at its local
+	// midnight. Defaults to the local time zone of the process.
+	Location *time.Location
+}
+
+// NewDownsampler creates a downsampler for the monitors of the registry that runs on every interval.
+// Interval defaults to 10 minutes.
+func NewDownsampler(registry *MonitorRegistry, reader *MonitorHistoricalReader, writer *MonitorHistoricalWriter, interval time.Duration) *Downsampler {
+	return NewDownsamplerWithOptions(registry, reader, writer, interval, DownsamplerOptions{})
+}
+
+// NewDownsamplerWithOptions creates a downsampler that buckets the raw historical data as specified by the options.
+func NewDownsamplerWithOptions(registry *MonitorRegistry, reader *MonitorHistoricalReader, writer *MonitorHistoricalWriter, interval time.Duration, options DownsamplerOptions) *Downsampler {
+	if interval <= 0 {
+		interval = time.Minute * 10
+	}
+
+	if options.Location == nil {
+		options.Location = time.Local
+	}
+
+	return &Downsampler{
+		registry: registry,
+		reader:   reader,
+		writer:   writer,
+		interval: interval,
+		location: options.Location,
+	}
+}
+
+// Run downsamples right away and then on every interval until the context is cancelled.
+func (d *Downsampler) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.interval)
+	defer ticker.Stop()
+
+	for {
+		if err := d.Downsample(ctx, time.Now()); err != nil {
+			log.Error().Err(err).Msg("failed to downsample historical data")
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Downsample computes the aggregates of the buckets that contain now, along with the previous buckets so the data
+// that came in after the last run of the previous bucket is included. The aggregates are recomputed from the raw
+// data every time, so running it again over the same data produces the same result. The days are 23 or 25 hours
+// long when the location switches to or from daylight saving time.
+func (d *Downsampler) Downsample(ctx context.Context, now time.Time) error {
+	now = now.In(d.location)
+	hour := time.Date(now.Year(), now.Month(), now.Day(), now.Hour(), 0, 0, 0, d.location)
+	day := startOfDay(now, d.location)
+	// Every day is longer than 12 hours, so these are within the previous and the next day
+	previousDay := startOfDay(day.Add(-time.Hour*12), d.location)
+	nextDay := startOfDay(day.Add(time.Hour*36), d.location)
+
+	buckets := []struct {
+		interval string
+		from     time.Time
+		to       time.Time
+	}{
+		{interval: "hourly", from: hour.Add(-time.Hour), to: hour},
+		{interval: "hourly", from: hour, to: hour.Add(time.Hour)},
+		{interval: "daily", from: previousDay, to: day},
+		{interval: "daily", from: day, to: nextDay},
+	}
+
+	var errs []error
+	for _, monitorId := range d.registry.IDs() {
+		for _, bucket := range buckets {
+			historical, err := d.reader.ReadRawHistoricalRange(ctx, monitorId, bucket.from, bucket.to)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("monitor %s: %w", monitorId, err))
+				continue
+			}
+
+			aggregate, ok := AggregateHistorical(monitorId, bucket.from, historical)
+			if !ok {
+				continue
+			}
+
+			if err := d.writer.WriteAggregate(ctx, bucket.interval, aggregate); err != nil {
+				errs = append(errs, fmt.Errorf("monitor %s: %w", monitorId, err))
+			}
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// startOfDay returns the first instant of the day of t in the location. On the days that daylight saving time
+// starts at midnight, e.g. in America/Santiago, the midnight doesn't exist and the day starts at the end of
+// the skipped hour instead.
+func startOfDay(t time.Time, location *time.Location) time.Time {
+	year, month, day := t.In(location).Date()
+	start := time.Date(year, month, day, 0, 0, 0, 0, location)
+	if y, m, d := start.Date(); y != year || m != month || d != day {
+		// The skipped midnight was normalized into the previous day
+		_, start = start.ZoneBounds()
+	} else if start.Hour() != 0 {
+		// The skipped midnight was normalized past the skipped hour
+		start, _ = start.ZoneBounds()
+	}
+
+	return start
+}
+
+// AggregateHistorical summarizes the historical data of a bucket that starts at the given timestamp.
+// It returns false if there's no data to summarize.
+func AggregateHistorical(monitorId string, timestamp time.Time, historical []MonitorHistorical) (MonitorAggregate, bool) {
+	if len(historical) == 0 {
+		return MonitorAggregate{}, false
+	}
+
+	aggregate := MonitorAggregate{
+		MonitorID:   monitorId,
+		Timestamp:   timestamp,
+		Status:      MonitorStatusSuccess,
+		SampleCount: len(historical),
+	}
+
+	latencies := make([]int64, 0, len(historical))
+	var totalLatency int64
+	for _, h := range historical {
+		latencies = append(latencies, h.Latency)
+		totalLatency += h.Latency
+
+		// A failure outweighs a degradation, which outweighs a success
+		if h.Status == MonitorStatusFailure || (h.Status == MonitorStatusDegraded && aggregate.Status == MonitorStatusSuccess) {
+			aggregate.Status = h.Status
+		}
+	}
+
+	sort.Slice(latencies, func(i, j int) bool {
+		return latencies[i] < latencies[j]
+	})
+
+	aggregate.Latency = totalLatency / int64(len(latencies))
+	aggregate.MinLatency = latencies[0]
+	aggregate.MaxLatency = latencies[len(latencies)-1]
+	aggregate.P50Latency = percentile(latencies, 50)
+	aggregate.P95Latency = percentile(latencies, 95)
+	aggregate.P99Latency = percentile(latencies, 99)
+
+	if uptime := CalculateUptime(historical).Uptime; uptime != nil {
+		aggregate.Uptime = *uptime
+	}
+
+	return aggregate, true
+}
+
+// percentile returns the nearest-rank percentile of the sorted values.
+func percentile(sorted []int64, p float64) int64 {
+	rank := int(math.Ceil(float64(len(sorted)) * p / 100))
+	if rank < 1 {
+		rank = 1
+	}
+
+	return sorted[rank-1]
+}
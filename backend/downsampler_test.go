@@ -0,0 +1,318 @@
+package main_test
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	main "semyi"
+)
+
+func TestAggregateHistorical(t *testing.T) {
+	bucket := time.Date(2024, 6, 1, 10, 0, 0, 0, time.UTC)
+
+	t.Run("Should summarize the bucket", func(t *testing.T) {
+		var historical []main.MonitorHistorical
+		for i := 1; i <= 20; i++ {
+			status := main.MonitorStatusSuccess
+			switch i {
+			case 5:
+				status = main.MonitorStatusDegraded
+			case 10:
+				status = main.MonitorStatusFailure
+			}
+
+			historical = append(historical, main.MonitorHistorical{
+				MonitorID: "aggregate-test",
+				Status:    status,
+				// Shuffled, so the percentile depends on sorting
+				Latency:   int64((i*7)%20+1) * 10,
+				Timestamp: bucket.Add(time.Minute * time.Duration(i)),
+			})
+		}
+
+		aggregate, ok := main.AggregateHistorical("aggregate-test", bucket, historical)
+		if !ok {
+			t.Fatal("expected an aggregate")
+		}
+
+		expected := main.MonitorAggregate{
+			MonitorID:   "aggregate-test",
+			Timestamp:   bucket,
+			Status:      main.MonitorStatusFailure,
+			Uptime:      95,
+			Latency:     105,
+			MinLatency:  10,
+			MaxLatency:  200,
+			P50Latency:  100,
+			P95Latency:  190,
+			P99Latency:  200,
+			SampleCount: 20,
+		}
+		if aggregate != expected {
+			t.Errorf("expected %+v, got %+v", expected, aggregate)
+		}
+	})
+
+	t.Run("Should compute the latency percentiles", func(t *testing.T) {
+		// Uniformly distributed from 1ms to 1000ms, in a scrambled order
+		var historical []main.MonitorHistorical
+		for i := 0; i < 1000; i++ {
+			historical = append(historical, main.MonitorHistorical{Status: main.MonitorStatusSuccess, Latency: int64((i*389)%1000 + 1)})
+		}
+
+		aggregate, _ := main.AggregateHistorical("aggregate-test", bucket, historical)
+
+		percentiles := []struct {
+			name  string
+			value int64
+			lower int64
+			upper int64
+		}{
+			{name: "p50", value: aggregate.P50Latency, lower: 495, upper: 505},
+			{name: "p95", value: aggregate.P95Latency, lower: 945, upper: 955},
+			{name: "p99", value: aggregate.P99Latency, lower: 985, upper: 995},
+		}
+		for _, p := range percentiles {
+			if p.value < p.lower || p.value > p.upper {
+				t.Errorf("expected %s within [%d, %d], got %d", p.name, p.lower, p.upper, p.value)
+			}
+		}
+	})
+
+	t.Run("Should report degraded over success", func(t *testing.T) {
+		aggregate, _ := main.AggregateHistorical("aggregate-test", bucket, []main.MonitorHistorical{
+			{Status: main.MonitorStatusSuccess, Latency: 10},
+			{Status: main.MonitorStatusDegraded, Latency: 30},
+			{Status: main.MonitorStatusSuccess, Latency: 20},
+		})
+
+		if aggregate.Status != main.MonitorStatusDegraded || aggregate.Uptime != 100 || aggregate.P95Latency != 30 || aggregate.Latency != 20 {
+			t.Errorf("unexpected aggregate: %+v", aggregate)
+		}
+	})
+
+	t.Run("Should skip empty buckets", func(t *testing.T) {
+		if _, ok := main.AggregateHistorical("aggregate-test", bucket, nil); ok {
+			t.Error("expected no aggregate for an empty bucket")
+		}
+	})
+}
+
+func TestDownsampler_Downsample(t *testing.T) {
+	// A recent date, so the data is left alone by the retention tests
+	date := time.Now().AddDate(0, 0, -7)
+	now := time.Date(date.Year(), date.Month(), date.Day(), 10, 30, 0, 0, time.Local)
+	hour := time.Date(date.Year(), date.Month(), date.Day(), 10, 0, 0, 0, time.Local)
+	day := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.Local)
+
+	writer := main.NewMonitorHistoricalWriter(database)
+	reader := main.NewMonitorHistoricalReader(database)
+	for _, historical := range []main.MonitorHistorical{
+		// Yesterday
+		{Status: main.MonitorStatusFailure, Latency: 50, Timestamp: day.Add(-time.Hour)},
+		// Previous hour
+		{Status: main.MonitorStatusSuccess, Latency: 40, Timestamp: hour.Add(-time.Second)},
+		// Current hour, the start is inclusive
+		{Status: main.MonitorStatusSuccess, Latency: 10, Timestamp: hour},
+		{Status: main.MonitorStatusDegraded, Latency: 30, Timestamp: hour.Add(time.Hour - time.Second)},
+		// Next hour, the end is exclusive
+		{Status: main.MonitorStatusFailure, Latency: 20, Timestamp: hour.Add(time.Hour)},
+	} {
+		historical.MonitorID = "downsample-test"
+		if err := writer.Write(context.Background(), historical); err != nil {
+			t.Fatalf("unexpected error writing historical data: %v", err)
+		}
+	}
+
+	downsampler := main.NewDownsampler(main.NewMonitorRegistry([]main.Monitor{{UniqueID: "downsample-test"}}), reader, writer, 0)
+
+	read := func() ([]main.MonitorAggregate, []main.MonitorAggregate) {
+		hourly, err := reader.ReadAggregates(context.Background(), "downsample-test", "hourly")
+		if err != nil {
+			t.Fatalf("unexpected error reading hourly aggregates: %v", err)
+		}
+
+		daily, err := reader.ReadAggregates(context.Background(), "downsample-test", "daily")
+		if err != nil {
+			t.Fatalf("unexpected error reading daily aggregates: %v", err)
+		}
+
+		return hourly, daily
+	}
+
+	if err := downsampler.Downsample(context.Background(), now); err != nil {
+		t.Fatalf("unexpected error downsampling: %v", err)
+	}
+
+	hourly, daily := read()
+	if len(hourly) != 2 {
+		t.Fatalf("expected 2 hourly aggregates, got %+v", hourly)
+	}
+
+	if !hourly[0].Timestamp.Equal(hour.Add(-time.Hour)) || hourly[0].SampleCount != 1 || hourly[0].Latency != 40 {
+		t.Errorf("unexpected previous hour aggregate: %+v", hourly[0])
+	}
+
+	if !hourly[1].Timestamp.Equal(hour) || hourly[1].SampleCount != 2 || hourly[1].Latency != 20 || hourly[1].Status != main.MonitorStatusDegraded {
+		t.Errorf("unexpected current hour aggregate: %+v", hourly[1])
+	}
+
+	if len(daily) != 2 {
+		t.Fatalf("expected 2 daily aggregates, got %+v", daily)
+	}
+
+	if !daily[0].Timestamp.Equal(day.AddDate(0, 0, -1)) || daily[0].SampleCount != 1 || daily[0].Uptime != 0 {
+		t.Errorf("unexpected yesterday aggregate: %+v", daily[0])
+	}
+
+	if !daily[1].Timestamp.Equal(day) || daily[1].SampleCount != 4 || daily[1].Uptime != 75 || daily[1].MaxLatency != 40 {
+		t.Errorf("unexpected today aggregate: %+v", daily[1])
+	}
+
+	// Running it again over the same data must not change the result
+	if err := downsampler.Downsample(context.Background(), now); err != nil {
+		t.Fatalf("unexpected error downsampling again: %v", err)
+	}
+
+	rerunHourly, rerunDaily := read()
+	if !reflect.DeepEqual(hourly, rerunHourly) || !reflect.DeepEqual(daily, rerunDaily) {
+		t.Errorf("expected the same aggregates after a rerun, got %+v and %+v", rerunHourly, rerunDaily)
+	}
+}
+
+func TestDownsampler_Timezone(t *testing.T) {
+	writer := main.NewMonitorHistoricalWriter(database)
+
+	// downsample writes the raw data of the monitor, and downsamples it at now in the location
+	downsample := func(t *testing.T, monitorId string, location *time.Location, now time.Time, timestamps []time.Time) (*main.MonitorHistoricalReader, []main.MonitorAggregate) {
+		t.Helper()
+
+		for _, timestamp := range timestamps {
+			historical := main.MonitorHistorical{MonitorID: monitorId, Status: main.MonitorStatusSuccess, Latency: 10, Timestamp: timestamp}
+			if err := writer.Write(context.Background(), historical); err != nil {
+				t.Fatalf("unexpected error writing historical data: %v", err)
+			}
+		}
+
+		reader := main.NewMonitorHistoricalReaderWithOptions(database, main.MonitorHistoricalReaderOptions{Location: location})
+		downsampler := main.NewDownsamplerWithOptions(main.NewMonitorRegistry([]main.Monitor{{UniqueID: monitorId}}), reader, writer, 0, main.DownsamplerOptions{Location: location})
+		if err := downsampler.Downsample(context.Background(), now); err != nil {
+			t.Fatalf("unexpected error downsampling: %v", err)
+		}
+
+		daily, err := reader.ReadAggregates(context.Background(), monitorId, "daily")
+		if err != nil {
+			t.Fatalf("unexpected error reading daily aggregates: %v", err)
+		}
+
+		return reader, daily
+	}
+
+	// findDay returns a day within the last year in the location that matches, the data is left alone by the
+	// retention tests then
+	findDay := func(t *testing.T, location *time.Location, match func(year int, month time.Month, day int) bool) (int, time.Month, int) {
+		t.Helper()
+
+		date := time.Now().In(location).AddDate(0, 0, -2)
+		for i := 0; i < 360; i++ {
+			year, month, day := date.AddDate(0, 0, -i).Date()
+			if match(year, month, day) {
+				return year, month, day
+			}
+		}
+
+		t.Skipf("no matching day in %s within the last year", location)
+		return 0, 0, 0
+	}
+
+	t.Run("Should bucket by the local midnight", func(t *testing.T) {
+		jakarta, err := time.LoadLocation("Asia/Jakarta")
+		if err != nil {
+			t.Fatalf("unexpected error loading location: %v", err)
+		}
+
+		date := time.Now().In(jakarta).AddDate(0, 0, -7)
+		day := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, jakarta)
+		reader, daily := downsample(t, "timezone-jakarta-test", jakarta, day.Add(time.Hour*10), []time.Time{
+			// The same UTC day as the local midnight, but the previous local day
+			day.Add(-time.Minute),
+			day,
+			day.Add(time.Hour*24 - time.Second),
+		})
+
+		if len(daily) != 2 {
+			t.Fatalf("expected 2 daily aggregates, got %+v", daily)
+		}
+
+		if !daily[0].Timestamp.Equal(day.AddDate(0, 0, -1)) || daily[0].SampleCount != 1 {
+			t.Errorf("unexpected yesterday aggregate: %+v", daily[0])
+		}
+
+		if !daily[1].Timestamp.Equal(day) || daily[1].SampleCount != 2 {
+			t.Errorf("unexpected today aggregate: %+v", daily[1])
+		}
+
+		historical, err := reader.ReadDailyHistorical(context.Background(), "timezone-jakarta-test")
+		if err != nil {
+			t.Fatalf("unexpected error reading daily historical data: %v", err)
+		}
+
+		for _, row := range historical {
+			if row.Timestamp.Location() != jakarta || row.Timestamp.Hour() != 0 || row.Timestamp.Minute() != 0 {
+				t.Errorf("expected the timestamp to be a local midnight, got %s", row.Timestamp)
+			}
+		}
+	})
+
+	t.Run("Should bucket the days of daylight saving time transitions", func(t *testing.T) {
+		berlin, err := time.LoadLocation("Europe/Berlin")
+		if err != nil {
+			t.Fatalf("unexpected error loading location: %v", err)
+		}
+
+		year, month, day := findDay(t, berlin, func(year int, month time.Month, day int) bool {
+			return time.Date(year, month, day+1, 0, 0, 0, 0, berlin).Sub(time.Date(year, month, day, 0, 0, 0, 0, berlin)) != time.Hour*24
+		})
+		start := time.Date(year, month, day, 0, 0, 0, 0, berlin)
+		end := time.Date(year, month, day+1, 0, 0, 0, 0, berlin)
+
+		_, daily := downsample(t, "timezone-dst-test", berlin, start.Add(time.Hour*12), []time.Time{start, end.Add(-time.Second), end})
+		if len(daily) != 1 {
+			t.Fatalf("expected a single daily aggregate, got %+v", daily)
+		}
+
+		// The day is 23 or 25 hours long, and the next local midnight is already in the next bucket
+		if !daily[0].Timestamp.Equal(start) || daily[0].SampleCount != 2 {
+			t.Errorf("expected the %s long day from %s to have 2 samples, got %+v", end.Sub(start), start, daily[0])
+		}
+	})
+
+	t.Run("Should start the day after a skipped midnight", func(t *testing.T) {
+		santiago, err := time.LoadLocation("America/Santiago")
+		if err != nil {
+			t.Fatalf("unexpected error loading location: %v", err)
+		}
+
+		// Daylight saving time starts at midnight in Chile, so the clocks go from 23:59:59 to 01:00:00
+		year, month, day := findDay(t, santiago, func(year int, month time.Month, day int) bool {
+			midnight := time.Date(year, month, day, 0, 0, 0, 0, santiago)
+			return midnight.Day() != day || midnight.Hour() != 0
+		})
+		start := time.Date(year, month, day, 1, 0, 0, 0, santiago)
+
+		_, daily := downsample(t, "timezone-skipped-midnight-test", santiago, start.Add(time.Hour*11), []time.Time{start.Add(-time.Second), start})
+		if len(daily) != 2 {
+			t.Fatalf("expected 2 daily aggregates, got %+v", daily)
+		}
+
+		if !daily[1].Timestamp.Equal(start) || daily[1].SampleCount != 1 {
+			t.Errorf("expected the day to start at %s with a single sample, got %+v", start, daily[1])
+		}
+
+		if daily[0].SampleCount != 1 || daily[0].Timestamp.Day() == day {
+			t.Errorf("expected the last second before the skipped midnight in the previous day, got %+v", daily[0])
+		}
+	})
+}
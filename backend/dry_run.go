@@ -0,0 +1,93 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync"
+	"text/tabwriter"
+)
+
+// DryRunResult is the result of the single check of a monitor in a dry run.
+type DryRunResult struct {
+	Monitor Monitor
+	// Status is "up", "degraded", "down", or "error" when the check couldn't be made at all.
+	Status  string
+	Latency int64
+	Error   string
+}
+
+// Passed reports whether the monitor is reachable. Degraded monitors are reachable.
+func (r DryRunResult) Passed() bool {
+	return r.Status == "up" || r.Status == "degraded"
+}
+
+// DryRun checks every monitor of the configuration once, without storing or broadcasting the results, and
+// writes a table of the results to w. It reports whether every check has passed. The configuration is
+// expected to be validated already.
+func DryRun(ctx context.Context, config ConfigurationFile, w io.Writer) (bool, error) {
+	httpClient := NewHttpClient(config.HttpClient)
+	pool := NewCheckPool(config.MaxConcurrentChecks)
+
+	results := make([]DryRunResult, len(config.Monitors))
+	var wg sync.WaitGroup
+	for i, monitor := range config.Monitors {
+		worker, err := NewWorker(monitor, nil)
+		if err != nil {
+			return false, fmt.Errorf("invalid monitor %s: %w", monitor.UniqueID, err)
+		}
+		worker.useHttpClient(httpClient)
+
+		wg.Add(1)
+		go func(i int, worker *Worker) {
+			defer wg.Done()
+			results[i] = dryRunCheck(ctx, pool, worker)
+		}(i, worker)
+	}
+	wg.Wait()
+
+	passed := true
+	table := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(table, "MONITOR\tSTATUS\tLATENCY\tERROR")
+	for _, result := range results {
+		if !result.Passed() {
+			passed = false
+		}
+
+		fmt.Fprintf(table, "%s\t%s\t%dms\t%s\n", result.Monitor.UniqueID, result.Status, result.Latency, result.Error)
+	}
+
+	if err := table.Flush(); err != nil {
+		return false, fmt.Errorf("failed to write results: %w", err)
+	}
+
+	return passed, nil
+}
+
+func dryRunCheck(ctx context.Context, pool *CheckPool, worker *Worker) DryRunResult {
+	result := DryRunResult{Monitor: worker.monitor}
+	if err := pool.Acquire(ctx); err != nil {
+		result.Status, result.Error = "error", err.Error()
+		return result
+	}
+	defer pool.Release()
+
+	response, err := worker.Check(ctx)
+	if err != nil {
+		result.Status, result.Error = "error", err.Error()
+		return result
+	}
+
+	status := MonitorStatusFailure
+	if response.Success {
+		status = MonitorStatusSuccess
+		if response.Degraded {
+			status = MonitorStatusDegraded
+		}
+	}
+
+	result.Status = status.String()
+	result.Latency = response.RequestDuration
+	result.Error = response.Error
+	return result
+}
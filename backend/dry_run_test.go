@@ -0,0 +1,68 @@
+package main_test
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	main "semyi"
+)
+
+func TestDryRun(t *testing.T) {
+	upServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer upServer.Close()
+
+	downServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer downServer.Close()
+
+	upMonitor := main.Monitor{UniqueID: "dry-run-up", Name: "Up", Type: main.MonitorTypeHTTP, HttpEndpoint: upServer.URL, Timeout: 5}
+	downMonitor := main.Monitor{UniqueID: "dry-run-down", Name: "Down", Type: main.MonitorTypeHTTP, HttpEndpoint: downServer.URL, Timeout: 5}
+
+	tests := []struct {
+		name     string
+		monitors []main.Monitor
+		passed   bool
+		statuses map[string]string
+	}{
+		{name: "every check passes", monitors: []main.Monitor{upMonitor}, passed: true, statuses: map[string]string{"dry-run-up": "up"}},
+		{name: "a check fails", monitors: []main.Monitor{upMonitor, downMonitor}, passed: false, statuses: map[string]string{"dry-run-up": "up", "dry-run-down": "down"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var output bytes.Buffer
+			passed, err := main.DryRun(context.Background(), main.ConfigurationFile{Monitors: tt.monitors}, &output)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if passed != tt.passed {
+				t.Errorf("expected passed to be %v, got %v", tt.passed, passed)
+			}
+
+			lines := strings.Split(strings.TrimSpace(output.String()), "\n")
+			if len(lines) != len(tt.monitors)+1 || !strings.HasPrefix(lines[0], "MONITOR") {
+				t.Fatalf("expected a header and a row per monitor, got:\n%s", output.String())
+			}
+
+			// The rows keep the order of the configuration
+			for i, monitor := range tt.monitors {
+				fields := strings.Fields(lines[i+1])
+				if len(fields) < 3 || fields[0] != monitor.UniqueID || fields[1] != tt.statuses[monitor.UniqueID] || !strings.HasSuffix(fields[2], "ms") {
+					t.Errorf("unexpected row of %s: %q", monitor.UniqueID, lines[i+1])
+				}
+			}
+
+			if !tt.passed && !strings.Contains(output.String(), "unexpected status code 500") {
+				t.Errorf("expected the error of the failed check, got:\n%s", output.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// EscalationPolicy specifies the alerts that are sent while a monitor stays down, after the initial down alert.
+type EscalationPolicy struct {
+	// RepeatInterval specifies how often the down alert is repeated in seconds, as long as the monitor stays down.
+	// Alerts are not repeated if it's zero.
+	RepeatInterval int `json:"repeat_interval" yaml:"repeat_interval" toml:"repeat_interval"`
+	// EscalateAfter specifies how long the monitor has to stay down in seconds before Webhooks are alerted.
+	// Repeated alerts are sent to Webhooks as well once escalated. The outage is not escalated if it's zero.
+	EscalateAfter int `json:"escalate_after" yaml:"escalate_after" toml:"escalate_after"`
+	// Webhooks specifies the secondary destinations of an escalated outage, e.g. the on-call channel.
+	// Like any other webhook, they only receive the alerts if failed_response is enabled.
+	Webhooks []Webhook `json:"webhooks" yaml:"webhooks" toml:"webhooks"`
+}
+
+// Validate checks the durations and the webhooks of the policy.
+func (p EscalationPolicy) Validate() error {
+	if p.RepeatInterval < 0 {
+		return fmt.Errorf("repeat_interval must not be negative")
+	}
+
+	if p.EscalateAfter < 0 {
+		return fmt.Errorf("escalate_after must not be negative")
+	}
+
+	if p.EscalateAfter > 0 && len(p.Webhooks) == 0 {
+		return fmt.Errorf("webhooks are required to escalate")
+	}
+
+	for _, webhook := range p.Webhooks {
+		if _, err := ValidateWebhook(webhook); err != nil {
+			return fmt.Errorf("invalid webhook: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// EscalationTracker keeps the outage of every monitor that is down in memory, to know when the down alert
+// should be repeated or escalated.
+type EscalationTracker struct {
+	mu     sync.Mutex
+	states map[string]*escalationState
+}
+
+type escalationState struct {
+	// downSince is the timestamp of the check that was alerted as down
+	downSince time.Time
+	// lastNotified is the timestamp of the latest alert of the outage, either the down alert or a repeat
+	lastNotified time.Time
+	escalated    bool
+}
+
+// EscalationEvent is an alert that is due for an ongoing outage.
+type EscalationEvent struct {
+	// Type is either AlertEventTypeRepeat or AlertEventTypeEscalated.
+	Type AlertEventType
+	// Downtime specifies how long the monitor has been down so far.
+	Downtime time.Duration
+	// Escalated specifies whether the outage has been escalated, so the escalation webhooks should be alerted.
+	Escalated bool
+}
+
+func NewEscalationTracker() *EscalationTracker {
+	return &EscalationTracker{states: make(map[string]*escalationState)}
+}
+
+// Start records the start of an outage, when the down alert of the monitor is sent.
+func (t *EscalationTracker) Start(monitorId string, timestamp time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.states[monitorId] = &escalationState{downSince: timestamp, lastNotified: timestamp}
+}
+
+// Stop forgets the outage of the monitor, when it's alerted as up again.
+func (t *EscalationTracker) Stop(monitorId string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	delete(t.states, monitorId)
+}
+
+// Observe returns the alert that is due at the timestamp of a failed check according to the policy, and true
+// if an alert should be sent. The escalation takes precedence when a repeat is due on the same check.
+// Outages that weren't started with Start, e.g. the ones that started before a restart, are never repeated.
+func (t *EscalationTracker) Observe(monitorId string, timestamp time.Time, policy EscalationPolicy) (EscalationEvent, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	state, ok := t.states[monitorId]
+	if !ok {
+		return EscalationEvent{}, false
+	}
+
+	downtime := timestamp.Sub(state.downSince)
+	escalateAfter := time.Duration(policy.EscalateAfter) * time.Second
+	if !state.escalated && escalateAfter > 0 && downtime >= escalateAfter {
+		state.escalated = true
+		state.lastNotified = timestamp
+		return EscalationEvent{Type: AlertEventTypeEscalated, Downtime: downtime, Escalated: true}, true
+	}
+
+	repeatInterval := time.Duration(policy.RepeatInterval) * time.Second
+	if repeatInterval > 0 && timestamp.Sub(state.lastNotified) >= repeatInterval {
+		state.lastNotified = timestamp
+		return EscalationEvent{Type: AlertEventTypeRepeat, Downtime: downtime, Escalated: state.escalated}, true
+	}
+
+	return EscalationEvent{}, false
+}
@@ -0,0 +1,184 @@
+package main_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	main "semyi"
+)
+
+func TestEscalationTracker(t *testing.T) {
+	policy := main.EscalationPolicy{RepeatInterval: 15 * 60, EscalateAfter: 60 * 60}
+	tracker := main.NewEscalationTracker()
+
+	start := time.Date(2024, 6, 20, 9, 0, 0, 0, time.UTC)
+	if _, ok := tracker.Observe("escalation-tracker", start, policy); ok {
+		t.Fatal("expected no alert before the outage is started")
+	}
+
+	tracker.Start("escalation-tracker", start)
+
+	// A check every minute over a two hours outage
+	var events []string
+	for minute := 1; minute <= 120; minute++ {
+		event, ok := tracker.Observe("escalation-tracker", start.Add(time.Duration(minute)*time.Minute), policy)
+		if !ok {
+			continue
+		}
+
+		if event.Downtime != time.Duration(minute)*time.Minute {
+			t.Errorf("expected a downtime of %dm, got %s", minute, event.Downtime)
+		}
+
+		events = append(events, string(event.Type)+"@"+(time.Duration(minute)*time.Minute).String())
+	}
+
+	expected := []string{
+		"repeat@15m0s",
+		"repeat@30m0s",
+		"repeat@45m0s",
+		"escalated@1h0m0s",
+		"repeat@1h15m0s",
+		"repeat@1h30m0s",
+		"repeat@1h45m0s",
+		"repeat@2h0m0s",
+	}
+	if len(events) != len(expected) {
+		t.Fatalf("expected events %v, got %v", expected, events)
+	}
+
+	for i := range expected {
+		if events[i] != expected[i] {
+			t.Errorf("expected event %d to be %s, got %s", i, expected[i], events[i])
+		}
+	}
+
+	tracker.Stop("escalation-tracker")
+	if _, ok := tracker.Observe("escalation-tracker", start.Add(time.Hour*3), policy); ok {
+		t.Error("expected no alert after the outage is stopped")
+	}
+}
+
+func TestProcessor_Escalation(t *testing.T) {
+	newWebhookServer := func(received chan<- main.WebhookPayload) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var payload main.WebhookPayload
+			if err := json.NewDecoder(r.Body).Decode(&payload); err == nil {
+				received <- payload
+			}
+			w.WriteHeader(http.StatusOK)
+		}))
+	}
+
+	primary := make(chan main.WebhookPayload, 20)
+	primaryServer := newWebhookServer(primary)
+	defer primaryServer.Close()
+
+	secondary := make(chan main.WebhookPayload, 20)
+	secondaryServer := newWebhookServer(secondary)
+	defer secondaryServer.Close()
+
+	monitor := main.Monitor{
+		UniqueID:   "escalation-processor",
+		Name:       "Escalation Processor",
+		AlertAfter: 1,
+		Escalation: &main.EscalationPolicy{
+			RepeatInterval: 15 * 60,
+			EscalateAfter:  60 * 60,
+			Webhooks:       []main.Webhook{{URL: secondaryServer.URL, FailedResponse: true, Template: "on-call"}},
+		},
+	}
+
+	// The escalation webhooks are rendered with the templates and recorded like the other webhooks
+	templates, err := main.ParseWebhookTemplates(map[string]string{
+		"on-call": `{"type": {{json .Type}}, "downtime": {{printf "%.0f" .Duration.Seconds}}, "text": {{json .MonitorName}}}`,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error parsing templates: %v", err)
+	}
+	deliveryLog := main.NewWebhookDeliveryLog(100)
+
+	processor := main.NewProcessor(main.ProcessorConfig{
+		HistoricalWriter:        main.NewMonitorHistoricalWriter(database),
+		HistoricalReader:        main.NewMonitorHistoricalReader(database),
+		AlertDebouncer:          main.NewAlertDebouncer(),
+		WebhookAlertProvider:    main.NewWebhookDispatcher([]main.Webhook{{URL: primaryServer.URL, SuccessResponse: true, FailedResponse: true}}, nil),
+		EscalationAlertProvider: main.NewEscalationDispatcher([]main.Monitor{monitor}, main.WebhookDispatcherOptions{Templates: templates, DeliveryLog: deliveryLog}),
+	})
+
+	now := time.Now().Add(-time.Hour * 3)
+	check := func(success bool, after time.Duration) {
+		now = now.Add(after)
+		processor.ProcessResponse(main.Response{Success: success, Timestamp: now, Monitor: monitor})
+	}
+
+	expectAlert := func(t *testing.T, received <-chan main.WebhookPayload, eventType string, downtime time.Duration) {
+		t.Helper()
+
+		select {
+		case payload := <-received:
+			if payload.Type != eventType {
+				t.Errorf("expected a %s alert, got %s", eventType, payload.Type)
+			}
+
+			if payload.Downtime != int64(downtime.Seconds()) {
+				t.Errorf("expected a downtime of %ds, got %ds", int64(downtime.Seconds()), payload.Downtime)
+			}
+		case <-time.After(time.Second * 5):
+			t.Fatalf("timed out waiting for a %s alert", eventType)
+		}
+	}
+
+	expectNoAlert := func(t *testing.T) {
+		t.Helper()
+
+		select {
+		case payload := <-primary:
+			t.Errorf("expected no alert, got %s", payload.Type)
+		case payload := <-secondary:
+			t.Errorf("expected no escalation alert, got %s", payload.Type)
+		case <-time.After(time.Millisecond * 200):
+		}
+	}
+
+	check(true, 0)
+	check(false, time.Minute)
+	expectAlert(t, primary, "down", 0)
+
+	// Checks every 5 minutes, the alert is repeated every 15 minutes
+	for i := 0; i < 3; i++ {
+		check(false, time.Minute*5)
+		check(false, time.Minute*5)
+		expectNoAlert(t)
+		check(false, time.Minute*5)
+		expectAlert(t, primary, "repeat", time.Duration(i+1)*time.Minute*15)
+	}
+
+	// After an hour, the outage is escalated to the secondary webhook only
+	check(false, time.Minute*15)
+	expectAlert(t, secondary, "escalated", time.Hour)
+	expectNoAlert(t)
+
+	deliveries := deliveryLog.List()
+	if len(deliveries) != 1 || deliveries[0].Destination != secondaryServer.URL || deliveries[0].MonitorID != "escalation-processor" {
+		t.Errorf("expected the escalation to be recorded, got %+v", deliveries)
+	}
+
+	// Repeats go to both webhooks once escalated
+	check(false, time.Minute*15)
+	expectAlert(t, primary, "repeat", time.Hour+time.Minute*15)
+	expectAlert(t, secondary, "repeat", time.Hour+time.Minute*15)
+
+	check(true, time.Minute)
+	expectAlert(t, primary, "recovered", time.Hour+time.Minute*17)
+
+	// A new outage starts over
+	check(false, time.Minute)
+	expectAlert(t, primary, "down", 0)
+	check(false, time.Minute*15)
+	expectAlert(t, primary, "repeat", time.Minute*15)
+	expectNoAlert(t)
+}
@@ -6,44 +6,194 @@ import (
 	"net"
 	"net/http"
 	"slices"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
 	"github.com/rs/cors"
+	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 	"github.com/unrolled/secure"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
 )
 
 type Server struct {
-	historicalReader *MonitorHistoricalReader
-	centralBroker    *Broker[MonitorHistorical]
-	incidentWriter   *IncidentWriter
-	monitors         []Monitor
+	historicalReader   *MonitorHistoricalReader
+	centralBroker      *Broker[MonitorHistorical]
+	incidentWriter     *IncidentWriter
+	monitors           *MonitorRegistry
+	metrics            *Metrics
+	checkStats         *CheckStats
+	webhookDeliveryLog *WebhookDeliveryLog
+	workerManager      *WorkerManager
+	subscribers        *SubscriberRegistry
+
+	sseHeartbeatInterval time.Duration
+	maxUptimeWindow      time.Duration
+	badgeThresholds      BadgeThresholds
+	outageFlapTolerance  int
+
+	// shutdown is closed when the server is shutting down, so the streams can be drained
+	shutdown     chan struct{}
+	shutdownOnce sync.Once
+
+	// websocketOrigins specifies the origin hosts that are accepted on the WebSocket handshake
+	websocketOrigins []string
 
 	apiKey string
 }
 
 type ServerConfig struct {
-	SSLRedirect             bool
-	Environment             string
-	Hostname                string
-	Port                    string
+	SSLRedirect bool
+	Environment string
+	Hostname    string
+	Port        string
+	// BasePath specifies the prefix of every route, e.g. "/status" when the server is reverse proxied under
+	// /status/ of a shared domain. The routes are served on the root if it's empty.
+	BasePath string
+	// StaticPath specifies the directory of the dashboard files. The files embedded in the binary are served
+	// if it's empty.
 	StaticPath              string
 	MonitorHistoricalReader *MonitorHistoricalReader
 	CentralBroker           *Broker[MonitorHistorical]
 	IncidentWriter          *IncidentWriter
 	MonitorList             []Monitor
-
+	// MonitorRegistry holds the monitors that can be replaced on configuration reload.
+	// It takes precedence over MonitorList.
+	MonitorRegistry *MonitorRegistry
+	// Metrics specifies the collectors that will be exposed on the /metrics endpoint. The endpoint is not
+	// registered if it's nil.
+	Metrics *Metrics
+	// SSEHeartbeatInterval specifies how long an SSE stream can stay idle before a keepalive comment
+	// is written to it. WebSocket streams are pinged on the same interval. Defaults to 15 seconds.
+	SSEHeartbeatInterval time.Duration
+	// MaxUptimeWindow specifies the largest window that can be requested for uptime calculation. It should not be
+	// larger than the raw historical data retention. Defaults to 90 days.
+	MaxUptimeWindow time.Duration
+	// BadgeThresholds specifies the uptime percentages used to color the status badge.
+	// Defaults to green above 99% and yellow above 95%.
+	BadgeThresholds BadgeThresholds
+	// OutageFlapTolerance specifies how many consecutive successful checks in between failures are tolerated
+	// before an outage is considered over. Defaults to 1, set it to a negative value to disable the tolerance.
+	OutageFlapTolerance int
+
+	// ApiKey specifies the key that is required to submit incidents.
 	ApiKey string
+	// ApiKeys specifies the keys that are accepted in the X-API-Key header of the API endpoints, except for the
+	// health probes. When it's empty, the API is public. ApiKey is accepted as well when it's not empty.
+	// Browsers can't set headers on SSE connections, so the bundled dashboard needs a proxy that adds the key.
+	ApiKeys []string
+
+	// AllowedOrigins specifies the origins that are allowed to make cross-origin requests to the API.
+	// Defaults to ["*"].
+	AllowedOrigins []string
+	// AllowCredentials specifies whether cross-origin requests can include credentials. It can't be combined
+	// with a wildcard origin.
+	AllowCredentials bool
+
+	// RateLimit specifies the request and stream limits of each client. The limits are disabled by default.
+	RateLimit RateLimitConfig
+	// TrustedProxies specifies the networks of the reverse proxies in front of the server. The client address
+	// is read from X-Forwarded-For only when the request comes from one of them.
+	TrustedProxies []net.IPNet
+
+	// CheckStats specifies the counters of the checks that are reported on /api/debug/stats. The monitors are
+	// reported without their checks if it's nil. The endpoint is only registered when ApiKeys is not empty.
+	CheckStats *CheckStats
+	// WebhookDeliveryLog specifies the webhook delivery attempts that are reported on /api/webhooks/deliveries.
+	// The endpoint is only registered when ApiKeys is not empty and the log is set.
+	WebhookDeliveryLog *WebhookDeliveryLog
+	// WorkerManager pauses and resumes the monitors on /api/monitors/{id}/pause and /api/monitors/{id}/resume.
+	// The endpoints are only registered when ApiKeys is not empty and the manager is set.
+	WorkerManager *WorkerManager
+
+	// Logger is used for the access logs. Defaults to the global logger.
+	Logger *zerolog.Logger
+	// TracerProvider records a span for every request. Defaults to a no-op provider.
+	TracerProvider trace.TracerProvider
 }
 
-func NewServer(config ServerConfig) *http.Server {
+func NewServer(config ServerConfig) (*http.Server, error) {
+	if config.CentralBroker == nil {
+		return nil, fmt.Errorf("central broker is required")
+	}
+
+	if config.MonitorHistoricalReader == nil {
+		return nil, fmt.Errorf("monitor historical reader is required")
+	}
+
+	if config.SSEHeartbeatInterval <= 0 {
+		config.SSEHeartbeatInterval = time.Second * 15
+	}
+
+	if config.MaxUptimeWindow <= 0 {
+		config.MaxUptimeWindow = time.Hour * 24 * 90
+	}
+
+	if config.BadgeThresholds.Green == 0 {
+		config.BadgeThresholds.Green = 99
+	}
+
+	if config.BadgeThresholds.Yellow == 0 {
+		config.BadgeThresholds.Yellow = 95
+	}
+
+	if config.OutageFlapTolerance == 0 {
+		config.OutageFlapTolerance = 1
+	} else if config.OutageFlapTolerance < 0 {
+		config.OutageFlapTolerance = 0
+	}
+
+	if len(config.AllowedOrigins) == 0 {
+		config.AllowedOrigins = []string{"*"}
+	}
+
+	if config.AllowCredentials && slices.Contains(config.AllowedOrigins, "*") {
+		return nil, fmt.Errorf("wildcard allowed origin can't be combined with credentials")
+	}
+
+	if config.RateLimit.Window <= 0 {
+		config.RateLimit.Window = time.Minute
+	}
+
+	if config.Logger == nil {
+		config.Logger = &log.Logger
+	}
+
+	if config.MonitorRegistry == nil {
+		config.MonitorRegistry = NewMonitorRegistry(config.MonitorList)
+	}
+
+	if config.TracerProvider == nil {
+		config.TracerProvider = noop.NewTracerProvider()
+	}
+
+	basePath := strings.TrimSuffix(config.BasePath, "/")
+	if basePath != "" && !strings.HasPrefix(basePath, "/") {
+		basePath = "/" + basePath
+	}
+
 	server := &Server{
-		historicalReader: config.MonitorHistoricalReader,
-		centralBroker:    config.CentralBroker,
-		monitors:         config.MonitorList,
-		incidentWriter:   config.IncidentWriter,
+		historicalReader:   config.MonitorHistoricalReader,
+		centralBroker:      config.CentralBroker,
+		monitors:           config.MonitorRegistry,
+		metrics:            config.Metrics,
+		checkStats:         config.CheckStats,
+		webhookDeliveryLog: config.WebhookDeliveryLog,
+		workerManager:      config.WorkerManager,
+		subscribers:        NewSubscriberRegistry(),
+		incidentWriter:     config.IncidentWriter,
+
+		sseHeartbeatInterval: config.SSEHeartbeatInterval,
+		maxUptimeWindow:      config.MaxUptimeWindow,
+		badgeThresholds:      config.BadgeThresholds,
+		outageFlapTolerance:  config.OutageFlapTolerance,
+		shutdown:             make(chan struct{}),
+		websocketOrigins:     websocketOriginPatterns(config.AllowedOrigins),
 
 		apiKey: config.ApiKey,
 	}
@@ -56,43 +206,121 @@ func NewServer(config ServerConfig) *http.Server {
 	})
 
 	corsMiddleware := cors.New(cors.Options{
-		Debug:          config.Environment == "development",
-		AllowedOrigins: []string{"*"},
-		AllowedMethods: []string{"GET", "OPTIONS"},
-		AllowedHeaders: []string{"Content-Type"},
+		Debug:            config.Environment == "development",
+		AllowedOrigins:   config.AllowedOrigins,
+		AllowCredentials: config.AllowCredentials,
+		AllowedMethods:   []string{"GET", "POST", "OPTIONS"},
+		AllowedHeaders:   []string{"Content-Type", "X-API-Key"},
 	})
 
+	accessLog := accessLogMiddleware(*config.Logger, config.TrustedProxies)
+	// The incident submission key is sent in the same header, so it's accepted as well
+	apiKeys := config.ApiKeys
+	if len(apiKeys) > 0 && config.ApiKey != "" {
+		apiKeys = append([]string{config.ApiKey}, apiKeys...)
+	}
+	apiKeyAuth := apiKeyMiddleware(apiKeys)
+	rateLimit := rateLimitMiddleware(config.RateLimit, config.TrustedProxies)
+
 	api := chi.NewRouter()
 	api.Use(corsMiddleware.Handler)
-	api.Get("/api/overview", server.snapshotOverview)
-	api.Get("/api/by", server.snapshotBy)
-	api.Get("/api/static", server.staticSnapshot)
-	api.Post("/api/incident", server.submitIncindent)
+	// Probes of orchestrators are frequent, they are left out of the access log
+	api.Get("/api/health", server.health)
+	api.Get("/api/ready", server.ready)
+	// SSE and WebSocket endpoints stream their responses, so they are left uncompressed and only logged on
+	// open and close
+	api.Group(func(api chi.Router) {
+		api.Use(streamLogMiddleware(*config.Logger, config.TrustedProxies))
+		api.Use(rateLimit)
+		api.Use(streamLimitMiddleware(config.RateLimit, config.TrustedProxies))
+		api.Use(apiKeyAuth)
+		api.Get("/api/overview", server.snapshotOverview)
+		api.Get("/api/by", server.snapshotBy)
+		api.Get("/api/ws", server.websocketStream)
+	})
+	api.Group(func(api chi.Router) {
+		api.Use(accessLog)
+		api.Use(rateLimit)
+		api.Use(apiKeyAuth)
+		api.Use(middleware.Compress(5, "application/json", "image/svg+xml"))
+		api.Get("/api/static", server.staticSnapshot)
+		api.Get("/api/status", server.currentStatus)
+		api.Get("/api/overview/status", server.overviewStatus)
+		api.Get("/api/monitors", server.listMonitors)
+		api.Get("/api/export", server.export)
+		api.Get("/api/export/snapshot", server.exportSnapshot)
+		api.Get("/api/uptime", server.uptime)
+		api.Get("/api/badge", server.badge)
+		api.Get("/api/incidents", server.outages)
+		api.Post("/api/incident", server.submitIncindent)
+		// The internals of the deployment are never public
+		if len(apiKeys) > 0 {
+			api.Get("/api/debug/stats", server.debugStats)
+			// The destinations contain the secrets of chat webhooks
+			if config.WebhookDeliveryLog != nil {
+				api.Get("/api/webhooks/deliveries", server.webhookDeliveries)
+			}
+
+			if config.WorkerManager != nil {
+				api.Post("/api/monitors/{id}/pause", server.pauseMonitor)
+				api.Post("/api/monitors/{id}/resume", server.resumeMonitor)
+			}
+		}
+	})
 
 	r := chi.NewRouter()
 	r.Use(secureMiddleware.Handler)
+	r.Use(middleware.RequestID)
+	r.Use(requestIdHeaderMiddleware)
+	r.Use(tracingMiddleware(config.TracerProvider))
 	r.Handle("/api/*", corsMiddleware.Handler(api))
-	r.Handle("/", http.FileServer(http.Dir(config.StaticPath)))
+	if config.Metrics != nil {
+		r.With(accessLog).Handle("/metrics", config.Metrics.Handler())
+	}
+	staticFiles, err := staticFileSystem(config.StaticPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open static files: %w", err)
+	}
+	// The file server looks the files up by the path of the request, so it must not contain the base path
+	r.With(accessLog).Handle("/*", http.StripPrefix(basePath, spaHandler(staticFiles)))
+
+	var handler http.Handler = r
+	if basePath != "" {
+		root := chi.NewRouter()
+		root.Mount(basePath, r)
+		handler = root
+	}
 
-	return &http.Server{
+	httpServer := &http.Server{
 		Addr:    net.JoinHostPort(config.Hostname, config.Port),
-		Handler: r,
+		Handler: handler,
 	}
+	// SSE connections never become idle, they have to be told to finish for Shutdown to complete
+	httpServer.RegisterOnShutdown(server.closeStreams)
+
+	return httpServer, nil
+}
+
+func (s *Server) closeStreams() {
+	s.shutdownOnce.Do(func() {
+		close(s.shutdown)
+	})
 }
 
 func (s *Server) snapshotOverview(w http.ResponseWriter, r *http.Request) {
 	flusher, ok := w.(http.Flusher)
 	if !ok {
-		w.WriteHeader(http.StatusPreconditionFailed)
 		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusPreconditionFailed)
 		w.Write([]byte(`{"error": "not flusher"}`))
 		return
 	}
 
-	subscriber, err := NewSubscriber(s.centralBroker, monitorIds...)
+	monitorIds := s.monitors.IDs()
+	subscriber, err := NewSubscriberWithOptions(s.centralBroker, s.subscriberOptions(), monitorIds...)
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
 		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
 		errBytes, err := json.Marshal(map[string]string{"error": fmt.Errorf("failed to subscribe to endpoints: %s", err).Error()})
 		if err != nil {
 			w.Write([]byte(`{"error": "internal server error"}`))
@@ -102,41 +330,22 @@ func (s *Server) snapshotOverview(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	for {
-		select {
-		case <-r.Context().Done():
-			return
-		case data := <-subscriber.Listen(r.Context()):
-			marshaled, err := json.Marshal(data)
-			if err != nil {
-				log.Printf("failed to marshal data: %s", err)
-			}
-
-			_, err = w.Write([]byte("data: " + string(marshaled) + "\n\n"))
-			if err != nil {
-				log.Printf("failed to write data: %s", err)
-			}
-
-			flusher.Flush()
-		default:
-			time.Sleep(time.Millisecond * 10)
-		}
-	}
+	s.stream(w, r, flusher, subscriber, monitorIds)
 }
 
 func (s *Server) snapshotBy(w http.ResponseWriter, r *http.Request) {
 	flusher, ok := w.(http.Flusher)
 	if !ok {
-		w.WriteHeader(http.StatusPreconditionFailed)
 		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusPreconditionFailed)
 		w.Write([]byte(`{"error": "not flusher"}`))
 		return
 	}
 
 	ids := r.URL.Query().Get("ids")
 	if ids == "" {
-		w.WriteHeader(http.StatusBadRequest)
 		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
 		w.Write([]byte(`{"error":"ids is required"}`))
 		return
 	}
@@ -144,23 +353,18 @@ func (s *Server) snapshotBy(w http.ResponseWriter, r *http.Request) {
 	wantedMonitorIds := strings.Split(ids, ",")
 
 	for _, id := range wantedMonitorIds {
-		if !slices.Contains(monitorIds, id) {
+		if !s.monitors.Contains(id) {
 			w.Header().Set("Content-Type", "application/json")
-			w.WriteHeader(http.StatusBadRequest)
+			w.WriteHeader(http.StatusNotFound)
 			w.Write([]byte(`{"error": "id is not in the list of monitors"}`))
 			return
 		}
 	}
 
-	w.Header().Set("Content-Type", "text/event-stream")
-	w.Header().Set("Cache-Control", "no-cache")
-	w.Header().Set("Connection", "keep-alive")
-	w.WriteHeader(http.StatusOK)
-
-	sub, err := NewSubscriber(s.centralBroker, wantedMonitorIds...)
+	sub, err := NewSubscriberWithOptions(s.centralBroker, s.subscriberOptions(), wantedMonitorIds...)
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
 		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
 		errBytes, err := json.Marshal(map[string]string{"error": fmt.Errorf("failed to subscribe to endpoints: %s", err).Error()})
 		if err != nil {
 			w.Write([]byte(`{"error": "internal server error"}`))
@@ -170,33 +374,112 @@ func (s *Server) snapshotBy(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	s.stream(w, r, flusher, sub, wantedMonitorIds)
+}
+
+// subscriberOptions returns the options of the subscribers of the SSE and WebSocket streams.
+func (s *Server) subscriberOptions() SubscriberOptions {
+	options := SubscriberOptions{Registry: s.subscribers}
+	if s.metrics != nil {
+		options.OnDrop = s.metrics.ObserveDroppedEvent
+	}
+
+	return options
+}
+
+// stream writes the subscribed events to the client until it disconnects. If the client is resuming with
+// a Last-Event-ID header, the buffered events after that id are replayed before the live events. A keepalive
+// comment is written whenever the stream has been idle for the heartbeat interval, so proxies and load
+// balancers won't consider the connection dead.
+func (s *Server) stream(w http.ResponseWriter, r *http.Request, flusher http.Flusher, subscriber *Subscriber, monitorIds []string) {
+	defer func() {
+		err := subscriber.Close()
+		if err != nil {
+			log.Warn().Err(err).Msg("failed to close subscriber")
+		}
+	}()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	// The subscription is made before replaying, so events published in between are not missed.
+	// They are deduplicated by the id instead.
+	var lastEventId uint64
+	if value := r.Header.Get("Last-Event-ID"); value != "" {
+		parsed, err := strconv.ParseUint(value, 10, 64)
+		if err == nil {
+			for _, message := range s.centralBroker.Replay(parsed, monitorIds...) {
+				writeEvent(w, message)
+				lastEventId = message.ID
+			}
+
+			flusher.Flush()
+		}
+	}
+
+	heartbeat := time.NewTicker(s.sseHeartbeatInterval)
+	defer heartbeat.Stop()
+
 	for {
 		select {
 		case <-r.Context().Done():
 			return
-		case data := <-sub.Listen(r.Context()):
-			marshaled, err := json.Marshal(data)
+		case <-s.shutdown:
+			// Let the client know it should reconnect, rather than treating it as a network failure
+			_, err := w.Write([]byte("retry: 5000\n: server is shutting down\n\n"))
 			if err != nil {
-				log.Printf("failed to marshal data: %s", err)
+				log.Printf("failed to write shutdown comment: %s", err)
 			}
 
-			_, err = w.Write([]byte("data: " + string(marshaled) + "\n\n"))
+			flusher.Flush()
+			return
+		case <-heartbeat.C:
+			_, err := w.Write([]byte(": keepalive\n\n"))
 			if err != nil {
-				log.Printf("failed to write data: %s", err)
+				log.Printf("failed to write heartbeat: %s", err)
+			}
+
+			flusher.Flush()
+		case message := <-subscriber.Listen(r.Context()):
+			if message.ID <= lastEventId {
+				// Already sent on replay
+				continue
 			}
 
+			writeEvent(w, message)
 			flusher.Flush()
-		default:
-			time.Sleep(time.Millisecond * 10)
+			heartbeat.Reset(s.sseHeartbeatInterval)
 		}
 	}
 }
 
+func writeEvent(w http.ResponseWriter, message *BrokerMessage[MonitorHistorical]) {
+	marshaled, err := json.Marshal(message.Body)
+	if err != nil {
+		log.Printf("failed to marshal data: %s", err)
+	}
+
+	_, err = w.Write([]byte("id: " + strconv.FormatUint(message.ID, 10) + "\ndata: " + string(marshaled) + "\n\n"))
+	if err != nil {
+		log.Printf("failed to write data: %s", err)
+	}
+}
+
+const (
+	// defaultStaticSnapshotLimit is the page size of the static snapshot when no limit is given.
+	defaultStaticSnapshotLimit = 1000
+	// maxStaticSnapshotLimit is the largest page size that can be requested from the static snapshot.
+	maxStaticSnapshotLimit = 10000
+)
+
 func (s *Server) staticSnapshot(w http.ResponseWriter, r *http.Request) {
 	monitorId := r.URL.Query().Get("id")
 	if monitorId == "" {
-		w.WriteHeader(http.StatusBadRequest)
 		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
 		w.Write([]byte(`{"error": "id is required"}`))
 		return
 	}
@@ -207,69 +490,110 @@ func (s *Server) staticSnapshot(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if interval != "hourly" && interval != "daily" && interval != "raw" {
-		w.WriteHeader(http.StatusBadRequest)
 		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
 		w.Write([]byte(`{"error": "interval must be hourly, daily, or raw"}`))
 		return
 	}
 
-	if !slices.Contains(monitorIds, monitorId) {
-		w.WriteHeader(http.StatusBadRequest)
+	if !s.monitors.Contains(monitorId) {
 		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
 		w.Write([]byte(`{"error": "id is not in the list of monitors"}`))
 		return
 	}
 
-	var err error
-	var monitor Monitor
-	var monitorHistorical []MonitorHistorical
-	switch interval {
-	case "raw":
-		monitorHistorical, err = s.historicalReader.ReadRawHistorical(r.Context(), monitorId)
-		if err != nil {
-			// TODO: Handle error properly
-			w.WriteHeader(http.StatusInternalServerError)
+	limit := defaultStaticSnapshotLimit
+	if value := r.URL.Query().Get("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed <= 0 {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error": "limit must be a positive integer"}`))
 			return
 		}
-		break
-	case "hourly":
-		monitorHistorical, err = s.historicalReader.ReadHourlyHistorical(r.Context(), monitorId)
-		if err != nil {
-			// TODO: Handle error properly
-			w.WriteHeader(http.StatusInternalServerError)
+
+		limit = min(parsed, maxStaticSnapshotLimit)
+	}
+
+	var offset int
+	if value := r.URL.Query().Get("offset"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 0 {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error": "offset must be a non-negative integer"}`))
 			return
 		}
-		break
-	case "daily":
-		monitorHistorical, err = s.historicalReader.ReadDailyHistorical(r.Context(), monitorId)
+
+		offset = parsed
+	}
+
+	// The range is filtered by the database, so only the requested page is read into memory
+	var from, to time.Time
+	for _, param := range []struct {
+		name   string
+		target *time.Time
+	}{{name: "from", target: &from}, {name: "to", target: &to}} {
+		value := r.URL.Query().Get(param.name)
+		if value == "" {
+			continue
+		}
+
+		parsed, err := time.Parse(time.RFC3339, value)
 		if err != nil {
-			// TODO: Handle error properly
-			w.WriteHeader(http.StatusInternalServerError)
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error": "` + param.name + ` must be an RFC3339 timestamp"}`))
 			return
 		}
-		break
-	default:
+
+		*param.target = parsed
+	}
+
+	if !from.IsZero() && !to.IsZero() && from.After(to) {
+		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"error": "from must not be after to"}`))
+		return
+	}
+
+	monitorHistorical, total, err := s.historicalReader.ReadHistoricalPage(r.Context(), monitorId, interval, from, to, limit, offset)
+	if err != nil {
+		log.Error().Err(err).Str("monitor_id", monitorId).Msg("failed to read historical data")
 		w.Header().Set("Content-Type", "application/json")
-		w.Write([]byte(`{"error": "interval must be hourly, daily, or raw"}`))
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(`{"error": "failed to read historical data"}`))
 		return
 	}
 
-	// Acquire monitor metadata
-	for _, m := range s.monitors {
-		if m.UniqueID == monitorId {
-			monitor = m
-			break
-		}
+	if monitorHistorical == nil {
+		monitorHistorical = []MonitorHistorical{}
+	}
+
+	// The metadata comes from the configured monitors, it's not stored with the historical data. The monitor
+	// may have been removed by a configuration reload while the historical data was read.
+	monitor, ok := s.monitors.Get(monitorId)
+	if !ok {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"error": "monitor metadata is not found"}`))
+		return
 	}
 
 	data, err := json.Marshal(map[string]any{
-		"metadata":   monitor,
+		"metadata":   s.metadata(monitor),
 		"historical": monitorHistorical,
+		"pagination": map[string]any{
+			"limit":    limit,
+			"offset":   offset,
+			"total":    total,
+			"has_more": offset+len(monitorHistorical) < total,
+		},
 	})
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
 		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
 		errBytes, err := json.Marshal(map[string]string{"error": err.Error()})
 		if err != nil {
 			w.Write([]byte(`{"error": "internal server error"}`))
@@ -279,22 +603,31 @@ func (s *Server) staticSnapshot(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	w.WriteHeader(http.StatusOK)
+	// The client has to revalidate on every request, new checks are written all the time
+	etag := entityTag(data)
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", "no-cache")
+	if notModified(r, etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
 	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
 	w.Write(data)
 }
 
 func (s *Server) submitIncindent(w http.ResponseWriter, r *http.Request) {
 	apiKey := r.Header.Get("x-api-key")
 	if apiKey == "" {
-		w.WriteHeader(http.StatusUnauthorized)
 		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusUnauthorized)
 		w.Write([]byte(`{"error": "api key is required"}`))
 		return
 	} else {
 		if apiKey != s.apiKey {
-			w.WriteHeader(http.StatusUnauthorized)
 			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusUnauthorized)
 			w.Write([]byte(`{"error": "api key is invalid"}`))
 			return
 		}
@@ -303,8 +636,8 @@ func (s *Server) submitIncindent(w http.ResponseWriter, r *http.Request) {
 	decoder := json.NewDecoder(r.Body)
 	var body Incident
 	if err := decoder.Decode(&body); err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
 		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
 		errBytes, marshalErr := json.Marshal(map[string]string{
 			"error": err.Error(),
 		})
@@ -319,8 +652,8 @@ func (s *Server) submitIncindent(w http.ResponseWriter, r *http.Request) {
 	defer r.Body.Close()
 
 	if err := body.Validate(); err != nil {
-		w.WriteHeader(http.StatusBadRequest)
 		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
 		errBytes, marshalErr := json.Marshal(map[string]string{
 			"error": err.Error(),
 		})
@@ -335,8 +668,8 @@ func (s *Server) submitIncindent(w http.ResponseWriter, r *http.Request) {
 
 	err := s.incidentWriter.Write(r.Context(), body)
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
 		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
 		errBytes, err := json.Marshal(map[string]string{
 			"error": err.Error(),
 		})
@@ -349,7 +682,7 @@ func (s *Server) submitIncindent(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	w.WriteHeader(http.StatusOK)
 	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
 	w.Write([]byte(`{"message": "success"}`))
 }
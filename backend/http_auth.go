@@ -0,0 +1,51 @@
+package main
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"net/http"
+)
+
+// apiKeyMiddleware rejects requests without one of the keys in the X-API-Key header. Every request is let through
+// when there are no keys.
+func apiKeyMiddleware(keys []string) func(http.Handler) http.Handler {
+	// The keys are hashed, so each comparison takes the same time regardless of the key length
+	hashes := make([][sha256.Size]byte, 0, len(keys))
+	for _, key := range keys {
+		if key != "" {
+			hashes = append(hashes, sha256.Sum256([]byte(key)))
+		}
+	}
+
+	return func(next http.Handler) http.Handler {
+		if len(hashes) == 0 {
+			return next
+		}
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get("X-API-Key")
+			if key == "" {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusUnauthorized)
+				w.Write([]byte(`{"error": "api key is required"}`))
+				return
+			}
+
+			hash := sha256.Sum256([]byte(key))
+			valid := 0
+			// Every key is compared to not leak which one matched
+			for _, expected := range hashes {
+				valid |= subtle.ConstantTimeCompare(hash[:], expected[:])
+			}
+
+			if valid != 1 {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusUnauthorized)
+				w.Write([]byte(`{"error": "api key is invalid"}`))
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
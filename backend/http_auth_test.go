@@ -0,0 +1,86 @@
+package main_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	main "semyi"
+)
+
+func TestServer_ApiKeyAuth(t *testing.T) {
+	server, err := main.NewServer(main.ServerConfig{
+		MonitorHistoricalReader: main.NewMonitorHistoricalReader(database),
+		CentralBroker:           main.NewBroker[main.MonitorHistorical](),
+		MonitorList:             []main.Monitor{{UniqueID: "auth-test", Name: "Auth Test"}},
+		ApiKey:                  "incident-key",
+		ApiKeys:                 []string{"first-key", "second-key"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error creating server: %v", err)
+	}
+
+	testCases := []struct {
+		name   string
+		key    string
+		expect int
+	}{
+		{name: "Missing key", key: "", expect: http.StatusUnauthorized},
+		{name: "Wrong key", key: "wrong-key", expect: http.StatusUnauthorized},
+		{name: "Prefix of a key", key: "first", expect: http.StatusUnauthorized},
+		{name: "Correct key", key: "first-key", expect: http.StatusOK},
+		{name: "Another correct key", key: "second-key", expect: http.StatusOK},
+		{name: "Incident key", key: "incident-key", expect: http.StatusOK},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			request := httptest.NewRequest(http.MethodGet, "/api/status", nil)
+			if testCase.key != "" {
+				request.Header.Set("X-API-Key", testCase.key)
+			}
+
+			recorder := httptest.NewRecorder()
+			server.Handler.ServeHTTP(recorder, request)
+			if recorder.Code != testCase.expect {
+				t.Errorf("expected status code %d, got %d", testCase.expect, recorder.Code)
+			}
+		})
+	}
+
+	t.Run("Should protect the streams", func(t *testing.T) {
+		recorder := httptest.NewRecorder()
+		server.Handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/api/overview", nil))
+		if recorder.Code != http.StatusUnauthorized {
+			t.Errorf("expected status code %d, got %d", http.StatusUnauthorized, recorder.Code)
+		}
+	})
+
+	t.Run("Should leave the health probes public", func(t *testing.T) {
+		for _, path := range []string{"/api/health", "/api/ready"} {
+			recorder := httptest.NewRecorder()
+			server.Handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, path, nil))
+			if recorder.Code != http.StatusOK {
+				t.Errorf("%s: expected status code %d, got %d", path, http.StatusOK, recorder.Code)
+			}
+		}
+	})
+}
+
+func TestServer_ApiKeyAuthDisabled(t *testing.T) {
+	server, err := main.NewServer(main.ServerConfig{
+		MonitorHistoricalReader: main.NewMonitorHistoricalReader(database),
+		CentralBroker:           main.NewBroker[main.MonitorHistorical](),
+		MonitorList:             []main.Monitor{{UniqueID: "auth-disabled-test", Name: "Auth Disabled Test"}},
+		ApiKey:                  "incident-key",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error creating server: %v", err)
+	}
+
+	recorder := httptest.NewRecorder()
+	server.Handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/api/status", nil))
+	if recorder.Code != http.StatusOK {
+		t.Errorf("expected status code %d, got %d", http.StatusOK, recorder.Code)
+	}
+}
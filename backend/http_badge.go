@@ -0,0 +1,107 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"net/http"
+	"strconv"
+)
+
+const (
+	badgeColorGreen  = "#4c1"
+	badgeColorYellow = "#dfb317"
+	badgeColorRed    = "#e05d44"
+	badgeColorGrey   = "#9f9f9f"
+)
+
+// BadgeThresholds specifies the uptime percentages that decide the color of the status badge.
+type BadgeThresholds struct {
+	// Green specifies the uptime percentage that must be exceeded for a green badge. Defaults to 99.
+	Green float64
+	// Yellow specifies the uptime percentage that must be exceeded for a yellow badge. Anything lower
+	// than this results in a red badge. Defaults to 95.
+	Yellow float64
+}
+
+func (t BadgeThresholds) color(uptime *float64) string {
+	switch {
+	case uptime == nil:
+		return badgeColorGrey
+	case *uptime > t.Green:
+		return badgeColorGreen
+	case *uptime > t.Yellow:
+		return badgeColorYellow
+	default:
+		return badgeColorRed
+	}
+}
+
+func (s *Server) badge(w http.ResponseWriter, r *http.Request) {
+	monitorId := r.URL.Query().Get("id")
+	if monitorId == "" {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"error": "id is required"}`))
+		return
+	}
+
+	if !s.monitors.Contains(monitorId) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"error": "id is not in the list of monitors"}`))
+		return
+	}
+
+	_, summary, err := s.calculateUptime(r, monitorId)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(err.statusCode)
+		errBytes, marshalErr := json.Marshal(map[string]string{"error": err.Error()})
+		if marshalErr != nil {
+			w.Write([]byte(`{"error": "internal server error"}`))
+			return
+		}
+		w.Write(errBytes)
+		return
+	}
+
+	label := monitorId
+	for _, m := range s.monitors.List() {
+		if m.UniqueID == monitorId && m.Name != "" {
+			label = m.Name
+			break
+		}
+	}
+
+	message := "no data"
+	if summary.Uptime != nil {
+		message = strconv.FormatFloat(*summary.Uptime, 'f', -1, 64) + "%"
+	}
+
+	// Badges are embedded in READMEs and proxied by image caches, keep them fresh but not too fresh.
+	w.Header().Set("Content-Type", "image/svg+xml")
+	w.Header().Set("Cache-Control", "public, max-age=60, s-maxage=60")
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(renderBadge(label, message, s.badgeThresholds.color(summary.Uptime))))
+}
+
+// renderBadge renders a shields.io flat style badge. The text width is approximated, as we don't have access
+// to the font metrics.
+func renderBadge(label string, message string, color string) string {
+	labelWidth := len(label)*7 + 10
+	messageWidth := len(message)*7 + 10
+	totalWidth := labelWidth + messageWidth
+
+	label = html.EscapeString(label)
+	message = html.EscapeString(message)
+
+	return fmt.Sprintf(`<svg xmlns="http://www.w3.org/2000/svg" width="%[1]d" height="20" role="img" aria-label="%[4]s: %[5]s">`+
+		`<title>%[4]s: %[5]s</title>`+
+		`<linearGradient id="s" x2="0" y2="100%%"><stop offset="0" stop-color="#bbb" stop-opacity=".1"/><stop offset="1" stop-opacity=".1"/></linearGradient>`+
+		`<clipPath id="r"><rect width="%[1]d" height="20" rx="3" fill="#fff"/></clipPath>`+
+		`<g clip-path="url(#r)"><rect width="%[2]d" height="20" fill="#555"/><rect x="%[2]d" width="%[3]d" height="20" fill="%[6]s"/><rect width="%[1]d" height="20" fill="url(#s)"/></g>`+
+		`<g fill="#fff" text-anchor="middle" font-family="Verdana,Geneva,DejaVu Sans,sans-serif" font-size="11">`+
+		`<text x="%[7]d" y="14">%[4]s</text><text x="%[8]d" y="14">%[5]s</text></g></svg>`,
+		totalWidth, labelWidth, messageWidth, label, message, color, labelWidth/2, labelWidth+messageWidth/2)
+}
@@ -0,0 +1,75 @@
+package main_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	main "semyi"
+)
+
+func TestServer_Badge(t *testing.T) {
+	writer := main.NewMonitorHistoricalWriter(database)
+	now := time.Now()
+	for i, status := range []main.MonitorStatus{main.MonitorStatusSuccess, main.MonitorStatusSuccess, main.MonitorStatusSuccess, main.MonitorStatusFailure} {
+		err := writer.Write(context.Background(), main.MonitorHistorical{
+			MonitorID: "badge-test",
+			Status:    status,
+			Latency:   10,
+			Timestamp: now.Add(-time.Minute * time.Duration(i+1)),
+		})
+		if err != nil {
+			t.Fatalf("unexpected error writing historical data: %v", err)
+		}
+	}
+
+	tests := []struct {
+		name       string
+		thresholds main.BadgeThresholds
+		wantColor  string
+	}{
+		{"default thresholds", main.BadgeThresholds{}, "#e05d44"},
+		{"custom thresholds", main.BadgeThresholds{Green: 90, Yellow: 70}, "#dfb317"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, err := main.NewServer(main.ServerConfig{
+				MonitorHistoricalReader: main.NewMonitorHistoricalReader(database),
+				CentralBroker:           main.NewBroker[main.MonitorHistorical](),
+				MonitorList:             []main.Monitor{{UniqueID: "badge-test", Name: "Badge Test"}},
+				BadgeThresholds:         tt.thresholds,
+			})
+			if err != nil {
+				t.Fatalf("unexpected error creating server: %v", err)
+			}
+
+			recorder := httptest.NewRecorder()
+			server.Handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/api/badge?id=badge-test&window=1h", nil))
+			if recorder.Code != http.StatusOK {
+				t.Fatalf("expected status code %d, got %d", http.StatusOK, recorder.Code)
+			}
+
+			if contentType := recorder.Result().Header.Get("Content-Type"); contentType != "image/svg+xml" {
+				t.Errorf("expected Content-Type image/svg+xml, got %q", contentType)
+			}
+
+			body, _ := io.ReadAll(recorder.Body)
+			if !strings.Contains(string(body), "75%") {
+				t.Errorf("expected badge to contain the uptime percentage, got %s", body)
+			}
+
+			if !strings.Contains(string(body), tt.wantColor) {
+				t.Errorf("expected badge to be colored %s, got %s", tt.wantColor, body)
+			}
+
+			if !strings.Contains(string(body), "Badge Test") {
+				t.Errorf("expected badge to contain the monitor name, got %s", body)
+			}
+		})
+	}
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"net"
+	"net/http"
+	"time"
+)
+
+// HttpClientConfig tunes the connection pool of the HTTP client shared by every HTTP monitor.
+type HttpClientConfig struct {
+	// MaxIdleConns specifies how many idle connections are kept across every host. Defaults to 100.
+	MaxIdleConns int `json:"max_idle_conns" yaml:"max_idle_conns" toml:"max_idle_conns"`
+	// MaxIdleConnsPerHost specifies how many idle connections are kept for each host. Defaults to 10.
+	MaxIdleConnsPerHost int `json:"max_idle_conns_per_host" yaml:"max_idle_conns_per_host" toml:"max_idle_conns_per_host"`
+	// IdleConnTimeout specifies how long an idle connection is kept before it's closed, in seconds.
+	// Defaults to 90 seconds.
+	IdleConnTimeout int `json:"idle_conn_timeout" yaml:"idle_conn_timeout" toml:"idle_conn_timeout"`
+}
+
+// Version is the version of semya, it's set on build with -ldflags "-X main.Version=<version>".
+var Version = "dev"
+
+// DefaultUserAgent returns the User-Agent header of the checks of the monitors that don't configure one.
+func DefaultUserAgent() string {
+	return "semya/" + Version
+}
+
+// DefaultHttpClient is used by the workers that are not given a client, e.g. the ones created outside of
+// a WorkerManager, and by managers that are not given one.
+var DefaultHttpClient = NewHttpClient(HttpClientConfig{})
+
+// NewHttpClient creates the client that is shared by the HTTP monitors, so connections to the same host
+// are reused between checks. The request timeout is left to the context of each check.
+func NewHttpClient(config HttpClientConfig) *http.Client {
+	if config.MaxIdleConns <= 0 {
+		config.MaxIdleConns = 100
+	}
+
+	if config.MaxIdleConnsPerHost <= 0 {
+		config.MaxIdleConnsPerHost = 10
+	}
+
+	if config.IdleConnTimeout <= 0 {
+		config.IdleConnTimeout = 90
+	}
+
+	return &http.Client{
+		Transport: &http.Transport{
+			Proxy: http.ProxyFromEnvironment,
+			DialContext: (&net.Dialer{
+				Timeout:   30 * time.Second,
+				KeepAlive: 30 * time.Second,
+			}).DialContext,
+			ForceAttemptHTTP2:     true,
+			MaxIdleConns:          config.MaxIdleConns,
+			MaxIdleConnsPerHost:   config.MaxIdleConnsPerHost,
+			IdleConnTimeout:       time.Duration(config.IdleConnTimeout) * time.Second,
+			TLSHandshakeTimeout:   10 * time.Second,
+			ExpectContinueTimeout: 1 * time.Second,
+		},
+	}
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"net"
+	"net/http"
+	"strings"
+)
+
+// ClientIP returns the address of the client. When the request comes from a trusted proxy, X-Forwarded-For is
+// walked from right to left, skipping the trusted proxies, and the first address that isn't a trusted proxy is the
+// client. Everything on the left of it could have been set by the client, so it's never trusted. It falls back
+// to RemoteAddr when the request doesn't come from a trusted proxy.
+func ClientIP(r *http.Request, trustedProxies []net.IPNet) string {
+	remote, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		remote = r.RemoteAddr
+	}
+
+	if !isTrustedProxy(remote, trustedProxies) {
+		return remote
+	}
+
+	addresses := strings.Split(strings.Join(r.Header.Values("X-Forwarded-For"), ","), ",")
+	for i := len(addresses) - 1; i >= 0; i-- {
+		address := strings.TrimSpace(addresses[i])
+		if address == "" {
+			continue
+		}
+
+		if net.ParseIP(address) == nil {
+			// Whatever is on the left of a malformed entry can't be trusted
+			return remote
+		}
+
+		if !isTrustedProxy(address, trustedProxies) {
+			return address
+		}
+
+		remote = address
+	}
+
+	return remote
+}
+
+func isTrustedProxy(address string, trustedProxies []net.IPNet) bool {
+	ip := net.ParseIP(address)
+	if ip == nil {
+		return false
+	}
+
+	for _, network := range trustedProxies {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,101 @@
+package main_test
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	main "semyi"
+)
+
+func TestClientIP(t *testing.T) {
+	var trustedProxies []net.IPNet
+	for _, cidr := range []string{"10.0.0.0/8", "2001:db8::/32"} {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			t.Fatalf("unexpected error parsing cidr: %v", err)
+		}
+		trustedProxies = append(trustedProxies, *network)
+	}
+
+	testCases := []struct {
+		name         string
+		remoteAddr   string
+		forwardedFor []string
+		expect       string
+	}{
+		{
+			name:       "Direct connection",
+			remoteAddr: "203.0.113.1:1234",
+			expect:     "203.0.113.1",
+		},
+		{
+			name:         "Forwarded header from an untrusted client",
+			remoteAddr:   "203.0.113.1:1234",
+			forwardedFor: []string{"198.51.100.1"},
+			expect:       "203.0.113.1",
+		},
+		{
+			name:         "Single trusted proxy",
+			remoteAddr:   "10.0.0.1:1234",
+			forwardedFor: []string{"198.51.100.1"},
+			expect:       "198.51.100.1",
+		},
+		{
+			name:         "Chain of trusted proxies",
+			remoteAddr:   "10.0.0.1:1234",
+			forwardedFor: []string{"198.51.100.1, 10.0.0.3, 10.0.0.2"},
+			expect:       "198.51.100.1",
+		},
+		{
+			name:         "Spoofed address on the left of the client",
+			remoteAddr:   "10.0.0.1:1234",
+			forwardedFor: []string{"1.2.3.4, 198.51.100.1"},
+			expect:       "198.51.100.1",
+		},
+		{
+			name:         "Multiple forwarded headers",
+			remoteAddr:   "10.0.0.1:1234",
+			forwardedFor: []string{"1.2.3.4", "198.51.100.1, 10.0.0.2"},
+			expect:       "198.51.100.1",
+		},
+		{
+			name:         "Only trusted proxies",
+			remoteAddr:   "10.0.0.1:1234",
+			forwardedFor: []string{"10.0.0.3, 10.0.0.2"},
+			expect:       "10.0.0.3",
+		},
+		{
+			name:         "Malformed entry",
+			remoteAddr:   "10.0.0.1:1234",
+			forwardedFor: []string{"198.51.100.1, not-an-ip, 10.0.0.2"},
+			expect:       "10.0.0.2",
+		},
+		{
+			name:       "Trusted proxy without a forwarded header",
+			remoteAddr: "10.0.0.1:1234",
+			expect:     "10.0.0.1",
+		},
+		{
+			name:         "IPv6 trusted proxy",
+			remoteAddr:   "[2001:db8::1]:1234",
+			forwardedFor: []string{"2001:db8:ffff::1, 2001:db9::1"},
+			expect:       "2001:db9::1",
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			request := httptest.NewRequest(http.MethodGet, "/", nil)
+			request.RemoteAddr = testCase.remoteAddr
+			for _, value := range testCase.forwardedFor {
+				request.Header.Add("X-Forwarded-For", value)
+			}
+
+			if got := main.ClientIP(request, trustedProxies); got != testCase.expect {
+				t.Errorf("expected %s, got %s", testCase.expect, got)
+			}
+		})
+	}
+}
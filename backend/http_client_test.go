@@ -0,0 +1,70 @@
+package main_test
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	main "semyi"
+)
+
+func TestWorker_HttpConnectionReuse(t *testing.T) {
+	testCases := []struct {
+		name              string
+		disableKeepAlives bool
+		expect            int64
+	}{
+		{name: "Should reuse the connection", expect: 1},
+		{name: "Should not reuse the connection with keep-alives disabled", disableKeepAlives: true, expect: 3},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			var connections atomic.Int64
+			testServer := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte("a body that has to be drained before the connection is reused"))
+			}))
+			testServer.Config.ConnState = func(conn net.Conn, state http.ConnState) {
+				if state == http.StateNew {
+					connections.Add(1)
+				}
+			}
+			testServer.Start()
+			defer testServer.Close()
+
+			worker, err := main.NewWorker(main.Monitor{
+				UniqueID:              "connection-reuse",
+				Name:                  "Connection Reuse",
+				Type:                  main.MonitorTypeHTTP,
+				HttpEndpoint:          testServer.URL,
+				HttpDisableKeepAlives: testCase.disableKeepAlives,
+				Interval:              30,
+				Timeout:               10,
+			}, nil)
+			if err != nil {
+				t.Fatalf("unexpected error creating worker: %v", err)
+			}
+
+			for i := 0; i < 3; i++ {
+				ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+				response, err := worker.Check(ctx)
+				cancel()
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+
+				if !response.Success {
+					t.Fatalf("expected a successful check, got %+v", response)
+				}
+			}
+
+			if got := connections.Load(); got != testCase.expect {
+				t.Errorf("expected %d connections, got %d", testCase.expect, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// DebugStats is the response of the diagnostics endpoint.
+type DebugStats struct {
+	Monitors    []MonitorDebugStats `json:"monitors"`
+	Subscribers SubscriberStats     `json:"subscribers"`
+	Broker      []BrokerTopicStats  `json:"broker"`
+}
+
+// MonitorDebugStats is the state of the checks of a configured monitor.
+type MonitorDebugStats struct {
+	ID string `json:"id"`
+	MonitorCheckStats
+}
+
+// debugStats responds with the live counters of the workers, the subscribers, and the broker, to diagnose
+// a stuck monitor or stream.
+func (s *Server) debugStats(w http.ResponseWriter, r *http.Request) {
+	stats := DebugStats{
+		Subscribers: s.subscribers.Stats(),
+		Broker:      s.centralBroker.Stats(),
+	}
+
+	for _, monitor := range s.monitors.List() {
+		monitorStats := MonitorDebugStats{ID: monitor.UniqueID}
+		if s.checkStats != nil {
+			monitorStats.MonitorCheckStats = s.checkStats.Get(monitor.UniqueID)
+		}
+
+		stats.Monitors = append(stats.Monitors, monitorStats)
+	}
+
+	data, err := json.Marshal(stats)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(`{"error": "internal server error"}`))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
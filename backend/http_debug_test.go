@@ -0,0 +1,202 @@
+package main_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	main "semyi"
+)
+
+func TestServer_DebugStats(t *testing.T) {
+	broker := main.NewBroker[main.MonitorHistorical]()
+	checkStats := main.NewCheckStats()
+	server, err := main.NewServer(main.ServerConfig{
+		MonitorHistoricalReader: main.NewMonitorHistoricalReader(database),
+		CentralBroker:           broker,
+		MonitorList: []main.Monitor{
+			{UniqueID: "debug-test-api", Name: "API"},
+			{UniqueID: "debug-test-db", Name: "Database"},
+		},
+		CheckStats: checkStats,
+		ApiKeys:    []string{"debug-key"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error creating server: %v", err)
+	}
+
+	testServer := httptest.NewServer(server.Handler)
+	defer testServer.Close()
+
+	now := time.Now()
+	checkStats.Observe("debug-test-api", main.Response{Success: true, Timestamp: now.Add(-time.Minute)}, nil)
+	checkStats.Observe("debug-test-api", main.Response{Success: false, Timestamp: now.Add(-time.Second * 30)}, nil)
+	checkStats.Observe("debug-test-api", main.Response{Success: false, Timestamp: now}, nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Two streams of the API monitor, and one of both monitors
+	for _, ids := range []string{"debug-test-api", "debug-test-api", "debug-test-api,debug-test-db"} {
+		request, err := http.NewRequestWithContext(ctx, http.MethodGet, testServer.URL+"/api/by?ids="+ids, nil)
+		if err != nil {
+			t.Fatalf("unexpected error creating request: %v", err)
+		}
+		request.Header.Set("X-API-Key", "debug-key")
+
+		resp, err := http.DefaultClient.Do(request)
+		if err != nil {
+			t.Fatalf("unexpected error connecting: %v", err)
+		}
+		defer resp.Body.Close()
+	}
+
+	getStats := func(key string) (*http.Response, main.DebugStats) {
+		request, err := http.NewRequest(http.MethodGet, testServer.URL+"/api/debug/stats", nil)
+		if err != nil {
+			t.Fatalf("unexpected error creating request: %v", err)
+		}
+		if key != "" {
+			request.Header.Set("X-API-Key", key)
+		}
+
+		resp, err := http.DefaultClient.Do(request)
+		if err != nil {
+			t.Fatalf("unexpected error requesting stats: %v", err)
+		}
+		defer resp.Body.Close()
+
+		var stats main.DebugStats
+		if resp.StatusCode == http.StatusOK {
+			if err := json.NewDecoder(resp.Body).Decode(&stats); err != nil {
+				t.Fatalf("unexpected error decoding stats: %v", err)
+			}
+		}
+
+		return resp, stats
+	}
+
+	t.Run("Should require an api key", func(t *testing.T) {
+		resp, _ := getStats("")
+		if resp.StatusCode != http.StatusUnauthorized {
+			t.Errorf("expected status code %d, got %d", http.StatusUnauthorized, resp.StatusCode)
+		}
+	})
+
+	t.Run("Should report the active subscribers", func(t *testing.T) {
+		var stats main.DebugStats
+		// The streams are subscribed once their handler is running
+		deadline := time.Now().Add(time.Second * 5)
+		for {
+			var resp *http.Response
+			resp, stats = getStats("debug-key")
+			if resp.StatusCode != http.StatusOK {
+				t.Fatalf("expected status code %d, got %d", http.StatusOK, resp.StatusCode)
+			}
+
+			if stats.Subscribers.Active == 3 || time.Now().After(deadline) {
+				break
+			}
+			time.Sleep(time.Millisecond * 10)
+		}
+
+		if stats.Subscribers.Active != 3 || len(stats.Subscribers.Queues) != 3 {
+			t.Errorf("expected 3 active subscribers, got %+v", stats.Subscribers)
+		}
+
+		topics := map[string]main.BrokerTopicStats{}
+		for _, topic := range stats.Broker {
+			topics[topic.Topic] = topic
+		}
+
+		if topics["debug-test-api"].Subscribers != 3 || topics["debug-test-db"].Subscribers != 1 {
+			t.Errorf("expected 3 subscribers of debug-test-api and 1 of debug-test-db, got %+v", stats.Broker)
+		}
+	})
+
+	t.Run("Should report the checks of every monitor", func(t *testing.T) {
+		_, stats := getStats("debug-key")
+		if len(stats.Monitors) != 2 {
+			t.Fatalf("expected 2 monitors, got %+v", stats.Monitors)
+		}
+
+		api, db := stats.Monitors[0], stats.Monitors[1]
+		if api.ID != "debug-test-api" || api.Checks != 3 || api.ConsecutiveFailures != 2 || api.LastCheck == nil || !api.LastCheck.Equal(now) {
+			t.Errorf("unexpected stats of debug-test-api: %+v", api)
+		}
+
+		if db.ID != "debug-test-db" || db.Checks != 0 || db.LastCheck != nil {
+			t.Errorf("expected debug-test-db not to be checked, got %+v", db)
+		}
+	})
+
+	t.Run("Should release the closed subscribers", func(t *testing.T) {
+		cancel()
+
+		deadline := time.Now().Add(time.Second * 5)
+		for {
+			_, stats := getStats("debug-key")
+			if stats.Subscribers.Active == 0 {
+				break
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("expected no active subscribers, got %+v", stats.Subscribers)
+			}
+			time.Sleep(time.Millisecond * 10)
+		}
+	})
+}
+
+func TestServer_DebugStats_WithoutApiKeys(t *testing.T) {
+	server, err := main.NewServer(main.ServerConfig{
+		MonitorHistoricalReader: main.NewMonitorHistoricalReader(database),
+		CentralBroker:           main.NewBroker[main.MonitorHistorical](),
+	})
+	if err != nil {
+		t.Fatalf("unexpected error creating server: %v", err)
+	}
+
+	recorder := httptest.NewRecorder()
+	server.Handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/api/debug/stats", nil))
+	if recorder.Code != http.StatusNotFound {
+		t.Errorf("expected status code %d, got %d", http.StatusNotFound, recorder.Code)
+	}
+}
+
+func TestWorkerManager_CheckStats(t *testing.T) {
+	failingServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer failingServer.Close()
+
+	checkStats := main.NewCheckStats()
+	manager := main.NewWorkerManagerWithOptions(nil, nil, main.WorkerManagerOptions{CheckStats: checkStats})
+	defer manager.Stop()
+
+	_, err := manager.Apply([]main.Monitor{{
+		UniqueID:     "check-stats-test",
+		Name:         "Check Stats Test",
+		Type:         main.MonitorTypeHTTP,
+		HttpEndpoint: failingServer.URL,
+		Interval:     3600,
+		Timeout:      5,
+	}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	deadline := time.Now().Add(time.Second * 5)
+	for checkStats.Get("check-stats-test").Checks == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for the check to be recorded")
+		}
+		time.Sleep(time.Millisecond * 10)
+	}
+
+	if stats := checkStats.Get("check-stats-test"); stats.ConsecutiveFailures != 1 || stats.LastCheck == nil {
+		t.Errorf("expected 1 consecutive failure, got %+v", stats)
+	}
+}
@@ -0,0 +1,194 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// export streams the historical data of a monitor as a downloadable file. The format is either "json" (default)
+// or "csv".
+func (s *Server) export(w http.ResponseWriter, r *http.Request) {
+	monitorId := r.URL.Query().Get("id")
+	if monitorId == "" {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"error": "id is required"}`))
+		return
+	}
+
+	interval := r.URL.Query().Get("interval")
+	if interval == "" {
+		interval = "raw"
+	}
+
+	if interval != "hourly" && interval != "daily" && interval != "raw" {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"error": "interval must be hourly, daily, or raw"}`))
+		return
+	}
+
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = "json"
+	}
+
+	if format != "json" && format != "csv" {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"error": "format must be json or csv"}`))
+		return
+	}
+
+	if !s.monitors.Contains(monitorId) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"error": "id is not in the list of monitors"}`))
+		return
+	}
+
+	filename := monitorId + "-" + interval + "." + format
+	w.Header().Set("Content-Disposition", `attachment; filename="`+filename+`"`)
+
+	var err error
+	if format == "csv" {
+		w.Header().Set("Content-Type", "text/csv")
+		w.WriteHeader(http.StatusOK)
+
+		writer := csv.NewWriter(w)
+		if err = writer.Write([]string{"timestamp", "status", "latency_ms"}); err == nil {
+			err = s.historicalReader.StreamHistorical(r.Context(), monitorId, interval, func(historical MonitorHistorical) error {
+				return writer.Write([]string{
+					historical.Timestamp.UTC().Format(time.RFC3339),
+					historical.Status.String(),
+					strconv.FormatInt(historical.Latency, 10),
+				})
+			})
+		}
+		writer.Flush()
+		if err == nil {
+			err = writer.Error()
+		}
+	} else {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+
+		// Each entry is encoded as it's read. On failure the array is left unclosed, so a truncated export
+		// can't be mistaken for a complete one.
+		encoder := json.NewEncoder(w)
+		separator := "["
+		err = s.historicalReader.StreamHistorical(r.Context(), monitorId, interval, func(historical MonitorHistorical) error {
+			if _, err := w.Write([]byte(separator)); err != nil {
+				return err
+			}
+			separator = ","
+
+			return encoder.Encode(historical)
+		})
+		if err == nil {
+			if separator == "[" {
+				w.Write([]byte("["))
+			}
+			w.Write([]byte("]"))
+		}
+	}
+
+	// The status code is already written, the error can only be logged at this point
+	if err != nil {
+		log.Error().Err(err).Str("monitor_id", monitorId).Msg("failed to export historical data")
+	}
+}
+
+// StatusPageSnapshot is the state of every monitor at a point in time, for archiving.
+type StatusPageSnapshot struct {
+	GeneratedAt time.Time `json:"generated_at"`
+	// Window specifies how far back the history of every monitor is summarized.
+	Window   string                      `json:"window"`
+	Monitors []MonitorStatusPageSnapshot `json:"monitors"`
+}
+
+// MonitorStatusPageSnapshot is the metadata, the latest status, and the recent history of a monitor.
+type MonitorStatusPageSnapshot struct {
+	Monitor Monitor               `json:"monitor"`
+	Status  MonitorStatusSnapshot `json:"status"`
+	// Uptime specifies the uptime of the monitor within the window.
+	Uptime UptimeSummary `json:"uptime"`
+	// Outages lists the outages within the window, the oldest first.
+	Outages []Outage `json:"outages"`
+}
+
+// exportSnapshot responds with the state of every monitor as a single downloadable document, e.g. to archive
+// it for a post-mortem. The history is summarized over the window query parameter, which defaults to 24h.
+func (s *Server) exportSnapshot(w http.ResponseWriter, r *http.Request) {
+	window, duration, uptimeErr := s.uptimeWindow(r)
+	if uptimeErr != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(uptimeErr.statusCode)
+		errBytes, err := json.Marshal(map[string]string{"error": uptimeErr.Error()})
+		if err != nil {
+			w.Write([]byte(`{"error": "internal server error"}`))
+			return
+		}
+		w.Write(errBytes)
+		return
+	}
+
+	now := time.Now()
+	monitors := s.monitors.List()
+	snapshot := StatusPageSnapshot{
+		GeneratedAt: now.UTC(),
+		Window:      window,
+		Monitors:    make([]MonitorStatusPageSnapshot, 0, len(monitors)),
+	}
+
+	for _, monitor := range monitors {
+		status, err := s.statusSnapshot(r.Context(), monitor)
+		if err != nil {
+			log.Error().Err(err).Str("monitor_id", monitor.UniqueID).Msg("failed to read latest historical data for snapshot")
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusInternalServerError)
+			w.Write([]byte(`{"error": "failed to read historical data"}`))
+			return
+		}
+
+		historical, err := s.historicalReader.ReadRawHistoricalRange(r.Context(), monitor.UniqueID, now.Add(-duration), now)
+		if err != nil {
+			log.Error().Err(err).Str("monitor_id", monitor.UniqueID).Msg("failed to read historical data for snapshot")
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusInternalServerError)
+			w.Write([]byte(`{"error": "failed to read historical data"}`))
+			return
+		}
+
+		outages := GroupOutages(historical, s.outageFlapTolerance)
+		if outages == nil {
+			outages = []Outage{}
+		}
+
+		snapshot.Monitors = append(snapshot.Monitors, MonitorStatusPageSnapshot{
+			Monitor: monitor,
+			Status:  status,
+			Uptime:  CalculateUptime(historical),
+			Outages: outages,
+		})
+	}
+
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(`{"error": "internal server error"}`))
+		return
+	}
+
+	filename := "snapshot-" + now.UTC().Format("20060102T150405Z") + ".json"
+	w.Header().Set("Content-Disposition", `attachment; filename="`+filename+`"`)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
@@ -0,0 +1,223 @@
+package main_test
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	main "semyi"
+)
+
+func TestServer_Export(t *testing.T) {
+	writer := main.NewMonitorHistoricalWriter(database)
+	start := time.Now().Add(-time.Hour).Truncate(time.Second)
+	for i, status := range []main.MonitorStatus{main.MonitorStatusSuccess, main.MonitorStatusFailure, main.MonitorStatusDegraded} {
+		err := writer.Write(context.Background(), main.MonitorHistorical{
+			MonitorID: "export-test",
+			Status:    status,
+			Latency:   int64(i+1) * 100,
+			Timestamp: start.Add(time.Minute * time.Duration(i)),
+		})
+		if err != nil {
+			t.Fatalf("unexpected error writing historical data: %v", err)
+		}
+	}
+
+	server, err := main.NewServer(main.ServerConfig{
+		MonitorHistoricalReader: main.NewMonitorHistoricalReader(database),
+		CentralBroker:           main.NewBroker[main.MonitorHistorical](),
+		MonitorList:             []main.Monitor{{UniqueID: "export-test", Name: "Export Test"}},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error creating server: %v", err)
+	}
+
+	t.Run("Should export csv", func(t *testing.T) {
+		recorder := httptest.NewRecorder()
+		server.Handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/api/export?id=export-test&interval=raw&format=csv", nil))
+		if recorder.Code != http.StatusOK {
+			t.Fatalf("expected status code %d, got %d", http.StatusOK, recorder.Code)
+		}
+
+		if contentType := recorder.Header().Get("Content-Type"); contentType != "text/csv" {
+			t.Errorf("expected content type text/csv, got %s", contentType)
+		}
+
+		records, err := csv.NewReader(recorder.Body).ReadAll()
+		if err != nil {
+			t.Fatalf("unexpected error reading csv: %v", err)
+		}
+
+		if len(records) != 4 {
+			t.Fatalf("expected a header and 3 rows, got %v", records)
+		}
+
+		header := records[0]
+		if len(header) != 3 || header[0] != "timestamp" || header[1] != "status" || header[2] != "latency_ms" {
+			t.Errorf("unexpected header: %v", header)
+		}
+
+		row := records[2]
+		expected := []string{start.Add(time.Minute).UTC().Format(time.RFC3339), "down", "200"}
+		if len(row) != 3 || row[0] != expected[0] || row[1] != expected[1] || row[2] != expected[2] {
+			t.Errorf("expected row %v, got %v", expected, row)
+		}
+	})
+
+	t.Run("Should export json by default", func(t *testing.T) {
+		recorder := httptest.NewRecorder()
+		server.Handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/api/export?id=export-test", nil))
+		if recorder.Code != http.StatusOK {
+			t.Fatalf("expected status code %d, got %d", http.StatusOK, recorder.Code)
+		}
+
+		var historical []main.MonitorHistorical
+		if err := json.NewDecoder(recorder.Body).Decode(&historical); err != nil {
+			t.Fatalf("unexpected error decoding body: %v", err)
+		}
+
+		if len(historical) != 3 || historical[2].Latency != 300 || historical[2].Status != main.MonitorStatusDegraded {
+			t.Errorf("unexpected historical data: %+v", historical)
+		}
+	})
+
+	t.Run("Should export an empty json array", func(t *testing.T) {
+		recorder := httptest.NewRecorder()
+		server.Handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/api/export?id=export-test&interval=daily", nil))
+		if body := recorder.Body.String(); body != "[]" {
+			t.Errorf("expected an empty array, got %q", body)
+		}
+	})
+
+	t.Run("Should reject invalid parameters", func(t *testing.T) {
+		for query, status := range map[string]int{
+			"":                               http.StatusBadRequest,
+			"?id=export-test&format=xml":     http.StatusBadRequest,
+			"?id=export-test&interval=weeks": http.StatusBadRequest,
+			"?id=unknown":                    http.StatusNotFound,
+		} {
+			recorder := httptest.NewRecorder()
+			server.Handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/api/export"+query, nil))
+			if recorder.Code != status {
+				t.Errorf("%q: expected status code %d, got %d", query, status, recorder.Code)
+			}
+		}
+	})
+}
+
+func TestServer_ExportSnapshot(t *testing.T) {
+	writer := main.NewMonitorHistoricalWriter(database)
+	start := time.Now().Add(-time.Hour).Truncate(time.Second)
+	statuses := map[string][]main.MonitorStatus{
+		"export-snapshot-api": {main.MonitorStatusSuccess, main.MonitorStatusSuccess, main.MonitorStatusSuccess, main.MonitorStatusSuccess},
+		"export-snapshot-db":  {main.MonitorStatusSuccess, main.MonitorStatusFailure, main.MonitorStatusFailure, main.MonitorStatusSuccess},
+	}
+	for monitorId, monitorStatuses := range statuses {
+		for i, status := range monitorStatuses {
+			err := writer.Write(context.Background(), main.MonitorHistorical{
+				MonitorID: monitorId,
+				Status:    status,
+				Latency:   100,
+				Timestamp: start.Add(time.Minute * time.Duration(i)),
+			})
+			if err != nil {
+				t.Fatalf("unexpected error writing historical data: %v", err)
+			}
+		}
+	}
+
+	server, err := main.NewServer(main.ServerConfig{
+		MonitorHistoricalReader: main.NewMonitorHistoricalReader(database),
+		CentralBroker:           main.NewBroker[main.MonitorHistorical](),
+		MonitorList: []main.Monitor{
+			{UniqueID: "export-snapshot-api", Name: "API"},
+			{UniqueID: "export-snapshot-db", Name: "Database"},
+			{UniqueID: "export-snapshot-new", Name: "New"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error creating server: %v", err)
+	}
+
+	recorder := httptest.NewRecorder()
+	server.Handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/api/export/snapshot?window=2h", nil))
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("expected status code %d, got %d", http.StatusOK, recorder.Code)
+	}
+
+	if disposition := recorder.Header().Get("Content-Disposition"); disposition == "" {
+		t.Error("expected the snapshot to be downloadable")
+	}
+
+	var snapshot struct {
+		GeneratedAt time.Time `json:"generated_at"`
+		Window      string    `json:"window"`
+		Monitors    []struct {
+			Monitor map[string]any             `json:"monitor"`
+			Status  main.MonitorStatusSnapshot `json:"status"`
+			Uptime  main.UptimeSummary         `json:"uptime"`
+			Outages []main.Outage              `json:"outages"`
+		} `json:"monitors"`
+	}
+	if err := json.NewDecoder(recorder.Body).Decode(&snapshot); err != nil {
+		t.Fatalf("unexpected error decoding body: %v", err)
+	}
+
+	if snapshot.Window != "2h" || snapshot.GeneratedAt.IsZero() {
+		t.Errorf("expected the window and the generation time, got %q and %s", snapshot.Window, snapshot.GeneratedAt)
+	}
+
+	if len(snapshot.Monitors) != 3 {
+		t.Fatalf("expected 3 monitors, got %d", len(snapshot.Monitors))
+	}
+
+	expected := []struct {
+		id      string
+		name    string
+		status  string
+		total   int
+		up      int
+		outages int
+	}{
+		{id: "export-snapshot-api", name: "API", status: "up", total: 4, up: 4, outages: 0},
+		{id: "export-snapshot-db", name: "Database", status: "up", total: 4, up: 2, outages: 1},
+		{id: "export-snapshot-new", name: "New", total: 0, up: 0, outages: 0},
+	}
+
+	for i, want := range expected {
+		got := snapshot.Monitors[i]
+		if got.Monitor["id"] != want.id || got.Monitor["name"] != want.name {
+			t.Errorf("expected monitor %s (%s), got %v", want.id, want.name, got.Monitor)
+		}
+
+		if got.Status.ID != want.id {
+			t.Errorf("expected the status of %s, got %s", want.id, got.Status.ID)
+		}
+
+		if want.status == "" && got.Status.Status != nil {
+			t.Errorf("expected no status for %s, got %s", want.id, *got.Status.Status)
+		} else if want.status != "" && (got.Status.Status == nil || *got.Status.Status != want.status) {
+			t.Errorf("expected status %s for %s, got %v", want.status, want.id, got.Status.Status)
+		}
+
+		if got.Uptime.Total != want.total || got.Uptime.Up != want.up {
+			t.Errorf("expected %d of %d checks up for %s, got %d of %d", want.up, want.total, want.id, got.Uptime.Up, got.Uptime.Total)
+		}
+
+		if got.Outages == nil || len(got.Outages) != want.outages {
+			t.Errorf("expected %d outages for %s, got %v", want.outages, want.id, got.Outages)
+		}
+	}
+
+	t.Run("invalid window", func(t *testing.T) {
+		recorder := httptest.NewRecorder()
+		server.Handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/api/export/snapshot?window=forever", nil))
+		if recorder.Code != http.StatusBadRequest {
+			t.Errorf("expected status code %d, got %d", http.StatusBadRequest, recorder.Code)
+		}
+	})
+}
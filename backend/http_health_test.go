@@ -0,0 +1,86 @@
+package main_test
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	main "semyi"
+)
+
+func TestServer_Health(t *testing.T) {
+	server, err := main.NewServer(main.ServerConfig{
+		MonitorHistoricalReader: main.NewMonitorHistoricalReader(database),
+		CentralBroker:           main.NewBroker[main.MonitorHistorical](),
+		MonitorList:             []main.Monitor{{UniqueID: "health-test", Name: "Health Test"}},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error creating server: %v", err)
+	}
+
+	for _, path := range []string{"/api/health", "/api/ready"} {
+		recorder := httptest.NewRecorder()
+		server.Handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, path, nil))
+		if recorder.Code != http.StatusOK {
+			t.Errorf("%s: expected status code %d, got %d", path, http.StatusOK, recorder.Code)
+		}
+	}
+
+	t.Run("Should not be ready while shutting down", func(t *testing.T) {
+		if err := server.Shutdown(context.Background()); err != nil {
+			t.Fatalf("unexpected error shutting down: %v", err)
+		}
+
+		// The shutdown hooks run in the background
+		deadline := time.Now().Add(time.Second * 5)
+		for {
+			recorder := httptest.NewRecorder()
+			server.Handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/api/ready", nil))
+			if recorder.Code == http.StatusServiceUnavailable {
+				break
+			}
+
+			if time.Now().After(deadline) {
+				t.Fatalf("expected status code %d, got %d", http.StatusServiceUnavailable, recorder.Code)
+			}
+			time.Sleep(time.Millisecond * 10)
+		}
+	})
+}
+
+func TestServer_ReadyUnreachableStore(t *testing.T) {
+	// A closed database fails every ping
+	closed, err := sql.Open("duckdb", "")
+	if err != nil {
+		t.Fatalf("unexpected error opening database: %v", err)
+	}
+
+	if err := closed.Close(); err != nil {
+		t.Fatalf("unexpected error closing database: %v", err)
+	}
+
+	server, err := main.NewServer(main.ServerConfig{
+		MonitorHistoricalReader: main.NewMonitorHistoricalReader(closed),
+		CentralBroker:           main.NewBroker[main.MonitorHistorical](),
+		MonitorList:             []main.Monitor{{UniqueID: "ready-test", Name: "Ready Test"}},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error creating server: %v", err)
+	}
+
+	recorder := httptest.NewRecorder()
+	server.Handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/api/ready", nil))
+	if recorder.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected status code %d, got %d", http.StatusServiceUnavailable, recorder.Code)
+	}
+
+	// The process is still alive
+	recorder = httptest.NewRecorder()
+	server.Handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/api/health", nil))
+	if recorder.Code != http.StatusOK {
+		t.Errorf("expected status code %d, got %d", http.StatusOK, recorder.Code)
+	}
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/rs/zerolog"
+)
+
+// accessLogMiddleware logs every request after it has been served, along with the response status code,
+// the response size, and how long it took.
+func accessLogMiddleware(logger zerolog.Logger, trustedProxies []net.IPNet) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+
+			next.ServeHTTP(ww, r)
+
+			logger.Info().
+				Str("request_id", middleware.GetReqID(r.Context())).
+				Str("client_ip", ClientIP(r, trustedProxies)).
+				Str("method", r.Method).
+				Str("path", r.URL.Path).
+				Int("status", ww.Status()).
+				Int("size", ww.BytesWritten()).
+				Dur("duration", time.Since(start)).
+				Msg("request served")
+		})
+	}
+}
+
+// streamLogMiddleware logs when a streaming connection is opened and closed, rather than on every flush.
+func streamLogMiddleware(logger zerolog.Logger, trustedProxies []net.IPNet) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+			requestId := middleware.GetReqID(r.Context())
+			clientIp := ClientIP(r, trustedProxies)
+
+			logger.Info().
+				Str("request_id", requestId).
+				Str("client_ip", clientIp).
+				Str("method", r.Method).
+				Str("path", r.URL.Path).
+				Msg("stream opened")
+
+			next.ServeHTTP(ww, r)
+
+			logger.Info().
+				Str("request_id", requestId).
+				Str("client_ip", clientIp).
+				Str("method", r.Method).
+				Str("path", r.URL.Path).
+				Int("status", ww.Status()).
+				Int("size", ww.BytesWritten()).
+				Dur("duration", time.Since(start)).
+				Msg("stream closed")
+		})
+	}
+}
+
+// requestIdHeaderMiddleware sends the request id assigned by middleware.RequestID back to the client.
+func requestIdHeaderMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requestId := middleware.GetReqID(r.Context()); requestId != "" {
+			w.Header().Set(middleware.RequestIDHeader, requestId)
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,72 @@
+package main_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	main "semyi"
+
+	"github.com/rs/zerolog"
+)
+
+func TestServer_AccessLog(t *testing.T) {
+	var output bytes.Buffer
+	logger := zerolog.New(&output)
+
+	server, err := main.NewServer(main.ServerConfig{
+		MonitorHistoricalReader: main.NewMonitorHistoricalReader(database),
+		CentralBroker:           main.NewBroker[main.MonitorHistorical](),
+		MonitorList:             []main.Monitor{{UniqueID: "access-log-test", Name: "Access Log Test"}},
+		Logger:                  &logger,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error creating server: %v", err)
+	}
+
+	recorder := httptest.NewRecorder()
+	server.Handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/api/static?id=does-not-exist", nil))
+
+	requestId := recorder.Header().Get("X-Request-Id")
+	if requestId == "" {
+		t.Error("expected a request id in the response headers")
+	}
+
+	lines := strings.Split(strings.TrimSpace(output.String()), "\n")
+	if len(lines) != 1 {
+		t.Fatalf("expected 1 log line, got %d: %q", len(lines), output.String())
+	}
+
+	var entry struct {
+		RequestID string `json:"request_id"`
+		ClientIP  string `json:"client_ip"`
+		Method    string `json:"method"`
+		Path      string `json:"path"`
+		Status    int    `json:"status"`
+		Size      int    `json:"size"`
+		Message   string `json:"message"`
+	}
+	if err := json.Unmarshal([]byte(lines[0]), &entry); err != nil {
+		t.Fatalf("unexpected error decoding log line: %v", err)
+	}
+
+	if entry.Status != http.StatusNotFound {
+		t.Errorf("expected status %d, got %d", http.StatusNotFound, entry.Status)
+	}
+
+	if entry.Method != http.MethodGet || entry.Path != "/api/static" || entry.Size != recorder.Body.Len() {
+		t.Errorf("unexpected log entry: %+v", entry)
+	}
+
+	if entry.RequestID != requestId {
+		t.Errorf("expected request id %q, got %q", requestId, entry.RequestID)
+	}
+
+	// httptest.NewRequest sets the remote address to 192.0.2.1
+	if entry.ClientIP != "192.0.2.1" {
+		t.Errorf("expected client ip 192.0.2.1, got %q", entry.ClientIP)
+	}
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/rs/zerolog/log"
+)
+
+// MonitorMetadata is the public information of a configured monitor, the rest of the configuration such as
+// the endpoint and the credentials is never exposed.
+type MonitorMetadata struct {
+	ID          string      `json:"id"`
+	Name        string      `json:"name"`
+	Description string      `json:"description"`
+	PublicUrl   string      `json:"public_url"`
+	Type        MonitorType `json:"type"`
+	// Group, DisplayOrder, and Color organize the monitors in the dashboard.
+	Group        string `json:"group"`
+	DisplayOrder int    `json:"display_order"`
+	Color        string `json:"color"`
+	// Interval specifies the interval of each check in seconds, with the default interval applied. It's zero
+	// when the checks are scheduled by Cron.
+	Interval int `json:"interval"`
+	// Cron specifies the cron expression of the checks, it's omitted when the checks run on the interval.
+	Cron string `json:"cron,omitempty"`
+	// Paused specifies whether the monitor is not being checked, either because it's disabled in the configuration
+	// or because it's paused through the API. Its historical data is still available.
+	Paused bool `json:"paused"`
+}
+
+// Metadata returns the public information of the monitor.
+func (m Monitor) Metadata() MonitorMetadata {
+	interval := m.Interval
+	if interval == 0 && m.Cron == "" {
+		interval = DefaultInterval
+	}
+
+	return MonitorMetadata{
+		ID:           m.UniqueID,
+		Name:         m.Name,
+		Description:  m.Description,
+		PublicUrl:    m.PublicUrl,
+		Type:         m.Type,
+		Group:        m.Group,
+		DisplayOrder: m.DisplayOrder,
+		Color:        m.Color,
+		Interval:     interval,
+		Cron:         m.Cron,
+		Paused:       !m.IsEnabled(),
+	}
+}
+
+// listMonitors responds with the metadata of every configured monitor, so the dashboard doesn't need to be
+// told the monitor IDs beforehand. The monitors are sorted by their group, then by their display order.
+func (s *Server) listMonitors(w http.ResponseWriter, r *http.Request) {
+	monitors := s.monitors.List()
+	metadata := make([]MonitorMetadata, 0, len(monitors))
+	for _, monitor := range monitors {
+		metadata = append(metadata, s.metadata(monitor))
+	}
+
+	slices.SortStableFunc(metadata, func(a, b MonitorMetadata) int {
+		if a.Group != b.Group {
+			return strings.Compare(a.Group, b.Group)
+		}
+
+		return a.DisplayOrder - b.DisplayOrder
+	})
+
+	data, err := json.Marshal(metadata)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(`{"error": "internal server error"}`))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
+
+// paused reports whether the monitor is not being checked, either because it's disabled in the configuration or
+// because it's paused through the API.
+func (s *Server) paused(monitor Monitor) bool {
+	return !monitor.IsEnabled() || (s.workerManager != nil && s.workerManager.Paused(monitor.UniqueID))
+}
+
+// metadata returns the public information of the monitor, including the pauses made through the API.
+func (s *Server) metadata(monitor Monitor) MonitorMetadata {
+	metadata := monitor.Metadata()
+	metadata.Paused = s.paused(monitor)
+	return metadata
+}
+
+// pauseMonitor stops the checks and the alerts of a monitor until it's resumed, e.g. during an incident that is
+// already being handled. The pause is kept across restarts.
+func (s *Server) pauseMonitor(w http.ResponseWriter, r *http.Request) {
+	s.setMonitorPaused(w, r, s.workerManager.Pause)
+}
+
+// resumeMonitor starts the checks and the alerts of a paused monitor again.
+func (s *Server) resumeMonitor(w http.ResponseWriter, r *http.Request) {
+	s.setMonitorPaused(w, r, s.workerManager.Resume)
+}
+
+// setMonitorPaused applies the pause or the resume to the monitor of the id path parameter, and responds
+// with its metadata.
+func (s *Server) setMonitorPaused(w http.ResponseWriter, r *http.Request, apply func(ctx context.Context, monitorId string) error) {
+	monitorId := chi.URLParam(r, "id")
+	monitor, ok := s.monitors.Get(monitorId)
+	if !ok {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"error": "id is not in the list of monitors"}`))
+		return
+	}
+
+	if err := apply(r.Context(), monitorId); err != nil {
+		if errors.Is(err, ErrMonitorNotFound) {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"error": "id is not in the list of monitors"}`))
+			return
+		}
+
+		log.Error().Err(err).Str("monitor_id", monitorId).Msg("failed to change the pause of the monitor")
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(`{"error": "internal server error"}`))
+		return
+	}
+
+	data, err := json.Marshal(s.metadata(monitor))
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(`{"error": "internal server error"}`))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
@@ -0,0 +1,289 @@
+package main_test
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+
+	main "semyi"
+)
+
+func TestServer_ListMonitors(t *testing.T) {
+	server, err := main.NewServer(main.ServerConfig{
+		MonitorHistoricalReader: main.NewMonitorHistoricalReader(database),
+		CentralBroker:           main.NewBroker[main.MonitorHistorical](),
+		MonitorList: []main.Monitor{
+			{
+				UniqueID:     "monitors-test-api",
+				Name:         "API",
+				Description:  "Public API",
+				PublicUrl:    "https://api.example.com",
+				Type:         main.MonitorTypeHTTP,
+				Interval:     60,
+				HttpEndpoint: "https://internal.example.com/health",
+				Group:        "APIs",
+				Color:        "#3b82f6",
+			},
+			{UniqueID: "monitors-test-gateway", Name: "Gateway", Type: main.MonitorTypePing, IcmpHostname: "10.0.0.1", Group: "Network"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error creating server: %v", err)
+	}
+
+	recorder := httptest.NewRecorder()
+	server.Handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/api/monitors", nil))
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("expected status code %d, got %d", http.StatusOK, recorder.Code)
+	}
+
+	if contentType := recorder.Header().Get("Content-Type"); contentType != "application/json" {
+		t.Errorf("expected content type application/json, got %s", contentType)
+	}
+
+	var monitors []map[string]any
+	if err := json.NewDecoder(recorder.Body).Decode(&monitors); err != nil {
+		t.Fatalf("unexpected error decoding body: %v", err)
+	}
+
+	expected := []map[string]any{
+		{
+			"id":            "monitors-test-api",
+			"name":          "API",
+			"description":   "Public API",
+			"public_url":    "https://api.example.com",
+			"type":          "http",
+			"interval":      float64(60),
+			"group":         "APIs",
+			"display_order": float64(0),
+			"color":         "#3b82f6",
+			"paused":        false,
+		},
+		{
+			"id":            "monitors-test-gateway",
+			"name":          "Gateway",
+			"description":   "",
+			"public_url":    "",
+			"type":          "ping",
+			"interval":      float64(main.DefaultInterval),
+			"group":         "Network",
+			"display_order": float64(0),
+			"color":         "",
+			"paused":        false,
+		},
+	}
+
+	if len(monitors) != len(expected) {
+		t.Fatalf("expected %d monitors, got %d", len(expected), len(monitors))
+	}
+
+	for i, want := range expected {
+		if len(monitors[i]) != len(want) {
+			t.Errorf("expected only the keys of %v, got %v", want, monitors[i])
+		}
+
+		for key, value := range want {
+			if monitors[i][key] != value {
+				t.Errorf("expected %s of monitor %d to be %v, got %v", key, i, value, monitors[i][key])
+			}
+		}
+	}
+}
+
+func TestServer_ListMonitors_Order(t *testing.T) {
+	server, err := main.NewServer(main.ServerConfig{
+		MonitorHistoricalReader: main.NewMonitorHistoricalReader(database),
+		CentralBroker:           main.NewBroker[main.MonitorHistorical](),
+		MonitorList: []main.Monitor{
+			{UniqueID: "order-test-search", Name: "Search", Group: "APIs", DisplayOrder: 2},
+			{UniqueID: "order-test-postgres", Name: "Postgres", Group: "Databases", DisplayOrder: 1},
+			{UniqueID: "order-test-homepage", Name: "Homepage"},
+			{UniqueID: "order-test-auth", Name: "Auth", Group: "APIs", DisplayOrder: 1},
+			{UniqueID: "order-test-redis", Name: "Redis", Group: "Databases"},
+			{UniqueID: "order-test-payments", Name: "Payments", Group: "APIs", DisplayOrder: 2},
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error creating server: %v", err)
+	}
+
+	recorder := httptest.NewRecorder()
+	server.Handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/api/monitors", nil))
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("expected status code %d, got %d", http.StatusOK, recorder.Code)
+	}
+
+	var monitors []main.MonitorMetadata
+	if err := json.NewDecoder(recorder.Body).Decode(&monitors); err != nil {
+		t.Fatalf("unexpected error decoding body: %v", err)
+	}
+
+	var ids []string
+	for _, monitor := range monitors {
+		ids = append(ids, monitor.ID)
+	}
+
+	// Sorted by group, then by display order, keeping the configuration order of the ties
+	expected := []string{
+		"order-test-homepage",
+		"order-test-auth",
+		"order-test-search",
+		"order-test-payments",
+		"order-test-redis",
+		"order-test-postgres",
+	}
+	if !slices.Equal(ids, expected) {
+		t.Errorf("expected monitors %v, got %v", expected, ids)
+	}
+}
+
+func TestServer_PauseMonitor(t *testing.T) {
+	target, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("unexpected error listening: %v", err)
+	}
+	defer target.Close()
+
+	alerted := make(chan string, 10)
+	webhookServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// The recoveries from the previous runs of the same monitor don't count
+		var payload main.WebhookPayload
+		if err := json.NewDecoder(r.Body).Decode(&payload); err == nil && payload.Type == "down" {
+			alerted <- payload.MonitorID
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer webhookServer.Close()
+
+	monitors := []main.Monitor{{
+		UniqueID:   "pause-test",
+		Name:       "Pause Test",
+		Type:       main.MonitorTypeTCP,
+		TcpAddress: target.Addr().String(),
+		Interval:   1,
+		AlertAfter: 1,
+	}}
+
+	broker := main.NewBroker[main.MonitorHistorical]()
+	processor := main.NewProcessor(main.ProcessorConfig{
+		HistoricalWriter:     main.NewMonitorHistoricalWriter(database),
+		HistoricalReader:     main.NewMonitorHistoricalReader(database),
+		CentralBroker:        broker,
+		AlertDebouncer:       main.NewAlertDebouncer(),
+		WebhookAlertProvider: main.NewWebhookDispatcher([]main.Webhook{{URL: webhookServer.URL, SuccessResponse: true, FailedResponse: true}}, monitors),
+	})
+
+	registry := main.NewMonitorRegistry(nil)
+	manager := main.NewWorkerManagerWithOptions(processor, registry, main.WorkerManagerOptions{PauseStore: main.NewPauseStore(database)})
+	defer manager.Stop()
+
+	if _, err := manager.Apply(monitors); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	server, err := main.NewServer(main.ServerConfig{
+		MonitorHistoricalReader: main.NewMonitorHistoricalReader(database),
+		CentralBroker:           broker,
+		MonitorRegistry:         registry,
+		WorkerManager:           manager,
+		ApiKeys:                 []string{"pause-key"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error creating server: %v", err)
+	}
+
+	request := func(path string, key string) *httptest.ResponseRecorder {
+		request := httptest.NewRequest(http.MethodPost, path, nil)
+		if key != "" {
+			request.Header.Set("X-API-Key", key)
+		}
+
+		recorder := httptest.NewRecorder()
+		server.Handler.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	expectPaused := func(recorder *httptest.ResponseRecorder, paused bool) {
+		t.Helper()
+		if recorder.Code != http.StatusOK {
+			t.Fatalf("expected status code %d, got %d: %s", http.StatusOK, recorder.Code, recorder.Body.String())
+		}
+
+		var metadata main.MonitorMetadata
+		if err := json.NewDecoder(recorder.Body).Decode(&metadata); err != nil {
+			t.Fatalf("unexpected error decoding body: %v", err)
+		}
+
+		if metadata.ID != "pause-test" || metadata.Paused != paused {
+			t.Errorf("expected pause-test to be paused %v, got %+v", paused, metadata)
+		}
+	}
+
+	// The first check succeeds, so the debouncer knows the monitor as up
+	deadline := time.Now().Add(time.Second * 5)
+	for {
+		if message, ok := broker.Latest("pause-test"); ok && message.Body.Status == main.MonitorStatusSuccess {
+			break
+		}
+
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for the first check")
+		}
+
+		time.Sleep(time.Millisecond * 10)
+	}
+
+	if recorder := request("/api/monitors/pause-test/pause", ""); recorder.Code != http.StatusUnauthorized {
+		t.Errorf("expected status code %d without an api key, got %d", http.StatusUnauthorized, recorder.Code)
+	}
+
+	if recorder := request("/api/monitors/unknown/pause", "pause-key"); recorder.Code != http.StatusNotFound {
+		t.Errorf("expected status code %d for an unknown monitor, got %d", http.StatusNotFound, recorder.Code)
+	}
+
+	expectPaused(request("/api/monitors/pause-test/pause", "pause-key"), true)
+	if running := manager.Running(); len(running) != 0 {
+		t.Errorf("expected no running monitors, got %v", running)
+	}
+
+	// The monitor goes down while it's paused, which would be alerted within a couple of checks otherwise
+	target.Close()
+	select {
+	case monitorId := <-alerted:
+		t.Fatalf("expected no down alert while paused, got an alert for %s", monitorId)
+	case <-time.After(time.Millisecond * 2500):
+	}
+
+	// The pause is read back by a fresh store, as it would be after a restart
+	restarted := main.NewPauseStore(database)
+	if err := restarted.Load(context.Background()); err != nil {
+		t.Fatalf("unexpected error loading pauses: %v", err)
+	}
+
+	if !restarted.Paused("pause-test") {
+		t.Error("expected the pause to be persisted")
+	}
+
+	expectPaused(request("/api/monitors/pause-test/resume", "pause-key"), false)
+	select {
+	case monitorId := <-alerted:
+		if monitorId != "pause-test" {
+			t.Errorf("expected a down alert for pause-test, got an alert for %s", monitorId)
+		}
+	case <-time.After(time.Second * 5):
+		t.Fatal("timed out waiting for the down alert after resuming")
+	}
+
+	if err := restarted.Load(context.Background()); err != nil {
+		t.Fatalf("unexpected error loading pauses: %v", err)
+	}
+
+	if restarted.Paused("pause-test") {
+		t.Error("expected the resume to be persisted")
+	}
+}
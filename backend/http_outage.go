@@ -0,0 +1,51 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/rs/zerolog/log"
+)
+
+func (s *Server) outages(w http.ResponseWriter, r *http.Request) {
+	monitorId := r.URL.Query().Get("id")
+	if monitorId == "" {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"error": "id is required"}`))
+		return
+	}
+
+	if !s.monitors.Contains(monitorId) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"error": "id is not in the list of monitors"}`))
+		return
+	}
+
+	historical, err := s.historicalReader.ReadRawHistorical(r.Context(), monitorId)
+	if err != nil {
+		log.Error().Err(err).Str("monitor_id", monitorId).Msg("failed to read historical data for outages")
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(`{"error": "failed to read historical data"}`))
+		return
+	}
+
+	outages := GroupOutages(historical, s.outageFlapTolerance)
+	if outages == nil {
+		outages = []Outage{}
+	}
+
+	data, err := json.Marshal(outages)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(`{"error": "internal server error"}`))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
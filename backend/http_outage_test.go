@@ -0,0 +1,109 @@
+package main_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	main "semyi"
+)
+
+func TestGroupOutages(t *testing.T) {
+	now := time.Now()
+	historical := []main.MonitorHistorical{
+		{MonitorID: "a", Status: main.MonitorStatusSuccess, Timestamp: now.Add(-time.Minute * 10)},
+		{MonitorID: "a", Status: main.MonitorStatusFailure, Timestamp: now.Add(-time.Minute * 9)},
+		// A single isolated success is tolerated
+		{MonitorID: "a", Status: main.MonitorStatusSuccess, Timestamp: now.Add(-time.Minute * 8)},
+		{MonitorID: "a", Status: main.MonitorStatusFailure, Timestamp: now.Add(-time.Minute * 7)},
+		{MonitorID: "a", Status: main.MonitorStatusDegraded, Timestamp: now.Add(-time.Minute * 6)},
+		{MonitorID: "a", Status: main.MonitorStatusSuccess, Timestamp: now.Add(-time.Minute * 5)},
+		{MonitorID: "a", Status: main.MonitorStatusFailure, Timestamp: now.Add(-time.Minute * 2)},
+	}
+
+	t.Run("With flap tolerance", func(t *testing.T) {
+		outages := main.GroupOutages(historical, 1)
+		if len(outages) != 2 {
+			t.Fatalf("expected 2 outages, got %d", len(outages))
+		}
+
+		if !outages[0].StartTime.Equal(now.Add(-time.Minute*9)) || outages[0].EndTime == nil || !outages[0].EndTime.Equal(now.Add(-time.Minute*6)) {
+			t.Errorf("unexpected first outage: %+v", outages[0])
+		}
+
+		if outages[0].Duration != 180 {
+			t.Errorf("expected duration of 180 seconds, got %d", outages[0].Duration)
+		}
+
+		if !outages[1].StartTime.Equal(now.Add(-time.Minute*2)) || outages[1].EndTime != nil {
+			t.Errorf("expected an ongoing outage, got %+v", outages[1])
+		}
+	})
+
+	t.Run("Without flap tolerance", func(t *testing.T) {
+		outages := main.GroupOutages(historical, 0)
+		if len(outages) != 3 {
+			t.Fatalf("expected 3 outages, got %d", len(outages))
+		}
+	})
+}
+
+func TestServer_Outages(t *testing.T) {
+	writer := main.NewMonitorHistoricalWriter(database)
+	now := time.Now()
+	for _, historical := range []main.MonitorHistorical{
+		{MonitorID: "outage-test", Status: main.MonitorStatusFailure, Latency: 10, Timestamp: now.Add(-time.Hour * 4)},
+		{MonitorID: "outage-test", Status: main.MonitorStatusSuccess, Latency: 10, Timestamp: now.Add(-time.Hour * 3)},
+		{MonitorID: "outage-test", Status: main.MonitorStatusSuccess, Latency: 10, Timestamp: now.Add(-time.Hour * 2)},
+		{MonitorID: "outage-test", Status: main.MonitorStatusFailure, Latency: 10, Timestamp: now.Add(-time.Hour)},
+	} {
+		if err := writer.Write(context.Background(), historical); err != nil {
+			t.Fatalf("unexpected error writing historical data: %v", err)
+		}
+	}
+
+	server, err := main.NewServer(main.ServerConfig{
+		MonitorHistoricalReader: main.NewMonitorHistoricalReader(database),
+		CentralBroker:           main.NewBroker[main.MonitorHistorical](),
+		MonitorList:             []main.Monitor{{UniqueID: "outage-test", Name: "Outage Test"}},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error creating server: %v", err)
+	}
+
+	t.Run("Should return closed and ongoing outages", func(t *testing.T) {
+		recorder := httptest.NewRecorder()
+		server.Handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/api/incidents?id=outage-test", nil))
+		if recorder.Code != http.StatusOK {
+			t.Fatalf("expected status code %d, got %d", http.StatusOK, recorder.Code)
+		}
+
+		var outages []main.Outage
+		if err := json.NewDecoder(recorder.Body).Decode(&outages); err != nil {
+			t.Fatalf("unexpected error decoding body: %v", err)
+		}
+
+		if len(outages) != 2 {
+			t.Fatalf("expected 2 outages, got %d", len(outages))
+		}
+
+		if outages[0].MonitorID != "outage-test" || outages[0].EndTime == nil || outages[0].Duration != 3600 {
+			t.Errorf("unexpected closed outage: %+v", outages[0])
+		}
+
+		if outages[1].EndTime != nil {
+			t.Errorf("expected an ongoing outage, got %+v", outages[1])
+		}
+	})
+
+	t.Run("Should reject unknown id", func(t *testing.T) {
+		recorder := httptest.NewRecorder()
+		server.Handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/api/incidents?id=unknown", nil))
+		if recorder.Code != http.StatusNotFound {
+			t.Errorf("expected status code %d, got %d", http.StatusNotFound, recorder.Code)
+		}
+	})
+}
@@ -0,0 +1,29 @@
+package main
+
+import (
+	"net/http"
+	"net/http/pprof"
+)
+
+// DefaultPprofAddress is the address of the profiling listener when the configuration file doesn't set one.
+const DefaultPprofAddress = "localhost:6060"
+
+// NewPprofServer creates the server of the net/http/pprof profiles. It's separate from the API server, so
+// the profiles are only reachable on the address of their own listener.
+func NewPprofServer(address string) *http.Server {
+	if address == "" {
+		address = DefaultPprofAddress
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+
+	return &http.Server{
+		Addr:    address,
+		Handler: mux,
+	}
+}
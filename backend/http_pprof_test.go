@@ -0,0 +1,66 @@
+package main_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	main "semyi"
+)
+
+func TestPprofServer(t *testing.T) {
+	t.Run("Should not be served by the API server", func(t *testing.T) {
+		server, err := main.NewServer(main.ServerConfig{
+			MonitorHistoricalReader: main.NewMonitorHistoricalReader(database),
+			CentralBroker:           main.NewBroker[main.MonitorHistorical](),
+			StaticPath:              t.TempDir(),
+		})
+		if err != nil {
+			t.Fatalf("unexpected error creating server: %v", err)
+		}
+
+		recorder := httptest.NewRecorder()
+		server.Handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/debug/pprof/", nil))
+		if recorder.Code != http.StatusNotFound {
+			t.Errorf("expected status code %d, got %d", http.StatusNotFound, recorder.Code)
+		}
+	})
+
+	t.Run("Should serve the profiles when enabled", func(t *testing.T) {
+		server := main.NewPprofServer("")
+		if server.Addr != main.DefaultPprofAddress {
+			t.Errorf("expected the default address %s, got %s", main.DefaultPprofAddress, server.Addr)
+		}
+
+		recorder := httptest.NewRecorder()
+		server.Handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/debug/pprof/", nil))
+		if recorder.Code != http.StatusOK {
+			t.Fatalf("expected status code %d, got %d", http.StatusOK, recorder.Code)
+		}
+
+		if !strings.Contains(recorder.Body.String(), "goroutine") {
+			t.Errorf("expected the index of the profiles, got %s", recorder.Body.String())
+		}
+
+		recorder = httptest.NewRecorder()
+		server.Handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/debug/pprof/cmdline", nil))
+		if recorder.Code != http.StatusOK {
+			t.Errorf("expected status code %d, got %d", http.StatusOK, recorder.Code)
+		}
+	})
+}
+
+func TestReadConfigurationFile_Pprof(t *testing.T) {
+	path := t.TempDir() + "/config.yaml"
+	writeFile(t, path, []byte("enable_pprof: true\npprof_address: 127.0.0.1:6061\nmonitors: []\n"))
+
+	config, err := main.ReadConfigurationFile(path)
+	if err != nil {
+		t.Fatalf("unexpected error reading configuration file: %v", err)
+	}
+
+	if !config.EnablePprof || config.PprofAddress != "127.0.0.1:6061" {
+		t.Errorf("expected pprof to be enabled on 127.0.0.1:6061, got %v on %q", config.EnablePprof, config.PprofAddress)
+	}
+}
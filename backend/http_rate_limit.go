@@ -0,0 +1,169 @@
+package main
+
+import (
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// RateLimitConfig specifies the limits of the API per client. Each limit is disabled when it's zero.
+type RateLimitConfig struct {
+	// Requests specifies how many requests a client can make within the window.
+	Requests int
+	// Window specifies the period the requests are counted in. Defaults to a minute.
+	Window time.Duration
+	// MaxStreams specifies how many SSE connections can be open at once across every client.
+	MaxStreams int
+	// MaxStreamsPerClient specifies how many SSE connections a single client can have open at once.
+	MaxStreamsPerClient int
+}
+
+// streamRetryAfter is sent to the clients that have too many open streams. It matches the SSE reconnection delay.
+const streamRetryAfter = time.Second * 5
+
+type rateWindow struct {
+	start time.Time
+	count int
+}
+
+// rateLimiter counts the requests of each client in fixed windows.
+type rateLimiter struct {
+	mu        sync.Mutex
+	requests  int
+	window    time.Duration
+	clients   map[string]*rateWindow
+	lastSweep time.Time
+}
+
+func newRateLimiter(requests int, window time.Duration) *rateLimiter {
+	return &rateLimiter{requests: requests, window: window, clients: make(map[string]*rateWindow)}
+}
+
+// Allow counts a request of the client, and returns false along with the time until the window resets
+// if the client is over the limit.
+func (l *rateLimiter) Allow(client string, now time.Time) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	// Forget the clients that haven't made a request within the last window
+	if now.Sub(l.lastSweep) >= l.window {
+		for key, window := range l.clients {
+			if now.Sub(window.start) >= l.window {
+				delete(l.clients, key)
+			}
+		}
+		l.lastSweep = now
+	}
+
+	window, ok := l.clients[client]
+	if !ok || now.Sub(window.start) >= l.window {
+		window = &rateWindow{start: now}
+		l.clients[client] = window
+	}
+
+	if window.count >= l.requests {
+		return false, window.start.Add(l.window).Sub(now)
+	}
+
+	window.count++
+	return true, 0
+}
+
+// streamLimiter counts the open streams in total and per client.
+type streamLimiter struct {
+	mu        sync.Mutex
+	max       int
+	perClient int
+	total     int
+	clients   map[string]int
+}
+
+func newStreamLimiter(max int, perClient int) *streamLimiter {
+	return &streamLimiter{max: max, perClient: perClient, clients: make(map[string]int)}
+}
+
+// Acquire reserves a stream for the client, it returns false if either limit is reached.
+func (l *streamLimiter) Acquire(client string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.max > 0 && l.total >= l.max {
+		return false
+	}
+
+	if l.perClient > 0 && l.clients[client] >= l.perClient {
+		return false
+	}
+
+	l.total++
+	l.clients[client]++
+	return true
+}
+
+func (l *streamLimiter) Release(client string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.total--
+	l.clients[client]--
+	if l.clients[client] <= 0 {
+		delete(l.clients, client)
+	}
+}
+
+// rateLimitMiddleware rejects the requests of clients that are over the request limit with 429.
+func rateLimitMiddleware(config RateLimitConfig, trustedProxies []net.IPNet) func(http.Handler) http.Handler {
+	// The limiter is shared by every route the middleware is used on
+	limiter := newRateLimiter(config.Requests, config.Window)
+	return func(next http.Handler) http.Handler {
+		if config.Requests <= 0 {
+			return next
+		}
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			allowed, retryAfter := limiter.Allow(ClientIP(r, trustedProxies), time.Now())
+			if !allowed {
+				writeTooManyRequests(w, retryAfter, `{"error": "too many requests"}`)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// streamLimitMiddleware rejects new streams with 429 when there are too many open streams.
+func streamLimitMiddleware(config RateLimitConfig, trustedProxies []net.IPNet) func(http.Handler) http.Handler {
+	limiter := newStreamLimiter(config.MaxStreams, config.MaxStreamsPerClient)
+	return func(next http.Handler) http.Handler {
+		if config.MaxStreams <= 0 && config.MaxStreamsPerClient <= 0 {
+			return next
+		}
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			client := ClientIP(r, trustedProxies)
+			if !limiter.Acquire(client) {
+				writeTooManyRequests(w, streamRetryAfter, `{"error": "too many concurrent streams"}`)
+				return
+			}
+			defer limiter.Release(client)
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func writeTooManyRequests(w http.ResponseWriter, retryAfter time.Duration, body string) {
+	seconds := int(math.Ceil(retryAfter.Seconds()))
+	if seconds < 1 {
+		seconds = 1
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Retry-After", strconv.Itoa(seconds))
+	w.WriteHeader(http.StatusTooManyRequests)
+	w.Write([]byte(body))
+}
@@ -0,0 +1,156 @@
+package main_test
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	main "semyi"
+)
+
+func TestServer_RateLimit(t *testing.T) {
+	_, trusted, _ := net.ParseCIDR("10.0.0.0/8")
+	server, err := main.NewServer(main.ServerConfig{
+		MonitorHistoricalReader: main.NewMonitorHistoricalReader(database),
+		CentralBroker:           main.NewBroker[main.MonitorHistorical](),
+		MonitorList:             []main.Monitor{{UniqueID: "rate-limit-test", Name: "Rate Limit Test"}},
+		RateLimit:               main.RateLimitConfig{Requests: 3, Window: time.Millisecond * 300},
+		TrustedProxies:          []net.IPNet{*trusted},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error creating server: %v", err)
+	}
+
+	request := func(remoteAddr string, forwardedFor string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, "/api/status", nil)
+		r.RemoteAddr = remoteAddr
+		if forwardedFor != "" {
+			r.Header.Set("X-Forwarded-For", forwardedFor)
+		}
+
+		recorder := httptest.NewRecorder()
+		server.Handler.ServeHTTP(recorder, r)
+		return recorder
+	}
+
+	t.Run("Should limit and reset", func(t *testing.T) {
+		for i := 0; i < 3; i++ {
+			if recorder := request("203.0.113.1:1234", ""); recorder.Code != http.StatusOK {
+				t.Fatalf("request %d: expected status code %d, got %d", i, http.StatusOK, recorder.Code)
+			}
+		}
+
+		recorder := request("203.0.113.1:1234", "")
+		if recorder.Code != http.StatusTooManyRequests {
+			t.Fatalf("expected status code %d, got %d", http.StatusTooManyRequests, recorder.Code)
+		}
+
+		if retryAfter := recorder.Header().Get("Retry-After"); retryAfter != "1" {
+			t.Errorf("expected Retry-After of 1 second, got %q", retryAfter)
+		}
+
+		if recorder := request("203.0.113.2:1234", ""); recorder.Code != http.StatusOK {
+			t.Errorf("expected another client not to be limited, got %d", recorder.Code)
+		}
+
+		time.Sleep(time.Millisecond * 350)
+		if recorder := request("203.0.113.1:1234", ""); recorder.Code != http.StatusOK {
+			t.Errorf("expected the limit to reset after the window, got %d", recorder.Code)
+		}
+	})
+
+	t.Run("Should count the forwarded client of a trusted proxy", func(t *testing.T) {
+		for i := 0; i < 3; i++ {
+			request("10.0.0.1:1234", "198.51.100.7")
+		}
+
+		if recorder := request("10.0.0.2:1234", "198.51.100.7"); recorder.Code != http.StatusTooManyRequests {
+			t.Errorf("expected the forwarded client to be limited through any proxy, got %d", recorder.Code)
+		}
+
+		if recorder := request("10.0.0.1:1234", "198.51.100.8"); recorder.Code != http.StatusOK {
+			t.Errorf("expected another forwarded client not to be limited, got %d", recorder.Code)
+		}
+	})
+
+	t.Run("Should ignore the forwarded header of an untrusted client", func(t *testing.T) {
+		for i := 0; i < 3; i++ {
+			request("203.0.113.9:1234", "198.51.100.20")
+		}
+
+		// Spoofing another address doesn't get around the limit
+		if recorder := request("203.0.113.9:1234", "198.51.100.21"); recorder.Code != http.StatusTooManyRequests {
+			t.Errorf("expected the spoofing client to be limited, got %d", recorder.Code)
+		}
+	})
+}
+
+func TestServer_StreamLimit(t *testing.T) {
+	server, err := main.NewServer(main.ServerConfig{
+		MonitorHistoricalReader: main.NewMonitorHistoricalReader(database),
+		CentralBroker:           main.NewBroker[main.MonitorHistorical](),
+		MonitorList:             []main.Monitor{{UniqueID: "stream-limit-test", Name: "Stream Limit Test"}},
+		RateLimit:               main.RateLimitConfig{MaxStreamsPerClient: 1},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error creating server: %v", err)
+	}
+
+	testServer := httptest.NewServer(server.Handler)
+	defer testServer.Close()
+
+	connect := func(ctx context.Context) *http.Response {
+		request, err := http.NewRequestWithContext(ctx, http.MethodGet, testServer.URL+"/api/by?ids=stream-limit-test", nil)
+		if err != nil {
+			t.Fatalf("unexpected error creating request: %v", err)
+		}
+
+		resp, err := http.DefaultClient.Do(request)
+		if err != nil {
+			t.Fatalf("unexpected error connecting: %v", err)
+		}
+
+		return resp
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	first := connect(ctx)
+	if first.StatusCode != http.StatusOK {
+		t.Fatalf("expected status code %d, got %d", http.StatusOK, first.StatusCode)
+	}
+
+	second := connect(context.Background())
+	second.Body.Close()
+	if second.StatusCode != http.StatusTooManyRequests {
+		t.Errorf("expected status code %d, got %d", http.StatusTooManyRequests, second.StatusCode)
+	}
+
+	if retryAfter := second.Header.Get("Retry-After"); retryAfter == "" {
+		t.Error("expected a Retry-After header")
+	}
+
+	// The stream is released once the first connection is closed
+	cancel()
+	first.Body.Close()
+
+	deadline := time.Now().Add(time.Second * 5)
+	for {
+		ctx, cancel := context.WithCancel(context.Background())
+		resp := connect(ctx)
+		status := resp.StatusCode
+		cancel()
+		resp.Body.Close()
+
+		if status == http.StatusOK {
+			break
+		}
+
+		if time.Now().After(deadline) {
+			t.Fatalf("expected the stream limit to reset, got %d", status)
+		}
+		time.Sleep(time.Millisecond * 10)
+	}
+}
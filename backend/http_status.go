@@ -0,0 +1,209 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// MonitorStatusSnapshot is the most recent known state of a monitor. The status, latency, and timestamp are null
+// when the monitor has not been checked yet. The status is "maintenance" during a maintenance window,
+// "suppressed" when the monitor is failing while a parent monitor is down, and "paused" when the monitor is
+// disabled or paused through the API, regardless of its latest check.
+type MonitorStatusSnapshot struct {
+	ID        string     `json:"id"`
+	Name      string     `json:"name"`
+	Status    *string    `json:"status"`
+	Latency   *int64     `json:"latency"`
+	Timestamp *time.Time `json:"timestamp"`
+	// Maintenance specifies whether the latest check was made during a maintenance window.
+	Maintenance bool `json:"maintenance"`
+	// Suppressed specifies whether the latest check failed while a parent monitor was down.
+	Suppressed bool `json:"suppressed"`
+	// ErrorCategory classifies why the latest check failed, it's omitted when the check succeeded.
+	ErrorCategory ErrorCategory `json:"error_category,omitempty"`
+	// Protocol specifies the protocol negotiated by the latest HTTP check, it's omitted for other monitors.
+	Protocol string `json:"protocol,omitempty"`
+	// TlsVersion specifies the TLS version negotiated by the latest HTTPS check, it's omitted for other monitors.
+	TlsVersion string `json:"tls_version,omitempty"`
+	// Retries specifies how many times the latest check was retried before its result.
+	Retries int `json:"retries,omitempty"`
+	// Stale specifies whether the snapshot is from before the last restart, and the monitor hasn't been
+	// checked again since.
+	Stale bool `json:"stale"`
+	// Paused specifies whether the monitor is not being checked.
+	Paused bool `json:"paused"`
+}
+
+// currentStatus responds with the latest snapshot of every monitor in a single JSON response, for consumers
+// that can't handle SSE.
+func (s *Server) currentStatus(w http.ResponseWriter, r *http.Request) {
+	monitors := s.monitors.List()
+	snapshots := make([]MonitorStatusSnapshot, 0, len(monitors))
+	for _, monitor := range monitors {
+		snapshot, err := s.statusSnapshot(r.Context(), monitor)
+		if err != nil {
+			log.Error().Err(err).Str("monitor_id", monitor.UniqueID).Msg("failed to read latest historical data")
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusInternalServerError)
+			w.Write([]byte(`{"error": "failed to read historical data"}`))
+			return
+		}
+
+		snapshots = append(snapshots, snapshot)
+	}
+
+	data, err := json.Marshal(snapshots)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(`{"error": "internal server error"}`))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
+
+// statusSnapshot returns the latest snapshot of the monitor, which has no status if the monitor has not been
+// checked yet.
+func (s *Server) statusSnapshot(ctx context.Context, monitor Monitor) (MonitorStatusSnapshot, error) {
+	snapshot := MonitorStatusSnapshot{ID: monitor.UniqueID, Name: monitor.Name, Paused: s.paused(monitor)}
+
+	historical, stale, err := s.latestHistorical(ctx, monitor.UniqueID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			if snapshot.Paused {
+				status := "paused"
+				snapshot.Status = &status
+			}
+
+			return snapshot, nil
+		}
+
+		return MonitorStatusSnapshot{}, err
+	}
+	snapshot.Stale = stale
+
+	status := historical.Status.String()
+	if snapshot.Paused {
+		status = "paused"
+	} else if historical.Maintenance {
+		status = "maintenance"
+	} else if historical.Suppressed {
+		status = "suppressed"
+	}
+	snapshot.Status = &status
+	snapshot.Maintenance = historical.Maintenance
+	snapshot.Suppressed = historical.Suppressed
+	snapshot.ErrorCategory = historical.ErrorCategory
+	snapshot.Protocol = historical.Protocol
+	snapshot.TlsVersion = historical.TlsVersion
+	snapshot.Retries = historical.Retries
+	snapshot.Latency = &historical.Latency
+	snapshot.Timestamp = &historical.Timestamp
+	return snapshot, nil
+}
+
+// latestHistorical returns the latest result of the monitor, and whether it's from before the last restart.
+// The broker keeps the latest published results, the database is only needed right after a restart.
+// It returns sql.ErrNoRows if the monitor has not been checked yet.
+func (s *Server) latestHistorical(ctx context.Context, monitorId string) (MonitorHistorical, bool, error) {
+	if message, ok := s.centralBroker.Latest(monitorId); ok {
+		return message.Body, message.Header[BrokerHeaderStale] == "true", nil
+	}
+
+	latest, err := s.historicalReader.ReadRawLatest(ctx, monitorId)
+	if err != nil {
+		return MonitorHistorical{}, false, err
+	}
+
+	return latest, true, nil
+}
+
+// OverviewStatus is the aggregate status of every monitor.
+type OverviewStatus string
+
+const (
+	OverviewStatusOperational OverviewStatus = "operational"
+	// OverviewStatusDegraded is reported when a monitor that is not critical is down, or a monitor is degraded.
+	OverviewStatusDegraded OverviewStatus = "degraded"
+	// OverviewStatusOutage is reported when a critical monitor is down.
+	OverviewStatusOutage OverviewStatus = "outage"
+)
+
+// OverviewStatusResponse is the body of /api/overview/status.
+type OverviewStatusResponse struct {
+	Status OverviewStatus `json:"status"`
+	// Down lists the IDs of the monitors that are down.
+	Down []string `json:"down"`
+	// Degraded lists the IDs of the monitors that are degraded.
+	Degraded []string `json:"degraded"`
+}
+
+// overviewStatus responds with a single signal of whether everything is up, from the latest snapshot of every
+// monitor. The monitors that are paused, were not checked yet, are in maintenance, or are suppressed by a parent
+// monitor that is down don't count.
+func (s *Server) overviewStatus(w http.ResponseWriter, r *http.Request) {
+	response := OverviewStatusResponse{
+		Status:   OverviewStatusOperational,
+		Down:     []string{},
+		Degraded: []string{},
+	}
+
+	for _, monitor := range s.monitors.List() {
+		if s.paused(monitor) {
+			continue
+		}
+
+		historical, _, err := s.latestHistorical(r.Context(), monitor.UniqueID)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				continue
+			}
+
+			log.Error().Err(err).Str("monitor_id", monitor.UniqueID).Msg("failed to read latest historical data")
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusInternalServerError)
+			w.Write([]byte(`{"error": "failed to read historical data"}`))
+			return
+		}
+
+		if historical.Maintenance || historical.Suppressed {
+			continue
+		}
+
+		switch historical.Status {
+		case MonitorStatusFailure:
+			response.Down = append(response.Down, monitor.UniqueID)
+			if monitor.Critical {
+				response.Status = OverviewStatusOutage
+			} else if response.Status == OverviewStatusOperational {
+				response.Status = OverviewStatusDegraded
+			}
+		case MonitorStatusDegraded:
+			response.Degraded = append(response.Degraded, monitor.UniqueID)
+			if response.Status == OverviewStatusOperational {
+				response.Status = OverviewStatusDegraded
+			}
+		}
+	}
+
+	data, err := json.Marshal(response)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(`{"error": "internal server error"}`))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
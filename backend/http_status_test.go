@@ -0,0 +1,204 @@
+package main_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+
+	main "semyi"
+)
+
+func TestServer_CurrentStatus(t *testing.T) {
+	writer := main.NewMonitorHistoricalWriter(database)
+	now := time.Now().Truncate(time.Second)
+	for _, historical := range []main.MonitorHistorical{
+		{MonitorID: "status-test-stored", Status: main.MonitorStatusSuccess, Latency: 10, Timestamp: now.Add(-time.Minute * 2)},
+		{MonitorID: "status-test-stored", Status: main.MonitorStatusFailure, Latency: 20, Timestamp: now.Add(-time.Minute)},
+	} {
+		if err := writer.Write(context.Background(), historical); err != nil {
+			t.Fatalf("unexpected error writing historical data: %v", err)
+		}
+	}
+
+	broker := main.NewBroker[main.MonitorHistorical]()
+	server, err := main.NewServer(main.ServerConfig{
+		MonitorHistoricalReader: main.NewMonitorHistoricalReader(database),
+		CentralBroker:           broker,
+		MonitorList: []main.Monitor{
+			{UniqueID: "status-test-stored", Name: "Stored"},
+			{UniqueID: "status-test-live", Name: "Live"},
+			{UniqueID: "status-test-empty", Name: "Empty"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error creating server: %v", err)
+	}
+
+	for _, latency := range []int64{30, 40} {
+		err := broker.Publish("status-test-live", &main.BrokerMessage[main.MonitorHistorical]{
+			Body: main.MonitorHistorical{MonitorID: "status-test-live", Status: main.MonitorStatusDegraded, Latency: latency, Timestamp: now},
+		})
+		if err != nil {
+			t.Fatalf("unexpected error publishing: %v", err)
+		}
+	}
+
+	recorder := httptest.NewRecorder()
+	server.Handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/api/status", nil))
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("expected status code %d, got %d", http.StatusOK, recorder.Code)
+	}
+
+	if contentType := recorder.Header().Get("Content-Type"); contentType != "application/json" {
+		t.Errorf("expected content type application/json, got %s", contentType)
+	}
+
+	var snapshots []map[string]any
+	if err := json.NewDecoder(recorder.Body).Decode(&snapshots); err != nil {
+		t.Fatalf("unexpected error decoding body: %v", err)
+	}
+
+	if len(snapshots) != 3 {
+		t.Fatalf("expected 3 snapshots, got %d", len(snapshots))
+	}
+
+	for _, snapshot := range snapshots {
+		for _, key := range []string{"id", "name", "status", "latency", "timestamp"} {
+			if _, ok := snapshot[key]; !ok {
+				t.Errorf("expected key %q in snapshot %v", key, snapshot)
+			}
+		}
+	}
+
+	expected := []struct {
+		id      string
+		status  any
+		latency any
+		stale   bool
+	}{
+		// Read from the database, as nothing was published since the restart
+		{id: "status-test-stored", status: "down", latency: float64(20), stale: true},
+		{id: "status-test-live", status: "degraded", latency: float64(40), stale: false},
+		{id: "status-test-empty", status: nil, latency: nil, stale: false},
+	}
+	for i, want := range expected {
+		got := snapshots[i]
+		if got["id"] != want.id || got["status"] != want.status || got["latency"] != want.latency || got["stale"] != want.stale {
+			t.Errorf("expected snapshot %+v, got %v", want, got)
+		}
+	}
+
+	timestamp, err := time.Parse(time.RFC3339, snapshots[0]["timestamp"].(string))
+	if err != nil {
+		t.Fatalf("unexpected error parsing timestamp: %v", err)
+	}
+
+	if !timestamp.Equal(now.Add(-time.Minute)) {
+		t.Errorf("expected timestamp %s, got %s", now.Add(-time.Minute), timestamp)
+	}
+}
+
+func TestServer_OverviewStatus(t *testing.T) {
+	monitors := []main.Monitor{
+		{UniqueID: "overview-status-database", Name: "Database", Critical: true},
+		{UniqueID: "overview-status-search", Name: "Search"},
+		{UniqueID: "overview-status-blog", Name: "Blog"},
+	}
+
+	tests := []struct {
+		name         string
+		statuses     map[string]main.MonitorStatus
+		wantStatus   main.OverviewStatus
+		wantDown     []string
+		wantDegraded []string
+	}{
+		{
+			name: "operational",
+			statuses: map[string]main.MonitorStatus{
+				"overview-status-database": main.MonitorStatusSuccess,
+				"overview-status-search":   main.MonitorStatusSuccess,
+			},
+			wantStatus: main.OverviewStatusOperational,
+		},
+		{
+			name: "non-critical monitor down",
+			statuses: map[string]main.MonitorStatus{
+				"overview-status-database": main.MonitorStatusSuccess,
+				"overview-status-search":   main.MonitorStatusFailure,
+			},
+			wantStatus: main.OverviewStatusDegraded,
+			wantDown:   []string{"overview-status-search"},
+		},
+		{
+			name: "monitor degraded",
+			statuses: map[string]main.MonitorStatus{
+				"overview-status-database": main.MonitorStatusDegraded,
+				"overview-status-search":   main.MonitorStatusSuccess,
+			},
+			wantStatus:   main.OverviewStatusDegraded,
+			wantDegraded: []string{"overview-status-database"},
+		},
+		{
+			name: "critical monitor down",
+			statuses: map[string]main.MonitorStatus{
+				"overview-status-database": main.MonitorStatusFailure,
+				"overview-status-search":   main.MonitorStatusFailure,
+				"overview-status-blog":     main.MonitorStatusDegraded,
+			},
+			wantStatus:   main.OverviewStatusOutage,
+			wantDown:     []string{"overview-status-database", "overview-status-search"},
+			wantDegraded: []string{"overview-status-blog"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// The monitors that are not published are never checked, so they don't count
+			broker := main.NewBroker[main.MonitorHistorical]()
+			for id, status := range tt.statuses {
+				err := broker.Publish(id, &main.BrokerMessage[main.MonitorHistorical]{
+					Body: main.MonitorHistorical{MonitorID: id, Status: status, Timestamp: time.Now()},
+				})
+				if err != nil {
+					t.Fatalf("unexpected error publishing: %v", err)
+				}
+			}
+
+			server, err := main.NewServer(main.ServerConfig{
+				MonitorHistoricalReader: main.NewMonitorHistoricalReader(database),
+				CentralBroker:           broker,
+				MonitorList:             monitors,
+			})
+			if err != nil {
+				t.Fatalf("unexpected error creating server: %v", err)
+			}
+
+			recorder := httptest.NewRecorder()
+			server.Handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/api/overview/status", nil))
+			if recorder.Code != http.StatusOK {
+				t.Fatalf("expected status code %d, got %d", http.StatusOK, recorder.Code)
+			}
+
+			var body main.OverviewStatusResponse
+			if err := json.NewDecoder(recorder.Body).Decode(&body); err != nil {
+				t.Fatalf("unexpected error decoding body: %v", err)
+			}
+
+			if body.Status != tt.wantStatus {
+				t.Errorf("expected status %s, got %s", tt.wantStatus, body.Status)
+			}
+
+			if !slices.Equal(body.Down, tt.wantDown) {
+				t.Errorf("expected down %v, got %v", tt.wantDown, body.Down)
+			}
+
+			if !slices.Equal(body.Degraded, tt.wantDegraded) {
+				t.Errorf("expected degraded %v, got %v", tt.wantDegraded, body.Degraded)
+			}
+		})
+	}
+}
@@ -0,0 +1,1160 @@
+package main_test
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	main "semyi"
+)
+
+func TestNewServer(t *testing.T) {
+	t.Run("Should fail without central broker", func(t *testing.T) {
+		_, err := main.NewServer(main.ServerConfig{
+			MonitorHistoricalReader: main.NewMonitorHistoricalReader(database),
+		})
+		if err == nil {
+			t.Error("expected error, got nil")
+		}
+	})
+
+	t.Run("Should fail without historical reader", func(t *testing.T) {
+		_, err := main.NewServer(main.ServerConfig{
+			CentralBroker: main.NewBroker[main.MonitorHistorical](),
+		})
+		if err == nil {
+			t.Error("expected error, got nil")
+		}
+	})
+}
+
+func TestServer_StaticSnapshot(t *testing.T) {
+	server, err := main.NewServer(main.ServerConfig{
+		MonitorHistoricalReader: main.NewMonitorHistoricalReader(database),
+		CentralBroker:           main.NewBroker[main.MonitorHistorical](),
+		MonitorList: []main.Monitor{{
+			UniqueID:     "static-test",
+			Name:         "Static Test",
+			Description:  "Static snapshot test",
+			PublicUrl:    "https://static.example.com",
+			Type:         main.MonitorTypeHTTP,
+			Interval:     45,
+			HttpEndpoint: "https://internal.example.com/health",
+		}},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error creating server: %v", err)
+	}
+
+	t.Run("Should include the metadata of the monitor", func(t *testing.T) {
+		recorder := httptest.NewRecorder()
+		server.Handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/api/static?id=static-test&interval=raw", nil))
+		if recorder.Code != http.StatusOK {
+			t.Fatalf("expected status code %d, got %d", http.StatusOK, recorder.Code)
+		}
+
+		var body struct {
+			Metadata map[string]any `json:"metadata"`
+		}
+		if err := json.NewDecoder(recorder.Body).Decode(&body); err != nil {
+			t.Fatalf("unexpected error decoding body: %v", err)
+		}
+
+		expected := map[string]any{
+			"id":            "static-test",
+			"name":          "Static Test",
+			"description":   "Static snapshot test",
+			"public_url":    "https://static.example.com",
+			"type":          "http",
+			"interval":      float64(45),
+			"group":         "",
+			"display_order": float64(0),
+			"color":         "",
+			"paused":        false,
+		}
+		for key, value := range expected {
+			if body.Metadata[key] != value {
+				t.Errorf("expected metadata %s to be %v, got %v", key, value, body.Metadata[key])
+			}
+		}
+
+		// The configuration that is not public is left out
+		if len(body.Metadata) != len(expected) {
+			t.Errorf("expected only the keys of %v, got %v", expected, body.Metadata)
+		}
+	})
+
+	t.Run("Should respond with json on missing id", func(t *testing.T) {
+		recorder := httptest.NewRecorder()
+		server.Handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/api/static", nil))
+
+		if recorder.Code != http.StatusBadRequest {
+			t.Errorf("expected status code %d, got %d", http.StatusBadRequest, recorder.Code)
+		}
+
+		if contentType := recorder.Result().Header.Get("Content-Type"); contentType != "application/json" {
+			t.Errorf("expected Content-Type application/json, got %q", contentType)
+		}
+	})
+
+	writer := main.NewMonitorHistoricalWriter(database)
+	start := time.Now().Add(-time.Hour)
+	for i := 0; i < 5; i++ {
+		err := writer.Write(context.Background(), main.MonitorHistorical{
+			MonitorID: "static-test",
+			Status:    main.MonitorStatusSuccess,
+			Latency:   int64(i),
+			Timestamp: start.Add(time.Minute * time.Duration(i)),
+		})
+		if err != nil {
+			t.Fatalf("unexpected error writing historical data: %v", err)
+		}
+	}
+
+	type pagination struct {
+		Limit   int  `json:"limit"`
+		Offset  int  `json:"offset"`
+		Total   int  `json:"total"`
+		HasMore bool `json:"has_more"`
+	}
+
+	type snapshot struct {
+		Historical []main.MonitorHistorical `json:"historical"`
+		Pagination pagination               `json:"pagination"`
+	}
+
+	pageTests := []struct {
+		name           string
+		query          string
+		wantLatencies  []int64
+		wantPagination pagination
+	}{
+		{
+			name:           "Should use the default limit",
+			query:          "",
+			wantLatencies:  []int64{0, 1, 2, 3, 4},
+			wantPagination: pagination{Limit: 1000, Offset: 0, Total: 5, HasMore: false},
+		},
+		{
+			name:           "Should return the first page",
+			query:          "&limit=2",
+			wantLatencies:  []int64{0, 1},
+			wantPagination: pagination{Limit: 2, Offset: 0, Total: 5, HasMore: true},
+		},
+		{
+			name:           "Should return the last page",
+			query:          "&limit=2&offset=4",
+			wantLatencies:  []int64{4},
+			wantPagination: pagination{Limit: 2, Offset: 4, Total: 5, HasMore: false},
+		},
+		{
+			name:           "Should return an empty page past the end",
+			query:          "&limit=2&offset=5",
+			wantLatencies:  []int64{},
+			wantPagination: pagination{Limit: 2, Offset: 5, Total: 5, HasMore: false},
+		},
+		{
+			name:           "Should enforce the max limit",
+			query:          "&limit=1000000",
+			wantLatencies:  []int64{0, 1, 2, 3, 4},
+			wantPagination: pagination{Limit: 10000, Offset: 0, Total: 5, HasMore: false},
+		},
+	}
+
+	for _, tt := range pageTests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			server.Handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/api/static?id=static-test&interval=raw"+tt.query, nil))
+			if recorder.Code != http.StatusOK {
+				t.Fatalf("expected status code %d, got %d", http.StatusOK, recorder.Code)
+			}
+
+			var body snapshot
+			if err := json.NewDecoder(recorder.Body).Decode(&body); err != nil {
+				t.Fatalf("unexpected error decoding body: %v", err)
+			}
+
+			latencies := []int64{}
+			for _, historical := range body.Historical {
+				latencies = append(latencies, historical.Latency)
+			}
+
+			if !slices.Equal(latencies, tt.wantLatencies) {
+				t.Errorf("expected latencies %v, got %v", tt.wantLatencies, latencies)
+			}
+
+			if body.Pagination != tt.wantPagination {
+				t.Errorf("expected pagination %+v, got %+v", tt.wantPagination, body.Pagination)
+			}
+		})
+	}
+
+	rangeTests := []struct {
+		name           string
+		from           time.Duration
+		to             time.Duration
+		wantLatencies  []int64
+		wantPagination pagination
+	}{
+		{
+			name:           "Should filter by the time range",
+			from:           time.Minute + time.Second*30,
+			to:             time.Minute*3 + time.Second*30,
+			wantLatencies:  []int64{2, 3},
+			wantPagination: pagination{Limit: 1000, Offset: 0, Total: 2, HasMore: false},
+		},
+		{
+			name:           "Should filter from a timestamp only",
+			from:           time.Minute*3 + time.Second*30,
+			wantLatencies:  []int64{4},
+			wantPagination: pagination{Limit: 1000, Offset: 0, Total: 1, HasMore: false},
+		},
+		{
+			name:           "Should filter to a timestamp only",
+			to:             time.Second * 30,
+			wantLatencies:  []int64{0},
+			wantPagination: pagination{Limit: 1000, Offset: 0, Total: 1, HasMore: false},
+		},
+	}
+
+	for _, tt := range rangeTests {
+		t.Run(tt.name, func(t *testing.T) {
+			query := ""
+			if tt.from != 0 {
+				query += "&from=" + start.Add(tt.from).UTC().Format(time.RFC3339)
+			}
+			if tt.to != 0 {
+				query += "&to=" + start.Add(tt.to).UTC().Format(time.RFC3339)
+			}
+
+			recorder := httptest.NewRecorder()
+			server.Handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/api/static?id=static-test&interval=raw"+query, nil))
+			if recorder.Code != http.StatusOK {
+				t.Fatalf("expected status code %d, got %d", http.StatusOK, recorder.Code)
+			}
+
+			var body snapshot
+			if err := json.NewDecoder(recorder.Body).Decode(&body); err != nil {
+				t.Fatalf("unexpected error decoding body: %v", err)
+			}
+
+			latencies := []int64{}
+			for _, historical := range body.Historical {
+				latencies = append(latencies, historical.Latency)
+			}
+
+			if !slices.Equal(latencies, tt.wantLatencies) {
+				t.Errorf("expected latencies %v, got %v", tt.wantLatencies, latencies)
+			}
+
+			if body.Pagination != tt.wantPagination {
+				t.Errorf("expected pagination %+v, got %+v", tt.wantPagination, body.Pagination)
+			}
+		})
+	}
+
+	t.Run("Should reject an invalid time range", func(t *testing.T) {
+		for _, query := range []string{
+			"&from=yesterday",
+			"&to=2024-06-20",
+			"&from=2024-06-20T10:00:00Z&to=2024-06-20T09:00:00Z",
+		} {
+			recorder := httptest.NewRecorder()
+			server.Handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/api/static?id=static-test&interval=raw"+query, nil))
+			if recorder.Code != http.StatusBadRequest {
+				t.Errorf("%s: expected status code %d, got %d", query, http.StatusBadRequest, recorder.Code)
+			}
+
+			if contentType := recorder.Header().Get("Content-Type"); contentType != "application/json" {
+				t.Errorf("%s: expected Content-Type application/json, got %q", query, contentType)
+			}
+		}
+	})
+
+	t.Run("Should include the latency percentiles of the hourly aggregate", func(t *testing.T) {
+		err := writer.WriteAggregate(context.Background(), "hourly", main.MonitorAggregate{
+			MonitorID:   "static-test",
+			Timestamp:   start.Truncate(time.Hour),
+			Status:      main.MonitorStatusSuccess,
+			Uptime:      100,
+			Latency:     20,
+			P50Latency:  15,
+			P95Latency:  40,
+			P99Latency:  60,
+			SampleCount: 5,
+		})
+		if err != nil {
+			t.Fatalf("unexpected error writing the aggregate: %v", err)
+		}
+
+		recorder := httptest.NewRecorder()
+		server.Handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/api/static?id=static-test&interval=hourly", nil))
+		if recorder.Code != http.StatusOK {
+			t.Fatalf("expected status code %d, got %d", http.StatusOK, recorder.Code)
+		}
+
+		var body snapshot
+		if err := json.NewDecoder(recorder.Body).Decode(&body); err != nil {
+			t.Fatalf("unexpected error decoding body: %v", err)
+		}
+
+		if len(body.Historical) != 1 {
+			t.Fatalf("expected 1 hourly entry, got %d", len(body.Historical))
+		}
+
+		got := body.Historical[0]
+		if got.P50Latency != 15 || got.P95Latency != 40 || got.P99Latency != 60 {
+			t.Errorf("expected percentiles 15/40/60, got %d/%d/%d", got.P50Latency, got.P95Latency, got.P99Latency)
+		}
+	})
+
+	t.Run("Should reject invalid limit", func(t *testing.T) {
+		for _, query := range []string{"&limit=0", "&limit=abc", "&offset=-1"} {
+			recorder := httptest.NewRecorder()
+			server.Handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/api/static?id=static-test&interval=raw"+query, nil))
+			if recorder.Code != http.StatusBadRequest {
+				t.Errorf("%s: expected status code %d, got %d", query, http.StatusBadRequest, recorder.Code)
+			}
+		}
+	})
+
+	t.Run("Should respond with not modified on a matching ETag", func(t *testing.T) {
+		recorder := httptest.NewRecorder()
+		server.Handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/api/static?id=static-test&interval=raw", nil))
+		if recorder.Code != http.StatusOK {
+			t.Fatalf("expected status code %d, got %d", http.StatusOK, recorder.Code)
+		}
+
+		etag := recorder.Header().Get("ETag")
+		if etag == "" {
+			t.Fatal("expected an ETag header")
+		}
+
+		if cacheControl := recorder.Header().Get("Cache-Control"); cacheControl != "no-cache" {
+			t.Errorf("expected Cache-Control no-cache, got %q", cacheControl)
+		}
+
+		request := httptest.NewRequest(http.MethodGet, "/api/static?id=static-test&interval=raw", nil)
+		request.Header.Set("If-None-Match", etag)
+		recorder = httptest.NewRecorder()
+		server.Handler.ServeHTTP(recorder, request)
+		if recorder.Code != http.StatusNotModified {
+			t.Fatalf("expected status code %d, got %d", http.StatusNotModified, recorder.Code)
+		}
+
+		if recorder.Body.Len() != 0 {
+			t.Errorf("expected no body, got %q", recorder.Body.String())
+		}
+
+		// A new check changes the response, so the old ETag doesn't match anymore
+		err := writer.Write(context.Background(), main.MonitorHistorical{
+			MonitorID: "static-test",
+			Status:    main.MonitorStatusFailure,
+			Timestamp: start.Add(time.Minute * 30),
+		})
+		if err != nil {
+			t.Fatalf("unexpected error writing historical data: %v", err)
+		}
+
+		request = httptest.NewRequest(http.MethodGet, "/api/static?id=static-test&interval=raw", nil)
+		request.Header.Set("If-None-Match", etag)
+		recorder = httptest.NewRecorder()
+		server.Handler.ServeHTTP(recorder, request)
+		if recorder.Code != http.StatusOK {
+			t.Fatalf("expected status code %d, got %d", http.StatusOK, recorder.Code)
+		}
+
+		if got := recorder.Header().Get("ETag"); got == etag {
+			t.Errorf("expected a new ETag, got the previous %s", got)
+		}
+	})
+}
+
+func TestServer_SnapshotOverview(t *testing.T) {
+	broker := main.NewBroker[main.MonitorHistorical]()
+	server, err := main.NewServer(main.ServerConfig{
+		MonitorHistoricalReader: main.NewMonitorHistoricalReader(database),
+		CentralBroker:           broker,
+		MonitorList:             []main.Monitor{{UniqueID: "overview-test", Name: "Overview Test"}},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error creating server: %v", err)
+	}
+
+	testServer := httptest.NewServer(server.Handler)
+	defer testServer.Close()
+
+	type frameResult struct {
+		line string
+		err  error
+	}
+
+	frames := make(chan frameResult, 1)
+	go func() {
+		resp, err := http.Get(testServer.URL + "/api/overview")
+		if err != nil {
+			frames <- frameResult{err: err}
+			return
+		}
+		defer resp.Body.Close()
+
+		// Every event starts with its id, followed by the data
+		reader := bufio.NewReader(resp.Body)
+		line, err := reader.ReadString('\n')
+		if err == nil && strings.HasPrefix(line, "id: ") {
+			line, err = reader.ReadString('\n')
+		}
+		frames <- frameResult{line: line, err: err}
+	}()
+
+	// Wait for the handler to subscribe before publishing
+	deadline := time.Now().Add(time.Second * 5)
+	for {
+		broker.RLock()
+		subscribed := len(broker.Subscribers["overview-test"]) > 0
+		broker.RUnlock()
+		if subscribed {
+			break
+		}
+
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for subscriber")
+		}
+		time.Sleep(time.Millisecond * 10)
+	}
+
+	err = broker.Publish("overview-test", &main.BrokerMessage[main.MonitorHistorical]{
+		Body: main.MonitorHistorical{
+			MonitorID: "overview-test",
+			Status:    main.MonitorStatusSuccess,
+			Latency:   10,
+			Timestamp: time.Now(),
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error publishing: %v", err)
+	}
+
+	select {
+	case frame := <-frames:
+		if frame.err != nil {
+			t.Fatalf("unexpected error reading overview: %v", frame.err)
+		}
+
+		if !strings.HasPrefix(frame.line, "data: ") {
+			t.Errorf("expected data frame, got %q", frame.line)
+		}
+	case <-time.After(time.Second * 5):
+		t.Fatal("timed out waiting for data frame")
+	}
+
+	// The client is gone, publishing must not block on the released subscription
+	published := make(chan error, 1)
+	go func() {
+		published <- broker.Publish("overview-test", &main.BrokerMessage[main.MonitorHistorical]{
+			Body: main.MonitorHistorical{MonitorID: "overview-test", Timestamp: time.Now()},
+		})
+	}()
+
+	select {
+	case err := <-published:
+		if err != nil {
+			t.Errorf("unexpected error publishing: %v", err)
+		}
+	case <-time.After(time.Second * 5):
+		t.Fatal("publish blocked after client disconnected")
+	}
+}
+
+func TestServer_SnapshotBy(t *testing.T) {
+	broker := main.NewBroker[main.MonitorHistorical]()
+	server, err := main.NewServer(main.ServerConfig{
+		MonitorHistoricalReader: main.NewMonitorHistoricalReader(database),
+		CentralBroker:           broker,
+		MonitorList:             []main.Monitor{{UniqueID: "by-test", Name: "By Test"}},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error creating server: %v", err)
+	}
+
+	testServer := httptest.NewServer(server.Handler)
+	defer testServer.Close()
+
+	subscriberCount := func() int {
+		broker.RLock()
+		defer broker.RUnlock()
+		return len(broker.Subscribers["by-test"])
+	}
+
+	t.Run("Should block while idle and release subscription on disconnect", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		request, err := http.NewRequestWithContext(ctx, http.MethodGet, testServer.URL+"/api/by?ids=by-test", nil)
+		if err != nil {
+			t.Fatalf("unexpected error creating request: %v", err)
+		}
+
+		resp, err := http.DefaultClient.Do(request)
+		if err != nil {
+			t.Fatalf("unexpected error requesting snapshot: %v", err)
+		}
+		defer resp.Body.Close()
+
+		if resp.Header.Get("Content-Type") != "text/event-stream" {
+			t.Errorf("expected Content-Type text/event-stream, got %q", resp.Header.Get("Content-Type"))
+		}
+
+		// The connection is idle, nothing should be written to the stream
+		read := make(chan error, 1)
+		go func() {
+			_, err := bufio.NewReader(resp.Body).ReadString('\n')
+			read <- err
+		}()
+
+		select {
+		case err := <-read:
+			t.Fatalf("expected idle stream, got read result: %v", err)
+		case <-time.After(time.Millisecond * 100):
+		}
+
+		if subscriberCount() != 1 {
+			t.Fatalf("expected 1 subscriber, got %d", subscriberCount())
+		}
+
+		cancel()
+
+		deadline := time.Now().Add(time.Second * 5)
+		for subscriberCount() != 0 {
+			if time.Now().After(deadline) {
+				t.Fatalf("expected subscription to be released, got %d subscribers", subscriberCount())
+			}
+			time.Sleep(time.Millisecond * 10)
+		}
+	})
+}
+
+func TestServer_SSEHeartbeat(t *testing.T) {
+	server, err := main.NewServer(main.ServerConfig{
+		MonitorHistoricalReader: main.NewMonitorHistoricalReader(database),
+		CentralBroker:           main.NewBroker[main.MonitorHistorical](),
+		MonitorList:             []main.Monitor{{UniqueID: "heartbeat-test", Name: "Heartbeat Test"}},
+		SSEHeartbeatInterval:    time.Millisecond * 50,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error creating server: %v", err)
+	}
+
+	testServer := httptest.NewServer(server.Handler)
+	defer testServer.Close()
+
+	resp, err := http.Get(testServer.URL + "/api/by?ids=heartbeat-test")
+	if err != nil {
+		t.Fatalf("unexpected error requesting snapshot: %v", err)
+	}
+	defer resp.Body.Close()
+
+	type lineResult struct {
+		line string
+		err  error
+	}
+
+	lines := make(chan lineResult, 1)
+	go func() {
+		line, err := bufio.NewReader(resp.Body).ReadString('\n')
+		lines <- lineResult{line: line, err: err}
+	}()
+
+	select {
+	case result := <-lines:
+		if result.err != nil {
+			t.Fatalf("unexpected error reading stream: %v", result.err)
+		}
+
+		if result.line != ": keepalive\n" {
+			t.Errorf("expected keepalive comment, got %q", result.line)
+		}
+	case <-time.After(time.Second * 5):
+		t.Fatal("timed out waiting for keepalive comment")
+	}
+}
+
+func TestServer_GzipCompression(t *testing.T) {
+	server, err := main.NewServer(main.ServerConfig{
+		MonitorHistoricalReader: main.NewMonitorHistoricalReader(database),
+		CentralBroker:           main.NewBroker[main.MonitorHistorical](),
+		MonitorList:             []main.Monitor{{UniqueID: "gzip-test", Name: "Gzip Test"}},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error creating server: %v", err)
+	}
+
+	t.Run("Should compress when requested", func(t *testing.T) {
+		request := httptest.NewRequest(http.MethodGet, "/api/static?id=gzip-test&interval=raw", nil)
+		request.Header.Set("Accept-Encoding", "gzip")
+
+		recorder := httptest.NewRecorder()
+		server.Handler.ServeHTTP(recorder, request)
+		if recorder.Code != http.StatusOK {
+			t.Fatalf("expected status code %d, got %d", http.StatusOK, recorder.Code)
+		}
+
+		if encoding := recorder.Header().Get("Content-Encoding"); encoding != "gzip" {
+			t.Fatalf("expected Content-Encoding gzip, got %q", encoding)
+		}
+
+		if vary := recorder.Header().Values("Vary"); !slices.Contains(vary, "Accept-Encoding") {
+			t.Errorf("expected Vary to contain Accept-Encoding, got %v", vary)
+		}
+
+		reader, err := gzip.NewReader(recorder.Body)
+		if err != nil {
+			t.Fatalf("unexpected error creating gzip reader: %v", err)
+		}
+
+		var body map[string]any
+		if err := json.NewDecoder(reader).Decode(&body); err != nil {
+			t.Fatalf("unexpected error decoding body: %v", err)
+		}
+
+		if _, ok := body["historical"]; !ok {
+			t.Errorf("expected historical in body, got %v", body)
+		}
+	})
+
+	t.Run("Should not compress when not requested", func(t *testing.T) {
+		recorder := httptest.NewRecorder()
+		server.Handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/api/static?id=gzip-test&interval=raw", nil))
+
+		if encoding := recorder.Header().Get("Content-Encoding"); encoding != "" {
+			t.Errorf("expected no Content-Encoding, got %q", encoding)
+		}
+	})
+}
+
+func TestServer_UnknownMonitor(t *testing.T) {
+	server, err := main.NewServer(main.ServerConfig{
+		MonitorHistoricalReader: main.NewMonitorHistoricalReader(database),
+		CentralBroker:           main.NewBroker[main.MonitorHistorical](),
+		MonitorList:             []main.Monitor{{UniqueID: "unknown-test", Name: "Unknown Test"}},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error creating server: %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		target     string
+		statusCode int
+	}{
+		{name: "by with unknown id", target: "/api/by?ids=unknown-test,does-not-exist", statusCode: http.StatusNotFound},
+		{name: "by with missing ids", target: "/api/by", statusCode: http.StatusBadRequest},
+		{name: "static with unknown id", target: "/api/static?id=does-not-exist", statusCode: http.StatusNotFound},
+		{name: "static with missing id", target: "/api/static", statusCode: http.StatusBadRequest},
+		{name: "static with invalid interval", target: "/api/static?id=unknown-test&interval=weekly", statusCode: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			server.Handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, tt.target, nil))
+			if recorder.Code != tt.statusCode {
+				t.Errorf("expected status code %d, got %d", tt.statusCode, recorder.Code)
+			}
+
+			var body map[string]string
+			if err := json.NewDecoder(recorder.Body).Decode(&body); err != nil || body["error"] == "" {
+				t.Errorf("expected a json error, got %q (%v)", recorder.Body.String(), err)
+			}
+		})
+	}
+}
+
+func TestServer_CorsAllowedOrigins(t *testing.T) {
+	newRequest := func(origin string) *http.Request {
+		request := httptest.NewRequest(http.MethodGet, "/api/static?id=cors-test", nil)
+		request.Header.Set("Origin", origin)
+		return request
+	}
+
+	t.Run("Should allow any origin by default", func(t *testing.T) {
+		server, err := main.NewServer(main.ServerConfig{
+			MonitorHistoricalReader: main.NewMonitorHistoricalReader(database),
+			CentralBroker:           main.NewBroker[main.MonitorHistorical](),
+			MonitorList:             []main.Monitor{{UniqueID: "cors-test", Name: "CORS Test"}},
+		})
+		if err != nil {
+			t.Fatalf("unexpected error creating server: %v", err)
+		}
+
+		recorder := httptest.NewRecorder()
+		server.Handler.ServeHTTP(recorder, newRequest("https://anywhere.example.com"))
+		if origin := recorder.Header().Get("Access-Control-Allow-Origin"); origin != "*" {
+			t.Errorf("expected Access-Control-Allow-Origin *, got %q", origin)
+		}
+	})
+
+	t.Run("Should only allow configured origins", func(t *testing.T) {
+		server, err := main.NewServer(main.ServerConfig{
+			MonitorHistoricalReader: main.NewMonitorHistoricalReader(database),
+			CentralBroker:           main.NewBroker[main.MonitorHistorical](),
+			MonitorList:             []main.Monitor{{UniqueID: "cors-test", Name: "CORS Test"}},
+			AllowedOrigins:          []string{"https://status.example.com"},
+			AllowCredentials:        true,
+		})
+		if err != nil {
+			t.Fatalf("unexpected error creating server: %v", err)
+		}
+
+		recorder := httptest.NewRecorder()
+		server.Handler.ServeHTTP(recorder, newRequest("https://status.example.com"))
+		if origin := recorder.Header().Get("Access-Control-Allow-Origin"); origin != "https://status.example.com" {
+			t.Errorf("expected Access-Control-Allow-Origin https://status.example.com, got %q", origin)
+		}
+
+		if credentials := recorder.Header().Get("Access-Control-Allow-Credentials"); credentials != "true" {
+			t.Errorf("expected Access-Control-Allow-Credentials true, got %q", credentials)
+		}
+
+		recorder = httptest.NewRecorder()
+		server.Handler.ServeHTTP(recorder, newRequest("https://evil.example.com"))
+		if origin := recorder.Header().Get("Access-Control-Allow-Origin"); origin != "" {
+			t.Errorf("expected no Access-Control-Allow-Origin, got %q", origin)
+		}
+	})
+
+	t.Run("Should allow the preflight of the pause and resume requests", func(t *testing.T) {
+		server, err := main.NewServer(main.ServerConfig{
+			MonitorHistoricalReader: main.NewMonitorHistoricalReader(database),
+			CentralBroker:           main.NewBroker[main.MonitorHistorical](),
+			MonitorList:             []main.Monitor{{UniqueID: "cors-test", Name: "CORS Test"}},
+			WorkerManager:           main.NewWorkerManager(nil, main.NewMonitorRegistry(nil)),
+			ApiKeys:                 []string{"cors-key"},
+			AllowedOrigins:          []string{"https://dashboard.example.com"},
+		})
+		if err != nil {
+			t.Fatalf("unexpected error creating server: %v", err)
+		}
+
+		for _, path := range []string{"/api/monitors/cors-test/pause", "/api/monitors/cors-test/resume"} {
+			request := httptest.NewRequest(http.MethodOptions, path, nil)
+			request.Header.Set("Origin", "https://dashboard.example.com")
+			request.Header.Set("Access-Control-Request-Method", http.MethodPost)
+			request.Header.Set("Access-Control-Request-Headers", "X-API-Key")
+
+			recorder := httptest.NewRecorder()
+			server.Handler.ServeHTTP(recorder, request)
+			if origin := recorder.Header().Get("Access-Control-Allow-Origin"); origin != "https://dashboard.example.com" {
+				t.Errorf("expected the preflight of %s to allow the origin, got %q", path, origin)
+			}
+
+			if methods := recorder.Header().Get("Access-Control-Allow-Methods"); methods != http.MethodPost {
+				t.Errorf("expected the preflight of %s to allow POST, got %q", path, methods)
+			}
+		}
+	})
+
+	t.Run("Should reject wildcard origin with credentials", func(t *testing.T) {
+		_, err := main.NewServer(main.ServerConfig{
+			MonitorHistoricalReader: main.NewMonitorHistoricalReader(database),
+			CentralBroker:           main.NewBroker[main.MonitorHistorical](),
+			AllowCredentials:        true,
+		})
+		if err == nil {
+			t.Error("expected error, got nil")
+		}
+	})
+}
+
+func TestServer_GracefulShutdown(t *testing.T) {
+	server, err := main.NewServer(main.ServerConfig{
+		MonitorHistoricalReader: main.NewMonitorHistoricalReader(database),
+		CentralBroker:           main.NewBroker[main.MonitorHistorical](),
+		MonitorList:             []main.Monitor{{UniqueID: "shutdown-test", Name: "Shutdown Test"}},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error creating server: %v", err)
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("unexpected error listening: %v", err)
+	}
+
+	served := make(chan error, 1)
+	go func() {
+		served <- server.Serve(listener)
+	}()
+
+	resp, err := http.Get("http://" + listener.Addr().String() + "/api/by?ids=shutdown-test")
+	if err != nil {
+		t.Fatalf("unexpected error opening stream: %v", err)
+	}
+	defer resp.Body.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	if err := server.Shutdown(ctx); err != nil {
+		t.Fatalf("expected shutdown to complete without error, got %v", err)
+	}
+
+	if err := <-served; !errors.Is(err, http.ErrServerClosed) {
+		t.Errorf("expected ErrServerClosed, got %v", err)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("unexpected error reading stream: %v", err)
+	}
+
+	if !strings.Contains(string(body), ": server is shutting down") {
+		t.Errorf("expected a shutdown comment, got %q", string(body))
+	}
+}
+
+func TestServer_LastEventID(t *testing.T) {
+	broker := main.NewBroker[main.MonitorHistorical]()
+	server, err := main.NewServer(main.ServerConfig{
+		MonitorHistoricalReader: main.NewMonitorHistoricalReader(database),
+		CentralBroker:           broker,
+		MonitorList:             []main.Monitor{{UniqueID: "resume-test", Name: "Resume Test"}},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error creating server: %v", err)
+	}
+
+	testServer := httptest.NewServer(server.Handler)
+	defer testServer.Close()
+
+	type event struct {
+		id      string
+		latency int64
+	}
+
+	// readEvent reads a single event, skipping comments
+	readEvent := func(reader *bufio.Reader) (event, error) {
+		var e event
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				return e, err
+			}
+
+			switch {
+			case strings.HasPrefix(line, "id: "):
+				e.id = strings.TrimSpace(strings.TrimPrefix(line, "id: "))
+			case strings.HasPrefix(line, "data: "):
+				var historical main.MonitorHistorical
+				if err := json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &historical); err != nil {
+					return e, err
+				}
+				e.latency = historical.Latency
+			case line == "\n" && e.id != "":
+				return e, nil
+			}
+		}
+	}
+
+	publish := func(latency int64) {
+		err := broker.Publish("resume-test", &main.BrokerMessage[main.MonitorHistorical]{
+			Body: main.MonitorHistorical{MonitorID: "resume-test", Latency: latency},
+		})
+		if err != nil {
+			t.Fatalf("unexpected error publishing: %v", err)
+		}
+	}
+
+	waitForSubscriber := func(want int) {
+		deadline := time.Now().Add(time.Second * 5)
+		for time.Now().Before(deadline) {
+			broker.RLock()
+			count := len(broker.Subscribers["resume-test"])
+			broker.RUnlock()
+			if count == want {
+				return
+			}
+			time.Sleep(time.Millisecond * 10)
+		}
+		t.Fatalf("timed out waiting for %d subscribers", want)
+	}
+
+	connect := func(ctx context.Context, lastEventId string) (*http.Response, *bufio.Reader) {
+		request, err := http.NewRequestWithContext(ctx, http.MethodGet, testServer.URL+"/api/by?ids=resume-test", nil)
+		if err != nil {
+			t.Fatalf("unexpected error creating request: %v", err)
+		}
+
+		if lastEventId != "" {
+			request.Header.Set("Last-Event-ID", lastEventId)
+		}
+
+		resp, err := http.DefaultClient.Do(request)
+		if err != nil {
+			t.Fatalf("unexpected error connecting: %v", err)
+		}
+
+		return resp, bufio.NewReader(resp.Body)
+	}
+
+	// Receive the first event, then disconnect
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	resp, reader := connect(ctx, "")
+	waitForSubscriber(1)
+	publish(1)
+
+	first, err := readEvent(reader)
+	if err != nil {
+		t.Fatalf("unexpected error reading event: %v", err)
+	}
+	cancel()
+	resp.Body.Close()
+	waitForSubscriber(0)
+
+	// Missed while disconnected
+	publish(2)
+	publish(3)
+
+	ctx, cancel = context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+	resp, reader = connect(ctx, first.id)
+	defer resp.Body.Close()
+
+	var replayed []int64
+	for i := 0; i < 2; i++ {
+		e, err := readEvent(reader)
+		if err != nil {
+			t.Fatalf("unexpected error reading replayed event: %v", err)
+		}
+		replayed = append(replayed, e.latency)
+	}
+
+	if !slices.Equal(replayed, []int64{2, 3}) {
+		t.Errorf("expected replayed events [2 3], got %v", replayed)
+	}
+
+	// The live stream resumes after the replay
+	waitForSubscriber(1)
+	publish(4)
+
+	live, err := readEvent(reader)
+	if err != nil {
+		t.Fatalf("unexpected error reading live event: %v", err)
+	}
+
+	if live.latency != 4 {
+		t.Errorf("expected live event 4, got %d", live.latency)
+	}
+}
+
+func TestServer_DeduplicatedBroadcasts(t *testing.T) {
+	broker := main.NewBrokerWithOptions(main.BrokerOptions[main.MonitorHistorical]{
+		HistorySize: main.DefaultBrokerHistorySize,
+		Unchanged:   main.UnchangedMonitorHistorical(50),
+	})
+	server, err := main.NewServer(main.ServerConfig{
+		MonitorHistoricalReader: main.NewMonitorHistoricalReader(database),
+		CentralBroker:           broker,
+		MonitorList:             []main.Monitor{{UniqueID: "dedupe-test", Name: "Dedupe Test"}},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error creating server: %v", err)
+	}
+
+	testServer := httptest.NewServer(server.Handler)
+	defer testServer.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, testServer.URL+"/api/by?ids=dedupe-test", nil)
+	if err != nil {
+		t.Fatalf("unexpected error creating request: %v", err)
+	}
+
+	resp, err := http.DefaultClient.Do(request)
+	if err != nil {
+		t.Fatalf("unexpected error connecting: %v", err)
+	}
+	defer resp.Body.Close()
+
+	deadline := time.Now().Add(time.Second * 5)
+	for {
+		broker.RLock()
+		count := len(broker.Subscribers["dedupe-test"])
+		broker.RUnlock()
+		if count == 1 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for the subscriber")
+		}
+		time.Sleep(time.Millisecond * 10)
+	}
+
+	for _, historical := range []main.MonitorHistorical{
+		{Status: main.MonitorStatusSuccess, Latency: 100},
+		// Unchanged, the latency is within the delta
+		{Status: main.MonitorStatusSuccess, Latency: 120},
+		{Status: main.MonitorStatusSuccess, Latency: 80},
+		// Changed status
+		{Status: main.MonitorStatusFailure, Latency: 90},
+		{Status: main.MonitorStatusFailure, Latency: 90},
+		// Changed latency, compared to the last broadcast rather than the previous snapshot
+		{Status: main.MonitorStatusFailure, Latency: 130},
+		{Status: main.MonitorStatusFailure, Latency: 150},
+		// Unchanged, it's only kept as the latest snapshot
+		{Status: main.MonitorStatusFailure, Latency: 170},
+	} {
+		historical.MonitorID = "dedupe-test"
+		if err := broker.Publish("dedupe-test", &main.BrokerMessage[main.MonitorHistorical]{Body: historical}); err != nil {
+			t.Fatalf("unexpected error publishing: %v", err)
+		}
+	}
+
+	// The end of the stream marks that nothing else was broadcast
+	go func() {
+		time.Sleep(time.Millisecond * 200)
+		cancel()
+	}()
+
+	var received []main.MonitorHistorical
+	reader := bufio.NewReader(resp.Body)
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			break
+		}
+
+		if data, ok := strings.CutPrefix(line, "data: "); ok {
+			var historical main.MonitorHistorical
+			if err := json.Unmarshal([]byte(data), &historical); err != nil {
+				t.Fatalf("unexpected error decoding event: %v", err)
+			}
+			received = append(received, historical)
+		}
+	}
+
+	var latencies []int64
+	for _, historical := range received {
+		latencies = append(latencies, historical.Latency)
+	}
+
+	if !slices.Equal(latencies, []int64{100, 90, 150}) {
+		t.Errorf("expected the broadcasts with latencies [100 90 150], got %v", latencies)
+	}
+
+	latest, ok := broker.Latest("dedupe-test")
+	if !ok || latest.Body.Latency != 170 {
+		t.Fatalf("expected the latest snapshot to have a latency of 170, got %+v", latest)
+	}
+
+	// Each unchanged snapshot replaced the broadcast of its run in the replay, rather than being added as another message
+	var replayed []int64
+	for _, message := range broker.Replay(0, "dedupe-test") {
+		replayed = append(replayed, message.Body.Latency)
+	}
+
+	if !slices.Equal(replayed, []int64{80, 130, 170}) {
+		t.Errorf("expected the replay with latencies [80 130 170], got %v", replayed)
+	}
+}
+
+func TestServer_BasePath(t *testing.T) {
+	staticPath := t.TempDir()
+	if err := os.WriteFile(filepath.Join(staticPath, "index.html"), []byte("<p>dashboard</p>"), 0o644); err != nil {
+		t.Fatalf("unexpected error writing index: %v", err)
+	}
+
+	if err := os.WriteFile(filepath.Join(staticPath, "app.js"), []byte("console.log(1)"), 0o644); err != nil {
+		t.Fatalf("unexpected error writing asset: %v", err)
+	}
+
+	server, err := main.NewServer(main.ServerConfig{
+		MonitorHistoricalReader: main.NewMonitorHistoricalReader(database),
+		CentralBroker:           main.NewBroker[main.MonitorHistorical](),
+		MonitorList:             []main.Monitor{{UniqueID: "base-path-test", Name: "Base Path Test"}},
+		BasePath:                "/status/",
+		StaticPath:              staticPath,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error creating server: %v", err)
+	}
+
+	testServer := httptest.NewServer(server.Handler)
+	defer testServer.Close()
+
+	t.Run("Should stream the overview under the base path", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		request, err := http.NewRequestWithContext(ctx, http.MethodGet, testServer.URL+"/status/api/overview", nil)
+		if err != nil {
+			t.Fatalf("unexpected error creating request: %v", err)
+		}
+
+		resp, err := http.DefaultClient.Do(request)
+		if err != nil {
+			t.Fatalf("unexpected error requesting overview: %v", err)
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("expected status code %d, got %d", http.StatusOK, resp.StatusCode)
+		}
+
+		if contentType := resp.Header.Get("Content-Type"); contentType != "text/event-stream" {
+			t.Errorf("expected Content-Type text/event-stream, got %q", contentType)
+		}
+	})
+
+	tests := []struct {
+		path       string
+		wantStatus int
+		wantBody   string
+	}{
+		{path: "/status/api/monitors", wantStatus: http.StatusOK},
+		{path: "/status/", wantStatus: http.StatusOK, wantBody: "<p>dashboard</p>"},
+		{path: "/status/app.js", wantStatus: http.StatusOK, wantBody: "console.log(1)"},
+		{path: "/status/by", wantStatus: http.StatusOK, wantBody: "<p>dashboard</p>"},
+		{path: "/status/missing.js", wantStatus: http.StatusNotFound},
+		// Nothing is served outside of the base path
+		{path: "/api/monitors", wantStatus: http.StatusNotFound},
+		{path: "/app.js", wantStatus: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			resp, err := http.Get(testServer.URL + tt.path)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			defer resp.Body.Close()
+
+			if resp.StatusCode != tt.wantStatus {
+				t.Fatalf("expected status code %d, got %d", tt.wantStatus, resp.StatusCode)
+			}
+
+			body, err := io.ReadAll(resp.Body)
+			if err != nil {
+				t.Fatalf("unexpected error reading body: %v", err)
+			}
+
+			if tt.wantBody != "" && string(body) != tt.wantBody {
+				t.Errorf("expected body %q, got %q", tt.wantBody, body)
+			}
+		})
+	}
+}
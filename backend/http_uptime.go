@@ -0,0 +1,108 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+func (s *Server) uptime(w http.ResponseWriter, r *http.Request) {
+	monitorId := r.URL.Query().Get("id")
+	if monitorId == "" {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"error": "id is required"}`))
+		return
+	}
+
+	if !s.monitors.Contains(monitorId) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"error": "id is not in the list of monitors"}`))
+		return
+	}
+
+	window, summary, err := s.calculateUptime(r, monitorId)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(err.statusCode)
+		errBytes, marshalErr := json.Marshal(map[string]string{"error": err.Error()})
+		if marshalErr != nil {
+			w.Write([]byte(`{"error": "internal server error"}`))
+			return
+		}
+		w.Write(errBytes)
+		return
+	}
+
+	data, marshalErr := json.Marshal(map[string]any{
+		"id":     monitorId,
+		"window": window,
+		"uptime": summary.Uptime,
+		"total":  summary.Total,
+		"up":     summary.Up,
+	})
+	if marshalErr != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(`{"error": "internal server error"}`))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
+
+type uptimeError struct {
+	statusCode int
+	message    string
+}
+
+func (e *uptimeError) Error() string {
+	return e.message
+}
+
+// calculateUptime parses the window query parameter (defaults to 24h) and computes the uptime of the monitor
+// within the window. The returned window is the normalized query value.
+func (s *Server) calculateUptime(r *http.Request, monitorId string) (string, UptimeSummary, *uptimeError) {
+	window, duration, uptimeErr := s.uptimeWindow(r)
+	if uptimeErr != nil {
+		return "", UptimeSummary{}, uptimeErr
+	}
+
+	now := time.Now()
+	historical, err := s.historicalReader.ReadRawHistoricalRange(r.Context(), monitorId, now.Add(-duration), now)
+	if err != nil {
+		log.Error().Err(err).Str("monitor_id", monitorId).Msg("failed to read historical data for uptime")
+		return "", UptimeSummary{}, &uptimeError{statusCode: http.StatusInternalServerError, message: "failed to read historical data"}
+	}
+
+	return window, CalculateUptime(historical), nil
+}
+
+// uptimeWindow parses the window query parameter, which defaults to 24h and can't be larger than the maximum
+// uptime window.
+func (s *Server) uptimeWindow(r *http.Request) (string, time.Duration, *uptimeError) {
+	window := r.URL.Query().Get("window")
+	if window == "" {
+		window = "24h"
+	}
+
+	duration, err := time.ParseDuration(window)
+	if err != nil || duration <= 0 {
+		return "", 0, &uptimeError{statusCode: http.StatusBadRequest, message: "window must be a positive duration"}
+	}
+
+	if duration > s.maxUptimeWindow {
+		return "", 0, &uptimeError{
+			statusCode: http.StatusBadRequest,
+			message:    fmt.Sprintf("window must not be larger than %s", s.maxUptimeWindow),
+		}
+	}
+
+	return window, duration, nil
+}
@@ -0,0 +1,84 @@
+package main_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	main "semyi"
+)
+
+func TestServer_Uptime(t *testing.T) {
+	writer := main.NewMonitorHistoricalWriter(database)
+	now := time.Now()
+	for _, historical := range []main.MonitorHistorical{
+		{MonitorID: "uptime-test", Status: main.MonitorStatusSuccess, Latency: 10, Timestamp: now.Add(-time.Hour * 4)},
+		{MonitorID: "uptime-test", Status: main.MonitorStatusSuccess, Latency: 10, Timestamp: now.Add(-time.Hour * 3)},
+		{MonitorID: "uptime-test", Status: main.MonitorStatusFailure, Latency: 10, Timestamp: now.Add(-time.Hour * 2)},
+		{MonitorID: "uptime-test", Status: main.MonitorStatusDegraded, Latency: 10, Timestamp: now.Add(-time.Hour)},
+		// Outside the 24h window
+		{MonitorID: "uptime-test", Status: main.MonitorStatusFailure, Latency: 10, Timestamp: now.Add(-time.Hour * 48)},
+	} {
+		if err := writer.Write(context.Background(), historical); err != nil {
+			t.Fatalf("unexpected error writing historical data: %v", err)
+		}
+	}
+
+	server, err := main.NewServer(main.ServerConfig{
+		MonitorHistoricalReader: main.NewMonitorHistoricalReader(database),
+		CentralBroker:           main.NewBroker[main.MonitorHistorical](),
+		MonitorList:             []main.Monitor{{UniqueID: "uptime-test", Name: "Uptime Test"}},
+		MaxUptimeWindow:         time.Hour * 24 * 7,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error creating server: %v", err)
+	}
+
+	t.Run("Should calculate uptime within the window", func(t *testing.T) {
+		recorder := httptest.NewRecorder()
+		server.Handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/api/uptime?id=uptime-test&window=24h", nil))
+		if recorder.Code != http.StatusOK {
+			t.Fatalf("expected status code %d, got %d", http.StatusOK, recorder.Code)
+		}
+
+		var body struct {
+			ID     string  `json:"id"`
+			Window string  `json:"window"`
+			Uptime float64 `json:"uptime"`
+			Total  int     `json:"total"`
+			Up     int     `json:"up"`
+		}
+		if err := json.NewDecoder(recorder.Body).Decode(&body); err != nil {
+			t.Fatalf("unexpected error decoding body: %v", err)
+		}
+
+		if body.ID != "uptime-test" || body.Window != "24h" {
+			t.Errorf("unexpected id or window: %+v", body)
+		}
+
+		if body.Total != 4 || body.Up != 3 || body.Uptime != 75 {
+			t.Errorf("expected 3 of 4 up (75%%), got %d of %d (%v%%)", body.Up, body.Total, body.Uptime)
+		}
+	})
+
+	t.Run("Should reject invalid windows", func(t *testing.T) {
+		for _, window := range []string{"yesterday", "-1h", "720h"} {
+			recorder := httptest.NewRecorder()
+			server.Handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/api/uptime?id=uptime-test&window="+window, nil))
+			if recorder.Code != http.StatusBadRequest {
+				t.Errorf("expected status code %d for window %s, got %d", http.StatusBadRequest, window, recorder.Code)
+			}
+		}
+	})
+
+	t.Run("Should reject unknown monitor", func(t *testing.T) {
+		recorder := httptest.NewRecorder()
+		server.Handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/api/uptime?id=unknown", nil))
+		if recorder.Code != http.StatusNotFound {
+			t.Errorf("expected status code %d, got %d", http.StatusNotFound, recorder.Code)
+		}
+	})
+}
@@ -0,0 +1,21 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// webhookDeliveries responds with the recent webhook delivery attempts.
+func (s *Server) webhookDeliveries(w http.ResponseWriter, r *http.Request) {
+	data, err := json.Marshal(s.webhookDeliveryLog.List())
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(`{"error": "internal server error"}`))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
@@ -0,0 +1,117 @@
+package main_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	main "semyi"
+)
+
+func TestServer_WebhookDeliveries(t *testing.T) {
+	webhookServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer webhookServer.Close()
+
+	deliveryLog := main.NewWebhookDeliveryLog(10)
+	dispatcher := main.NewWebhookDispatcherWithOptions([]main.Webhook{
+		{URL: webhookServer.URL, FailedResponse: true, MaxRetries: 1, RetryBaseDelay: 1},
+	}, nil, main.WebhookDispatcherOptions{DeliveryLog: deliveryLog})
+
+	err := dispatcher.Send(context.Background(), main.AlertMessage{
+		MonitorID: "deliveries-test",
+		Success:   false,
+		Status:    main.MonitorStatusFailure,
+		Timestamp: time.Now(),
+	})
+	if err == nil {
+		t.Fatal("expected an error from the failed delivery")
+	}
+
+	server, err := main.NewServer(main.ServerConfig{
+		MonitorHistoricalReader: main.NewMonitorHistoricalReader(database),
+		CentralBroker:           main.NewBroker[main.MonitorHistorical](),
+		WebhookDeliveryLog:      deliveryLog,
+		ApiKeys:                 []string{"deliveries-key"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error creating server: %v", err)
+	}
+
+	t.Run("requires the api key", func(t *testing.T) {
+		recorder := httptest.NewRecorder()
+		server.Handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/api/webhooks/deliveries", nil))
+		if recorder.Code != http.StatusUnauthorized {
+			t.Errorf("expected status code %d, got %d", http.StatusUnauthorized, recorder.Code)
+		}
+	})
+
+	t.Run("failed delivery", func(t *testing.T) {
+		request := httptest.NewRequest(http.MethodGet, "/api/webhooks/deliveries", nil)
+		request.Header.Set("X-API-Key", "deliveries-key")
+		recorder := httptest.NewRecorder()
+		server.Handler.ServeHTTP(recorder, request)
+		if recorder.Code != http.StatusOK {
+			t.Fatalf("expected status code %d, got %d", http.StatusOK, recorder.Code)
+		}
+
+		var deliveries []main.WebhookDelivery
+		if err := json.NewDecoder(recorder.Body).Decode(&deliveries); err != nil {
+			t.Fatalf("unexpected error decoding body: %v", err)
+		}
+
+		if len(deliveries) != 2 {
+			t.Fatalf("expected 2 delivery attempts, got %d", len(deliveries))
+		}
+
+		// The most recent attempt comes first
+		for i, delivery := range deliveries {
+			if delivery.Attempt != 2-i {
+				t.Errorf("expected attempt %d, got %d", 2-i, delivery.Attempt)
+			}
+
+			if delivery.Destination != webhookServer.URL {
+				t.Errorf("expected destination %s, got %s", webhookServer.URL, delivery.Destination)
+			}
+
+			if delivery.MonitorID != "deliveries-test" {
+				t.Errorf("expected monitor id deliveries-test, got %s", delivery.MonitorID)
+			}
+
+			if delivery.StatusCode != http.StatusServiceUnavailable {
+				t.Errorf("expected status code %d, got %d", http.StatusServiceUnavailable, delivery.StatusCode)
+			}
+
+			if !strings.Contains(delivery.Error, "503") {
+				t.Errorf("expected the error to contain the status code, got %q", delivery.Error)
+			}
+
+			if delivery.Timestamp.IsZero() {
+				t.Error("expected the timestamp to be set")
+			}
+		}
+	})
+}
+
+func TestWebhookDeliveryLog_Bounded(t *testing.T) {
+	deliveryLog := main.NewWebhookDeliveryLog(3)
+	for attempt := 1; attempt <= 5; attempt++ {
+		deliveryLog.Record(main.WebhookDelivery{Attempt: attempt})
+	}
+
+	deliveries := deliveryLog.List()
+	if len(deliveries) != 3 {
+		t.Fatalf("expected 3 deliveries, got %d", len(deliveries))
+	}
+
+	for i, expected := range []int{5, 4, 3} {
+		if deliveries[i].Attempt != expected {
+			t.Errorf("expected attempt %d at %d, got %d", expected, i, deliveries[i].Attempt)
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"nhooyr.io/websocket"
+	"nhooyr.io/websocket/wsjson"
+)
+
+// websocketOriginPatterns converts the CORS allowed origins into the host patterns that are accepted
+// on the WebSocket handshake. Requests from the same host are always accepted.
+func websocketOriginPatterns(allowedOrigins []string) []string {
+	var patterns []string
+	for _, origin := range allowedOrigins {
+		if origin == "*" {
+			patterns = append(patterns, "*")
+			continue
+		}
+
+		u, err := url.Parse(origin)
+		if err != nil || u.Host == "" {
+			continue
+		}
+
+		patterns = append(patterns, u.Host)
+	}
+
+	return patterns
+}
+
+// websocketStream sends the same events as the SSE streams over a WebSocket, for clients behind proxies
+// that buffer or mangle SSE responses. Every event is sent as a JSON text message. The ids query parameter
+// is optional, every monitor is subscribed to when it's empty.
+func (s *Server) websocketStream(w http.ResponseWriter, r *http.Request) {
+	monitorIds := s.monitors.IDs()
+	if ids := r.URL.Query().Get("ids"); ids != "" {
+		monitorIds = strings.Split(ids, ",")
+		for _, id := range monitorIds {
+			if !s.monitors.Contains(id) {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusNotFound)
+				w.Write([]byte(`{"error": "id is not in the list of monitors"}`))
+				return
+			}
+		}
+	}
+
+	subscriber, err := NewSubscriberWithOptions(s.centralBroker, s.subscriberOptions(), monitorIds...)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		errBytes, err := json.Marshal(map[string]string{"error": fmt.Errorf("failed to subscribe to endpoints: %s", err).Error()})
+		if err != nil {
+			w.Write([]byte(`{"error": "internal server error"}`))
+			return
+		}
+		w.Write(errBytes)
+		return
+	}
+	defer func() {
+		err := subscriber.Close()
+		if err != nil {
+			log.Warn().Err(err).Msg("failed to close subscriber")
+		}
+	}()
+
+	conn, err := websocket.Accept(w, r, &websocket.AcceptOptions{OriginPatterns: s.websocketOrigins})
+	if err != nil {
+		// Accept has already written the error response
+		log.Debug().Err(err).Msg("failed to accept websocket")
+		return
+	}
+	defer conn.CloseNow()
+
+	// Clients are not expected to send any message. CloseRead keeps reading the control frames, so pongs and
+	// the close handshake are handled, and cancels the context once the connection is closed.
+	ctx := conn.CloseRead(r.Context())
+
+	ping := time.NewTicker(s.sseHeartbeatInterval)
+	defer ping.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.shutdown:
+			err := conn.Close(websocket.StatusGoingAway, "server is shutting down")
+			if err != nil {
+				log.Debug().Err(err).Msg("failed to close websocket")
+			}
+			return
+		case <-ping.C:
+			pingCtx, cancel := context.WithTimeout(ctx, s.sseHeartbeatInterval)
+			err := conn.Ping(pingCtx)
+			cancel()
+			if err != nil {
+				log.Debug().Err(err).Msg("websocket ping failed")
+				return
+			}
+		case message := <-subscriber.Listen(ctx):
+			writeCtx, cancel := context.WithTimeout(ctx, s.sseHeartbeatInterval)
+			err := wsjson.Write(writeCtx, conn, message.Body)
+			cancel()
+			if err != nil {
+				log.Debug().Err(err).Msg("failed to write websocket message")
+				return
+			}
+
+			ping.Reset(s.sseHeartbeatInterval)
+		}
+	}
+}
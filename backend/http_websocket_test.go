@@ -0,0 +1,135 @@
+package main_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	main "semyi"
+
+	"nhooyr.io/websocket"
+	"nhooyr.io/websocket/wsjson"
+)
+
+func TestServer_WebSocket(t *testing.T) {
+	broker := main.NewBroker[main.MonitorHistorical]()
+	server, err := main.NewServer(main.ServerConfig{
+		MonitorHistoricalReader: main.NewMonitorHistoricalReader(database),
+		CentralBroker:           broker,
+		MonitorList:             []main.Monitor{{UniqueID: "websocket-test", Name: "WebSocket Test"}},
+		AllowedOrigins:          []string{"https://status.example.com"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error creating server: %v", err)
+	}
+
+	testServer := httptest.NewServer(server.Handler)
+	defer testServer.Close()
+
+	url := "ws" + strings.TrimPrefix(testServer.URL, "http") + "/api/ws"
+
+	waitForSubscriber := func(want int) {
+		deadline := time.Now().Add(time.Second * 5)
+		for time.Now().Before(deadline) {
+			broker.RLock()
+			count := len(broker.Subscribers["websocket-test"])
+			broker.RUnlock()
+			if count == want {
+				return
+			}
+			time.Sleep(time.Millisecond * 10)
+		}
+		t.Fatalf("timed out waiting for %d subscribers", want)
+	}
+
+	t.Run("Receive event", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+		defer cancel()
+
+		conn, _, err := websocket.Dial(ctx, url+"?ids=websocket-test", &websocket.DialOptions{
+			HTTPHeader: http.Header{"Origin": []string{"https://status.example.com"}},
+		})
+		if err != nil {
+			t.Fatalf("unexpected error connecting: %v", err)
+		}
+		defer conn.CloseNow()
+
+		waitForSubscriber(1)
+		err = broker.Publish("websocket-test", &main.BrokerMessage[main.MonitorHistorical]{
+			Body: main.MonitorHistorical{MonitorID: "websocket-test", Status: main.MonitorStatusSuccess, Latency: 42},
+		})
+		if err != nil {
+			t.Fatalf("unexpected error publishing: %v", err)
+		}
+
+		var historical main.MonitorHistorical
+		if err := wsjson.Read(ctx, conn, &historical); err != nil {
+			t.Fatalf("unexpected error reading event: %v", err)
+		}
+
+		if historical.MonitorID != "websocket-test" || historical.Latency != 42 {
+			t.Errorf("unexpected event %+v", historical)
+		}
+
+		if err := conn.Close(websocket.StatusNormalClosure, ""); err != nil {
+			t.Errorf("unexpected error closing: %v", err)
+		}
+
+		// The server unsubscribes once the connection is closed
+		waitForSubscriber(0)
+	})
+
+	t.Run("Unknown monitor", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+		defer cancel()
+
+		_, resp, err := websocket.Dial(ctx, url+"?ids=unknown", nil)
+		if err == nil {
+			t.Fatal("expected an error connecting")
+		}
+
+		if resp == nil || resp.StatusCode != http.StatusNotFound {
+			t.Errorf("expected status code %d, got %v", http.StatusNotFound, resp)
+		}
+	})
+
+	t.Run("Disallowed origin", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+		defer cancel()
+
+		_, resp, err := websocket.Dial(ctx, url, &websocket.DialOptions{
+			HTTPHeader: http.Header{"Origin": []string{"https://evil.example.com"}},
+		})
+		if err == nil {
+			t.Fatal("expected an error connecting")
+		}
+
+		if resp == nil || resp.StatusCode != http.StatusForbidden {
+			t.Errorf("expected status code %d, got %v", http.StatusForbidden, resp)
+		}
+	})
+
+	t.Run("Server shutdown", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+		defer cancel()
+
+		conn, _, err := websocket.Dial(ctx, url, nil)
+		if err != nil {
+			t.Fatalf("unexpected error connecting: %v", err)
+		}
+		defer conn.CloseNow()
+
+		waitForSubscriber(1)
+		if err := server.Shutdown(ctx); err != nil {
+			t.Fatalf("unexpected error shutting down: %v", err)
+		}
+
+		_, _, err = conn.Read(ctx)
+		if status := websocket.CloseStatus(err); status != websocket.StatusGoingAway {
+			t.Errorf("expected close status %v, got %v (%v)", websocket.StatusGoingAway, status, err)
+		}
+	})
+}
@@ -4,12 +4,17 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"flag"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
 	"strconv"
+	"strings"
 	"syscall"
 	"time"
+	// The slim images don't ship the time zone database that the timezone configuration is loaded from
+	_ "time/tzdata"
 
 	_ "github.com/marcboeker/go-duckdb"
 	"github.com/rs/zerolog/log"
@@ -18,26 +23,38 @@ import (
 var (
 	DefaultInterval int = 30
 	DefaultTimeout  int = 10
-	monitorIds      []string
 )
 
 func main() {
+	dryRun := flag.Bool("dry-run", false, "validate the configuration file, check every monitor once, and exit")
+	flag.Parse()
+
 	// Read environment variables
-	configPath, ok := os.LookupEnv("CONFIG_PATH")
-	if !ok {
-		configPath = "../config.json"
-	}
+	// An empty path makes the configuration file to be looked up from the default paths
+	configPath := os.Getenv("CONFIG_PATH")
 
 	dbPath, ok := os.LookupEnv("DB_PATH")
 	if !ok {
 		dbPath = "../db.duckdb"
 	}
 
-	staticPath, ok := os.LookupEnv("STATIC_PATH")
+	brokerStatePath, ok := os.LookupEnv("BROKER_STATE_PATH")
 	if !ok {
-		staticPath = "../frontend/dist"
+		brokerStatePath = "../broker_state.json"
 	}
 
+	// The state is compressed on shutdown, and is restored regardless of the compression it was saved with
+	brokerStateCompression := Compression(os.Getenv("BROKER_STATE_COMPRESSION"))
+	if err := brokerStateCompression.Validate(); err != nil {
+		log.Fatal().Err(err).Msg("Invalid BROKER_STATE_COMPRESSION")
+	}
+
+	// The embedded dashboard is served unless STATIC_PATH points to a build on disk
+	staticPath := os.Getenv("STATIC_PATH")
+
+	// BASE_PATH serves every route under a prefix, e.g. /status when reverse proxied under a shared domain
+	basePath := os.Getenv("BASE_PATH")
+
 	defaultInterval, ok := os.LookupEnv("DEFAULT_INTERVAL")
 	if !ok {
 		defaultInterval = "30"
@@ -53,11 +70,97 @@ func main() {
 		port = "5000"
 	}
 
+	shutdownGracePeriod := time.Second * 10
+	if value, ok := os.LookupEnv("SHUTDOWN_GRACE_PERIOD"); ok {
+		seconds, err := strconv.Atoi(value)
+		if err != nil || seconds < 0 {
+			log.Fatal().Msg("SHUTDOWN_GRACE_PERIOD must be a non-negative number of seconds")
+		}
+
+		shutdownGracePeriod = time.Second * time.Duration(seconds)
+	}
+
 	apiKey, ok := os.LookupEnv("API_KEY")
 	if !ok {
 		log.Warn().Msg("API_KEY is not set")
 	}
 
+	var apiKeys []string
+	if value, ok := os.LookupEnv("API_KEYS"); ok && value != "" {
+		for _, key := range strings.Split(value, ",") {
+			apiKeys = append(apiKeys, strings.TrimSpace(key))
+		}
+	}
+
+	var allowedOrigins []string
+	if value, ok := os.LookupEnv("CORS_ALLOWED_ORIGINS"); ok && value != "" {
+		for _, origin := range strings.Split(value, ",") {
+			allowedOrigins = append(allowedOrigins, strings.TrimSpace(origin))
+		}
+	}
+
+	allowCredentials := os.Getenv("CORS_ALLOW_CREDENTIALS") == "true"
+
+	var rateLimit RateLimitConfig
+	for name, target := range map[string]*int{
+		"RATE_LIMIT_REQUESTS":    &rateLimit.Requests,
+		"MAX_STREAMS":            &rateLimit.MaxStreams,
+		"MAX_STREAMS_PER_CLIENT": &rateLimit.MaxStreamsPerClient,
+	} {
+		if value, ok := os.LookupEnv(name); ok {
+			parsed, err := strconv.Atoi(value)
+			if err != nil || parsed < 0 {
+				log.Fatal().Msgf("%s must be a non-negative number", name)
+			}
+
+			*target = parsed
+		}
+	}
+
+	if value, ok := os.LookupEnv("RATE_LIMIT_WINDOW"); ok {
+		seconds, err := strconv.Atoi(value)
+		if err != nil || seconds <= 0 {
+			log.Fatal().Msg("RATE_LIMIT_WINDOW must be a positive number of seconds")
+		}
+
+		rateLimit.Window = time.Second * time.Duration(seconds)
+	}
+
+	var trustedProxies []net.IPNet
+	if value, ok := os.LookupEnv("TRUSTED_PROXIES"); ok && value != "" {
+		for _, cidr := range strings.Split(value, ",") {
+			_, network, err := net.ParseCIDR(strings.TrimSpace(cidr))
+			if err != nil {
+				log.Fatal().Err(err).Msg("TRUSTED_PROXIES must be a comma separated list of CIDRs")
+			}
+
+			trustedProxies = append(trustedProxies, *network)
+		}
+	}
+
+	// Unchanged snapshots are only stored, rather than broadcast to every client
+	var brokerOptions BrokerOptions[MonitorHistorical]
+	brokerOptions.HistorySize = DefaultBrokerHistorySize
+	if os.Getenv("DEDUPLICATE_BROADCASTS") == "true" {
+		latencyDelta := 100
+		if value, ok := os.LookupEnv("BROADCAST_LATENCY_DELTA"); ok {
+			parsed, err := strconv.Atoi(value)
+			if err != nil || parsed < 0 {
+				log.Fatal().Msg("BROADCAST_LATENCY_DELTA must be a non-negative number of milliseconds")
+			}
+
+			latencyDelta = parsed
+		}
+
+		brokerOptions.Unchanged = UnchangedMonitorHistorical(int64(latencyDelta))
+	}
+
+	// Tracing is disabled unless the collector endpoint is set
+	tracingConfig := TracingConfig{
+		Endpoint:    os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT"),
+		ServiceName: os.Getenv("OTEL_SERVICE_NAME"),
+	}
+
 	telegramChatID, ok := os.LookupEnv("TELEGRAM_CHAT_ID")
 	if !ok {
 		log.Warn().Msg("TELEGRAM_CHAT_ID is not set")
@@ -91,6 +194,31 @@ func main() {
 		log.Fatal().Err(err).Msg("Failed to parse default interval")
 	}
 
+	if err := ValidateConfig(config); err != nil {
+		var validationError *ValidationError
+		if errors.As(err, &validationError) {
+			for _, issue := range validationError.Issues {
+				log.Error().Str("field", issue.Field).Msg(issue.Message)
+			}
+		}
+
+		log.Fatal().Msg("Invalid configuration file")
+	}
+
+	if *dryRun {
+		// Nothing is stored, so the database and the server are never started
+		passed, err := DryRun(context.Background(), config, os.Stdout)
+		if err != nil {
+			log.Fatal().Err(err).Msg("Failed to run the checks")
+		}
+
+		if !passed {
+			os.Exit(1)
+		}
+
+		return
+	}
+
 	db, err := sql.Open("duckdb", dbPath)
 	if err != nil {
 		log.Fatal().Err(err).Msg("failed to open database")
@@ -110,71 +238,218 @@ func main() {
 		log.Fatal().Err(err).Msg("failed to migrate database")
 	}
 
-	processor := &Processor{
-		telegramAlertProvider: NewTelegramAlertProvider(TelegramProviderConfig{
-			Url:    telegramUrl,
-			ChatID: telegramChatID,
-		}),
+	tracerProvider, shutdownTracing, err := NewTracerProvider(ctx, tracingConfig)
+	if err != nil {
+		log.Fatal().Err(err).Msg("failed to create tracer provider")
+	}
+
+	centralBroker := NewBrokerWithOptions(brokerOptions)
+	if brokerStatePath != "" {
+		// Serve the last known status right away, rather than waiting for the first checks to complete
+		if err := LoadBrokerState(brokerStatePath, centralBroker); err != nil {
+			log.Warn().Err(err).Msg("failed to load broker state")
+		}
+	}
+	metrics := NewMetrics()
+	checkStats := NewCheckStats()
+	location, err := config.Location()
+	if err != nil {
+		log.Fatal().Err(err).Msg("Invalid timezone")
 	}
+	historicalReader := NewMonitorHistoricalReaderWithOptions(db, MonitorHistoricalReaderOptions{Location: location})
 
-	// Create a new worker
+	webhookConfigured := len(config.Webhooks) > 0
 	for _, monitor := range config.Monitors {
-		monitorIds = append(monitorIds, monitor.UniqueID)
+		if monitor.Webhook != nil && len(monitor.Webhook.Webhooks) > 0 {
+			webhookConfigured = true
+		}
+
+		if monitor.Escalation != nil && len(monitor.Escalation.Webhooks) > 0 {
+			webhookConfigured = true
+		}
+	}
 
-		worker, err := NewWorker(monitor, processor)
+	var webhookAlertProvider Alerter
+	var escalationAlertProvider Alerter
+	var webhookDeliveryLog *WebhookDeliveryLog
+	if webhookConfigured {
+		// The templates are validated along with the rest of the configuration
+		webhookTemplates, err := ParseWebhookTemplates(config.WebhookTemplates)
 		if err != nil {
-			log.Fatal().Err(err).Msg("Failed to create worker")
+			log.Fatal().Err(err).Msg("Failed to parse webhook templates")
 		}
 
-		log.Info().Str("UniqueID", monitor.UniqueID).Str("Name", monitor.Name).Msg("Registered monitor")
+		webhookDeliveryLog = NewWebhookDeliveryLog(100)
+		webhookOptions := WebhookDispatcherOptions{
+			Templates:   webhookTemplates,
+			DeliveryLog: webhookDeliveryLog,
+		}
+		webhookAlertProvider = NewWebhookDispatcherWithOptions(config.Webhooks, config.Monitors, webhookOptions)
+		escalationAlertProvider = NewEscalationDispatcher(config.Monitors, webhookOptions)
+	}
 
-		go func(worker *Worker) {
-			defer func() {
-				if r := recover(); r != nil {
-					log.Warn().Msgf("[Running worker] Recovered from panic: %v", r)
-				}
-			}()
+	historicalWriter := NewMonitorHistoricalWriterWithOptions(db, MonitorHistoricalWriterOptions{Compression: config.HistoricalCompression})
+	monitorRegistry := NewMonitorRegistry(nil)
+	processor := NewProcessor(ProcessorConfig{
+		HistoricalWriter: historicalWriter,
+		HistoricalReader: historicalReader,
+		CentralBroker:    centralBroker,
+		Metrics:          metrics,
+		AlertDebouncer:   NewAlertDebouncer(),
+		MonitorRegistry:  monitorRegistry,
+		TelegramAlertProvider: NewTelegramAlertProvider(TelegramProviderConfig{
+			Url:    telegramUrl,
+			ChatID: telegramChatID,
+		}),
+		WebhookAlertProvider:    webhookAlertProvider,
+		EscalationAlertProvider: escalationAlertProvider,
+	})
 
-			worker.Run()
-		}(worker)
+	// The monitors that were paused through the API stay paused after a restart
+	pauseStore := NewPauseStore(db)
+	if err := pauseStore.Load(ctx); err != nil {
+		log.Fatal().Err(err).Msg("Failed to load paused monitors")
 	}
 
-	aggregateWorker := NewAggregateWorker(monitorIds, nil, nil) // TODO: Add the reader and writer
+	// Create a worker for each monitor
+	workerManager := NewWorkerManagerWithOptions(processor, monitorRegistry, WorkerManagerOptions{
+		CheckPool:      NewCheckPool(config.MaxConcurrentChecks),
+		HttpClient:     NewHttpClient(config.HttpClient),
+		TracerProvider: tracerProvider,
+		CheckStats:     checkStats,
+		PauseStore:     pauseStore,
+	})
+	if _, err := workerManager.Apply(config.Monitors); err != nil {
+		log.Fatal().Err(err).Msg("Failed to create worker")
+	}
 
-	go aggregateWorker.RunDailyAggregate()
-	go aggregateWorker.RunHourlyAggregate()
+	go func() {
+		// Reload the monitors from the configuration file on SIGHUP
+		reloadChan := make(chan os.Signal, 1)
+		signal.Notify(reloadChan, syscall.SIGHUP)
+		for range reloadChan {
+			result, err := workerManager.Reload(configPath)
+			if err != nil {
+				log.Error().Err(err).Msg("Failed to reload configuration, keeping the running configuration")
+				continue
+			}
+
+			log.Info().
+				Strs("added", result.Added).
+				Strs("removed", result.Removed).
+				Strs("updated", result.Updated).
+				Msg("Reloaded configuration")
+		}
+	}()
+
+	retentionPolicy, err := config.RetentionPolicy()
+	if err != nil {
+		log.Fatal().Err(err).Msg("Invalid retention policy")
+	}
+
+	backgroundCtx, stopBackground := context.WithCancel(context.Background())
+	defer stopBackground()
+	go NewDownsamplerWithOptions(monitorRegistry, historicalReader, historicalWriter, time.Minute*10, DownsamplerOptions{Location: location}).Run(backgroundCtx)
+	go NewRetentionPruner(db, retentionPolicy, time.Hour).Run(backgroundCtx)
+
+	if config.Digest != nil {
+		digestReporter, err := NewDigestReporter(historicalReader, monitorRegistry, *config.Digest)
+		if err != nil {
+			log.Fatal().Err(err).Msg("Invalid digest configuration")
+		}
+
+		go digestReporter.Run(backgroundCtx)
+	}
 
 	// TODO: Complete the ServerConfig
-	server := NewServer(ServerConfig{
+	server, err := NewServer(ServerConfig{
 		SSLRedirect:             false,
 		Environment:             "",
 		Hostname:                "",
 		Port:                    port,
+		BasePath:                basePath,
 		StaticPath:              staticPath,
-		MonitorHistoricalReader: NewMonitorHistoricalReader(db),
+		MonitorHistoricalReader: historicalReader,
+		CentralBroker:           centralBroker,
 		IncidentWriter:          NewIncidentWriter(db),
+		MonitorRegistry:         monitorRegistry,
+		Metrics:                 metrics,
+		CheckStats:              checkStats,
+		WebhookDeliveryLog:      webhookDeliveryLog,
+		WorkerManager:           workerManager,
+		// Uptime is calculated from the raw snapshots, so windows past the raw retention can't be served
+		MaxUptimeWindow: retentionPolicy.Raw,
+
+		AllowedOrigins:   allowedOrigins,
+		AllowCredentials: allowCredentials,
+
+		RateLimit:      rateLimit,
+		TrustedProxies: trustedProxies,
 
-		ApiKey: apiKey,
+		ApiKey:  apiKey,
+		ApiKeys: apiKeys,
+
+		TracerProvider: tracerProvider,
 	})
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to create server")
+	}
+	var pprofServer *http.Server
+	if config.EnablePprof {
+		pprofServer = NewPprofServer(config.PprofAddress)
+		go func() {
+			log.Info().Msgf("Serving pprof on %s", pprofServer.Addr)
+			if err := pprofServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				log.Error().Err(err).Msg("Failed to serve pprof")
+			}
+		}()
+	}
+
+	shutdownDone := make(chan struct{})
 	go func() {
+		defer close(shutdownDone)
+
 		// Listen for SIGKILL and SIGTERM
 		signalChan := make(chan os.Signal, 1)
 		signal.Notify(signalChan, os.Interrupt, syscall.SIGTERM)
 		<-signalChan
 
 		log.Info().Msg("Shutting down server...")
-		ctx, cancel = context.WithTimeout(context.Background(), time.Second*10)
+		workerManager.Stop()
+		stopBackground()
+
+		if brokerStatePath != "" {
+			if err := SaveBrokerStateWithCompression(brokerStatePath, centralBroker, brokerStateCompression); err != nil {
+				log.Error().Err(err).Msg("Failed to save broker state")
+			}
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), shutdownGracePeriod)
 		defer cancel()
 
-		err = server.Shutdown(ctx)
+		err := server.Shutdown(ctx)
 		if err != nil {
-			log.Fatal().Err(err).Msg("Failed to shutdown server")
+			log.Error().Err(err).Msg("Failed to shutdown server gracefully")
+		}
+
+		if pprofServer != nil {
+			// Profiles that are still being collected are not worth waiting for
+			if err := pprofServer.Close(); err != nil {
+				log.Error().Err(err).Msg("Failed to close pprof server")
+			}
+		}
+
+		if err := shutdownTracing(ctx); err != nil {
+			log.Error().Err(err).Msg("Failed to flush traces")
 		}
 	}()
 
 	// Start the server
 	log.Printf("Starting server on port %s", port)
 	if e := server.ListenAndServe(); e != nil && !errors.Is(e, http.ErrServerClosed) {
-		log.Fatal().Err(err).Msg("Failed to start server")
+		log.Fatal().Err(e).Msg("Failed to start server")
 	}
+
+	// Wait for the in-flight connections to be drained
+	<-shutdownDone
 }
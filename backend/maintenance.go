@@ -0,0 +1,86 @@
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+type MaintenanceRecurrence string
+
+const (
+	MaintenanceRecurrenceNone   MaintenanceRecurrence = ""
+	MaintenanceRecurrenceDaily  MaintenanceRecurrence = "daily"
+	MaintenanceRecurrenceWeekly MaintenanceRecurrence = "weekly"
+)
+
+// MaintenanceWindow is a planned maintenance period of a monitor. Checks keep running during the window, but
+// alerts are suppressed and the status is reported as maintenance.
+type MaintenanceWindow struct {
+	// Start specifies the start of the (first) window as an RFC 3339 timestamp, e.g. "2024-06-01T02:00:00+07:00".
+	Start time.Time `json:"start" yaml:"start" toml:"start"`
+	// End specifies the end of the (first) window as an RFC 3339 timestamp. It must be after Start.
+	End time.Time `json:"end" yaml:"end" toml:"end"`
+	// Recurrence specifies how the window repeats after the first one. It can be either "daily", "weekly",
+	// or empty for a one-off window.
+	Recurrence MaintenanceRecurrence `json:"recurrence" yaml:"recurrence" toml:"recurrence"`
+}
+
+func (w MaintenanceWindow) period() time.Duration {
+	switch w.Recurrence {
+	case MaintenanceRecurrenceDaily:
+		return time.Hour * 24
+	case MaintenanceRecurrenceWeekly:
+		return time.Hour * 24 * 7
+	}
+
+	return 0
+}
+
+// Validate checks that the window ends after it starts, and that it's shorter than its recurrence.
+func (w MaintenanceWindow) Validate() error {
+	if w.Start.IsZero() || w.End.IsZero() {
+		return fmt.Errorf("start and end are required")
+	}
+
+	if !w.End.After(w.Start) {
+		return fmt.Errorf("end must be after start")
+	}
+
+	switch w.Recurrence {
+	case MaintenanceRecurrenceNone, MaintenanceRecurrenceDaily, MaintenanceRecurrenceWeekly:
+	default:
+		return fmt.Errorf("unknown recurrence %q", w.Recurrence)
+	}
+
+	if period := w.period(); period > 0 && w.End.Sub(w.Start) >= period {
+		return fmt.Errorf("window must be shorter than its %s recurrence", w.Recurrence)
+	}
+
+	return nil
+}
+
+// Active reports whether t falls within the window or one of its recurrences. The start is inclusive and
+// the end is exclusive.
+func (w MaintenanceWindow) Active(t time.Time) bool {
+	if t.Before(w.Start) {
+		return false
+	}
+
+	elapsed := t.Sub(w.Start)
+	if period := w.period(); period > 0 {
+		elapsed %= period
+	}
+
+	return elapsed < w.End.Sub(w.Start)
+}
+
+// InMaintenance reports whether any of the maintenance windows of the monitor is active at t.
+func (m Monitor) InMaintenance(t time.Time) bool {
+	for _, window := range m.MaintenanceWindows {
+		if window.Active(t) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,147 @@
+package main_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	main "semyi"
+)
+
+func TestMaintenanceWindow_Active(t *testing.T) {
+	start := time.Date(2024, 6, 1, 2, 0, 0, 0, time.UTC)
+	end := start.Add(time.Hour * 2)
+
+	testCases := []struct {
+		name       string
+		recurrence main.MaintenanceRecurrence
+		at         time.Time
+		expect     bool
+	}{
+		{name: "Before the start", at: start.Add(-time.Minute), expect: false},
+		{name: "At the start", at: start, expect: true},
+		{name: "Within the window", at: start.Add(time.Hour), expect: true},
+		{name: "At the end", at: end, expect: false},
+		{name: "One-off window on the next day", at: start.AddDate(0, 0, 1), expect: false},
+		{name: "Daily window on the next day", recurrence: main.MaintenanceRecurrenceDaily, at: start.AddDate(0, 0, 1).Add(time.Minute), expect: true},
+		{name: "Daily window outside of the hours", recurrence: main.MaintenanceRecurrenceDaily, at: start.AddDate(0, 0, 1).Add(time.Hour * 3), expect: false},
+		{name: "Weekly window on the next day", recurrence: main.MaintenanceRecurrenceWeekly, at: start.AddDate(0, 0, 1), expect: false},
+		{name: "Weekly window on the next week", recurrence: main.MaintenanceRecurrenceWeekly, at: start.AddDate(0, 0, 14).Add(time.Hour), expect: true},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			window := main.MaintenanceWindow{Start: start, End: end, Recurrence: testCase.recurrence}
+			if got := window.Active(testCase.at); got != testCase.expect {
+				t.Errorf("expected %v, got %v", testCase.expect, got)
+			}
+		})
+	}
+}
+
+func TestMaintenanceWindow_Validate(t *testing.T) {
+	start := time.Date(2024, 6, 1, 2, 0, 0, 0, time.UTC)
+
+	invalid := []main.MaintenanceWindow{
+		{Start: start},
+		{Start: start, End: start},
+		{Start: start, End: start.Add(time.Hour), Recurrence: "monthly"},
+		{Start: start, End: start.Add(time.Hour * 24), Recurrence: main.MaintenanceRecurrenceDaily},
+	}
+	for _, window := range invalid {
+		if err := window.Validate(); err == nil {
+			t.Errorf("expected an error for %+v", window)
+		}
+	}
+
+	if err := (main.MaintenanceWindow{Start: start, End: start.Add(time.Hour), Recurrence: main.MaintenanceRecurrenceWeekly}).Validate(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestProcessor_Maintenance(t *testing.T) {
+	alerted := make(chan string, 10)
+	webhookServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload main.WebhookPayload
+		if err := json.NewDecoder(r.Body).Decode(&payload); err == nil {
+			alerted <- payload.MonitorID
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer webhookServer.Close()
+
+	now := time.Now()
+	maintenance := main.Monitor{
+		UniqueID:   "maintenance-test",
+		Name:       "Maintenance Test",
+		AlertAfter: 1,
+		MaintenanceWindows: []main.MaintenanceWindow{
+			{Start: now.Add(-time.Hour), End: now.Add(time.Hour)},
+		},
+	}
+	control := main.Monitor{UniqueID: "maintenance-control", Name: "Maintenance Control", AlertAfter: 1}
+	monitors := []main.Monitor{maintenance, control}
+
+	broker := main.NewBroker[main.MonitorHistorical]()
+	processor := main.NewProcessor(main.ProcessorConfig{
+		HistoricalWriter:     main.NewMonitorHistoricalWriter(database),
+		HistoricalReader:     main.NewMonitorHistoricalReader(database),
+		CentralBroker:        broker,
+		AlertDebouncer:       main.NewAlertDebouncer(),
+		WebhookAlertProvider: main.NewWebhookDispatcher([]main.Webhook{{URL: webhookServer.URL, SuccessResponse: true, FailedResponse: true}}, monitors),
+	})
+
+	for _, monitor := range monitors {
+		processor.ProcessResponse(main.Response{Success: true, Timestamp: now.Add(-time.Minute * 2), Monitor: monitor})
+		processor.ProcessResponse(main.Response{Success: false, Timestamp: now.Add(-time.Minute), Error: "connection refused", Monitor: monitor})
+	}
+
+	// The control monitor is alerted, which is enough time for an alert of the monitor in maintenance to show up
+	select {
+	case monitorId := <-alerted:
+		if monitorId != "maintenance-control" {
+			t.Fatalf("expected no alert for the monitor in maintenance, got an alert for %s", monitorId)
+		}
+	case <-time.After(time.Second * 5):
+		t.Fatal("timed out waiting for the control alert")
+	}
+
+	select {
+	case monitorId := <-alerted:
+		t.Errorf("expected no other alerts, got an alert for %s", monitorId)
+	case <-time.After(time.Millisecond * 200):
+	}
+
+	message, ok := broker.Latest("maintenance-test")
+	if !ok || !message.Body.Maintenance || message.Body.Status != main.MonitorStatusFailure {
+		t.Errorf("expected a failed snapshot flagged as maintenance, got %+v", message)
+	}
+
+	if message, ok := broker.Latest("maintenance-control"); !ok || message.Body.Maintenance {
+		t.Errorf("expected the control snapshot not to be flagged as maintenance, got %+v", message)
+	}
+
+	server, err := main.NewServer(main.ServerConfig{
+		MonitorHistoricalReader: main.NewMonitorHistoricalReader(database),
+		CentralBroker:           main.NewBroker[main.MonitorHistorical](),
+		MonitorList:             []main.Monitor{maintenance},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error creating server: %v", err)
+	}
+
+	// A fresh broker makes the status to be read from the database
+	recorder := httptest.NewRecorder()
+	server.Handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/api/status", nil))
+
+	var snapshots []main.MonitorStatusSnapshot
+	if err := json.NewDecoder(recorder.Body).Decode(&snapshots); err != nil {
+		t.Fatalf("unexpected error decoding body: %v", err)
+	}
+
+	if len(snapshots) != 1 || snapshots[0].Status == nil || *snapshots[0].Status != "maintenance" || !snapshots[0].Maintenance {
+		t.Errorf("expected the status to be reported as maintenance, got %+v", snapshots)
+	}
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Metrics holds the Prometheus collectors for every check that has been completed.
+// It uses its own registry, so multiple instances can coexist (e.g., in tests).
+type Metrics struct {
+	registry      *prometheus.Registry
+	monitorUp     *prometheus.GaugeVec
+	checkLatency  *prometheus.HistogramVec
+	checksTotal   *prometheus.CounterVec
+	checkFailures *prometheus.CounterVec
+	droppedEvents *prometheus.CounterVec
+}
+
+func NewMetrics() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		monitorUp: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "semya_monitor_up",
+			Help: "Whether the last check of the monitor succeeded (1) or failed (0).",
+		}, []string{"monitor_id"}),
+		checkLatency: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "semya_check_latency_seconds",
+			Help:    "Latency of the monitor checks in seconds.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"monitor_id"}),
+		checksTotal: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "semya_checks_total",
+			Help: "Total number of completed checks.",
+		}, []string{"monitor_id"}),
+		checkFailures: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "semya_check_failures_total",
+			Help: "Total number of failed checks.",
+		}, []string{"monitor_id"}),
+		droppedEvents: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "semya_stream_dropped_events_total",
+			Help: "Total number of events dropped because a stream client read too slowly.",
+		}, []string{"monitor_id"}),
+	}
+
+	m.registry.MustRegister(m.monitorUp, m.checkLatency, m.checksTotal, m.checkFailures, m.droppedEvents)
+
+	return m
+}
+
+// Observe records a completed check into every related series.
+func (m *Metrics) Observe(response Response) {
+	monitorId := response.Monitor.UniqueID
+
+	m.checksTotal.WithLabelValues(monitorId).Inc()
+	m.checkLatency.WithLabelValues(monitorId).Observe((time.Duration(response.RequestDuration) * time.Millisecond).Seconds())
+
+	if response.Success {
+		m.monitorUp.WithLabelValues(monitorId).Set(1)
+	} else {
+		m.monitorUp.WithLabelValues(monitorId).Set(0)
+		m.checkFailures.WithLabelValues(monitorId).Inc()
+	}
+}
+
+// ObserveDroppedEvent records an event that was dropped for a slow stream subscriber.
+func (m *Metrics) ObserveDroppedEvent(monitorId string) {
+	m.droppedEvents.WithLabelValues(monitorId).Inc()
+}
+
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
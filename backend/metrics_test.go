@@ -0,0 +1,53 @@
+package main_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	main "semyi"
+)
+
+func TestMetrics(t *testing.T) {
+	metrics := main.NewMetrics()
+	server, err := main.NewServer(main.ServerConfig{
+		MonitorHistoricalReader: main.NewMonitorHistoricalReader(database),
+		CentralBroker:           main.NewBroker[main.MonitorHistorical](),
+		MonitorList:             []main.Monitor{{UniqueID: "metrics-test", Name: "Metrics Test"}},
+		Metrics:                 metrics,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error creating server: %v", err)
+	}
+
+	metrics.Observe(main.Response{
+		Success:         true,
+		RequestDuration: 120,
+		Timestamp:       time.Now(),
+		Monitor:         main.Monitor{UniqueID: "metrics-test"},
+	})
+
+	recorder := httptest.NewRecorder()
+	server.Handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("expected status code %d, got %d", http.StatusOK, recorder.Code)
+	}
+
+	body, err := io.ReadAll(recorder.Body)
+	if err != nil {
+		t.Fatalf("unexpected error reading body: %v", err)
+	}
+
+	for _, series := range []string{
+		`semya_monitor_up{monitor_id="metrics-test"} 1`,
+		`semya_checks_total{monitor_id="metrics-test"} 1`,
+		`semya_check_latency_seconds_count{monitor_id="metrics-test"} 1`,
+	} {
+		if !strings.Contains(string(body), series) {
+			t.Errorf("expected metrics to contain %q", series)
+		}
+	}
+}
@@ -0,0 +1,17 @@
+-- +goose Up
+-- +goose StatementBegin
+DROP INDEX IF EXISTS monitor_historical_monitor_id_idx;
+
+ALTER TABLE monitor_historical ADD COLUMN IF NOT EXISTS tls_expiry_days INTEGER DEFAULT 0;
+
+CREATE INDEX IF NOT EXISTS monitor_historical_monitor_id_idx ON monitor_historical (monitor_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS monitor_historical_monitor_id_idx;
+
+ALTER TABLE monitor_historical DROP COLUMN IF EXISTS tls_expiry_days;
+
+CREATE INDEX IF NOT EXISTS monitor_historical_monitor_id_idx ON monitor_historical (monitor_id);
+-- +goose StatementEnd
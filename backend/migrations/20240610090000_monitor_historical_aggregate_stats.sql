@@ -0,0 +1,41 @@
+-- +goose Up
+-- +goose StatementBegin
+DROP INDEX IF EXISTS monitor_historical_hourly_aggregate_monitor_id_timestamp_idx;
+DROP INDEX IF EXISTS monitor_historical_daily_aggregate_monitor_id_timestamp_idx;
+
+ALTER TABLE monitor_historical_hourly_aggregate ADD COLUMN IF NOT EXISTS uptime DOUBLE DEFAULT 0;
+ALTER TABLE monitor_historical_hourly_aggregate ADD COLUMN IF NOT EXISTS min_latency INTEGER DEFAULT 0;
+ALTER TABLE monitor_historical_hourly_aggregate ADD COLUMN IF NOT EXISTS max_latency INTEGER DEFAULT 0;
+ALTER TABLE monitor_historical_hourly_aggregate ADD COLUMN IF NOT EXISTS p95_latency INTEGER DEFAULT 0;
+ALTER TABLE monitor_historical_hourly_aggregate ADD COLUMN IF NOT EXISTS sample_count INTEGER DEFAULT 0;
+
+ALTER TABLE monitor_historical_daily_aggregate ADD COLUMN IF NOT EXISTS uptime DOUBLE DEFAULT 0;
+ALTER TABLE monitor_historical_daily_aggregate ADD COLUMN IF NOT EXISTS min_latency INTEGER DEFAULT 0;
+ALTER TABLE monitor_historical_daily_aggregate ADD COLUMN IF NOT EXISTS max_latency INTEGER DEFAULT 0;
+ALTER TABLE monitor_historical_daily_aggregate ADD COLUMN IF NOT EXISTS p95_latency INTEGER DEFAULT 0;
+ALTER TABLE monitor_historical_daily_aggregate ADD COLUMN IF NOT EXISTS sample_count INTEGER DEFAULT 0;
+
+CREATE UNIQUE INDEX IF NOT EXISTS monitor_historical_hourly_aggregate_monitor_id_timestamp_idx ON monitor_historical_hourly_aggregate (monitor_id, timestamp);
+CREATE UNIQUE INDEX IF NOT EXISTS monitor_historical_daily_aggregate_monitor_id_timestamp_idx ON monitor_historical_daily_aggregate (monitor_id, timestamp);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS monitor_historical_hourly_aggregate_monitor_id_timestamp_idx;
+DROP INDEX IF EXISTS monitor_historical_daily_aggregate_monitor_id_timestamp_idx;
+
+ALTER TABLE monitor_historical_hourly_aggregate DROP COLUMN IF EXISTS uptime;
+ALTER TABLE monitor_historical_hourly_aggregate DROP COLUMN IF EXISTS min_latency;
+ALTER TABLE monitor_historical_hourly_aggregate DROP COLUMN IF EXISTS max_latency;
+ALTER TABLE monitor_historical_hourly_aggregate DROP COLUMN IF EXISTS p95_latency;
+ALTER TABLE monitor_historical_hourly_aggregate DROP COLUMN IF EXISTS sample_count;
+
+ALTER TABLE monitor_historical_daily_aggregate DROP COLUMN IF EXISTS uptime;
+ALTER TABLE monitor_historical_daily_aggregate DROP COLUMN IF EXISTS min_latency;
+ALTER TABLE monitor_historical_daily_aggregate DROP COLUMN IF EXISTS max_latency;
+ALTER TABLE monitor_historical_daily_aggregate DROP COLUMN IF EXISTS p95_latency;
+ALTER TABLE monitor_historical_daily_aggregate DROP COLUMN IF EXISTS sample_count;
+
+CREATE UNIQUE INDEX IF NOT EXISTS monitor_historical_hourly_aggregate_monitor_id_timestamp_idx ON monitor_historical_hourly_aggregate (monitor_id, timestamp);
+CREATE UNIQUE INDEX IF NOT EXISTS monitor_historical_daily_aggregate_monitor_id_timestamp_idx ON monitor_historical_daily_aggregate (monitor_id, timestamp);
+-- +goose StatementEnd
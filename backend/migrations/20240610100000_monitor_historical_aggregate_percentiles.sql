@@ -0,0 +1,29 @@
+-- +goose Up
+-- +goose StatementBegin
+DROP INDEX IF EXISTS monitor_historical_hourly_aggregate_monitor_id_timestamp_idx;
+DROP INDEX IF EXISTS monitor_historical_daily_aggregate_monitor_id_timestamp_idx;
+
+ALTER TABLE monitor_historical_hourly_aggregate ADD COLUMN IF NOT EXISTS p50_latency INTEGER DEFAULT 0;
+ALTER TABLE monitor_historical_hourly_aggregate ADD COLUMN IF NOT EXISTS p99_latency INTEGER DEFAULT 0;
+
+ALTER TABLE monitor_historical_daily_aggregate ADD COLUMN IF NOT EXISTS p50_latency INTEGER DEFAULT 0;
+ALTER TABLE monitor_historical_daily_aggregate ADD COLUMN IF NOT EXISTS p99_latency INTEGER DEFAULT 0;
+
+CREATE UNIQUE INDEX IF NOT EXISTS monitor_historical_hourly_aggregate_monitor_id_timestamp_idx ON monitor_historical_hourly_aggregate (monitor_id, timestamp);
+CREATE UNIQUE INDEX IF NOT EXISTS monitor_historical_daily_aggregate_monitor_id_timestamp_idx ON monitor_historical_daily_aggregate (monitor_id, timestamp);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS monitor_historical_hourly_aggregate_monitor_id_timestamp_idx;
+DROP INDEX IF EXISTS monitor_historical_daily_aggregate_monitor_id_timestamp_idx;
+
+ALTER TABLE monitor_historical_hourly_aggregate DROP COLUMN IF EXISTS p50_latency;
+ALTER TABLE monitor_historical_hourly_aggregate DROP COLUMN IF EXISTS p99_latency;
+
+ALTER TABLE monitor_historical_daily_aggregate DROP COLUMN IF EXISTS p50_latency;
+ALTER TABLE monitor_historical_daily_aggregate DROP COLUMN IF EXISTS p99_latency;
+
+CREATE UNIQUE INDEX IF NOT EXISTS monitor_historical_hourly_aggregate_monitor_id_timestamp_idx ON monitor_historical_hourly_aggregate (monitor_id, timestamp);
+CREATE UNIQUE INDEX IF NOT EXISTS monitor_historical_daily_aggregate_monitor_id_timestamp_idx ON monitor_historical_daily_aggregate (monitor_id, timestamp);
+-- +goose StatementEnd
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"strings"
+	"sync"
+)
+
+// statusTracker keeps the latest status of each monitor, so the status of the parent monitors can be looked up
+// when a child monitor is processed.
+type statusTracker struct {
+	mu       sync.RWMutex
+	statuses map[string]MonitorStatus
+}
+
+func newStatusTracker() *statusTracker {
+	return &statusTracker{statuses: make(map[string]MonitorStatus)}
+}
+
+func (t *statusTracker) Set(monitorId string, status MonitorStatus) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.statuses[monitorId] = status
+}
+
+func (t *statusTracker) Get(monitorId string) (MonitorStatus, bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	status, ok := t.statuses[monitorId]
+	return status, ok
+}
+
+// downAncestor walks up the depends_on chain of the monitor and returns the first ancestor whose latest check
+// failed. Ancestors that aren't configured or haven't been checked yet are considered up.
+func (m *Processor) downAncestor(monitor Monitor) (string, bool) {
+	if m.monitors == nil || m.statuses == nil {
+		return "", false
+	}
+
+	visited := map[string]bool{monitor.UniqueID: true}
+	parentId := monitor.DependsOn
+	for parentId != "" && !visited[parentId] {
+		visited[parentId] = true
+
+		if status, ok := m.statuses.Get(parentId); ok && status == MonitorStatusFailure {
+			return parentId, true
+		}
+
+		parent, ok := m.monitors.Get(parentId)
+		if !ok {
+			return "", false
+		}
+
+		parentId = parent.DependsOn
+	}
+
+	return "", false
+}
+
+// dependencyCycle returns the depends_on chain that leads from the monitor back to itself, or nil if there's
+// no cycle. parents maps the unique ID of each monitor to its depends_on.
+func dependencyCycle(monitorId string, parents map[string]string) []string {
+	chain := []string{monitorId}
+	visited := map[string]bool{monitorId: true}
+	for current := parents[monitorId]; current != ""; current = parents[current] {
+		chain = append(chain, current)
+		if current == monitorId {
+			return chain
+		}
+
+		// A cycle further up the chain is reported on the monitors that are part of it
+		if visited[current] {
+			return nil
+		}
+		visited[current] = true
+	}
+
+	return nil
+}
+
+func formatDependencyChain(chain []string) string {
+	return strings.Join(chain, " -> ")
+}
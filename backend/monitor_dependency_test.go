@@ -0,0 +1,159 @@
+package main_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	main "semyi"
+)
+
+func TestValidateConfig_DependsOn(t *testing.T) {
+	monitor := func(uniqueId string, dependsOn string) main.Monitor {
+		return main.Monitor{UniqueID: uniqueId, Name: uniqueId, Type: main.MonitorTypePing, IcmpHostname: "localhost", DependsOn: dependsOn}
+	}
+
+	t.Run("Should accept a dependency chain", func(t *testing.T) {
+		err := main.ValidateConfig(main.ConfigurationFile{Monitors: []main.Monitor{
+			monitor("gateway", ""),
+			monitor("api", "gateway"),
+			monitor("worker", "api"),
+		}})
+		if err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("Should reject a cycle", func(t *testing.T) {
+		err := main.ValidateConfig(main.ConfigurationFile{Monitors: []main.Monitor{
+			monitor("a", "c"),
+			monitor("b", "a"),
+			monitor("c", "b"),
+			monitor("d", "a"),
+		}})
+
+		var validationError *main.ValidationError
+		if !errors.As(err, &validationError) {
+			t.Fatalf("expected a validation error, got %v", err)
+		}
+
+		if !strings.Contains(err.Error(), "depends_on forms a cycle: a -> c -> b -> a") {
+			t.Errorf("expected the cycle to be reported, got %v", err)
+		}
+
+		// d depends on the cycle, but it's not part of it
+		if strings.Contains(err.Error(), "monitors[3]") {
+			t.Errorf("expected only the monitors of the cycle to be reported, got %v", err)
+		}
+	})
+
+	t.Run("Should reject a self dependency", func(t *testing.T) {
+		err := main.ValidateConfig(main.ConfigurationFile{Monitors: []main.Monitor{monitor("self", "self")}})
+		if err == nil || !strings.Contains(err.Error(), "self -> self") {
+			t.Errorf("expected a cycle error, got %v", err)
+		}
+	})
+
+	t.Run("Should reject an unknown parent", func(t *testing.T) {
+		err := main.ValidateConfig(main.ConfigurationFile{Monitors: []main.Monitor{monitor("orphan", "missing")}})
+		if err == nil || !strings.Contains(err.Error(), `depends_on "missing" is not a configured monitor`) {
+			t.Errorf("expected an unknown parent error, got %v", err)
+		}
+	})
+}
+
+func TestProcessor_DependsOn(t *testing.T) {
+	alerted := make(chan string, 10)
+	webhookServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload main.WebhookPayload
+		if err := json.NewDecoder(r.Body).Decode(&payload); err == nil {
+			alerted <- payload.MonitorID + ":" + payload.Type
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer webhookServer.Close()
+
+	parent := main.Monitor{UniqueID: "dependency-parent", Name: "Parent", AlertAfter: 1}
+	child := main.Monitor{UniqueID: "dependency-child", Name: "Child", AlertAfter: 1, DependsOn: "dependency-parent"}
+	grandchild := main.Monitor{UniqueID: "dependency-grandchild", Name: "Grandchild", AlertAfter: 1, DependsOn: "dependency-child"}
+	monitors := []main.Monitor{parent, child, grandchild}
+
+	broker := main.NewBroker[main.MonitorHistorical]()
+	processor := main.NewProcessor(main.ProcessorConfig{
+		HistoricalWriter:     main.NewMonitorHistoricalWriter(database),
+		HistoricalReader:     main.NewMonitorHistoricalReader(database),
+		CentralBroker:        broker,
+		AlertDebouncer:       main.NewAlertDebouncer(),
+		MonitorRegistry:      main.NewMonitorRegistry(monitors),
+		WebhookAlertProvider: main.NewWebhookDispatcher([]main.Webhook{{URL: webhookServer.URL, SuccessResponse: true, FailedResponse: true}}, monitors),
+	})
+
+	now := time.Now().Add(-time.Minute * 10)
+	check := func(monitor main.Monitor, success bool) {
+		now = now.Add(time.Second)
+		processor.ProcessResponse(main.Response{Success: success, Timestamp: now, Monitor: monitor})
+	}
+
+	expectAlerts := func(t *testing.T, expected ...string) {
+		received := map[string]bool{}
+		for range expected {
+			select {
+			case alert := <-alerted:
+				received[alert] = true
+			case <-time.After(time.Second * 5):
+				t.Fatalf("timed out waiting for alerts %v, got %v", expected, received)
+			}
+		}
+
+		for _, alert := range expected {
+			if !received[alert] {
+				t.Errorf("expected alert %s, got %v", alert, received)
+			}
+		}
+
+		select {
+		case alert := <-alerted:
+			t.Errorf("expected no other alerts, got %s", alert)
+		case <-time.After(time.Millisecond * 200):
+		}
+	}
+
+	for _, monitor := range monitors {
+		check(monitor, true)
+	}
+
+	// The parent goes down and takes the children with it
+	check(parent, false)
+	check(child, false)
+	check(grandchild, false)
+	expectAlerts(t, "dependency-parent:down")
+
+	for _, monitorId := range []string{"dependency-child", "dependency-grandchild"} {
+		message, ok := broker.Latest(monitorId)
+		if !ok || !message.Body.Suppressed {
+			t.Errorf("expected %s to be suppressed, got %+v", monitorId, message)
+		}
+	}
+
+	if message, _ := broker.Latest("dependency-parent"); message.Body.Suppressed {
+		t.Error("expected the parent not to be suppressed")
+	}
+
+	// The parent recovers, but the child is still down on its own
+	check(parent, true)
+	check(child, false)
+	check(grandchild, false)
+	expectAlerts(t, "dependency-parent:recovered", "dependency-child:down")
+
+	if message, _ := broker.Latest("dependency-child"); message.Body.Suppressed {
+		t.Error("expected the child not to be suppressed after the parent recovered")
+	}
+
+	if message, _ := broker.Latest("dependency-grandchild"); !message.Body.Suppressed {
+		t.Error("expected the grandchild to be suppressed while the child is down")
+	}
+}
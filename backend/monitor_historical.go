@@ -7,6 +7,31 @@ type MonitorHistorical struct {
 	Status    MonitorStatus
 	Latency   int64
 	Timestamp time.Time
+	// PacketLoss specifies the percentage of lost packets, only recorded for ICMP monitors.
+	PacketLoss float64
+	// TlsExpiryDays specifies the days until the TLS certificate expires, only recorded for HTTP monitors
+	// with TLS expiry check enabled.
+	TlsExpiryDays int
+	// ResponseSize specifies the size of the response body in bytes, only recorded for HTTP monitors.
+	ResponseSize int64
+	// ErrorCategory classifies why the check failed, it's empty for a successful check.
+	ErrorCategory ErrorCategory
+	// Protocol specifies the negotiated protocol such as "HTTP/2.0", only recorded for HTTP monitors.
+	Protocol string
+	// TlsVersion specifies the negotiated TLS version such as "TLS 1.3", only recorded for HTTPS monitors.
+	TlsVersion string
+	// Retries specifies how many times the check was retried before its result, it's zero when the first
+	// attempt succeeded or the monitor has no retries.
+	Retries int
+	// P50Latency, P95Latency, and P99Latency specify the latency percentiles within the bucket, only recorded
+	// for the hourly and daily aggregates.
+	P50Latency int64
+	P95Latency int64
+	P99Latency int64
+	// Maintenance specifies whether the check was made during a maintenance window of the monitor.
+	Maintenance bool
+	// Suppressed specifies whether the check didn't succeed while a parent monitor was down.
+	Suppressed bool
 }
 
 func (m MonitorHistorical) Validate() (bool, error) {
@@ -20,11 +45,15 @@ func (m MonitorHistorical) Validate() (bool, error) {
 		validationError.AddIssue("latency", "latency must be greater than 0")
 	}
 
+	if m.PacketLoss < 0 || m.PacketLoss > 100 {
+		validationError.AddIssue("packet_loss", "packet loss must be between 0 and 100")
+	}
+
 	if m.Timestamp.IsZero() {
 		validationError.AddIssue("timestamp", "timestamp is required")
 	}
 
-	if m.Status != MonitorStatusSuccess && m.Status != MonitorStatusFailure {
+	if m.Status != MonitorStatusSuccess && m.Status != MonitorStatusFailure && m.Status != MonitorStatusDegraded {
 		validationError.AddIssue("status", "invalid status")
 	}
 
@@ -34,3 +63,43 @@ func (m MonitorHistorical) Validate() (bool, error) {
 
 	return true, nil
 }
+
+// UnchangedMonitorHistorical returns the BrokerOptions.Unchanged of the central broker. A snapshot is unchanged
+// while its status is the same, and its latency is within latencyDelta milliseconds of the previous one.
+func UnchangedMonitorHistorical(latencyDelta int64) func(previous, current MonitorHistorical) bool {
+	return func(previous, current MonitorHistorical) bool {
+		latencyChange := current.Latency - previous.Latency
+		if latencyChange < 0 {
+			latencyChange = -latencyChange
+		}
+
+		return previous.MonitorID == current.MonitorID &&
+			previous.Status == current.Status &&
+			previous.ErrorCategory == current.ErrorCategory &&
+			previous.Protocol == current.Protocol &&
+			previous.TlsVersion == current.TlsVersion &&
+			previous.TlsExpiryDays == current.TlsExpiryDays &&
+			previous.Maintenance == current.Maintenance &&
+			previous.Suppressed == current.Suppressed &&
+			latencyChange <= latencyDelta
+	}
+}
+
+// MonitorAggregate is the summary of the raw historical data of a monitor within an hourly or a daily bucket.
+type MonitorAggregate struct {
+	MonitorID string
+	// Timestamp specifies the start of the bucket.
+	Timestamp time.Time
+	// Status specifies the worst status within the bucket.
+	Status MonitorStatus
+	// Uptime specifies the percentage of up (including degraded) samples, rounded to two decimal places.
+	Uptime float64
+	// Latency specifies the average latency of the samples.
+	Latency     int64
+	MinLatency  int64
+	MaxLatency  int64
+	P50Latency  int64
+	P95Latency  int64
+	P99Latency  int64
+	SampleCount int
+}
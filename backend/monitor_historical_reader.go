@@ -3,17 +3,48 @@ package main
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"fmt"
+	"time"
 
 	"github.com/rs/zerolog/log"
 )
 
+// rawHistoricalColumns are the columns of the raw historical data that scanRawHistorical scans.
+const rawHistoricalColumns = "timestamp, monitor_id, status, latency, packet_loss, tls_expiry_days, maintenance, suppressed, response_size, error_category, protocol, retries, tls_version, compressed_data"
+
 type MonitorHistoricalReader struct {
-	db *sql.DB
+	db       *sql.DB
+	location *time.Location
+}
+
+// MonitorHistoricalReaderOptions specifies how the historical data is read.
+type MonitorHistoricalReaderOptions struct {
+	// Location specifies the time zone that the timestamps of the hourly and daily aggregates are read in, which
+	// should be the location of the Downsampler. Defaults to the local time zone of the process.
+	Location *time.Location
 }
 
 func NewMonitorHistoricalReader(db *sql.DB) *MonitorHistoricalReader {
-	return &MonitorHistoricalReader{db: db}
+	return NewMonitorHistoricalReaderWithOptions(db, MonitorHistoricalReaderOptions{})
+}
+
+// NewMonitorHistoricalReaderWithOptions creates a reader of the historical data as specified by the options.
+func NewMonitorHistoricalReaderWithOptions(db *sql.DB, options MonitorHistoricalReaderOptions) *MonitorHistoricalReader {
+	if options.Location == nil {
+		options.Location = time.Local
+	}
+
+	return &MonitorHistoricalReader{db: db, location: options.Location}
+}
+
+// Ping checks that the database is reachable.
+func (r *MonitorHistoricalReader) Ping(ctx context.Context) error {
+	if err := r.db.PingContext(ctx); err != nil {
+		return fmt.Errorf("failed to ping database: %w", err)
+	}
+
+	return nil
 }
 
 func (r *MonitorHistoricalReader) ReadRawHistorical(ctx context.Context, monitorId string) ([]MonitorHistorical, error) {
@@ -28,7 +59,7 @@ func (r *MonitorHistoricalReader) ReadRawHistorical(ctx context.Context, monitor
 		}
 	}()
 
-	rows, err := conn.QueryContext(ctx, "SELECT timestamp, monitor_id, status, latency FROM monitor_historical WHERE monitor_id = ?", monitorId)
+	rows, err := conn.QueryContext(ctx, "SELECT "+rawHistoricalColumns+" FROM monitor_historical WHERE monitor_id = ?", monitorId)
 	if err != nil {
 		return []MonitorHistorical{}, fmt.Errorf("failed to read raw historical data: %w", err)
 	}
@@ -40,8 +71,44 @@ func (r *MonitorHistoricalReader) ReadRawHistorical(ctx context.Context, monitor
 
 	var monitorsHistorical []MonitorHistorical
 	for rows.Next() {
-		var row MonitorHistorical
-		err := rows.Scan(&row.Timestamp, &row.MonitorID, &row.Status, &row.Latency)
+		row, err := scanRawHistorical(rows)
+		if err != nil {
+			return []MonitorHistorical{}, fmt.Errorf("failed to scan row")
+		}
+
+		monitorsHistorical = append(monitorsHistorical, row)
+	}
+
+	return monitorsHistorical, nil
+}
+
+// ReadRawHistoricalRange reads the raw historical data of a monitor, where the timestamp is within from (inclusive)
+// and to (exclusive). The result is ordered by the timestamp.
+func (r *MonitorHistoricalReader) ReadRawHistoricalRange(ctx context.Context, monitorId string, from time.Time, to time.Time) ([]MonitorHistorical, error) {
+	conn, err := r.db.Conn(ctx)
+	if err != nil {
+		return []MonitorHistorical{}, fmt.Errorf("failed to get connection: %w", err)
+	}
+	defer func() {
+		err := conn.Close()
+		if err != nil {
+			log.Warn().Stack().Err(err).Msg("failed to close connection")
+		}
+	}()
+
+	rows, err := conn.QueryContext(ctx, "SELECT "+rawHistoricalColumns+" FROM monitor_historical WHERE monitor_id = ? AND timestamp >= ? AND timestamp < ? ORDER BY timestamp", monitorId, from, to)
+	if err != nil {
+		return []MonitorHistorical{}, fmt.Errorf("failed to read raw historical data: %w", err)
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			log.Warn().Stack().Err(err).Msg("failed to close rows")
+		}
+	}()
+
+	var monitorsHistorical []MonitorHistorical
+	for rows.Next() {
+		row, err := scanRawHistorical(rows)
 		if err != nil {
 			return []MonitorHistorical{}, fmt.Errorf("failed to scan row")
 		}
@@ -64,7 +131,7 @@ func (r *MonitorHistoricalReader) ReadHourlyHistorical(ctx context.Context, moni
 		}
 	}()
 
-	rows, err := conn.QueryContext(ctx, "SELECT timestamp, monitor_id, status, latency FROM monitor_historical_hourly_aggregate WHERE monitor_id = ?", monitorId)
+	rows, err := conn.QueryContext(ctx, "SELECT timestamp, monitor_id, status, latency, p50_latency, p95_latency, p99_latency FROM monitor_historical_hourly_aggregate WHERE monitor_id = ?", monitorId)
 	if err != nil {
 		return []MonitorHistorical{}, fmt.Errorf("failed to read hourly historical data: %w", err)
 	}
@@ -77,10 +144,11 @@ func (r *MonitorHistoricalReader) ReadHourlyHistorical(ctx context.Context, moni
 	var monitorsHistorical []MonitorHistorical
 	for rows.Next() {
 		var row MonitorHistorical
-		err := rows.Scan(&row.Timestamp, &row.MonitorID, &row.Status, &row.Latency)
+		err := rows.Scan(&row.Timestamp, &row.MonitorID, &row.Status, &row.Latency, &row.P50Latency, &row.P95Latency, &row.P99Latency)
 		if err != nil {
 			return []MonitorHistorical{}, fmt.Errorf("failed to scan row")
 		}
+		row.Timestamp = row.Timestamp.In(r.location)
 
 		monitorsHistorical = append(monitorsHistorical, row)
 	}
@@ -88,6 +156,8 @@ func (r *MonitorHistoricalReader) ReadHourlyHistorical(ctx context.Context, moni
 	return monitorsHistorical, nil
 }
 
+// ReadDailyHistorical reads the daily aggregates of a monitor, whose timestamps are the local midnights of the
+// location of the reader.
 func (r *MonitorHistoricalReader) ReadDailyHistorical(ctx context.Context, monitorId string) ([]MonitorHistorical, error) {
 	conn, err := r.db.Conn(ctx)
 	if err != nil {
@@ -100,7 +170,7 @@ func (r *MonitorHistoricalReader) ReadDailyHistorical(ctx context.Context, monit
 		}
 	}()
 
-	rows, err := conn.QueryContext(ctx, "SELECT timestamp, monitor_id, status, latency FROM monitor_historical_daily_aggregate WHERE monitor_id = ?", monitorId)
+	rows, err := conn.QueryContext(ctx, "SELECT timestamp, monitor_id, status, latency, p50_latency, p95_latency, p99_latency FROM monitor_historical_daily_aggregate WHERE monitor_id = ?", monitorId)
 	if err != nil {
 		return []MonitorHistorical{}, fmt.Errorf("failed to read daily historical data: %w", err)
 	}
@@ -113,10 +183,11 @@ func (r *MonitorHistoricalReader) ReadDailyHistorical(ctx context.Context, monit
 	var monitorsHistorical []MonitorHistorical
 	for rows.Next() {
 		var row MonitorHistorical
-		err := rows.Scan(&row.Timestamp, &row.MonitorID, &row.Status, &row.Latency)
+		err := rows.Scan(&row.Timestamp, &row.MonitorID, &row.Status, &row.Latency, &row.P50Latency, &row.P95Latency, &row.P99Latency)
 		if err != nil {
 			return []MonitorHistorical{}, fmt.Errorf("failed to scan row")
 		}
+		row.Timestamp = row.Timestamp.In(r.location)
 
 		monitorsHistorical = append(monitorsHistorical, row)
 	}
@@ -137,16 +208,219 @@ func (r *MonitorHistoricalReader) ReadRawLatest(ctx context.Context, monitorId s
 		}
 	}()
 
-	var monitorsHistorical MonitorHistorical
-	err = conn.QueryRowContext(ctx, "SELECT timestamp, monitor_id, status, latency FROM monitor_historical WHERE monitor_id = ? ORDER BY timestamp DESC LIMIT 1", monitorId).Scan(
-		&monitorsHistorical.Timestamp,
-		&monitorsHistorical.MonitorID,
-		&monitorsHistorical.Status,
-		&monitorsHistorical.Latency,
-	)
+	monitorsHistorical, err := scanRawHistorical(conn.QueryRowContext(ctx, "SELECT "+rawHistoricalColumns+" FROM monitor_historical WHERE monitor_id = ? ORDER BY timestamp DESC LIMIT 1", monitorId))
 	if err != nil {
 		return MonitorHistorical{}, fmt.Errorf("failed to read latest raw historical data: %w", err)
 	}
 
 	return monitorsHistorical, nil
 }
+
+// scanRawHistorical scans a row of rawHistoricalColumns. The records that were written compressed only keep the
+// monitor id, status, and timestamp in their own columns, the rest is decoded from the compressed data, so the
+// compressed and uncompressed records are read alike.
+func scanRawHistorical(row interface{ Scan(dest ...any) error }) (MonitorHistorical, error) {
+	var historical MonitorHistorical
+	var data []byte
+	err := row.Scan(&historical.Timestamp, &historical.MonitorID, &historical.Status, &historical.Latency, &historical.PacketLoss, &historical.TlsExpiryDays, &historical.Maintenance, &historical.Suppressed, &historical.ResponseSize, &historical.ErrorCategory, &historical.Protocol, &historical.Retries, &historical.TlsVersion, &data)
+	if err != nil || data == nil {
+		return historical, err
+	}
+
+	decompressed, err := decompress(data)
+	if err != nil {
+		return MonitorHistorical{}, err
+	}
+
+	// The timestamp of the column is kept, so it's in the same location as the timestamps of uncompressed records
+	timestamp := historical.Timestamp
+	if err := json.Unmarshal(decompressed, &historical); err != nil {
+		return MonitorHistorical{}, fmt.Errorf("failed to decode historical data: %w", err)
+	}
+	historical.Timestamp = timestamp
+
+	return historical, nil
+}
+
+// ReadHistoricalPage reads a page of the historical data of a monitor ordered by the timestamp, along with the total
+// amount of entries. The interval must be either "raw", "hourly", or "daily". The entries are limited to the
+// timestamps from from (inclusive) to to (exclusive), a zero from or to leaves the range open on that side.
+func (r *MonitorHistoricalReader) ReadHistoricalPage(ctx context.Context, monitorId string, interval string, from time.Time, to time.Time, limit int, offset int) ([]MonitorHistorical, int, error) {
+	var table, columns string
+	switch interval {
+	case "raw":
+		table, columns = "monitor_historical", rawHistoricalColumns
+	case "hourly":
+		table, columns = "monitor_historical_hourly_aggregate", "timestamp, monitor_id, status, latency, p50_latency, p95_latency, p99_latency"
+	case "daily":
+		table, columns = "monitor_historical_daily_aggregate", "timestamp, monitor_id, status, latency, p50_latency, p95_latency, p99_latency"
+	default:
+		return []MonitorHistorical{}, 0, fmt.Errorf("invalid interval: %s", interval)
+	}
+
+	conn, err := r.db.Conn(ctx)
+	if err != nil {
+		return []MonitorHistorical{}, 0, fmt.Errorf("failed to get connection: %w", err)
+	}
+	defer func() {
+		err := conn.Close()
+		if err != nil {
+			log.Warn().Stack().Err(err).Msg("failed to close connection")
+		}
+	}()
+
+	where := "monitor_id = ?"
+	args := []any{monitorId}
+	if !from.IsZero() {
+		where += " AND timestamp >= ?"
+		args = append(args, from)
+	}
+	if !to.IsZero() {
+		where += " AND timestamp < ?"
+		args = append(args, to)
+	}
+
+	var total int
+	err = conn.QueryRowContext(ctx, "SELECT COUNT(*) FROM "+table+" WHERE "+where, args...).Scan(&total)
+	if err != nil {
+		return []MonitorHistorical{}, 0, fmt.Errorf("failed to count %s historical data: %w", interval, err)
+	}
+
+	rows, err := conn.QueryContext(ctx, "SELECT "+columns+" FROM "+table+" WHERE "+where+" ORDER BY timestamp LIMIT ? OFFSET ?", append(args, limit, offset)...)
+	if err != nil {
+		return []MonitorHistorical{}, 0, fmt.Errorf("failed to read %s historical data: %w", interval, err)
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			log.Warn().Stack().Err(err).Msg("failed to close rows")
+		}
+	}()
+
+	var monitorsHistorical []MonitorHistorical
+	for rows.Next() {
+		var row MonitorHistorical
+		if interval == "raw" {
+			row, err = scanRawHistorical(rows)
+		} else {
+			err = rows.Scan(&row.Timestamp, &row.MonitorID, &row.Status, &row.Latency, &row.P50Latency, &row.P95Latency, &row.P99Latency)
+			row.Timestamp = row.Timestamp.In(r.location)
+		}
+		if err != nil {
+			return []MonitorHistorical{}, 0, fmt.Errorf("failed to scan row")
+		}
+
+		monitorsHistorical = append(monitorsHistorical, row)
+	}
+
+	return monitorsHistorical, total, nil
+}
+
+// ReadAggregates reads the aggregates of a monitor ordered by the timestamp. The interval must be either
+// "hourly" or "daily".
+func (r *MonitorHistoricalReader) ReadAggregates(ctx context.Context, monitorId string, interval string) ([]MonitorAggregate, error) {
+	var table string
+	switch interval {
+	case "hourly":
+		table = "monitor_historical_hourly_aggregate"
+	case "daily":
+		table = "monitor_historical_daily_aggregate"
+	default:
+		return []MonitorAggregate{}, fmt.Errorf("invalid interval: %s", interval)
+	}
+
+	conn, err := r.db.Conn(ctx)
+	if err != nil {
+		return []MonitorAggregate{}, fmt.Errorf("failed to get connection: %w", err)
+	}
+	defer func() {
+		err := conn.Close()
+		if err != nil {
+			log.Warn().Stack().Err(err).Msg("failed to close connection")
+		}
+	}()
+
+	rows, err := conn.QueryContext(ctx, "SELECT timestamp, monitor_id, status, uptime, latency, min_latency, max_latency, p50_latency, p95_latency, p99_latency, sample_count FROM "+table+" WHERE monitor_id = ? ORDER BY timestamp", monitorId)
+	if err != nil {
+		return []MonitorAggregate{}, fmt.Errorf("failed to read %s aggregate data: %w", interval, err)
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			log.Warn().Stack().Err(err).Msg("failed to close rows")
+		}
+	}()
+
+	var aggregates []MonitorAggregate
+	for rows.Next() {
+		var row MonitorAggregate
+		err := rows.Scan(&row.Timestamp, &row.MonitorID, &row.Status, &row.Uptime, &row.Latency, &row.MinLatency, &row.MaxLatency, &row.P50Latency, &row.P95Latency, &row.P99Latency, &row.SampleCount)
+		if err != nil {
+			return []MonitorAggregate{}, fmt.Errorf("failed to scan row")
+		}
+		row.Timestamp = row.Timestamp.In(r.location)
+
+		aggregates = append(aggregates, row)
+	}
+
+	return aggregates, nil
+}
+
+// StreamHistorical calls fn for each historical data of a monitor ordered by the timestamp, without loading
+// the whole result set into memory. The interval must be either "raw", "hourly", or "daily". It stops at the
+// first error returned by fn.
+func (r *MonitorHistoricalReader) StreamHistorical(ctx context.Context, monitorId string, interval string, fn func(MonitorHistorical) error) error {
+	var table, columns string
+	switch interval {
+	case "raw":
+		table, columns = "monitor_historical", rawHistoricalColumns
+	case "hourly":
+		table, columns = "monitor_historical_hourly_aggregate", "timestamp, monitor_id, status, latency, p50_latency, p95_latency, p99_latency"
+	case "daily":
+		table, columns = "monitor_historical_daily_aggregate", "timestamp, monitor_id, status, latency, p50_latency, p95_latency, p99_latency"
+	default:
+		return fmt.Errorf("invalid interval: %s", interval)
+	}
+
+	conn, err := r.db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get connection: %w", err)
+	}
+	defer func() {
+		err := conn.Close()
+		if err != nil {
+			log.Warn().Stack().Err(err).Msg("failed to close connection")
+		}
+	}()
+
+	rows, err := conn.QueryContext(ctx, "SELECT "+columns+" FROM "+table+" WHERE monitor_id = ? ORDER BY timestamp", monitorId)
+	if err != nil {
+		return fmt.Errorf("failed to read %s historical data: %w", interval, err)
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			log.Warn().Stack().Err(err).Msg("failed to close rows")
+		}
+	}()
+
+	for rows.Next() {
+		var row MonitorHistorical
+		if interval == "raw" {
+			row, err = scanRawHistorical(rows)
+		} else {
+			err = rows.Scan(&row.Timestamp, &row.MonitorID, &row.Status, &row.Latency, &row.P50Latency, &row.P95Latency, &row.P99Latency)
+			row.Timestamp = row.Timestamp.In(r.location)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to scan row")
+		}
+
+		if err := fn(row); err != nil {
+			return err
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to read %s historical data: %w", interval, err)
+	}
+
+	return nil
+}
@@ -1,7 +1,9 @@
 package main_test
 
 import (
+	"encoding/json"
 	"errors"
+	"strings"
 	"testing"
 	"time"
 
@@ -61,3 +63,28 @@ func TestMonitorHistorical_Validate(t *testing.T) {
 		}
 	})
 }
+
+func TestMonitorStatus_JSON(t *testing.T) {
+	historical := main.MonitorHistorical{MonitorID: "json-test", Status: main.MonitorStatusDegraded}
+	marshaled, err := json.Marshal(historical)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !strings.Contains(string(marshaled), `"Status":"degraded"`) {
+		t.Errorf("expected the status to be encoded as degraded, got %s", marshaled)
+	}
+
+	var decoded main.MonitorHistorical
+	if err := json.Unmarshal(marshaled, &decoded); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if decoded.Status != main.MonitorStatusDegraded {
+		t.Errorf("expected degraded, got %v", decoded.Status)
+	}
+
+	if err := json.Unmarshal([]byte(`{"Status":"sideways"}`), &decoded); err == nil {
+		t.Error("expected an error decoding an unknown status, got nil")
+	}
+}
@@ -3,6 +3,7 @@ package main
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"fmt"
 	"time"
 
@@ -14,14 +15,68 @@ type MonitorStatus uint8
 const (
 	MonitorStatusSuccess MonitorStatus = iota
 	MonitorStatusFailure
+	MonitorStatusDegraded
 )
 
+func (s MonitorStatus) String() string {
+	switch s {
+	case MonitorStatusSuccess:
+		return "up"
+	case MonitorStatusFailure:
+		return "down"
+	case MonitorStatusDegraded:
+		return "degraded"
+	}
+	return "unknown"
+}
+
+// MarshalText encodes the status as "up", "degraded", or "down", so the API and SSE payloads don't expose
+// the numeric values of the database.
+func (s MonitorStatus) MarshalText() ([]byte, error) {
+	switch s {
+	case MonitorStatusSuccess, MonitorStatusFailure, MonitorStatusDegraded:
+		return []byte(s.String()), nil
+	}
+
+	return nil, fmt.Errorf("invalid monitor status %d", s)
+}
+
+// UnmarshalText decodes a status encoded by MarshalText.
+func (s *MonitorStatus) UnmarshalText(text []byte) error {
+	switch string(text) {
+	case "up":
+		*s = MonitorStatusSuccess
+	case "down":
+		*s = MonitorStatusFailure
+	case "degraded":
+		*s = MonitorStatusDegraded
+	default:
+		return fmt.Errorf("invalid monitor status %q", string(text))
+	}
+
+	return nil
+}
+
 type MonitorHistoricalWriter struct {
-	db *sql.DB
+	db          *sql.DB
+	compression Compression
+}
+
+// MonitorHistoricalWriterOptions specifies how the historical data is written.
+type MonitorHistoricalWriterOptions struct {
+	// Compression specifies how the raw historical data is compressed. The compressed records only keep the
+	// monitor id, status, and timestamp in their own columns, the MonitorHistoricalReader decodes the rest.
+	// The aggregates are never compressed. Defaults to no compression.
+	Compression Compression
 }
 
 func NewMonitorHistoricalWriter(db *sql.DB) *MonitorHistoricalWriter {
-	return &MonitorHistoricalWriter{db: db}
+	return NewMonitorHistoricalWriterWithOptions(db, MonitorHistoricalWriterOptions{})
+}
+
+// NewMonitorHistoricalWriterWithOptions creates a writer of the historical data as specified by the options.
+func NewMonitorHistoricalWriterWithOptions(db *sql.DB, options MonitorHistoricalWriterOptions) *MonitorHistoricalWriter {
+	return &MonitorHistoricalWriter{db: db, compression: options.Compression}
 }
 
 func (w *MonitorHistoricalWriter) Write(ctx context.Context, historical MonitorHistorical) error {
@@ -46,8 +101,23 @@ func (w *MonitorHistoricalWriter) Write(ctx context.Context, historical MonitorH
 		}
 	}()
 
-	_, err = conn.ExecContext(ctx, "INSERT INTO monitor_historical (monitor_id, status, latency, timestamp) VALUES (?, ?, ?, ?)",
-		historical.MonitorID, historical.Status, historical.Latency, historical.Timestamp)
+	if w.compression != CompressionNone {
+		data, err := compressHistorical(historical, w.compression)
+		if err != nil {
+			return err
+		}
+
+		_, err = conn.ExecContext(ctx, "INSERT INTO monitor_historical (monitor_id, status, timestamp, compressed_data) VALUES (?, ?, ?, ?)",
+			historical.MonitorID, historical.Status, historical.Timestamp, data)
+		if err != nil {
+			return fmt.Errorf("failed to insert historical data: %w", err)
+		}
+
+		return nil
+	}
+
+	_, err = conn.ExecContext(ctx, "INSERT INTO monitor_historical (monitor_id, status, latency, timestamp, packet_loss, tls_expiry_days, maintenance, suppressed, response_size, error_category, protocol, retries, tls_version) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+		historical.MonitorID, historical.Status, historical.Latency, historical.Timestamp, historical.PacketLoss, historical.TlsExpiryDays, historical.Maintenance, historical.Suppressed, historical.ResponseSize, string(historical.ErrorCategory), historical.Protocol, historical.Retries, historical.TlsVersion)
 	if err != nil {
 		return fmt.Errorf("failed to insert historical data: %w", err)
 	}
@@ -55,6 +125,16 @@ func (w *MonitorHistoricalWriter) Write(ctx context.Context, historical MonitorH
 	return nil
 }
 
+// compressHistorical encodes the whole historical data as JSON, compressed with the compression.
+func compressHistorical(historical MonitorHistorical, compression Compression) ([]byte, error) {
+	encoded, err := json.Marshal(historical)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode historical data: %w", err)
+	}
+
+	return compress(encoded, compression)
+}
+
 func (w *MonitorHistoricalWriter) WriteHourly(ctx context.Context, historical MonitorHistorical) error {
 	// Validate the historical data
 	valid, err := historical.Validate()
@@ -116,3 +196,42 @@ func (w *MonitorHistoricalWriter) WriteDaily(ctx context.Context, historical Mon
 
 	return nil
 }
+
+// WriteAggregate inserts or replaces the aggregate of a bucket. The interval must be either "hourly" or "daily".
+func (w *MonitorHistoricalWriter) WriteAggregate(ctx context.Context, interval string, aggregate MonitorAggregate) error {
+	var table string
+	switch interval {
+	case "hourly":
+		table = "monitor_historical_hourly_aggregate"
+	case "daily":
+		table = "monitor_historical_daily_aggregate"
+	default:
+		return fmt.Errorf("invalid interval: %s", interval)
+	}
+
+	if aggregate.MonitorID == "" || aggregate.Timestamp.IsZero() || aggregate.SampleCount <= 0 {
+		return fmt.Errorf("invalid aggregate: monitor id, timestamp, and samples are required")
+	}
+
+	conn, err := w.db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get connection: %w", err)
+	}
+	defer func() {
+		err := conn.Close()
+		if err != nil {
+			log.Warn().Err(err).Msg("failed to close connection")
+		}
+	}()
+
+	now := time.Now()
+	_, err = conn.ExecContext(ctx, "INSERT INTO "+table+" (monitor_id, status, latency, timestamp, created_at, uptime, min_latency, max_latency, p50_latency, p95_latency, p99_latency, sample_count) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?) "+
+		"ON CONFLICT (monitor_id, timestamp) DO UPDATE SET status = ?, latency = ?, created_at = ?, uptime = ?, min_latency = ?, max_latency = ?, p50_latency = ?, p95_latency = ?, p99_latency = ?, sample_count = ?",
+		aggregate.MonitorID, aggregate.Status, aggregate.Latency, aggregate.Timestamp, now, aggregate.Uptime, aggregate.MinLatency, aggregate.MaxLatency, aggregate.P50Latency, aggregate.P95Latency, aggregate.P99Latency, aggregate.SampleCount,
+		aggregate.Status, aggregate.Latency, now, aggregate.Uptime, aggregate.MinLatency, aggregate.MaxLatency, aggregate.P50Latency, aggregate.P95Latency, aggregate.P99Latency, aggregate.SampleCount)
+	if err != nil {
+		return fmt.Errorf("failed to insert %s aggregate data: %w", interval, err)
+	}
+
+	return nil
+}
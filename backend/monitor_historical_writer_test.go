@@ -58,6 +58,38 @@ func TestMonitorHistoricalWriter_Write(t *testing.T) {
 			t.Errorf("expected nil, got %v", err)
 		}
 	})
+
+	t.Run("Should record the response size, error category, and protocol", func(t *testing.T) {
+		err := writer.Write(context.Background(), main.MonitorHistorical{
+			MonitorID:     "response-size-test",
+			Status:        main.MonitorStatusFailure,
+			Latency:       1,
+			Timestamp:     time.Now(),
+			ResponseSize:  1234,
+			ErrorCategory: main.ErrorCategoryHttpStatus,
+			Protocol:      "HTTP/2.0",
+		})
+		if err != nil {
+			t.Fatalf("expected nil, got %v", err)
+		}
+
+		latest, err := main.NewMonitorHistoricalReader(database).ReadRawLatest(context.Background(), "response-size-test")
+		if err != nil {
+			t.Fatalf("unexpected error reading: %v", err)
+		}
+
+		if latest.ResponseSize != 1234 {
+			t.Errorf("expected a response size of 1234 bytes, got %d", latest.ResponseSize)
+		}
+
+		if latest.ErrorCategory != main.ErrorCategoryHttpStatus {
+			t.Errorf("expected error category %q, got %q", main.ErrorCategoryHttpStatus, latest.ErrorCategory)
+		}
+
+		if latest.Protocol != "HTTP/2.0" {
+			t.Errorf("expected protocol HTTP/2.0, got %q", latest.Protocol)
+		}
+	})
 }
 
 func TestMonitorHistoricalWriter_WriteHourly(t *testing.T) {
@@ -201,3 +233,69 @@ func TestMonitorHistoricalWriter_WriteDaily(t *testing.T) {
 		}
 	})
 }
+
+func TestMonitorHistoricalWriter_Compression(t *testing.T) {
+	if database == nil {
+		t.Skip("Database is nil")
+		return
+	}
+
+	now := time.Now().Truncate(time.Second)
+	written := []struct {
+		compression main.Compression
+		historical  main.MonitorHistorical
+	}{
+		// Written by a previous version, or with the compression disabled
+		{compression: main.CompressionNone, historical: main.MonitorHistorical{MonitorID: "historical-compression-test", Status: main.MonitorStatusSuccess, Latency: 10, Timestamp: now.Add(-time.Minute * 3), Protocol: "HTTP/1.1", ResponseSize: 512}},
+		{compression: main.CompressionGzip, historical: main.MonitorHistorical{MonitorID: "historical-compression-test", Status: main.MonitorStatusFailure, Latency: 20, Timestamp: now.Add(-time.Minute * 2), ErrorCategory: main.ErrorCategoryTimeout, Retries: 2}},
+		{compression: main.CompressionZstd, historical: main.MonitorHistorical{MonitorID: "historical-compression-test", Status: main.MonitorStatusDegraded, Latency: 30, Timestamp: now.Add(-time.Minute), Protocol: "HTTP/2.0", TlsVersion: "TLS 1.3", TlsExpiryDays: 30, Maintenance: true}},
+	}
+
+	for _, w := range written {
+		writer := main.NewMonitorHistoricalWriterWithOptions(database, main.MonitorHistoricalWriterOptions{Compression: w.compression})
+		if err := writer.Write(context.Background(), w.historical); err != nil {
+			t.Fatalf("unexpected error writing %q historical data: %v", w.compression, err)
+		}
+	}
+
+	var compressed int
+	err := database.QueryRowContext(context.Background(), "SELECT COUNT(*) FROM monitor_historical WHERE monitor_id = ? AND timestamp >= ? AND compressed_data IS NOT NULL AND latency = 0", "historical-compression-test", now.Add(-time.Minute*3)).Scan(&compressed)
+	if err != nil {
+		t.Fatalf("unexpected error counting compressed historical data: %v", err)
+	}
+
+	if compressed != 2 {
+		t.Errorf("expected 2 compressed records, got %d", compressed)
+	}
+
+	reader := main.NewMonitorHistoricalReader(database)
+	historical, err := reader.ReadRawHistoricalRange(context.Background(), "historical-compression-test", now.Add(-time.Minute*3), now)
+	if err != nil {
+		t.Fatalf("unexpected error reading historical data: %v", err)
+	}
+
+	if len(historical) != len(written) {
+		t.Fatalf("expected %d records, got %+v", len(written), historical)
+	}
+
+	for i, w := range written {
+		actual := historical[i]
+		if !actual.Timestamp.Equal(w.historical.Timestamp) {
+			t.Errorf("expected timestamp %s of the %q record, got %s", w.historical.Timestamp, w.compression, actual.Timestamp)
+		}
+
+		actual.Timestamp = w.historical.Timestamp
+		if actual != w.historical {
+			t.Errorf("expected the %q record to be read back as %+v, got %+v", w.compression, w.historical, actual)
+		}
+	}
+
+	latest, err := reader.ReadRawLatest(context.Background(), "historical-compression-test")
+	if err != nil {
+		t.Fatalf("unexpected error reading the latest historical data: %v", err)
+	}
+
+	if latest.TlsVersion != "TLS 1.3" || latest.Latency != 30 || !latest.Maintenance {
+		t.Errorf("expected the latest record to be decompressed, got %+v", latest)
+	}
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sync"
+
+	"github.com/rs/zerolog/log"
+)
+
+// PauseStore holds the monitors that are paused at runtime through the API, on top of the monitors that are
+// disabled in the configuration. The pauses are written to the database, so they survive a restart.
+type PauseStore struct {
+	mu     sync.RWMutex
+	db     *sql.DB
+	paused map[string]bool
+}
+
+// NewPauseStore creates a store that persists the pauses to db. The pauses are only kept in memory if db is nil.
+func NewPauseStore(db *sql.DB) *PauseStore {
+	return &PauseStore{db: db, paused: make(map[string]bool)}
+}
+
+// Load reads the pauses that were persisted by a previous run, replacing the pauses in memory.
+func (s *PauseStore) Load(ctx context.Context) error {
+	if s.db == nil {
+		return nil
+	}
+
+	conn, err := s.db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get database connection: %w", err)
+	}
+	defer func() {
+		err := conn.Close()
+		if err != nil {
+			log.Warn().Stack().Err(err).Msg("Failed to close connection")
+		}
+	}()
+
+	rows, err := conn.QueryContext(ctx, "SELECT monitor_id FROM monitor_pause")
+	if err != nil {
+		return fmt.Errorf("failed to read paused monitors: %w", err)
+	}
+	defer func() {
+		err := rows.Close()
+		if err != nil {
+			log.Warn().Stack().Err(err).Msg("Failed to close rows")
+		}
+	}()
+
+	paused := make(map[string]bool)
+	for rows.Next() {
+		var monitorId string
+		if err := rows.Scan(&monitorId); err != nil {
+			return fmt.Errorf("failed to read paused monitors: %w", err)
+		}
+
+		paused[monitorId] = true
+	}
+
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to read paused monitors: %w", err)
+	}
+
+	s.mu.Lock()
+	s.paused = paused
+	s.mu.Unlock()
+	return nil
+}
+
+// Paused reports whether the monitor is paused.
+func (s *PauseStore) Paused(monitorId string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.paused[monitorId]
+}
+
+// Pause marks the monitor as paused. Pausing a paused monitor does nothing.
+func (s *PauseStore) Pause(ctx context.Context, monitorId string) error {
+	return s.set(ctx, monitorId, true)
+}
+
+// Resume removes the pause of the monitor. Resuming a monitor that is not paused does nothing.
+func (s *PauseStore) Resume(ctx context.Context, monitorId string) error {
+	return s.set(ctx, monitorId, false)
+}
+
+func (s *PauseStore) set(ctx context.Context, monitorId string, paused bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.db != nil {
+		query := "DELETE FROM monitor_pause WHERE monitor_id = ?"
+		if paused {
+			query = "INSERT INTO monitor_pause (monitor_id) VALUES (?) ON CONFLICT DO NOTHING"
+		}
+
+		if _, err := s.db.ExecContext(ctx, query, monitorId); err != nil {
+			return fmt.Errorf("failed to persist the pause of %s: %w", monitorId, err)
+		}
+	}
+
+	if paused {
+		s.paused[monitorId] = true
+	} else {
+		delete(s.paused, monitorId)
+	}
+
+	return nil
+}
@@ -2,6 +2,8 @@ package main
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"math"
 	"time"
 
@@ -11,15 +13,67 @@ import (
 type Processor struct {
 	historicalWriter *MonitorHistoricalWriter
 	historicalReader *MonitorHistoricalReader
+	centralBroker    *Broker[MonitorHistorical]
+	metrics          *Metrics
+	alertDebouncer   *AlertDebouncer
+	monitors         *MonitorRegistry
+	statuses         *statusTracker
+	escalations      *EscalationTracker
 
 	telegramAlertProvider Alerter
 	discordAlertProvider  Alerter
+	webhookAlertProvider  Alerter
+	// escalationAlertProvider receives the alerts of the escalated outages.
+	escalationAlertProvider Alerter
+}
+
+// ProcessorConfig specifies the dependencies of a Processor. Every field is optional, except the writer and
+// the reader of the historical data.
+type ProcessorConfig struct {
+	HistoricalWriter *MonitorHistoricalWriter
+	HistoricalReader *MonitorHistoricalReader
+	CentralBroker    *Broker[MonitorHistorical]
+	Metrics          *Metrics
+	AlertDebouncer   *AlertDebouncer
+	// MonitorRegistry is used to resolve the depends_on chain of the monitors. Dependencies are ignored if it's nil.
+	MonitorRegistry *MonitorRegistry
+
+	TelegramAlertProvider Alerter
+	DiscordAlertProvider  Alerter
+	WebhookAlertProvider  Alerter
+	// EscalationAlertProvider receives the alerts of the outages that are escalated by the escalation policy of
+	// the monitor, usually a dispatcher created by NewEscalationDispatcher. Outages are not escalated if it's nil.
+	EscalationAlertProvider Alerter
+}
+
+func NewProcessor(config ProcessorConfig) *Processor {
+	return &Processor{
+		historicalWriter:        config.HistoricalWriter,
+		historicalReader:        config.HistoricalReader,
+		centralBroker:           config.CentralBroker,
+		metrics:                 config.Metrics,
+		alertDebouncer:          config.AlertDebouncer,
+		monitors:                config.MonitorRegistry,
+		statuses:                newStatusTracker(),
+		escalations:             NewEscalationTracker(),
+		telegramAlertProvider:   config.TelegramAlertProvider,
+		discordAlertProvider:    config.DiscordAlertProvider,
+		webhookAlertProvider:    config.WebhookAlertProvider,
+		escalationAlertProvider: config.EscalationAlertProvider,
+	}
 }
 
 func (m *Processor) ProcessResponse(response Response) {
+	if m.metrics != nil {
+		m.metrics.Observe(response)
+	}
+
 	status := MonitorStatusFailure
 	if response.Success {
 		status = MonitorStatusSuccess
+		if response.Degraded {
+			status = MonitorStatusDegraded
+		}
 	}
 
 	uniqueId := response.Monitor.UniqueID
@@ -28,15 +82,47 @@ func (m *Processor) ProcessResponse(response Response) {
 		uniqueId = uniqueId[:255]
 	}
 
+	historical := MonitorHistorical{
+		MonitorID:     uniqueId,
+		Status:        status,
+		Latency:       response.RequestDuration,
+		Timestamp:     response.Timestamp,
+		PacketLoss:    response.PacketLoss,
+		TlsExpiryDays: response.TlsExpiryDays,
+		ResponseSize:  response.ResponseSize,
+		ErrorCategory: response.ErrorCategory,
+		Protocol:      response.Protocol,
+		TlsVersion:    response.TlsVersion,
+		Retries:       response.Retries,
+		Maintenance:   response.Monitor.InMaintenance(response.Timestamp),
+	}
+
+	// A failing child of a monitor that is down is most likely failing because of it
+	if status != MonitorStatusSuccess {
+		if _, down := m.downAncestor(response.Monitor); down {
+			historical.Suppressed = true
+		}
+	}
+
+	if m.statuses != nil {
+		m.statuses.Set(uniqueId, status)
+	}
+
+	// Seed the alert state from the previous status before writing the current one, so status changes
+	// that happened across restarts are still alerted.
+	if m.alertDebouncer != nil && !m.alertDebouncer.Known(uniqueId) {
+		previousHistorical, err := m.historicalReader.ReadRawLatest(context.Background(), uniqueId)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			log.Error().Err(err).Msg("failed to get raw latest historical data")
+		} else if err == nil {
+			m.alertDebouncer.Seed(uniqueId, previousHistorical.Status, previousHistorical.Timestamp)
+		}
+	}
+
 	attemptRemaining := 3
 	attemptedEntries := 0
 	for attemptRemaining > 0 {
-		err := m.historicalWriter.Write(context.Background(), MonitorHistorical{
-			MonitorID: uniqueId,
-			Status:    status,
-			Latency:   response.RequestDuration,
-			Timestamp: response.Timestamp,
-		})
+		err := m.historicalWriter.Write(context.Background(), historical)
 		if err != nil {
 			attemptedEntries++
 			if attemptRemaining == 0 {
@@ -56,42 +142,101 @@ func (m *Processor) ProcessResponse(response Response) {
 		break
 	}
 
+	if m.centralBroker != nil {
+		err := m.centralBroker.Publish(uniqueId, &BrokerMessage[MonitorHistorical]{Body: historical})
+		if err != nil {
+			log.Error().Err(err).Msg("failed to publish historical data")
+		}
+	}
+
+	// Only alert on status changes that persist for the configured amount of consecutive checks.
+	// Checks during maintenance or while a parent is down aren't observed, so a monitor that is still down
+	// afterward is alerted right away.
+	if m.alertDebouncer == nil || historical.Maintenance || historical.Suppressed {
+		return
+	}
+
+	event, ok := m.alertDebouncer.Observe(uniqueId, status, response.Timestamp, response.Monitor.AlertAfter)
+	if ok {
+		switch event.Type {
+		case AlertEventTypeDown:
+			m.escalations.Start(uniqueId, response.Timestamp)
+		default:
+			m.escalations.Stop(uniqueId)
+		}
+	}
+
+	alertMessage := AlertMessage{
+		Success:       response.Success,
+		Status:        status,
+		EventType:     event.Type,
+		Downtime:      event.Downtime,
+		MonitorID:     uniqueId,
+		MonitorName:   response.Monitor.Name,
+		StatusCode:    response.StatusCode,
+		Timestamp:     response.Timestamp,
+		Latency:       response.RequestDuration,
+		TlsExpiryDays: response.TlsExpiryDays,
+		Error:         response.Error,
+	}
+
+	if ok {
+		go m.sendAlert(response.Monitor, alertMessage)
+		return
+	}
+
+	// The monitor is still down, the alert may have to be repeated or escalated
+	if status != MonitorStatusFailure || response.Monitor.Escalation == nil {
+		return
+	}
+
+	escalation, ok := m.escalations.Observe(uniqueId, response.Timestamp, *response.Monitor.Escalation)
+	if !ok {
+		return
+	}
+
+	alertMessage.EventType = escalation.Type
+	alertMessage.Downtime = escalation.Downtime
+
 	go func() {
-		if m.telegramAlertProvider == nil && m.discordAlertProvider == nil {
-			log.Warn().Msg("no alert providers are set")
-			return
+		if escalation.Type == AlertEventTypeRepeat {
+			m.sendAlert(response.Monitor, alertMessage)
 		}
 
-		alertMessage := AlertMessage{
-			Success:     response.Success,
-			MonitorID:   uniqueId,
-			MonitorName: response.Monitor.Name,
-			StatusCode:  response.StatusCode,
-			Timestamp:   response.Timestamp,
-			Latency:     response.RequestDuration,
+		if escalation.Escalated && m.escalationAlertProvider != nil {
+			if err := m.escalationAlertProvider.Send(context.Background(), alertMessage); err != nil {
+				log.Error().Err(err).Msg("failed to send escalation webhook alert")
+			}
 		}
+	}()
+}
+
+// sendAlert sends the alert to the webhook destinations and the alert provider of the monitor.
+func (m *Processor) sendAlert(monitor Monitor, alertMessage AlertMessage) {
+	if m.telegramAlertProvider == nil && m.discordAlertProvider == nil && m.webhookAlertProvider == nil {
+		log.Warn().Msg("no alert providers are set")
+		return
+	}
 
-		lastRawHistorical, err := m.historicalReader.ReadRawLatest(context.Background(), uniqueId)
+	if m.webhookAlertProvider != nil {
+		err := m.webhookAlertProvider.Send(context.Background(), alertMessage)
 		if err != nil {
-			log.Error().Err(err).Msg("failed to get raw latest historical data")
+			log.Error().Err(err).Msg("failed to send webhook alert")
+		}
+	}
+
+	switch monitor.AlertProvider {
+	case AlertProviderTypeTelegram, AlertProviderTypeUnspecified:
+		if m.telegramAlertProvider == nil {
+			log.Warn().Msg("telegram alert provider is not set")
 			return
 		}
 
-		if lastRawHistorical.Status != status {
-			switch response.Monitor.AlertProvider {
-			case AlertProviderTypeTelegram, AlertProviderTypeUnspecified:
-				if m.telegramAlertProvider == nil {
-					log.Warn().Msg("telegram alert provider is not set")
-					return
-				}
-
-				err := m.telegramAlertProvider.Send(context.Background(), alertMessage)
-				if err != nil {
-					log.Error().Err(err).Msg("failed to send alert")
-				}
-			case AlertProviderTypeDiscord:
-				panic("TODO: Implement me!")
-			}
+		err := m.telegramAlertProvider.Send(context.Background(), alertMessage)
+		if err != nil {
+			log.Error().Err(err).Msg("failed to send alert")
 		}
-	}()
-}
\ No newline at end of file
+	case AlertProviderTypeDiscord:
+		panic("TODO: Implement me!")
+	}
+}
@@ -0,0 +1,62 @@
+package main
+
+import "sync"
+
+// MonitorRegistry holds the list of configured monitors, which can be replaced when the configuration is reloaded.
+type MonitorRegistry struct {
+	mu       sync.RWMutex
+	monitors []Monitor
+}
+
+func NewMonitorRegistry(monitors []Monitor) *MonitorRegistry {
+	return &MonitorRegistry{monitors: monitors}
+}
+
+// List returns the configured monitors. The returned slice must not be modified.
+func (r *MonitorRegistry) List() []Monitor {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.monitors
+}
+
+// IDs returns the unique IDs of the configured monitors.
+func (r *MonitorRegistry) IDs() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	ids := make([]string, 0, len(r.monitors))
+	for _, monitor := range r.monitors {
+		ids = append(ids, monitor.UniqueID)
+	}
+
+	return ids
+}
+
+// Get returns the monitor with the given unique ID.
+func (r *MonitorRegistry) Get(id string) (Monitor, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, monitor := range r.monitors {
+		if monitor.UniqueID == id {
+			return monitor, true
+		}
+	}
+
+	return Monitor{}, false
+}
+
+// Contains returns whether a monitor with the given unique ID is configured.
+func (r *MonitorRegistry) Contains(id string) bool {
+	_, ok := r.Get(id)
+	return ok
+}
+
+// Replace replaces the configured monitors.
+func (r *MonitorRegistry) Replace(monitors []Monitor) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.monitors = monitors
+}
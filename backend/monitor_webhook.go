@@ -0,0 +1,86 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+)
+
+// MonitorWebhook is the per-monitor webhook override. In the configuration file, it can either be a boolean,
+// a single webhook object, or a list of webhook objects.
+type MonitorWebhook struct {
+	// Disabled disables webhook alerts for the monitor.
+	Disabled bool
+	// Webhooks replaces the global webhook destinations for the monitor. If it's empty, and the override
+	// is not disabled, the global webhook destinations are used.
+	Webhooks []Webhook
+}
+
+func (w *MonitorWebhook) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	switch {
+	case len(data) == 0:
+		return nil
+	case data[0] == '[':
+		return json.Unmarshal(data, &w.Webhooks)
+	case data[0] == '{':
+		var webhook Webhook
+		if err := json.Unmarshal(data, &webhook); err != nil {
+			return err
+		}
+
+		w.Webhooks = []Webhook{webhook}
+		return nil
+	default:
+		var enabled bool
+		if err := json.Unmarshal(data, &enabled); err != nil {
+			return fmt.Errorf("webhook must be a boolean, an object, or a list of objects")
+		}
+
+		w.Disabled = !enabled
+		return nil
+	}
+}
+
+func (w *MonitorWebhook) UnmarshalYAML(value *yaml.Node) error {
+	switch value.Kind {
+	case yaml.SequenceNode:
+		return value.Decode(&w.Webhooks)
+	case yaml.MappingNode:
+		var webhook Webhook
+		if err := value.Decode(&webhook); err != nil {
+			return err
+		}
+
+		w.Webhooks = []Webhook{webhook}
+		return nil
+	default:
+		var enabled bool
+		if err := value.Decode(&enabled); err != nil {
+			return fmt.Errorf("webhook must be a boolean, a mapping, or a sequence of mappings")
+		}
+
+		w.Disabled = !enabled
+		return nil
+	}
+}
+
+func (w *MonitorWebhook) UnmarshalTOML(data any) error {
+	switch value := data.(type) {
+	case bool:
+		w.Disabled = !value
+		return nil
+	case map[string]any, []map[string]any, []any:
+		// The decoded TOML values are plain Go values, the JSON tags of Webhook match its TOML tags
+		encoded, err := json.Marshal(value)
+		if err != nil {
+			return err
+		}
+
+		return w.UnmarshalJSON(encoded)
+	default:
+		return fmt.Errorf("webhook must be a boolean, a table, or an array of tables")
+	}
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"sort"
+	"time"
+)
+
+// Outage is a detected period of consecutive failed checks of a monitor. It is different from an Incident,
+// which is submitted manually by the operator.
+type Outage struct {
+	MonitorID string     `json:"monitor_id"`
+	StartTime time.Time  `json:"start_time"`
+	EndTime   *time.Time `json:"end_time"`
+	// Duration specifies the length of the outage in seconds. For an ongoing outage, it's calculated until now.
+	Duration int64 `json:"duration"`
+}
+
+// GroupOutages groups consecutive failed historical entries into outages. Up to flapTolerance consecutive
+// successful entries in between failures are tolerated, and won't close the outage. The last outage
+// is considered ongoing (has a nil EndTime) if it hasn't been closed yet.
+func GroupOutages(historical []MonitorHistorical, flapTolerance int) []Outage {
+	sorted := make([]MonitorHistorical, len(historical))
+	copy(sorted, historical)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Timestamp.Before(sorted[j].Timestamp)
+	})
+
+	var outages []Outage
+	var current *Outage
+	// recoveredAt is the timestamp of the first successful entry after the latest failure
+	var recoveredAt time.Time
+	var consecutiveUp int
+
+	for _, h := range sorted {
+		if h.Status == MonitorStatusFailure {
+			if current == nil {
+				current = &Outage{MonitorID: h.MonitorID, StartTime: h.Timestamp}
+			}
+			consecutiveUp = 0
+			continue
+		}
+
+		if current == nil {
+			continue
+		}
+
+		if consecutiveUp == 0 {
+			recoveredAt = h.Timestamp
+		}
+		consecutiveUp++
+
+		if consecutiveUp > flapTolerance {
+			endTime := recoveredAt
+			current.EndTime = &endTime
+			current.Duration = int64(endTime.Sub(current.StartTime).Seconds())
+			outages = append(outages, *current)
+			current = nil
+			consecutiveUp = 0
+		}
+	}
+
+	if current != nil {
+		current.Duration = int64(time.Since(current.StartTime).Seconds())
+		outages = append(outages, *current)
+	}
+
+	return outages
+}
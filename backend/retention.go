@@ -0,0 +1,166 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// RetentionPolicy specifies how long each kind of historical data is kept. A zero duration keeps the data forever.
+type RetentionPolicy struct {
+	Raw    time.Duration
+	Hourly time.Duration
+	Daily  time.Duration
+}
+
+// RetentionPolicy parses the retention durations of the configuration file.
+func (c ConfigurationFile) RetentionPolicy() (RetentionPolicy, error) {
+	var policy RetentionPolicy
+	var err error
+
+	policy.Raw, err = ParseRetention(c.RawRetention)
+	if err != nil {
+		return RetentionPolicy{}, fmt.Errorf("invalid raw_retention: %w", err)
+	}
+
+	policy.Hourly, err = ParseRetention(c.HourlyRetention)
+	if err != nil {
+		return RetentionPolicy{}, fmt.Errorf("invalid hourly_retention: %w", err)
+	}
+
+	policy.Daily, err = ParseRetention(c.DailyRetention)
+	if err != nil {
+		return RetentionPolicy{}, fmt.Errorf("invalid daily_retention: %w", err)
+	}
+
+	return policy, nil
+}
+
+// ParseRetention parses a retention duration. On top of the units of time.ParseDuration, it accepts whole
+// days ("7d"), weeks ("2w"), and years of 365 days ("2y"). An empty value returns zero.
+func ParseRetention(value string) (time.Duration, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, nil
+	}
+
+	var unit time.Duration
+	switch value[len(value)-1] {
+	case 'd':
+		unit = time.Hour * 24
+	case 'w':
+		unit = time.Hour * 24 * 7
+	case 'y':
+		unit = time.Hour * 24 * 365
+	}
+
+	var duration time.Duration
+	if unit > 0 {
+		amount, err := strconv.Atoi(value[:len(value)-1])
+		if err != nil {
+			return 0, fmt.Errorf("%q is not a valid duration", value)
+		}
+
+		duration = time.Duration(amount) * unit
+	} else {
+		var err error
+		duration, err = time.ParseDuration(value)
+		if err != nil {
+			return 0, fmt.Errorf("%q is not a valid duration", value)
+		}
+	}
+
+	if duration <= 0 {
+		return 0, fmt.Errorf("%q must be greater than 0", value)
+	}
+
+	return duration, nil
+}
+
+// PruneResult is the amount of rows that were deleted from each historical table.
+type PruneResult struct {
+	Raw    int64
+	Hourly int64
+	Daily  int64
+}
+
+// RetentionPruner periodically deletes historical data that is older than the retention policy.
+type RetentionPruner struct {
+	db       *sql.DB
+	policy   RetentionPolicy
+	interval time.Duration
+}
+
+// NewRetentionPruner creates a pruner that runs on every interval. Interval defaults to an hour.
+func NewRetentionPruner(db *sql.DB, policy RetentionPolicy, interval time.Duration) *RetentionPruner {
+	if interval <= 0 {
+		interval = time.Hour
+	}
+
+	return &RetentionPruner{db: db, policy: policy, interval: interval}
+}
+
+// Run prunes the historical data right away and then on every interval until the context is cancelled.
+func (p *RetentionPruner) Run(ctx context.Context) {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		result, err := p.Prune(ctx, time.Now())
+		if err != nil {
+			log.Error().Err(err).Msg("failed to prune historical data")
+		} else {
+			log.Debug().
+				Int64("raw", result.Raw).
+				Int64("hourly", result.Hourly).
+				Int64("daily", result.Daily).
+				Msg("pruned historical data")
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Prune deletes the historical data that is older than the retention policy relative to now.
+func (p *RetentionPruner) Prune(ctx context.Context, now time.Time) (PruneResult, error) {
+	var result PruneResult
+
+	tables := []struct {
+		name      string
+		retention time.Duration
+		deleted   *int64
+	}{
+		{name: "monitor_historical", retention: p.policy.Raw, deleted: &result.Raw},
+		{name: "monitor_historical_hourly_aggregate", retention: p.policy.Hourly, deleted: &result.Hourly},
+		{name: "monitor_historical_daily_aggregate", retention: p.policy.Daily, deleted: &result.Daily},
+	}
+
+	for _, table := range tables {
+		if table.retention <= 0 {
+			continue
+		}
+
+		res, err := p.db.ExecContext(ctx, "DELETE FROM "+table.name+" WHERE timestamp < ?", now.Add(-table.retention))
+		if err != nil {
+			return result, fmt.Errorf("failed to prune %s: %w", table.name, err)
+		}
+
+		deleted, err := res.RowsAffected()
+		if err != nil {
+			return result, fmt.Errorf("failed to get the pruned rows of %s: %w", table.name, err)
+		}
+
+		*table.deleted = deleted
+	}
+
+	return result, nil
+}
@@ -0,0 +1,155 @@
+package main_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	main "semyi"
+)
+
+func TestParseRetention(t *testing.T) {
+	testCases := []struct {
+		value  string
+		expect time.Duration
+		err    bool
+	}{
+		{value: "", expect: 0},
+		{value: "7d", expect: time.Hour * 24 * 7},
+		{value: "2w", expect: time.Hour * 24 * 14},
+		{value: "2y", expect: time.Hour * 24 * 365 * 2},
+		{value: "36h", expect: time.Hour * 36},
+		{value: "0d", err: true},
+		{value: "-1d", err: true},
+		{value: "1.5d", err: true},
+		{value: "forever", err: true},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.value, func(t *testing.T) {
+			duration, err := main.ParseRetention(testCase.value)
+			if testCase.err {
+				if err == nil {
+					t.Errorf("expected an error, got %s", duration)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if duration != testCase.expect {
+				t.Errorf("expected %s, got %s", testCase.expect, duration)
+			}
+		})
+	}
+}
+
+func TestValidateConfig_Retention(t *testing.T) {
+	if err := main.ValidateConfig(main.ConfigurationFile{RawRetention: "7d", HourlyRetention: "90d", DailyRetention: "2y"}); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	if err := main.ValidateConfig(main.ConfigurationFile{RawRetention: "36h"}); err == nil {
+		t.Error("expected an error for a raw retention shorter than the downsampled buckets")
+	}
+
+	if err := main.ValidateConfig(main.ConfigurationFile{DailyRetention: "forever"}); err == nil {
+		t.Error("expected an error for an invalid duration")
+	}
+}
+
+func TestRetentionPruner_Prune(t *testing.T) {
+	// The retention is long enough to leave the data of the other tests alone
+	policy := main.RetentionPolicy{
+		Raw:    time.Hour * 24 * 365,
+		Hourly: time.Hour * 24 * 365 * 2,
+		Daily:  time.Hour * 24 * 365 * 3,
+	}
+	now := time.Now().Truncate(time.Second)
+	old := main.MonitorHistorical{MonitorID: "retention-test", Status: main.MonitorStatusSuccess, Latency: 10}
+	recent := old
+
+	writer := main.NewMonitorHistoricalWriter(database)
+	reader := main.NewMonitorHistoricalReader(database)
+
+	old.Timestamp = now.Add(-policy.Raw - time.Hour)
+	recent.Timestamp = now.Add(-time.Hour)
+	for _, historical := range []main.MonitorHistorical{old, recent} {
+		if err := writer.Write(context.Background(), historical); err != nil {
+			t.Fatalf("unexpected error writing raw data: %v", err)
+		}
+	}
+
+	old.Timestamp = now.Add(-policy.Hourly - time.Hour).Truncate(time.Hour)
+	recent.Timestamp = now.Add(-policy.Raw - time.Hour).Truncate(time.Hour)
+	for _, historical := range []main.MonitorHistorical{old, recent} {
+		if err := writer.WriteHourly(context.Background(), historical); err != nil {
+			t.Fatalf("unexpected error writing hourly data: %v", err)
+		}
+	}
+
+	old.Timestamp = now.Add(-policy.Daily - time.Hour*24).Truncate(time.Hour * 24)
+	recent.Timestamp = now.Add(-policy.Hourly - time.Hour*24).Truncate(time.Hour * 24)
+	for _, historical := range []main.MonitorHistorical{old, recent} {
+		if err := writer.WriteDaily(context.Background(), historical); err != nil {
+			t.Fatalf("unexpected error writing daily data: %v", err)
+		}
+	}
+
+	pruner := main.NewRetentionPruner(database, policy, 0)
+	result, err := pruner.Prune(context.Background(), now)
+	if err != nil {
+		t.Fatalf("unexpected error pruning: %v", err)
+	}
+
+	if result.Raw != 1 || result.Hourly != 1 || result.Daily != 1 {
+		t.Errorf("expected a single pruned row of each table, got %+v", result)
+	}
+
+	raw, err := reader.ReadRawHistorical(context.Background(), "retention-test")
+	if err != nil {
+		t.Fatalf("unexpected error reading raw data: %v", err)
+	}
+
+	if len(raw) != 1 || !raw[0].Timestamp.Equal(now.Add(-time.Hour)) {
+		t.Errorf("expected only the recent raw data to be kept, got %+v", raw)
+	}
+
+	hourly, err := reader.ReadHourlyHistorical(context.Background(), "retention-test")
+	if err != nil {
+		t.Fatalf("unexpected error reading hourly data: %v", err)
+	}
+
+	if len(hourly) != 1 {
+		t.Errorf("expected only the recent hourly data to be kept, got %+v", hourly)
+	}
+
+	daily, err := reader.ReadDailyHistorical(context.Background(), "retention-test")
+	if err != nil {
+		t.Fatalf("unexpected error reading daily data: %v", err)
+	}
+
+	if len(daily) != 1 {
+		t.Errorf("expected only the recent daily data to be kept, got %+v", daily)
+	}
+}
+
+func TestRetentionPruner_Run(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	done := make(chan struct{})
+	go func() {
+		main.NewRetentionPruner(database, main.RetentionPolicy{}, time.Millisecond*10).Run(ctx)
+		close(done)
+	}()
+
+	cancel()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second * 5):
+		t.Fatal("expected the pruner to stop after the context is cancelled")
+	}
+}
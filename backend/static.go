@@ -0,0 +1,74 @@
+package main
+
+import (
+	"embed"
+	"errors"
+	"io/fs"
+	"net/http"
+	"path"
+	"strings"
+)
+
+// embeddedStatic holds the built frontend. The repository only has a placeholder index.html, the frontend
+// build is copied into the dist directory before the binary is built.
+//
+//go:embed all:dist
+var embeddedStatic embed.FS
+
+// staticFileSystem returns the files of the dashboard. They are read from staticPath on disk when it's set,
+// so the frontend can be rebuilt without rebuilding the binary, otherwise the embedded files are served.
+func staticFileSystem(staticPath string) (http.FileSystem, error) {
+	if staticPath != "" {
+		return http.Dir(staticPath), nil
+	}
+
+	dist, err := fs.Sub(embeddedStatic, "dist")
+	if err != nil {
+		return nil, err
+	}
+
+	return http.FS(dist), nil
+}
+
+// spaHandler serves the dashboard files, and serves index.html for the paths that don't match a file, so the
+// client-side routes like /monitor/foo can be linked to. Missing assets, the paths with a file extension,
+// and the API paths are still not found.
+func spaHandler(files http.FileSystem) http.Handler {
+	fileServer := http.FileServer(files)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name := path.Clean("/" + r.URL.Path)
+		file, err := files.Open(name)
+		if err == nil {
+			_ = file.Close()
+		}
+
+		// Anything but a missing file, e.g. a permission error, is left to the file server
+		if !errors.Is(err, fs.ErrNotExist) {
+			fileServer.ServeHTTP(w, r)
+			return
+		}
+
+		if path.Ext(name) != "" || name == "/api" || strings.HasPrefix(name, "/api/") {
+			http.NotFound(w, r)
+			return
+		}
+
+		index, err := files.Open("/index.html")
+		if err != nil {
+			http.NotFound(w, r)
+			return
+		}
+		defer func() {
+			_ = index.Close()
+		}()
+
+		stat, err := index.Stat()
+		if err != nil {
+			http.NotFound(w, r)
+			return
+		}
+
+		http.ServeContent(w, r, "index.html", stat.ModTime(), index)
+	})
+}
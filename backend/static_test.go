@@ -0,0 +1,116 @@
+package main_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	main "semyi"
+)
+
+func TestServer_StaticFiles(t *testing.T) {
+	t.Run("Should serve the embedded index without a static path", func(t *testing.T) {
+		server, err := main.NewServer(main.ServerConfig{
+			MonitorHistoricalReader: main.NewMonitorHistoricalReader(database),
+			CentralBroker:           main.NewBroker[main.MonitorHistorical](),
+		})
+		if err != nil {
+			t.Fatalf("unexpected error creating server: %v", err)
+		}
+
+		recorder := httptest.NewRecorder()
+		server.Handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/", nil))
+		if recorder.Code != http.StatusOK {
+			t.Fatalf("expected status code %d, got %d", http.StatusOK, recorder.Code)
+		}
+
+		if contentType := recorder.Header().Get("Content-Type"); !strings.HasPrefix(contentType, "text/html") {
+			t.Errorf("expected an html content type, got %q", contentType)
+		}
+
+		embedded, err := os.ReadFile(filepath.Join("dist", "index.html"))
+		if err != nil {
+			t.Fatalf("unexpected error reading the embedded index: %v", err)
+		}
+
+		if body, _ := io.ReadAll(recorder.Body); string(body) != string(embedded) {
+			t.Errorf("expected the embedded index, got %q", body)
+		}
+	})
+
+	t.Run("Should serve the static path when set", func(t *testing.T) {
+		staticPath := t.TempDir()
+		if err := os.WriteFile(filepath.Join(staticPath, "index.html"), []byte("<p>from disk</p>"), 0o644); err != nil {
+			t.Fatalf("unexpected error writing index: %v", err)
+		}
+
+		server, err := main.NewServer(main.ServerConfig{
+			MonitorHistoricalReader: main.NewMonitorHistoricalReader(database),
+			CentralBroker:           main.NewBroker[main.MonitorHistorical](),
+			StaticPath:              staticPath,
+		})
+		if err != nil {
+			t.Fatalf("unexpected error creating server: %v", err)
+		}
+
+		recorder := httptest.NewRecorder()
+		server.Handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/", nil))
+		if recorder.Code != http.StatusOK {
+			t.Fatalf("expected status code %d, got %d", http.StatusOK, recorder.Code)
+		}
+
+		if body := recorder.Body.String(); body != "<p>from disk</p>" {
+			t.Errorf("expected the index from disk, got %q", body)
+		}
+	})
+
+	t.Run("Should serve the index for client-side routes", func(t *testing.T) {
+		staticPath := t.TempDir()
+		if err := os.WriteFile(filepath.Join(staticPath, "index.html"), []byte("<p>dashboard</p>"), 0o644); err != nil {
+			t.Fatalf("unexpected error writing index: %v", err)
+		}
+
+		if err := os.WriteFile(filepath.Join(staticPath, "app.js"), []byte("console.log(1)"), 0o644); err != nil {
+			t.Fatalf("unexpected error writing asset: %v", err)
+		}
+
+		server, err := main.NewServer(main.ServerConfig{
+			MonitorHistoricalReader: main.NewMonitorHistoricalReader(database),
+			CentralBroker:           main.NewBroker[main.MonitorHistorical](),
+			StaticPath:              staticPath,
+		})
+		if err != nil {
+			t.Fatalf("unexpected error creating server: %v", err)
+		}
+
+		tests := []struct {
+			path       string
+			wantStatus int
+			wantBody   string
+		}{
+			{path: "/some/spa/route", wantStatus: http.StatusOK, wantBody: "<p>dashboard</p>"},
+			{path: "/monitor/foo", wantStatus: http.StatusOK, wantBody: "<p>dashboard</p>"},
+			{path: "/app.js", wantStatus: http.StatusOK, wantBody: "console.log(1)"},
+			{path: "/missing.js", wantStatus: http.StatusNotFound},
+			{path: "/assets/missing.css", wantStatus: http.StatusNotFound},
+			{path: "/api", wantStatus: http.StatusNotFound},
+		}
+
+		for _, tt := range tests {
+			recorder := httptest.NewRecorder()
+			server.Handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, tt.path, nil))
+			if recorder.Code != tt.wantStatus {
+				t.Errorf("%s: expected status code %d, got %d", tt.path, tt.wantStatus, recorder.Code)
+				continue
+			}
+
+			if tt.wantBody != "" && recorder.Body.String() != tt.wantBody {
+				t.Errorf("%s: expected body %q, got %q", tt.path, tt.wantBody, recorder.Body.String())
+			}
+		}
+	})
+}
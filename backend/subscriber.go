@@ -4,41 +4,130 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"sync"
+	"sync/atomic"
 )
 
 type Subscriber struct {
 	subscribers []*BrokerSubscriber[MonitorHistorical]
-	ch          chan MonitorHistorical
+	ch          chan *BrokerMessage[MonitorHistorical]
+	done        chan struct{}
+	closeOnce   sync.Once
+	dropped     atomic.Uint64
+	registry    *SubscriberRegistry
+}
+
+// DefaultSubscriberBufferSize is the amount of events that are buffered for a subscriber that reads slowly.
+const DefaultSubscriberBufferSize = 64
+
+type SubscriberOptions struct {
+	// BufferSize specifies the amount of events that can be buffered before the oldest event is dropped.
+	// Defaults to DefaultSubscriberBufferSize.
+	BufferSize int
+	// OnDrop is called with the monitor id of the event that is dropped because the buffer is full. This is optional.
+	OnDrop func(monitorId string)
+	// Registry keeps track of the subscriber until it's closed. This is optional.
+	Registry *SubscriberRegistry
 }
 
 func NewSubscriber(centralBroker *Broker[MonitorHistorical], monitorIds ...string) (*Subscriber, error) {
+	return NewSubscriberWithOptions(centralBroker, SubscriberOptions{}, monitorIds...)
+}
+
+// NewSubscriberWithOptions creates a subscriber with a bounded buffer. Publishers are never blocked by a slow
+// subscriber, the oldest buffered event is dropped instead.
+func NewSubscriberWithOptions(centralBroker *Broker[MonitorHistorical], options SubscriberOptions, monitorIds ...string) (*Subscriber, error) {
+	if options.BufferSize <= 0 {
+		options.BufferSize = DefaultSubscriberBufferSize
+	}
+
 	if len(monitorIds) == 0 {
 		return &Subscriber{}, errors.New("no monitorIds provided")
 	}
 
-	ch := make(chan MonitorHistorical)
-	var subscribers []*BrokerSubscriber[MonitorHistorical]
+	if centralBroker == nil {
+		return &Subscriber{}, errors.New("central broker is nil")
+	}
+
+	s := &Subscriber{
+		ch:       make(chan *BrokerMessage[MonitorHistorical], options.BufferSize),
+		done:     make(chan struct{}),
+		registry: options.Registry,
+	}
+
 	// create a new BrokerSubscriber
 	for _, monitorId := range monitorIds {
 		subscriber, err := centralBroker.Subscribe(monitorId, func(event BrokerEvent[MonitorHistorical]) error {
-			// send the event to the channel
 			message := event.Message()
-			ch <- message.Body
-			return nil
+			for {
+				// send the event to the channel, unless the subscriber is already closed
+				select {
+				case s.ch <- message:
+					return nil
+				case <-s.done:
+					return nil
+				default:
+				}
+
+				// the buffer is full, make room by dropping the oldest event
+				select {
+				case dropped := <-s.ch:
+					s.dropped.Add(1)
+					if s.registry != nil {
+						s.registry.dropped.Add(1)
+					}
+					if options.OnDrop != nil {
+						options.OnDrop(dropped.Body.MonitorID)
+					}
+				default:
+				}
+			}
 		})
 		if err != nil {
+			_ = s.Close()
 			return &Subscriber{}, fmt.Errorf("failed to subscribe to monitor %s: %w", monitorId, err)
 		}
 
-		subscribers = append(subscribers, subscriber)
+		s.subscribers = append(s.subscribers, subscriber)
+	}
 
+	if s.registry != nil {
+		s.registry.add(s)
 	}
-	return &Subscriber{
-		subscribers: subscribers,
-		ch:          ch,
-	}, nil
+
+	return s, nil
+}
+
+// Dropped returns the amount of events that have been dropped because the subscriber read too slowly.
+func (s *Subscriber) Dropped() uint64 {
+	return s.dropped.Load()
 }
 
-func (s *Subscriber) Listen(ctx context.Context) <-chan MonitorHistorical {
+// Listen returns the channel where every event of the subscribed monitors will be sent to.
+// The same channel is returned on every call, so it's safe to be used repeatedly inside a select statement.
+func (s *Subscriber) Listen(ctx context.Context) <-chan *BrokerMessage[MonitorHistorical] {
 	return s.ch
 }
+
+// Close unsubscribes from every monitor topic. Publishers that are waiting to deliver an event
+// to this subscriber will be released.
+func (s *Subscriber) Close() error {
+	var err error
+	s.closeOnce.Do(func() {
+		if s.done != nil {
+			close(s.done)
+		}
+
+		for _, subscriber := range s.subscribers {
+			if e := subscriber.Unsubscribe(); e != nil {
+				err = e
+			}
+		}
+
+		if s.registry != nil {
+			s.registry.remove(s)
+		}
+	})
+
+	return err
+}
@@ -0,0 +1,63 @@
+package main_test
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	main "semyi"
+)
+
+func TestSubscriber_SlowConsumer(t *testing.T) {
+	broker := main.NewBroker[main.MonitorHistorical]()
+
+	var dropCallbacks atomic.Int32
+	subscriber, err := main.NewSubscriberWithOptions(broker, main.SubscriberOptions{
+		BufferSize: 4,
+		OnDrop: func(monitorId string) {
+			dropCallbacks.Add(1)
+		},
+	}, "slow-subscriber-test")
+	if err != nil {
+		t.Fatalf("unexpected error creating subscriber: %v", err)
+	}
+	defer subscriber.Close()
+
+	// Nobody is reading from the subscriber, publishing must still complete
+	published := make(chan struct{})
+	go func() {
+		defer close(published)
+		for i := 0; i < 10; i++ {
+			_ = broker.Publish("slow-subscriber-test", &main.BrokerMessage[main.MonitorHistorical]{
+				Body: main.MonitorHistorical{MonitorID: "slow-subscriber-test", Latency: int64(i)},
+			})
+		}
+	}()
+
+	select {
+	case <-published:
+	case <-time.After(time.Second * 5):
+		t.Fatal("publisher was blocked by a slow subscriber")
+	}
+
+	if subscriber.Dropped() != 6 {
+		t.Errorf("expected 6 dropped events, got %d", subscriber.Dropped())
+	}
+
+	if dropCallbacks.Load() != 6 {
+		t.Errorf("expected 6 drop callbacks, got %d", dropCallbacks.Load())
+	}
+
+	// The oldest events are dropped, the latest ones are kept in order
+	for want := int64(6); want < 10; want++ {
+		select {
+		case event := <-subscriber.Listen(context.Background()):
+			if event.Body.Latency != want {
+				t.Errorf("expected event %d, got %d", want, event.Body.Latency)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("expected event %d to be buffered", want)
+		}
+	}
+}
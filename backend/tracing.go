@@ -0,0 +1,145 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+// tracerName is the instrumentation scope of the spans that are recorded by semya.
+const tracerName = "semyi"
+
+// tracePropagator propagates the trace context in the W3C traceparent and tracestate headers.
+var tracePropagator = propagation.TraceContext{}
+
+// TracingConfig specifies where the spans are exported to.
+type TracingConfig struct {
+	// Endpoint specifies the URL of the OTLP/HTTP collector, e.g. "http://localhost:4318". Tracing is disabled
+	// if it's empty.
+	Endpoint string
+	// ServiceName specifies the service.name resource attribute of the spans. Defaults to "semya".
+	ServiceName string
+}
+
+// NewTracerProvider creates the provider of the check and request spans. It returns a no-op provider when
+// the endpoint is not configured. The returned function flushes the spans that are not exported yet,
+// it should be called on shutdown.
+func NewTracerProvider(ctx context.Context, config TracingConfig) (trace.TracerProvider, func(context.Context) error, error) {
+	if config.Endpoint == "" {
+		return noop.NewTracerProvider(), func(context.Context) error { return nil }, nil
+	}
+
+	if config.ServiceName == "" {
+		config.ServiceName = "semya"
+	}
+
+	exporter, err := otlptracehttp.New(ctx, otlptracehttp.WithEndpointURL(config.Endpoint))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create otlp exporter: %w", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(resource.NewSchemaless(semconv.ServiceName(config.ServiceName))),
+	)
+
+	return provider, provider.Shutdown, nil
+}
+
+// startCheckSpan starts the span of a single check of the monitor.
+func startCheckSpan(ctx context.Context, tracer trace.Tracer, monitor Monitor) (context.Context, trace.Span) {
+	return tracer.Start(ctx, "check "+string(monitor.Type),
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("monitor.id", monitor.UniqueID),
+			attribute.String("monitor.name", monitor.Name),
+			attribute.String("monitor.type", string(monitor.Type)),
+		),
+	)
+}
+
+// endCheckSpan records the result of the check on the span and ends it.
+func endCheckSpan(span trace.Span, response Response, err error) {
+	defer span.End()
+
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return
+	}
+
+	status := MonitorStatusFailure
+	if response.Success {
+		status = MonitorStatusSuccess
+		if response.Degraded {
+			status = MonitorStatusDegraded
+		}
+	}
+
+	span.SetAttributes(
+		attribute.String("monitor.status", status.String()),
+		attribute.Int64("monitor.latency", response.RequestDuration),
+	)
+
+	if response.StatusCode > 0 {
+		span.SetAttributes(semconv.HTTPResponseStatusCode(response.StatusCode))
+	}
+
+	if !response.Success {
+		span.SetAttributes(attribute.String("monitor.error_category", string(response.ErrorCategory)))
+		span.SetStatus(codes.Error, response.Error)
+	}
+}
+
+// tracingMiddleware records a server span for every request. The span continues the trace of the client
+// when the request has a traceparent header.
+func tracingMiddleware(provider trace.TracerProvider) func(http.Handler) http.Handler {
+	tracer := provider.Tracer(tracerName)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := tracePropagator.Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+			ctx, span := tracer.Start(ctx, r.Method,
+				trace.WithSpanKind(trace.SpanKindServer),
+				trace.WithAttributes(
+					semconv.HTTPRequestMethodKey.String(r.Method),
+					semconv.URLPath(r.URL.Path),
+				),
+			)
+			defer span.End()
+
+			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+			next.ServeHTTP(ww, r.WithContext(ctx))
+
+			// The route is only known once chi has matched it. The API router is not mounted, it matches the
+			// full path, so its pattern is the route on its own.
+			if routeContext := chi.RouteContext(ctx); routeContext != nil && len(routeContext.RoutePatterns) > 0 {
+				pattern := routeContext.RoutePatterns[len(routeContext.RoutePatterns)-1]
+				span.SetName(r.Method + " " + pattern)
+				span.SetAttributes(semconv.HTTPRoute(pattern))
+			}
+
+			status := ww.Status()
+			if status == 0 {
+				status = http.StatusOK
+			}
+
+			span.SetAttributes(semconv.HTTPResponseStatusCode(status))
+			if status >= 500 {
+				span.SetStatus(codes.Error, http.StatusText(status))
+			}
+		})
+	}
+}
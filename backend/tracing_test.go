@@ -0,0 +1,135 @@
+package main_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	main "semyi"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func spanAttributes(span tracetest.SpanStub) map[attribute.Key]attribute.Value {
+	attributes := make(map[attribute.Key]attribute.Value, len(span.Attributes))
+	for _, kv := range span.Attributes {
+		attributes[kv.Key] = kv.Value
+	}
+
+	return attributes
+}
+
+func TestWorkerManager_Tracing(t *testing.T) {
+	var traceparent atomic.Value
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceparent.Store(r.Header.Get("traceparent"))
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer testServer.Close()
+
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+
+	manager := main.NewWorkerManagerWithOptions(nil, nil, main.WorkerManagerOptions{TracerProvider: provider})
+	defer manager.Stop()
+
+	_, err := manager.Apply([]main.Monitor{{
+		UniqueID:     "tracing-test",
+		Name:         "Tracing Test",
+		Type:         main.MonitorTypeHTTP,
+		HttpEndpoint: testServer.URL,
+		Interval:     3600,
+		Timeout:      10,
+	}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	deadline := time.Now().Add(time.Second * 10)
+	for len(exporter.GetSpans()) == 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond * 10)
+	}
+
+	spans := exporter.GetSpans()
+	if len(spans) != 1 {
+		t.Fatalf("expected 1 check span, got %d", len(spans))
+	}
+
+	span := spans[0]
+	if span.Name != "check http" || span.SpanKind != trace.SpanKindClient {
+		t.Errorf("expected a client span named check http, got %s (%s)", span.Name, span.SpanKind)
+	}
+
+	attributes := spanAttributes(span)
+	if got := attributes["monitor.id"].AsString(); got != "tracing-test" {
+		t.Errorf("expected monitor.id tracing-test, got %q", got)
+	}
+
+	if got := attributes["monitor.status"].AsString(); got != "up" {
+		t.Errorf("expected monitor.status up, got %q", got)
+	}
+
+	if _, ok := attributes["monitor.latency"]; !ok {
+		t.Error("expected the monitor.latency attribute")
+	}
+
+	if span.Status.Code == codes.Error {
+		t.Errorf("expected the span not to be an error, got %s", span.Status.Description)
+	}
+
+	// The monitored service receives the trace context of the check
+	expected := "00-" + span.SpanContext.TraceID().String() + "-" + span.SpanContext.SpanID().String() + "-01"
+	if got := traceparent.Load(); got != expected {
+		t.Errorf("expected traceparent %s, got %v", expected, got)
+	}
+}
+
+func TestServer_Tracing(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+
+	server, err := main.NewServer(main.ServerConfig{
+		MonitorHistoricalReader: main.NewMonitorHistoricalReader(database),
+		CentralBroker:           main.NewBroker[main.MonitorHistorical](),
+		TracerProvider:          provider,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error creating server: %v", err)
+	}
+
+	request := httptest.NewRequest(http.MethodGet, "/api/status", nil)
+	request.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	recorder := httptest.NewRecorder()
+	server.Handler.ServeHTTP(recorder, request)
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("expected status code %d, got %d", http.StatusOK, recorder.Code)
+	}
+
+	spans := exporter.GetSpans()
+	if len(spans) != 1 {
+		t.Fatalf("expected 1 request span, got %d", len(spans))
+	}
+
+	span := spans[0]
+	if span.Name != "GET /api/status" || span.SpanKind != trace.SpanKindServer {
+		t.Errorf("expected a server span named GET /api/status, got %s (%s)", span.Name, span.SpanKind)
+	}
+
+	if got := span.SpanContext.TraceID().String(); got != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Errorf("expected the span to continue the trace of the client, got trace id %s", got)
+	}
+
+	if got := span.Parent.SpanID().String(); got != "00f067aa0ba902b7" {
+		t.Errorf("expected the parent span id 00f067aa0ba902b7, got %s", got)
+	}
+
+	if got := spanAttributes(span)["http.response.status_code"].AsInt64(); got != http.StatusOK {
+		t.Errorf("expected http.response.status_code %d, got %d", http.StatusOK, got)
+	}
+}
@@ -0,0 +1,29 @@
+package main
+
+import "math"
+
+type UptimeSummary struct {
+	// Uptime specifies the uptime percentage, rounded to two decimal places. It's nil when there's no data.
+	Uptime *float64 `json:"uptime"`
+	Total  int      `json:"total"`
+	Up     int      `json:"up"`
+}
+
+// CalculateUptime computes the uptime of the given historical data. Degraded entries are counted as up,
+// as the monitor is still reachable.
+func CalculateUptime(historical []MonitorHistorical) UptimeSummary {
+	var summary UptimeSummary
+	for _, h := range historical {
+		summary.Total++
+		if h.Status == MonitorStatusSuccess || h.Status == MonitorStatusDegraded {
+			summary.Up++
+		}
+	}
+
+	if summary.Total > 0 {
+		uptime := math.Round(float64(summary.Up)/float64(summary.Total)*100*100) / 100
+		summary.Uptime = &uptime
+	}
+
+	return summary
+}
@@ -0,0 +1,373 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+	"text/template"
+	"time"
+)
+
+// WebhookPayload is the JSON body that is sent to the configured webhook URL.
+type WebhookPayload struct {
+	MonitorID string `json:"monitorId"`
+	Endpoint  string `json:"endpoint"`
+	Status    string `json:"status"`
+	// Type is the event type, either "down", "up", "recovered", "repeat", or "escalated".
+	Type string `json:"type"`
+	// Downtime specifies how long the monitor has been down in seconds, only set for recovered, repeat, and
+	// escalated events.
+	Downtime        int64  `json:"downtime,omitempty"`
+	StatusCode      int    `json:"statusCode"`
+	RequestDuration int64  `json:"requestDuration"`
+	Timestamp       int64  `json:"timestamp"`
+	TlsExpiryDays   int    `json:"tlsExpiryDays,omitempty"`
+	Error           string `json:"error,omitempty"`
+}
+
+type WebhookProvider struct {
+	url             string
+	successResponse bool
+	failedResponse  bool
+	maxRetries      int
+	retryBaseDelay  time.Duration
+	retryMaxDelay   time.Duration
+	timeout         time.Duration
+	secret          []byte
+	format          WebhookFormat
+	// endpoint is where the payload is posted to. It's the url, except for the telegram format, where the
+	// url is the Bot API server and the endpoint contains the bot token, so it's never logged.
+	endpoint       string
+	telegramChatID string
+	// pagerdutyRoutingKey is the integration key of the PagerDuty service of the pagerduty format.
+	pagerdutyRoutingKey string
+	// template renders the body in place of the format, if the webhook refers to one.
+	template *template.Template
+	// deliveryLog records every delivery attempt, if it's set.
+	deliveryLog *WebhookDeliveryLog
+	client      *http.Client
+}
+
+func NewWebhookAlertProvider(webhook Webhook) *WebhookProvider {
+	if webhook.MaxRetries == 0 {
+		webhook.MaxRetries = 3
+	} else if webhook.MaxRetries < 0 {
+		webhook.MaxRetries = 0
+	}
+
+	if webhook.RetryBaseDelay == 0 {
+		webhook.RetryBaseDelay = 500
+	}
+
+	if webhook.RetryMaxDelay == 0 {
+		webhook.RetryMaxDelay = 30_000
+	}
+
+	if webhook.Timeout == 0 {
+		webhook.Timeout = 10
+	}
+
+	endpoint := webhook.URL
+	if webhook.Format == WebhookFormatTelegram {
+		if webhook.URL == "" {
+			webhook.URL = defaultTelegramApiUrl
+		}
+
+		endpoint = telegramSendMessageUrl(webhook.URL, webhook.TelegramBotToken)
+	}
+
+	if webhook.Format == WebhookFormatPagerduty && webhook.URL == "" {
+		webhook.URL = defaultPagerdutyEventsUrl
+		endpoint = webhook.URL
+	}
+
+	return &WebhookProvider{
+		url:             webhook.URL,
+		successResponse: webhook.SuccessResponse,
+		failedResponse:  webhook.FailedResponse,
+		maxRetries:      webhook.MaxRetries,
+		retryBaseDelay:  time.Duration(webhook.RetryBaseDelay) * time.Millisecond,
+		retryMaxDelay:   time.Duration(webhook.RetryMaxDelay) * time.Millisecond,
+		timeout:         time.Duration(webhook.Timeout) * time.Second,
+		secret:          []byte(webhook.Secret),
+		format:          webhook.Format,
+		endpoint:        endpoint,
+		telegramChatID:  webhook.TelegramChatID,
+		client:          &http.Client{},
+
+		pagerdutyRoutingKey: webhook.PagerdutyRoutingKey,
+	}
+}
+
+// WebhookSignatureHeader is the header that contains the hex-encoded HMAC-SHA256 signature of the payload.
+const WebhookSignatureHeader = "X-Semya-Signature"
+
+// SignWebhookPayload computes the hex-encoded HMAC-SHA256 signature of the payload with the given secret.
+func SignWebhookPayload(secret []byte, payload []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(payload)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// webhookStatusError is returned when the webhook responded with an unsuccessful status code.
+type webhookStatusError struct {
+	statusCode int
+	// description is the reason given by the destination, if its responses have one.
+	description string
+}
+
+func (e webhookStatusError) Error() string {
+	if e.description != "" {
+		return fmt.Sprintf("webhook responded with status code %d: %s", e.statusCode, e.description)
+	}
+
+	return fmt.Sprintf("webhook responded with status code %d", e.statusCode)
+}
+
+func (p *WebhookProvider) Send(ctx context.Context, msg AlertMessage) error {
+	if p.url == "" {
+		return fmt.Errorf("can't make a webhook request: url is not set")
+	}
+
+	// Degraded monitors are treated as failed responses, as they need attention.
+	healthy := msg.Success && msg.Status != MonitorStatusDegraded
+	if (healthy && !p.successResponse) || (!healthy && !p.failedResponse) {
+		return nil
+	}
+
+	if p.template != nil {
+		payload, err := renderWebhookTemplate(p.template, msg)
+		if err != nil {
+			return err
+		}
+
+		return p.deliver(ctx, msg.MonitorID, payload)
+	}
+
+	var body any
+	switch p.format {
+	case WebhookFormatSlack:
+		body = newSlackPayload(msg)
+	case WebhookFormatDiscord:
+		body = newDiscordPayload(msg)
+	case WebhookFormatTelegram:
+		body = newTelegramPayload(p.telegramChatID, msg)
+	case WebhookFormatPagerduty:
+		body = newPagerdutyEvent(p.pagerdutyRoutingKey, msg)
+	default:
+		body = WebhookPayload{
+			MonitorID:       msg.MonitorID,
+			Endpoint:        msg.MonitorName,
+			Status:          msg.Status.String(),
+			Type:            string(msg.EventType),
+			Downtime:        int64(msg.Downtime.Seconds()),
+			StatusCode:      msg.StatusCode,
+			RequestDuration: msg.Latency,
+			Timestamp:       msg.Timestamp.Unix(),
+			TlsExpiryDays:   msg.TlsExpiryDays,
+			Error:           msg.Error,
+		}
+	}
+
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("failed to marshal webhook payload: %w", err)
+	}
+
+	return p.deliver(ctx, msg.MonitorID, payload)
+}
+
+// deliver posts the payload to the webhook, retrying with an exponential backoff on network and server errors.
+func (p *WebhookProvider) deliver(ctx context.Context, monitorId string, payload []byte) error {
+	var lastErr error
+	for attempt := 0; attempt <= p.maxRetries; attempt++ {
+		if attempt > 0 {
+			delay := p.retryBaseDelay << (attempt - 1)
+			if delay > p.retryMaxDelay || delay <= 0 {
+				delay = p.retryMaxDelay
+			}
+
+			timer := time.NewTimer(delay)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return fmt.Errorf("webhook request cancelled after %d attempts: %w", attempt, lastErr)
+			case <-timer.C:
+			}
+		}
+
+		var statusCode int
+		statusCode, lastErr = p.send(ctx, payload)
+		if p.deliveryLog != nil {
+			delivery := WebhookDelivery{
+				Timestamp:   time.Now(),
+				Destination: p.url,
+				MonitorID:   monitorId,
+				Attempt:     attempt + 1,
+				StatusCode:  statusCode,
+			}
+			if lastErr != nil {
+				delivery.Error = lastErr.Error()
+			}
+
+			p.deliveryLog.Record(delivery)
+		}
+
+		if lastErr == nil {
+			return nil
+		}
+
+		// Client errors won't be fixed by retrying the same request
+		var statusErr webhookStatusError
+		if errors.As(lastErr, &statusErr) && statusErr.statusCode < 500 {
+			return lastErr
+		}
+
+		if ctx.Err() != nil {
+			return lastErr
+		}
+	}
+
+	return fmt.Errorf("webhook request failed after %d attempts: %w", p.maxRetries+1, lastErr)
+}
+
+// send posts the payload once, returning the status code of the response, or 0 if the request couldn't be made.
+func (p *WebhookProvider) send(ctx context.Context, payload []byte) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, p.timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return 0, fmt.Errorf("failed to create webhook request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	if len(p.secret) > 0 {
+		req.Header.Set(WebhookSignatureHeader, SignWebhookPayload(p.secret, payload))
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		// The endpoint of the telegram format contains the bot token
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			urlErr.URL = p.url
+		}
+
+		return 0, fmt.Errorf("failed to make webhook request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch p.format {
+	case WebhookFormatTelegram:
+		return resp.StatusCode, telegramResponseError(resp)
+	case WebhookFormatPagerduty:
+		return resp.StatusCode, pagerdutyResponseError(resp)
+	}
+
+	if resp.StatusCode >= 400 {
+		return resp.StatusCode, webhookStatusError{statusCode: resp.StatusCode}
+	}
+
+	return resp.StatusCode, nil
+}
+
+// WebhookDispatcher fans out alerts to multiple webhook destinations, resolving the effective destinations
+// of each monitor from its webhook override.
+type WebhookDispatcher struct {
+	providers []*WebhookProvider
+	// overrides holds the destinations of monitors that override the global ones. A nil slice means
+	// the webhook alerts are disabled for the monitor.
+	overrides map[string][]*WebhookProvider
+}
+
+// WebhookDispatcherOptions specifies the optional dependencies of a WebhookDispatcher.
+type WebhookDispatcherOptions struct {
+	// Templates holds the webhook templates that the destinations refer to by name.
+	Templates WebhookTemplates
+	// DeliveryLog records every delivery attempt of the destinations. The attempts aren't recorded if it's nil.
+	DeliveryLog *WebhookDeliveryLog
+}
+
+func NewWebhookDispatcher(webhooks []Webhook, monitors []Monitor) *WebhookDispatcher {
+	return NewWebhookDispatcherWithOptions(webhooks, monitors, WebhookDispatcherOptions{})
+}
+
+func NewWebhookDispatcherWithOptions(webhooks []Webhook, monitors []Monitor, options WebhookDispatcherOptions) *WebhookDispatcher {
+	overrides := make(map[string][]*WebhookProvider)
+	for _, monitor := range monitors {
+		if monitor.Webhook == nil {
+			continue
+		}
+
+		if monitor.Webhook.Disabled {
+			overrides[monitor.UniqueID] = nil
+			continue
+		}
+
+		if len(monitor.Webhook.Webhooks) > 0 {
+			overrides[monitor.UniqueID] = newWebhookProviders(monitor.Webhook.Webhooks, options)
+		}
+	}
+
+	return &WebhookDispatcher{providers: newWebhookProviders(webhooks, options), overrides: overrides}
+}
+
+// NewEscalationDispatcher creates a dispatcher of the escalated outages, which sends the alerts of each monitor
+// to the webhooks of its escalation policy only. The monitors without escalation webhooks aren't alerted.
+func NewEscalationDispatcher(monitors []Monitor, options WebhookDispatcherOptions) *WebhookDispatcher {
+	overrides := make(map[string][]*WebhookProvider)
+	for _, monitor := range monitors {
+		if monitor.Escalation != nil && len(monitor.Escalation.Webhooks) > 0 {
+			overrides[monitor.UniqueID] = newWebhookProviders(monitor.Escalation.Webhooks, options)
+		}
+	}
+
+	return &WebhookDispatcher{overrides: overrides}
+}
+
+func newWebhookProviders(webhooks []Webhook, options WebhookDispatcherOptions) []*WebhookProvider {
+	providers := make([]*WebhookProvider, 0, len(webhooks))
+	for _, webhook := range webhooks {
+		provider := NewWebhookAlertProvider(webhook)
+		provider.template = options.Templates[webhook.Template]
+		provider.deliveryLog = options.DeliveryLog
+		providers = append(providers, provider)
+	}
+
+	return providers
+}
+
+// Send sends the alert to every destination of the monitor concurrently, so a slow destination won't delay
+// the others. It returns once every destination has been attempted, with the errors of the failed destinations.
+func (d *WebhookDispatcher) Send(ctx context.Context, msg AlertMessage) error {
+	providers := d.providers
+	if override, ok := d.overrides[msg.MonitorID]; ok {
+		providers = override
+	}
+
+	errs := make([]error, len(providers))
+
+	var wg sync.WaitGroup
+	for i, provider := range providers {
+		wg.Add(1)
+		go func(i int, provider *WebhookProvider) {
+			defer wg.Done()
+
+			if err := provider.Send(ctx, msg); err != nil {
+				errs[i] = fmt.Errorf("webhook %s: %w", provider.url, err)
+			}
+		}(i, provider)
+	}
+	wg.Wait()
+
+	return errors.Join(errs...)
+}
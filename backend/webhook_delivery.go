@@ -0,0 +1,67 @@
+package main
+
+import (
+	"sync"
+	"time"
+)
+
+// WebhookDelivery is a single attempt to deliver a webhook payload.
+type WebhookDelivery struct {
+	Timestamp time.Time `json:"timestamp"`
+	// Destination is the url of the webhook. The bot token of the telegram format is left out.
+	Destination string `json:"destination"`
+	// MonitorID is the monitor that the alert is about, it's empty for the payloads that aren't about
+	// a single monitor.
+	MonitorID string `json:"monitor_id,omitempty"`
+	// Attempt is the 1-based attempt number, it's greater than 1 for the retries.
+	Attempt int `json:"attempt"`
+	// StatusCode is the status code of the response, it's 0 if the request couldn't be made.
+	StatusCode int    `json:"status_code"`
+	Error      string `json:"error,omitempty"`
+}
+
+// WebhookDeliveryLog keeps the most recent webhook delivery attempts in memory, so undelivered alerts can be
+// inspected. The oldest attempts are discarded once it's full.
+type WebhookDeliveryLog struct {
+	mu         sync.Mutex
+	deliveries []WebhookDelivery
+	// next is the position that the next attempt is written to once the log is full.
+	next int
+	size int
+}
+
+// NewWebhookDeliveryLog creates a log that keeps up to size attempts. Defaults to 100 if size is not positive.
+func NewWebhookDeliveryLog(size int) *WebhookDeliveryLog {
+	if size <= 0 {
+		size = 100
+	}
+
+	return &WebhookDeliveryLog{deliveries: make([]WebhookDelivery, 0, size), size: size}
+}
+
+// Record adds the attempt to the log, discarding the oldest attempt if the log is full.
+func (l *WebhookDeliveryLog) Record(delivery WebhookDelivery) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if len(l.deliveries) < l.size {
+		l.deliveries = append(l.deliveries, delivery)
+		return
+	}
+
+	l.deliveries[l.next] = delivery
+	l.next = (l.next + 1) % l.size
+}
+
+// List returns the attempts in the log, the most recent first.
+func (l *WebhookDeliveryLog) List() []WebhookDelivery {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	deliveries := make([]WebhookDelivery, 0, len(l.deliveries))
+	for i := len(l.deliveries) - 1; i >= 0; i-- {
+		deliveries = append(deliveries, l.deliveries[(l.next+i)%len(l.deliveries)])
+	}
+
+	return deliveries
+}
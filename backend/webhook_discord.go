@@ -0,0 +1,88 @@
+package main
+
+import (
+	"strconv"
+	"time"
+	"unicode/utf8"
+)
+
+// DiscordPayload is a Discord webhook message.
+// See https://discord.com/developers/docs/resources/webhook#execute-webhook
+type DiscordPayload struct {
+	Embeds []DiscordEmbed `json:"embeds"`
+}
+
+type DiscordEmbed struct {
+	Title       string              `json:"title"`
+	Description string              `json:"description,omitempty"`
+	Color       int                 `json:"color"`
+	Timestamp   string              `json:"timestamp"`
+	Fields      []DiscordEmbedField `json:"fields"`
+}
+
+type DiscordEmbedField struct {
+	Name   string `json:"name"`
+	Value  string `json:"value"`
+	Inline bool   `json:"inline"`
+}
+
+// Embed limits, see https://discord.com/developers/docs/resources/message#embed-object-embed-limits
+const (
+	discordEmbedTitleLimit       = 256
+	discordEmbedDescriptionLimit = 4096
+	discordEmbedFieldNameLimit   = 256
+	discordEmbedFieldValueLimit  = 1024
+)
+
+const (
+	discordColorSuccess  = 0x2eb67d
+	discordColorDegraded = 0xecb22e
+	discordColorFailure  = 0xe01e5a
+)
+
+func newDiscordPayload(msg AlertMessage) DiscordPayload {
+	color := discordColorFailure
+	if msg.Success {
+		color = discordColorSuccess
+		if msg.Status == MonitorStatusDegraded {
+			color = discordColorDegraded
+		}
+	}
+
+	fields := []DiscordEmbedField{
+		{Name: "Status", Value: msg.Status.String(), Inline: true},
+	}
+
+	if msg.StatusCode != 0 {
+		fields = append(fields, DiscordEmbedField{Name: "Status Code", Value: strconv.Itoa(msg.StatusCode), Inline: true})
+	}
+
+	fields = append(fields, DiscordEmbedField{Name: "Latency", Value: strconv.FormatInt(msg.Latency, 10) + "ms", Inline: true})
+
+	for i := range fields {
+		fields[i].Name = truncate(fields[i].Name, discordEmbedFieldNameLimit)
+		fields[i].Value = truncate(fields[i].Value, discordEmbedFieldValueLimit)
+	}
+
+	return DiscordPayload{
+		Embeds: []DiscordEmbed{
+			{
+				Title:       truncate(msg.MonitorName+" is "+msg.Status.String(), discordEmbedTitleLimit),
+				Description: truncate(msg.Error, discordEmbedDescriptionLimit),
+				Color:       color,
+				Timestamp:   msg.Timestamp.UTC().Format(time.RFC3339),
+				Fields:      fields,
+			},
+		},
+	}
+}
+
+// truncate shortens s to at most limit characters, replacing the tail with an ellipsis if it's too long.
+func truncate(s string, limit int) string {
+	if utf8.RuneCountInString(s) <= limit {
+		return s
+	}
+
+	runes := []rune(s)
+	return string(runes[:limit-1]) + "…"
+}
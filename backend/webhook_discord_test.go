@@ -0,0 +1,96 @@
+package main_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	main "semyi"
+)
+
+func TestWebhookProvider_DiscordFormat(t *testing.T) {
+	received := make(chan main.DiscordPayload, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload main.DiscordPayload
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		received <- payload
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	provider := main.NewWebhookAlertProvider(main.Webhook{
+		URL:             server.URL,
+		SuccessResponse: true,
+		FailedResponse:  true,
+		Format:          main.WebhookFormatDiscord,
+	})
+
+	t.Run("Should send an embed", func(t *testing.T) {
+		timestamp := time.Date(2024, 6, 1, 10, 0, 0, 0, time.UTC)
+		err := provider.Send(context.Background(), main.AlertMessage{
+			Success:     true,
+			Status:      main.MonitorStatusSuccess,
+			MonitorID:   "discord-test",
+			MonitorName: "Discord Test",
+			StatusCode:  200,
+			Timestamp:   timestamp,
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		payload := <-received
+		if len(payload.Embeds) != 1 {
+			t.Fatalf("expected 1 embed, got %d", len(payload.Embeds))
+		}
+
+		embed := payload.Embeds[0]
+		if embed.Color != 0x2eb67d {
+			t.Errorf("expected color %d, got %d", 0x2eb67d, embed.Color)
+		}
+
+		if embed.Title != "Discord Test is up" {
+			t.Errorf("unexpected title: %s", embed.Title)
+		}
+
+		if embed.Timestamp != "2024-06-01T10:00:00Z" {
+			t.Errorf("unexpected timestamp: %s", embed.Timestamp)
+		}
+	})
+
+	t.Run("Should truncate long fields", func(t *testing.T) {
+		err := provider.Send(context.Background(), main.AlertMessage{
+			Success:     false,
+			Status:      main.MonitorStatusFailure,
+			MonitorID:   "discord-test",
+			MonitorName: strings.Repeat("a", 300),
+			Error:       strings.Repeat("b", 5000),
+			Timestamp:   time.Now(),
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		embed := (<-received).Embeds[0]
+		if embed.Color != 0xe01e5a {
+			t.Errorf("expected color %d, got %d", 0xe01e5a, embed.Color)
+		}
+
+		if utf8.RuneCountInString(embed.Title) != 256 {
+			t.Errorf("expected title to be truncated to 256 characters, got %d", utf8.RuneCountInString(embed.Title))
+		}
+
+		if utf8.RuneCountInString(embed.Description) != 4096 {
+			t.Errorf("expected description to be truncated to 4096 characters, got %d", utf8.RuneCountInString(embed.Description))
+		}
+	})
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// PagerdutyEvent is an event of the PagerDuty Events API v2.
+// See https://developer.pagerduty.com/docs/events-api-v2/trigger-events/
+type PagerdutyEvent struct {
+	RoutingKey  string `json:"routing_key"`
+	EventAction string `json:"event_action"`
+	// DedupKey correlates the trigger and resolve events of the same monitor into a single incident.
+	DedupKey string `json:"dedup_key"`
+	// Payload is only set on trigger events, resolve events only need the dedup key.
+	Payload *PagerdutyEventPayload `json:"payload,omitempty"`
+}
+
+type PagerdutyEventPayload struct {
+	Summary       string         `json:"summary"`
+	Source        string         `json:"source"`
+	Severity      string         `json:"severity"`
+	Timestamp     string         `json:"timestamp"`
+	CustomDetails map[string]any `json:"custom_details,omitempty"`
+}
+
+const (
+	pagerdutyEventActionTrigger = "trigger"
+	pagerdutyEventActionResolve = "resolve"
+)
+
+// defaultPagerdutyEventsUrl is the Events API v2 endpoint that is used when the webhook doesn't set an URL.
+const defaultPagerdutyEventsUrl = "https://events.pagerduty.com/v2/enqueue"
+
+// pagerdutySummaryLimit is the longest summary that PagerDuty accepts.
+const pagerdutySummaryLimit = 1024
+
+// pagerdutyDedupKey returns the dedup key of the incidents of the monitor.
+func pagerdutyDedupKey(monitorID string) string {
+	return "semya/" + monitorID
+}
+
+func newPagerdutyEvent(routingKey string, msg AlertMessage) PagerdutyEvent {
+	event := PagerdutyEvent{
+		RoutingKey: routingKey,
+		DedupKey:   pagerdutyDedupKey(msg.MonitorID),
+	}
+
+	// Degraded monitors keep their incident open until they are fully up
+	if msg.Success && msg.Status != MonitorStatusDegraded {
+		event.EventAction = pagerdutyEventActionResolve
+		return event
+	}
+
+	severity := "critical"
+	if msg.Status == MonitorStatusDegraded {
+		severity = "warning"
+	}
+
+	details := map[string]any{
+		"monitor_id": msg.MonitorID,
+		"status":     msg.Status.String(),
+		"event_type": string(msg.EventType),
+		"latency":    msg.Latency,
+	}
+
+	if msg.StatusCode != 0 {
+		details["status_code"] = msg.StatusCode
+	}
+
+	if msg.Downtime > 0 {
+		details["downtime"] = int64(msg.Downtime.Seconds())
+	}
+
+	if msg.Error != "" {
+		details["error"] = msg.Error
+	}
+
+	event.EventAction = pagerdutyEventActionTrigger
+	event.Payload = &PagerdutyEventPayload{
+		Summary:       truncate(msg.MonitorName+" is "+msg.Status.String(), pagerdutySummaryLimit),
+		Source:        msg.MonitorName,
+		Severity:      severity,
+		Timestamp:     msg.Timestamp.UTC().Format(time.RFC3339),
+		CustomDetails: details,
+	}
+
+	return event
+}
+
+// pagerdutyResponse is the body of an Events API v2 response. Errors is only set on invalid events.
+type pagerdutyResponse struct {
+	Status  string   `json:"status"`
+	Message string   `json:"message"`
+	Errors  []string `json:"errors"`
+}
+
+// pagerdutyResponseError returns the error of an Events API v2 response, with the reason that PagerDuty gives.
+func pagerdutyResponseError(resp *http.Response) error {
+	if resp.StatusCode < 400 {
+		return nil
+	}
+
+	var body pagerdutyResponse
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<16)).Decode(&body); err != nil {
+		return webhookStatusError{statusCode: resp.StatusCode}
+	}
+
+	description := body.Message
+	if len(body.Errors) > 0 {
+		description += ": " + strings.Join(body.Errors, ", ")
+	}
+
+	return webhookStatusError{statusCode: resp.StatusCode, description: description}
+}
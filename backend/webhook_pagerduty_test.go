@@ -0,0 +1,122 @@
+package main_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	main "semyi"
+)
+
+func TestWebhookProvider_PagerdutyFormat(t *testing.T) {
+	received := make(chan main.PagerdutyEvent, 2)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var event main.PagerdutyEvent
+		if err := json.NewDecoder(r.Body).Decode(&event); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		received <- event
+		w.WriteHeader(http.StatusAccepted)
+		w.Write([]byte(`{"status": "success", "message": "Event processed", "dedup_key": "` + event.DedupKey + `"}`))
+	}))
+	defer server.Close()
+
+	provider := main.NewWebhookAlertProvider(main.Webhook{
+		URL:                 server.URL,
+		SuccessResponse:     true,
+		FailedResponse:      true,
+		Format:              main.WebhookFormatPagerduty,
+		PagerdutyRoutingKey: "routing-key",
+	})
+
+	timestamp := time.Date(2024, 6, 1, 12, 30, 0, 0, time.UTC)
+	err := provider.Send(context.Background(), main.AlertMessage{
+		Success:     false,
+		Status:      main.MonitorStatusFailure,
+		EventType:   main.AlertEventTypeDown,
+		StatusCode:  503,
+		MonitorID:   "pagerduty-test",
+		MonitorName: "PagerDuty Test",
+		Error:       "unexpected status code (503)",
+		Timestamp:   timestamp,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error sending the down event: %v", err)
+	}
+
+	trigger := <-received
+	if trigger.RoutingKey != "routing-key" || trigger.EventAction != "trigger" || trigger.DedupKey != "semya/pagerduty-test" {
+		t.Errorf("expected a trigger event of semya/pagerduty-test with the routing key, got %+v", trigger)
+	}
+
+	if trigger.Payload == nil {
+		t.Fatal("expected the trigger event to have a payload")
+	}
+
+	if trigger.Payload.Summary != "PagerDuty Test is down" || trigger.Payload.Source != "PagerDuty Test" ||
+		trigger.Payload.Severity != "critical" || trigger.Payload.Timestamp != "2024-06-01T12:30:00Z" {
+		t.Errorf("unexpected trigger payload %+v", trigger.Payload)
+	}
+
+	if trigger.Payload.CustomDetails["error"] != "unexpected status code (503)" {
+		t.Errorf("expected the error in the custom details, got %v", trigger.Payload.CustomDetails)
+	}
+
+	err = provider.Send(context.Background(), main.AlertMessage{
+		Success:     true,
+		Status:      main.MonitorStatusSuccess,
+		EventType:   main.AlertEventTypeRecovered,
+		Downtime:    time.Minute * 5,
+		MonitorID:   "pagerduty-test",
+		MonitorName: "PagerDuty Test",
+		Timestamp:   timestamp.Add(time.Minute * 5),
+	})
+	if err != nil {
+		t.Fatalf("unexpected error sending the recovered event: %v", err)
+	}
+
+	// The resolve event has the same dedup key, so PagerDuty resolves the incident of the trigger event
+	resolve := <-received
+	if resolve.RoutingKey != "routing-key" || resolve.EventAction != "resolve" || resolve.DedupKey != trigger.DedupKey {
+		t.Errorf("expected a resolve event of %s with the routing key, got %+v", trigger.DedupKey, resolve)
+	}
+
+	if resolve.Payload != nil {
+		t.Errorf("expected the resolve event to have no payload, got %+v", resolve.Payload)
+	}
+}
+
+func TestWebhookProvider_PagerdutyError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"status": "invalid event", "message": "Event object is invalid", "errors": ["'routing_key' is invalid"]}`))
+	}))
+	defer server.Close()
+
+	provider := main.NewWebhookAlertProvider(main.Webhook{
+		URL:                 server.URL,
+		FailedResponse:      true,
+		Format:              main.WebhookFormatPagerduty,
+		PagerdutyRoutingKey: "invalid",
+	})
+
+	err := provider.Send(context.Background(), main.AlertMessage{
+		Status:      main.MonitorStatusFailure,
+		MonitorID:   "pagerduty-error-test",
+		MonitorName: "PagerDuty Error Test",
+		Timestamp:   time.Now(),
+	})
+	if err == nil {
+		t.Fatal("expected error, got nil")
+	}
+
+	if !strings.Contains(err.Error(), "Event object is invalid: 'routing_key' is invalid") {
+		t.Errorf("expected the reason of pagerduty in the error, got %v", err)
+	}
+}
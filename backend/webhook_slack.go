@@ -0,0 +1,66 @@
+package main
+
+import (
+	"strconv"
+	"time"
+)
+
+// SlackPayload is a Slack incoming webhook message.
+// See https://api.slack.com/reference/messaging/attachments
+type SlackPayload struct {
+	Text        string            `json:"text"`
+	Attachments []SlackAttachment `json:"attachments"`
+}
+
+type SlackAttachment struct {
+	Color  string       `json:"color"`
+	Title  string       `json:"title"`
+	Text   string       `json:"text,omitempty"`
+	Fields []SlackField `json:"fields"`
+	Ts     int64        `json:"ts"`
+}
+
+type SlackField struct {
+	Title string `json:"title"`
+	Value string `json:"value"`
+	Short bool   `json:"short"`
+}
+
+const (
+	slackColorSuccess  = "#2eb67d"
+	slackColorDegraded = "#ecb22e"
+	slackColorFailure  = "#e01e5a"
+)
+
+func newSlackPayload(msg AlertMessage) SlackPayload {
+	color := slackColorFailure
+	if msg.Success {
+		color = slackColorSuccess
+		if msg.Status == MonitorStatusDegraded {
+			color = slackColorDegraded
+		}
+	}
+
+	fields := []SlackField{
+		{Title: "Monitor", Value: msg.MonitorName, Short: true},
+		{Title: "Status", Value: msg.Status.String(), Short: true},
+		{Title: "Timestamp", Value: msg.Timestamp.UTC().Format(time.RFC3339), Short: true},
+	}
+
+	if msg.StatusCode != 0 {
+		fields = append(fields, SlackField{Title: "Status Code", Value: strconv.Itoa(msg.StatusCode), Short: true})
+	}
+
+	return SlackPayload{
+		Text: msg.MonitorName + " is " + msg.Status.String(),
+		Attachments: []SlackAttachment{
+			{
+				Color:  color,
+				Title:  msg.MonitorName,
+				Text:   msg.Error,
+				Fields: fields,
+				Ts:     msg.Timestamp.Unix(),
+			},
+		},
+	}
+}
@@ -0,0 +1,70 @@
+package main_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	main "semyi"
+)
+
+func TestWebhookProvider_SlackFormat(t *testing.T) {
+	received := make(chan main.SlackPayload, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload main.SlackPayload
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		received <- payload
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	provider := main.NewWebhookAlertProvider(main.Webhook{
+		URL:             server.URL,
+		SuccessResponse: true,
+		FailedResponse:  true,
+		Format:          main.WebhookFormatSlack,
+	})
+
+	for _, tc := range []struct {
+		name    string
+		success bool
+		status  main.MonitorStatus
+		color   string
+	}{
+		{name: "success", success: true, status: main.MonitorStatusSuccess, color: "#2eb67d"},
+		{name: "failure", success: false, status: main.MonitorStatusFailure, color: "#e01e5a"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			err := provider.Send(context.Background(), main.AlertMessage{
+				Success:     tc.success,
+				Status:      tc.status,
+				MonitorID:   "slack-test",
+				MonitorName: "Slack Test",
+				Timestamp:   time.Now(),
+			})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			payload := <-received
+			if len(payload.Attachments) != 1 {
+				t.Fatalf("expected 1 attachment, got %d", len(payload.Attachments))
+			}
+
+			if payload.Attachments[0].Color != tc.color {
+				t.Errorf("expected color %s, got %s", tc.color, payload.Attachments[0].Color)
+			}
+
+			if len(payload.Attachments[0].Fields) < 3 || payload.Attachments[0].Fields[0].Value != "Slack Test" {
+				t.Errorf("unexpected fields: %+v", payload.Attachments[0].Fields)
+			}
+		})
+	}
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TelegramPayload is the body of a Telegram Bot API sendMessage request.
+// See https://core.telegram.org/bots/api#sendmessage
+type TelegramPayload struct {
+	ChatID    string `json:"chat_id"`
+	Text      string `json:"text"`
+	ParseMode string `json:"parse_mode"`
+}
+
+// telegramResponse is the envelope of every Telegram Bot API response. Description is only set on errors.
+// See https://core.telegram.org/bots/api#making-requests
+type telegramResponse struct {
+	Ok          bool   `json:"ok"`
+	ErrorCode   int    `json:"error_code"`
+	Description string `json:"description"`
+}
+
+// defaultTelegramApiUrl is the Bot API server that is used when the webhook doesn't set an URL.
+const defaultTelegramApiUrl = "https://api.telegram.org"
+
+// telegramErrorLimit keeps the error within the 4096 characters of a message, along with the other lines.
+const telegramErrorLimit = 1024
+
+// telegramSendMessageUrl returns the sendMessage endpoint of the bot on the Bot API server.
+func telegramSendMessageUrl(apiUrl string, botToken string) string {
+	if apiUrl == "" {
+		apiUrl = defaultTelegramApiUrl
+	}
+
+	return strings.TrimSuffix(apiUrl, "/") + "/bot" + botToken + "/sendMessage"
+}
+
+func newTelegramPayload(chatID string, msg AlertMessage) TelegramPayload {
+	title := "🔴 " + msg.MonitorName + " is down"
+	if msg.Success {
+		title = "✅ " + msg.MonitorName + " is up"
+		if msg.Status == MonitorStatusDegraded {
+			title = "🟡 " + msg.MonitorName + " is degraded"
+		}
+	}
+
+	lines := []string{"*" + escapeTelegramMarkdown(title) + "*", ""}
+	field := func(name string, value string) {
+		lines = append(lines, "*"+escapeTelegramMarkdown(name)+":* "+escapeTelegramMarkdown(value))
+	}
+
+	if msg.Downtime > 0 {
+		field("Downtime", msg.Downtime.Round(time.Second).String())
+	}
+
+	if msg.StatusCode != 0 {
+		field("Status Code", strconv.Itoa(msg.StatusCode))
+	}
+
+	field("Latency", strconv.FormatInt(msg.Latency, 10)+"ms")
+
+	if msg.Error != "" {
+		field("Error", truncate(msg.Error, telegramErrorLimit))
+	}
+
+	field("Timestamp", msg.Timestamp.UTC().Format(time.RFC3339))
+
+	return TelegramPayload{
+		ChatID:    chatID,
+		Text:      strings.Join(lines, "\n"),
+		ParseMode: "MarkdownV2",
+	}
+}
+
+// telegramMarkdownEscaper escapes the characters that are reserved by MarkdownV2.
+// See https://core.telegram.org/bots/api#markdownv2-style
+var telegramMarkdownEscaper = strings.NewReplacer(
+	`\`, `\\`, "_", `\_`, "*", `\*`, "[", `\[`, "]", `\]`, "(", `\(`, ")", `\)`, "~", `\~`, "`", "\\`",
+	">", `\>`, "#", `\#`, "+", `\+`, "-", `\-`, "=", `\=`, "|", `\|`, "{", `\{`, "}", `\}`, ".", `\.`, "!", `\!`,
+)
+
+func escapeTelegramMarkdown(s string) string {
+	return telegramMarkdownEscaper.Replace(s)
+}
+
+// telegramResponseError returns the error of a Bot API response, with the description that Telegram gives
+// in place of the bare status code. The status code is kept, so only the server errors are retried.
+func telegramResponseError(resp *http.Response) error {
+	var body telegramResponse
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<16)).Decode(&body); err != nil {
+		if resp.StatusCode >= 400 {
+			return webhookStatusError{statusCode: resp.StatusCode}
+		}
+
+		// The request was accepted, the body is only needed for the error
+		return nil
+	}
+
+	if body.Ok && resp.StatusCode < 400 {
+		return nil
+	}
+
+	statusCode := resp.StatusCode
+	if statusCode < 400 {
+		statusCode = body.ErrorCode
+	}
+
+	return webhookStatusError{statusCode: statusCode, description: body.Description}
+}
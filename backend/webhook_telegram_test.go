@@ -0,0 +1,119 @@
+package main_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	main "semyi"
+)
+
+func TestWebhookProvider_TelegramFormat(t *testing.T) {
+	received := make(chan main.TelegramPayload, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/bot123:secret-token/sendMessage" {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"ok": false, "error_code": 404, "description": "Not Found"}`))
+			return
+		}
+
+		var payload main.TelegramPayload
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		received <- payload
+		w.Write([]byte(`{"ok": true, "result": {"message_id": 1}}`))
+	}))
+	defer server.Close()
+
+	provider := main.NewWebhookAlertProvider(main.Webhook{
+		URL:              server.URL,
+		FailedResponse:   true,
+		Format:           main.WebhookFormatTelegram,
+		TelegramBotToken: "123:secret-token",
+		TelegramChatID:   "-1001234567890",
+	})
+
+	err := provider.Send(context.Background(), main.AlertMessage{
+		Success:     false,
+		Status:      main.MonitorStatusFailure,
+		StatusCode:  503,
+		Latency:     120,
+		MonitorID:   "telegram-test",
+		MonitorName: "api.example.com",
+		Error:       "unexpected status code (503)",
+		Timestamp:   time.Date(2024, 6, 1, 12, 30, 0, 0, time.UTC),
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	payload := <-received
+	if payload.ChatID != "-1001234567890" {
+		t.Errorf("expected chat_id -1001234567890, got %q", payload.ChatID)
+	}
+
+	if payload.ParseMode != "MarkdownV2" {
+		t.Errorf("expected parse_mode MarkdownV2, got %q", payload.ParseMode)
+	}
+
+	expected := strings.Join([]string{
+		`*🔴 api\.example\.com is down*`,
+		``,
+		`*Status Code:* 503`,
+		`*Latency:* 120ms`,
+		`*Error:* unexpected status code \(503\)`,
+		`*Timestamp:* 2024\-06\-01T12:30:00Z`,
+	}, "\n")
+	if payload.Text != expected {
+		t.Errorf("expected text\n%s\ngot\n%s", expected, payload.Text)
+	}
+}
+
+func TestWebhookProvider_TelegramError(t *testing.T) {
+	var attempts atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"ok": false, "error_code": 400, "description": "Bad Request: chat not found"}`))
+	}))
+	defer server.Close()
+
+	provider := main.NewWebhookAlertProvider(main.Webhook{
+		URL:              server.URL,
+		FailedResponse:   true,
+		Format:           main.WebhookFormatTelegram,
+		TelegramBotToken: "123:secret-token",
+		TelegramChatID:   "-1",
+	})
+
+	err := provider.Send(context.Background(), main.AlertMessage{
+		Status:      main.MonitorStatusFailure,
+		MonitorName: "Telegram Error Test",
+		Timestamp:   time.Now(),
+	})
+	if err == nil {
+		t.Fatal("expected error, got nil")
+	}
+
+	if !strings.Contains(err.Error(), "Bad Request: chat not found") {
+		t.Errorf("expected the description of telegram in the error, got %v", err)
+	}
+
+	if strings.Contains(err.Error(), "secret-token") {
+		t.Errorf("expected the error not to contain the bot token, got %v", err)
+	}
+
+	// Client errors are not retried
+	if got := attempts.Load(); got != 1 {
+		t.Errorf("expected 1 attempt, got %d", got)
+	}
+}
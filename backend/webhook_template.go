@@ -0,0 +1,98 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"text/template"
+	"time"
+)
+
+// WebhookTemplateData is the event that the webhook templates are executed with.
+type WebhookTemplateData struct {
+	MonitorID   string
+	MonitorName string
+	// Status is either "up", "down", or "degraded".
+	Status string
+	// Type is the event type, either "down", "up", "recovered", "repeat", or "escalated".
+	Type string
+	// Duration specifies how long the monitor has been down, only set for recovered, repeat, and escalated events.
+	Duration      time.Duration
+	StatusCode    int
+	Latency       int64
+	Timestamp     time.Time
+	TlsExpiryDays int
+	Error         string
+}
+
+func newWebhookTemplateData(msg AlertMessage) WebhookTemplateData {
+	return WebhookTemplateData{
+		MonitorID:     msg.MonitorID,
+		MonitorName:   msg.MonitorName,
+		Status:        msg.Status.String(),
+		Type:          string(msg.EventType),
+		Duration:      msg.Downtime,
+		StatusCode:    msg.StatusCode,
+		Latency:       msg.Latency,
+		Timestamp:     msg.Timestamp,
+		TlsExpiryDays: msg.TlsExpiryDays,
+		Error:         msg.Error,
+	}
+}
+
+// webhookTemplateFuncs are available to every webhook template. The body is sent as JSON, so the values that
+// are written into a JSON string should go through json, e.g. {"text": {{json .MonitorName}}}.
+var webhookTemplateFuncs = template.FuncMap{
+	"json": func(value any) (string, error) {
+		encoded, err := json.Marshal(value)
+		return string(encoded), err
+	},
+}
+
+// WebhookTemplates holds the parsed webhook templates of the configuration file by their name.
+type WebhookTemplates map[string]*template.Template
+
+// ParseWebhookTemplates parses the webhook templates, and executes each with a sample event, so a reference
+// to a field that doesn't exist is reported on load rather than on the first alert.
+func ParseWebhookTemplates(sources map[string]string) (WebhookTemplates, error) {
+	names := make([]string, 0, len(sources))
+	for name := range sources {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	sample := WebhookTemplateData{
+		MonitorID:   "sample",
+		MonitorName: "Sample",
+		Status:      MonitorStatusFailure.String(),
+		Type:        string(AlertEventTypeDown),
+		Timestamp:   time.Unix(0, 0).UTC(),
+	}
+
+	templates := make(WebhookTemplates, len(sources))
+	for _, name := range names {
+		parsed, err := template.New(name).Funcs(webhookTemplateFuncs).Parse(sources[name])
+		if err != nil {
+			return nil, fmt.Errorf("invalid webhook template %q: %w", name, err)
+		}
+
+		if err := parsed.Execute(&bytes.Buffer{}, sample); err != nil {
+			return nil, fmt.Errorf("invalid webhook template %q: %w", name, err)
+		}
+
+		templates[name] = parsed
+	}
+
+	return templates, nil
+}
+
+// renderWebhookTemplate executes the template with the event.
+func renderWebhookTemplate(tmpl *template.Template, msg AlertMessage) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, newWebhookTemplateData(msg)); err != nil {
+		return nil, fmt.Errorf("failed to execute webhook template %q: %w", tmpl.Name(), err)
+	}
+
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,126 @@
+package main_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	main "semyi"
+)
+
+func TestWebhookDispatcher_Templates(t *testing.T) {
+	received := make(chan string, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		received <- string(body)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	templates, err := main.ParseWebhookTemplates(map[string]string{
+		"chat":    `{"text": {{json (printf "%s is %s after %s" .MonitorName .Status .Duration)}}, "at": {{json (.Timestamp.Format "2006-01-02T15:04:05Z07:00")}}}`,
+		"minimal": `{{.MonitorID}} {{.Type}}`,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error parsing templates: %v", err)
+	}
+
+	dispatcher := main.NewWebhookDispatcherWithOptions([]main.Webhook{
+		{URL: server.URL, SuccessResponse: true, FailedResponse: true, Template: "chat"},
+	}, nil, main.WebhookDispatcherOptions{Templates: templates})
+
+	err = dispatcher.Send(context.Background(), main.AlertMessage{
+		Success:     true,
+		Status:      main.MonitorStatusSuccess,
+		EventType:   main.AlertEventTypeRecovered,
+		Downtime:    time.Minute * 3,
+		MonitorID:   "template-test",
+		MonitorName: `Template "Test"`,
+		Timestamp:   time.Date(2024, 6, 1, 12, 30, 0, 0, time.UTC),
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := `{"text": "Template \"Test\" is up after 3m0s", "at": "2024-06-01T12:30:00Z"}`
+	if got := <-received; got != expected {
+		t.Errorf("expected body %s, got %s", expected, got)
+	}
+}
+
+func TestParseWebhookTemplates(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		source string
+	}{
+		{name: "syntax error", source: `{{.MonitorName`},
+		{name: "unknown field", source: `{{.Hostname}}`},
+		{name: "unknown function", source: `{{yaml .MonitorName}}`},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := main.ParseWebhookTemplates(map[string]string{"broken": tc.source})
+			if err == nil {
+				t.Fatal("expected error, got nil")
+			}
+
+			if !strings.Contains(err.Error(), `"broken"`) {
+				t.Errorf("expected the error to name the template, got %v", err)
+			}
+		})
+	}
+
+	t.Run("Should validate the template references", func(t *testing.T) {
+		config := main.ConfigurationFile{
+			WebhookTemplates: map[string]string{"chat": `{{.MonitorName}}`},
+			Webhooks: []main.Webhook{
+				{URL: "https://example.com/chat", FailedResponse: true, Template: "chat"},
+				{URL: "https://example.com/missing", FailedResponse: true, Template: "missing"},
+			},
+		}
+
+		err := main.ValidateConfig(config)
+		var validationError *main.ValidationError
+		if !errors.As(err, &validationError) {
+			t.Fatalf("expected a validation error, got %v", err)
+		}
+
+		if len(validationError.Issues) != 1 || validationError.Issues[0].Field != "webhooks[1]" {
+			t.Errorf("expected a single issue on webhooks[1], got %v", validationError.Issues)
+		}
+	})
+
+	t.Run("Should validate the template references of the escalation webhooks", func(t *testing.T) {
+		config := main.ConfigurationFile{
+			Monitors: []main.Monitor{{
+				UniqueID:     "escalation-template",
+				Name:         "Escalation Template",
+				Type:         main.MonitorTypeHTTP,
+				HttpEndpoint: "https://example.com",
+				Escalation: &main.EscalationPolicy{
+					EscalateAfter: 60 * 60,
+					Webhooks:      []main.Webhook{{URL: "https://example.com/on-call", FailedResponse: true, Template: "missing"}},
+				},
+			}},
+		}
+
+		err := main.ValidateConfig(config)
+		var validationError *main.ValidationError
+		if !errors.As(err, &validationError) {
+			t.Fatalf("expected a validation error, got %v", err)
+		}
+
+		if len(validationError.Issues) != 1 || validationError.Issues[0].Field != "monitors[0].escalation.webhooks[0]" {
+			t.Errorf("expected a single issue on monitors[0].escalation.webhooks[0], got %v", validationError.Issues)
+		}
+	})
+}
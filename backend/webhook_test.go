@@ -0,0 +1,389 @@
+package main_test
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	main "semyi"
+)
+
+func TestWebhookProvider_Send(t *testing.T) {
+	received := make(chan main.WebhookPayload, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload main.WebhookPayload
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		received <- payload
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	provider := main.NewWebhookAlertProvider(main.Webhook{
+		URL:             server.URL,
+		SuccessResponse: false,
+		FailedResponse:  true,
+	})
+
+	t.Run("Should send degraded event", func(t *testing.T) {
+		err := provider.Send(context.Background(), main.AlertMessage{
+			Success:       true,
+			Status:        main.MonitorStatusDegraded,
+			MonitorID:     "webhook-test",
+			MonitorName:   "Webhook Test",
+			Timestamp:     time.Now(),
+			TlsExpiryDays: 3,
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		payload := <-received
+		if payload.Status != "degraded" {
+			t.Errorf("expected status degraded, got %s", payload.Status)
+		}
+
+		if payload.TlsExpiryDays != 3 {
+			t.Errorf("expected 3 days until expiry, got %d", payload.TlsExpiryDays)
+		}
+	})
+
+	t.Run("Should send recovered event with the downtime", func(t *testing.T) {
+		provider := main.NewWebhookAlertProvider(main.Webhook{
+			URL:             server.URL,
+			SuccessResponse: true,
+			FailedResponse:  true,
+		})
+
+		err := provider.Send(context.Background(), main.AlertMessage{
+			Success:   true,
+			Status:    main.MonitorStatusSuccess,
+			EventType: main.AlertEventTypeRecovered,
+			Downtime:  time.Minute * 5,
+			MonitorID: "webhook-test",
+			Timestamp: time.Now(),
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		payload := <-received
+		if payload.Type != "recovered" || payload.Downtime != 300 {
+			t.Errorf("expected recovered event with 300 seconds downtime, got %+v", payload)
+		}
+	})
+
+	t.Run("Should skip success event when disabled", func(t *testing.T) {
+		err := provider.Send(context.Background(), main.AlertMessage{
+			Success:   true,
+			Status:    main.MonitorStatusSuccess,
+			MonitorID: "webhook-test",
+			Timestamp: time.Now(),
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		select {
+		case payload := <-received:
+			t.Errorf("expected no webhook, got %+v", payload)
+		default:
+		}
+	})
+}
+
+func TestWebhookProvider_Retry(t *testing.T) {
+	msg := main.AlertMessage{
+		Success:   false,
+		Status:    main.MonitorStatusFailure,
+		MonitorID: "webhook-retry-test",
+		Timestamp: time.Now(),
+	}
+
+	t.Run("Should retry on server errors", func(t *testing.T) {
+		var attempts atomic.Int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if attempts.Add(1) <= 2 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+
+			w.WriteHeader(http.StatusOK)
+		}))
+		defer server.Close()
+
+		provider := main.NewWebhookAlertProvider(main.Webhook{
+			URL:            server.URL,
+			FailedResponse: true,
+			MaxRetries:     3,
+			RetryBaseDelay: 10,
+			RetryMaxDelay:  50,
+		})
+
+		if err := provider.Send(context.Background(), msg); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if attempts.Load() != 3 {
+			t.Errorf("expected 3 attempts, got %d", attempts.Load())
+		}
+	})
+
+	t.Run("Should not retry on client errors", func(t *testing.T) {
+		var attempts atomic.Int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			attempts.Add(1)
+			w.WriteHeader(http.StatusBadRequest)
+		}))
+		defer server.Close()
+
+		provider := main.NewWebhookAlertProvider(main.Webhook{
+			URL:            server.URL,
+			FailedResponse: true,
+			MaxRetries:     3,
+			RetryBaseDelay: 10,
+		})
+
+		if err := provider.Send(context.Background(), msg); err == nil {
+			t.Fatal("expected an error, got nil")
+		}
+
+		if attempts.Load() != 1 {
+			t.Errorf("expected 1 attempt, got %d", attempts.Load())
+		}
+	})
+
+	t.Run("Should give up after max retries", func(t *testing.T) {
+		var attempts atomic.Int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			attempts.Add(1)
+			w.WriteHeader(http.StatusBadGateway)
+		}))
+		defer server.Close()
+
+		provider := main.NewWebhookAlertProvider(main.Webhook{
+			URL:            server.URL,
+			FailedResponse: true,
+			MaxRetries:     2,
+			RetryBaseDelay: 10,
+		})
+
+		if err := provider.Send(context.Background(), msg); err == nil {
+			t.Fatal("expected an error, got nil")
+		}
+
+		if attempts.Load() != 3 {
+			t.Errorf("expected 3 attempts, got %d", attempts.Load())
+		}
+	})
+
+	t.Run("Should stop when context is cancelled", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}))
+		defer server.Close()
+
+		provider := main.NewWebhookAlertProvider(main.Webhook{
+			URL:            server.URL,
+			FailedResponse: true,
+			MaxRetries:     5,
+			RetryBaseDelay: 10_000,
+		})
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*100)
+		defer cancel()
+
+		start := time.Now()
+		if err := provider.Send(ctx, msg); err == nil {
+			t.Fatal("expected an error, got nil")
+		}
+
+		if time.Since(start) > time.Second*5 {
+			t.Errorf("expected send to be cancelled early, took %s", time.Since(start))
+		}
+	})
+}
+
+func TestWebhookProvider_Signature(t *testing.T) {
+	secret := "webhook-secret"
+	result := make(chan bool, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		mac := hmac.New(sha256.New, []byte(secret))
+		mac.Write(body)
+		expected := hex.EncodeToString(mac.Sum(nil))
+
+		result <- hmac.Equal([]byte(expected), []byte(r.Header.Get(main.WebhookSignatureHeader)))
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	provider := main.NewWebhookAlertProvider(main.Webhook{
+		URL:            server.URL,
+		FailedResponse: true,
+		Secret:         secret,
+	})
+
+	err := provider.Send(context.Background(), main.AlertMessage{
+		Success:   false,
+		Status:    main.MonitorStatusFailure,
+		MonitorID: "webhook-signature-test",
+		Timestamp: time.Now(),
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !<-result {
+		t.Error("expected signature to match the payload")
+	}
+}
+
+func TestWebhookDispatcher_Send(t *testing.T) {
+	first := make(chan main.WebhookPayload, 1)
+	firstServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload main.WebhookPayload
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		first <- payload
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer firstServer.Close()
+
+	second := make(chan main.WebhookPayload, 1)
+	secondServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload main.WebhookPayload
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		second <- payload
+		// Slow destination shouldn't delay the first destination
+		time.Sleep(time.Millisecond * 200)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer secondServer.Close()
+
+	dispatcher := main.NewWebhookDispatcher([]main.Webhook{
+		{URL: firstServer.URL, FailedResponse: true},
+		{URL: secondServer.URL, FailedResponse: true, Secret: "second-secret"},
+	}, nil)
+
+	err := dispatcher.Send(context.Background(), main.AlertMessage{
+		Success:   false,
+		Status:    main.MonitorStatusFailure,
+		MonitorID: "webhook-dispatcher-test",
+		Timestamp: time.Now(),
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for name, received := range map[string]chan main.WebhookPayload{"first": first, "second": second} {
+		select {
+		case payload := <-received:
+			if payload.MonitorID != "webhook-dispatcher-test" {
+				t.Errorf("%s destination: expected monitor id webhook-dispatcher-test, got %s", name, payload.MonitorID)
+			}
+		default:
+			t.Errorf("%s destination didn't receive the event", name)
+		}
+	}
+}
+
+func TestWebhookDispatcher_Overrides(t *testing.T) {
+	newReceiver := func() (*httptest.Server, chan string) {
+		received := make(chan string, 4)
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var payload main.WebhookPayload
+			if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+
+			received <- payload.MonitorID
+			w.WriteHeader(http.StatusOK)
+		}))
+		return server, received
+	}
+
+	globalServer, global := newReceiver()
+	defer globalServer.Close()
+
+	overrideServer, override := newReceiver()
+	defer overrideServer.Close()
+
+	configPath := filepath.Join(t.TempDir(), "config.json")
+	err := os.WriteFile(configPath, []byte(`{
+		"monitors": [
+			{"unique_id": "production", "name": "Production", "type": "http", "http_endpoint": "http://localhost"},
+			{"unique_id": "staging", "name": "Staging", "type": "http", "http_endpoint": "http://localhost", "webhook": false},
+			{"unique_id": "partner", "name": "Partner", "type": "http", "http_endpoint": "http://localhost", "webhook": {"url": "`+overrideServer.URL+`", "failed_response": true}}
+		],
+		"webhooks": [{"url": "`+globalServer.URL+`", "failed_response": true}]
+	}`), 0o644)
+	if err != nil {
+		t.Fatalf("unexpected error writing config: %v", err)
+	}
+
+	config, err := main.ReadConfigurationFile(configPath)
+	if err != nil {
+		t.Fatalf("unexpected error reading config: %v", err)
+	}
+
+	dispatcher := main.NewWebhookDispatcher(config.Webhooks, config.Monitors)
+	for _, monitorId := range []string{"production", "staging", "partner"} {
+		err := dispatcher.Send(context.Background(), main.AlertMessage{
+			Success:   false,
+			Status:    main.MonitorStatusFailure,
+			MonitorID: monitorId,
+			Timestamp: time.Now(),
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	close(global)
+	close(override)
+
+	var globalReceived []string
+	for monitorId := range global {
+		globalReceived = append(globalReceived, monitorId)
+	}
+
+	if len(globalReceived) != 1 || globalReceived[0] != "production" {
+		t.Errorf("expected only production on the global webhook, got %v", globalReceived)
+	}
+
+	var overrideReceived []string
+	for monitorId := range override {
+		overrideReceived = append(overrideReceived, monitorId)
+	}
+
+	if len(overrideReceived) != 1 || overrideReceived[0] != "partner" {
+		t.Errorf("expected only partner on the override webhook, got %v", overrideReceived)
+	}
+}
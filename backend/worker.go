@@ -1,16 +1,33 @@
 package main
 
 import (
+	"bytes"
 	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"hash/fnv"
+	"io"
+	"math/rand"
+	"net"
 	"net/http"
+	"net/http/httptrace"
+	"net/url"
+	"regexp"
+	"slices"
 	"strconv"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	probing "github.com/prometheus-community/pro-bing"
+	"github.com/robfig/cron/v3"
 	"github.com/rs/zerolog/log"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
 )
 
 type Response struct {
@@ -18,6 +35,36 @@ type Response struct {
 	StatusCode      int       `json:"statusCode"`
 	RequestDuration int64     `json:"requestDuration"`
 	Timestamp       time.Time `json:"timestamp"`
+	// Degraded specifies whether the check succeeded, but something about the monitor needs attention.
+	Degraded bool `json:"degraded"`
+	// TlsExpiryDays specifies the number of days until the TLS certificate expires, only recorded when
+	// the monitor has CheckTlsExpiry enabled.
+	TlsExpiryDays int `json:"tlsExpiryDays"`
+	// PacketLoss specifies the percentage of lost packets, only applicable to ICMP checks.
+	PacketLoss float64 `json:"packetLoss"`
+	// ResponseSize specifies the size of the response body in bytes, only applicable to HTTP checks.
+	ResponseSize int64 `json:"responseSize"`
+	// Error contains the reason of a failed check, if any.
+	Error string `json:"error,omitempty"`
+	// ErrorCategory classifies the reason of a failed check, it's empty for a successful check.
+	ErrorCategory ErrorCategory `json:"errorCategory,omitempty"`
+	// Protocol specifies the protocol negotiated with the server such as "HTTP/2.0", only applicable to HTTP checks.
+	Protocol string `json:"protocol,omitempty"`
+	// TlsVersion specifies the TLS version negotiated with the server such as "TLS 1.3", only applicable to
+	// HTTPS checks.
+	TlsVersion string `json:"tlsVersion,omitempty"`
+	// TimeoutPhase specifies the phase of an HTTP check that exceeded its own timeout, it's empty if the check
+	// didn't time out, or ran out of the monitor timeout.
+	TimeoutPhase TimeoutPhase `json:"timeoutPhase,omitempty"`
+	// Retries specifies how many times the check was retried before this result, so it was attempted
+	// Retries + 1 times.
+	Retries int `json:"retries"`
+	// FailedEndpoints specifies the endpoints of a multi_http monitor that failed the check, even if the
+	// quorum was reached.
+	FailedEndpoints []string `json:"failedEndpoints,omitempty"`
+	// Steps specifies the outcome of every step of a transaction monitor that was made, RequestDuration is
+	// the total of the steps.
+	Steps []TransactionStepResult `json:"steps,omitempty"`
 	Monitor
 }
 
@@ -26,8 +73,46 @@ type Response struct {
 type Worker struct {
 	monitor   Monitor
 	processor *Processor
+
+	expectedBodyRegex *regexp.Regexp
+	expectedHeaders   []headerAssertion
+	jsonAssertions    []jsonAssertion
+	authorization     string
+	// random is only used by the goroutine running the worker
+	random *rand.Rand
+	// pool limits the checks running at the same time across workers, it's nil when they're not limited
+	pool *CheckPool
+	// httpClient is shared between workers, so connections are reused. Defaults to DefaultHttpClient.
+	httpClient *http.Client
+	// dohResolver resolves the monitor host when DohResolver is set, it's nil otherwise
+	dohResolver *DohResolver
+	// proxy is the parsed HttpProxy, it's nil when the requests are sent directly
+	proxy *url.URL
+	// tracer records a span for every check. Defaults to a no-op tracer.
+	tracer trace.Tracer
+	// transactionExtractors holds the compiled extractions of every step of a transaction monitor
+	transactionExtractors [][]transactionExtractor
+	// schedule is the parsed Cron, it's nil when the checks run on the interval
+	schedule cron.Schedule
+	// consecutiveFailures counts the failed checks since the last successful one, it's only used by the
+	// goroutine running the worker
+	consecutiveFailures int
+	// stats records the result of every check, it's nil when the checks are not recorded
+	stats *CheckStats
+	// tlsConfig holds the client certificate and the certificate authority, it's nil when none is set
+	tlsConfig *tlsClientConfig
 }
 
+// ResponseErrorTimeout is the error reason of a check that didn't complete within the monitor timeout.
+const ResponseErrorTimeout = "timeout"
+
+// maxResponseBodySize specifies the maximum amount of response body that will be read for body assertions.
+const maxResponseBodySize = 1 << 20
+
+// maxCountedResponseSize specifies the maximum amount of response body that will be counted for the response size.
+// The part of the body that is not needed for the body assertions is discarded, rather than kept in memory.
+const maxCountedResponseSize = 64 << 20
+
 func NewWorker(monitor Monitor, processor *Processor) (*Worker, error) {
 	// Validate the monitor
 	_, err := monitor.Validate()
@@ -36,7 +121,7 @@ func NewWorker(monitor Monitor, processor *Processor) (*Worker, error) {
 	}
 
 	// Set default values
-	if monitor.Interval == 0 {
+	if monitor.Interval == 0 && monitor.Cron == "" {
 		monitor.Interval = DefaultInterval
 	}
 
@@ -48,51 +133,432 @@ func NewWorker(monitor Monitor, processor *Processor) (*Worker, error) {
 		monitor.HttpMethod = http.MethodGet
 	}
 
-	if monitor.HttpExpectedStatusCode == "" {
-		monitor.HttpExpectedStatusCode = "2xx"
+	if monitor.Type == MonitorTypeGraphQL {
+		body, err := json.Marshal(graphqlRequest{Query: monitor.GraphqlQuery, Variables: monitor.GraphqlVariables})
+		if err != nil {
+			return &Worker{}, fmt.Errorf("invalid graphql_variables: %w", err)
+		}
+
+		monitor.HttpMethod = http.MethodPost
+		monitor.HttpBody = string(body)
 	}
 
 	if monitor.IcmpPacketSize <= 0 {
 		monitor.IcmpPacketSize = 56
 	}
 
-	return &Worker{
-		monitor:   monitor,
-		processor: processor,
-	}, nil
+	if monitor.IcmpCount <= 0 {
+		monitor.IcmpCount = 3
+	}
+
+	if monitor.TlsExpiryThresholdDays <= 0 {
+		monitor.TlsExpiryThresholdDays = 14
+	}
+
+	if monitor.DnsRecordType == "" {
+		monitor.DnsRecordType = DnsRecordTypeA
+	}
+
+	if monitor.Type == MonitorTypeMultiHTTP && monitor.Quorum == 0 {
+		monitor.Quorum = 1
+	}
+
+	if monitor.RetryDelay == 0 {
+		monitor.RetryDelay = 1000
+	}
+
+	if monitor.AlertAfter <= 0 {
+		monitor.AlertAfter = 2
+	}
+
+	var expectedBodyRegex *regexp.Regexp
+	if monitor.HttpExpectedBodyRegex != "" {
+		expectedBodyRegex, err = regexp.Compile(monitor.HttpExpectedBodyRegex)
+		if err != nil {
+			return &Worker{}, fmt.Errorf("invalid expected_body_regex: %w", err)
+		}
+	}
+
+	expectedHeaders, err := compileHeaderAssertions(monitor.HttpExpectedHeaders)
+	if err != nil {
+		return &Worker{}, err
+	}
+
+	jsonAssertions, err := compileJsonAssertions(monitor.HttpJsonAssertions)
+	if err != nil {
+		return &Worker{}, err
+	}
+
+	var transactionExtractors [][]transactionExtractor
+	for _, step := range monitor.TransactionSteps {
+		extractors, err := compileTransactionExtractors(step.Extract)
+		if err != nil {
+			return &Worker{}, err
+		}
+
+		transactionExtractors = append(transactionExtractors, extractors)
+	}
+
+	var authorization string
+	if monitor.HttpBasicAuth != nil {
+		username, err := expandEnvironment(monitor.HttpBasicAuth.Username)
+		if err != nil {
+			return &Worker{}, fmt.Errorf("invalid basic_auth username: %w", err)
+		}
+
+		password, err := expandEnvironment(monitor.HttpBasicAuth.Password)
+		if err != nil {
+			return &Worker{}, fmt.Errorf("invalid basic_auth password: %w", err)
+		}
+
+		authorization = "Basic " + base64.StdEncoding.EncodeToString([]byte(username+":"+password))
+	} else if monitor.HttpBearerToken != "" {
+		token, err := expandEnvironment(monitor.HttpBearerToken)
+		if err != nil {
+			return &Worker{}, fmt.Errorf("invalid bearer_token: %w", err)
+		}
+
+		authorization = "Bearer " + token
+	}
+
+	// Monitors sharing a seed should still be offset differently
+	hash := fnv.New64a()
+	hash.Write([]byte(monitor.UniqueID))
+	seed := monitor.JitterSeed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+
+	worker := &Worker{
+		monitor:           monitor,
+		processor:         processor,
+		expectedBodyRegex: expectedBodyRegex,
+		expectedHeaders:   expectedHeaders,
+		jsonAssertions:    jsonAssertions,
+		authorization:     authorization,
+		random:            rand.New(rand.NewSource(seed ^ int64(hash.Sum64()))),
+
+		transactionExtractors: transactionExtractors,
+	}
+
+	if monitor.DohResolver != "" {
+		worker.dohResolver = NewDohResolver(monitor.DohResolver, nil)
+	}
+
+	if monitor.HttpProxy != "" {
+		proxy, err := expandEnvironment(monitor.HttpProxy)
+		if err != nil {
+			return &Worker{}, fmt.Errorf("invalid proxy: %w", err)
+		}
+
+		worker.proxy, err = url.Parse(proxy)
+		if err != nil || worker.proxy.Host == "" {
+			// The error might contain the credentials
+			return &Worker{}, fmt.Errorf("invalid proxy: not a valid url")
+		}
+	}
+
+	worker.tlsConfig, err = newTlsClientConfig(monitor)
+	if err != nil {
+		return &Worker{}, err
+	}
+
+	if monitor.TlsInsecureSkipVerify {
+		log.Warn().Str("UniqueID", monitor.UniqueID).Msg("TLS certificate verification is disabled for this monitor")
+	}
+
+	worker.useHttpClient(DefaultHttpClient)
+	worker.tracer = noop.NewTracerProvider().Tracer(tracerName)
+
+	if monitor.Cron != "" {
+		worker.schedule, err = ParseCron(monitor.Cron)
+		if err != nil {
+			return &Worker{}, err
+		}
+	}
+
+	return worker, nil
 }
 
-func (w *Worker) Run() {
-	for {
-		ctx, cancel := context.WithTimeout(context.Background(), time.Second*time.Duration(w.monitor.Timeout))
-
-		var response Response
-		var err error
-		// Make the request
-		switch w.monitor.Type {
-		case MonitorTypeHTTP:
-			response, err = w.makeHttpRequest(ctx)
-			if err != nil {
-				cancel()
-				log.Error().Err(err).Msg("failed to make http request")
-				continue
-			}
-			break
-		case MonitorTypePing:
-			response, err = w.makeIcmpRequest(ctx)
-			if err != nil {
-				cancel()
-				log.Error().Err(err).Msg("failed to make icmp request")
-				continue
+// userAgent returns the user agent of the checks, which falls back to DefaultUserAgent.
+func (w *Worker) userAgent() string {
+	if w.monitor.HttpUserAgent != "" {
+		return w.monitor.HttpUserAgent
+	}
+
+	return DefaultUserAgent()
+}
+
+// useHttpClient sets the client of the HTTP checks. Monitors with a proxy, a resolve override, TLS certificates,
+// a forced HTTP version, their own connect or read timeout, or resolving through DoH get a copy of the client
+// with their own transport, so its connection pool is not shared with other monitors.
+func (w *Worker) useHttpClient(client *http.Client) {
+	if w.monitor.HttpVersion == HttpVersion3 {
+		w.httpClient = http3Client(client, w.tlsConfig)
+		return
+	}
+
+	dialer := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}
+	if w.monitor.ConnectTimeout > 0 {
+		dialer.Timeout = time.Duration(w.monitor.ConnectTimeout) * time.Second
+	}
+
+	var dial func(ctx context.Context, network, address string) (net.Conn, error)
+	switch {
+	case w.monitor.HttpResolveOverride != "":
+		// Redirects to other hosts are still dialed as usual
+		endpointAddress := httpEndpointAddress(w.monitor.HttpEndpoint)
+		fallback := dialer.DialContext
+		if w.dohResolver != nil {
+			fallback = w.dohResolver.DialContext(dialer)
+		}
+
+		dial = func(ctx context.Context, network, address string) (net.Conn, error) {
+			if address == endpointAddress {
+				address = w.monitor.HttpResolveOverride
 			}
+
+			return fallback(ctx, network, address)
+		}
+	case w.dohResolver != nil:
+		dial = w.dohResolver.DialContext(dialer)
+	case w.proxy == nil && w.tlsConfig == nil && w.monitor.HttpVersion != HttpVersion11 && w.monitor.ConnectTimeout == 0 && w.monitor.ReadTimeout == 0:
+		w.httpClient = client
+		return
+	}
+
+	transport, ok := client.Transport.(*http.Transport)
+	if !ok {
+		transport = http.DefaultTransport.(*http.Transport)
+	}
+
+	transport = transport.Clone()
+	if dial != nil {
+		transport.DialContext = dial
+	} else if w.monitor.ConnectTimeout > 0 {
+		transport.DialContext = dialer.DialContext
+	}
+
+	if w.monitor.ConnectTimeout > 0 {
+		transport.TLSHandshakeTimeout = dialer.Timeout
+	}
+
+	if w.monitor.ReadTimeout > 0 {
+		transport.ResponseHeaderTimeout = time.Duration(w.monitor.ReadTimeout) * time.Second
+	}
+
+	if w.proxy != nil {
+		transport.Proxy = http.ProxyURL(w.proxy)
+	}
+
+	if w.tlsConfig != nil {
+		transport.TLSClientConfig = w.tlsConfig.TLSConfig()
+	}
+
+	configureHttpVersion(transport, w.monitor.HttpVersion)
+
+	dialingClient := *client
+	dialingClient.Transport = transport
+	w.httpClient = &dialingClient
+}
+
+// httpEndpointAddress returns the host and port that are dialed for the endpoint, the port defaults to the one
+// of the scheme.
+func httpEndpointAddress(endpoint string) string {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return ""
+	}
+
+	port := u.Port()
+	if port == "" {
+		port = "80"
+		if u.Scheme == "https" {
+			port = "443"
+		}
+	}
+
+	return net.JoinHostPort(u.Hostname(), port)
+}
+
+// Run runs the checks on every interval until the context is cancelled.
+func (w *Worker) Run(ctx context.Context) {
+	if delay := w.NextDelay(true); delay > 0 {
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
 		}
+	}
 
-		// Insert the response to the database
-		go w.processor.ProcessResponse(response)
+	for {
+		// The timeout starts once the check is given a slot, waiting in the queue doesn't count
+		if err := w.pool.Acquire(ctx); err != nil {
+			return
+		}
+
+		// Make the request, each attempt has its own timeout
+		response, err := w.Check(ctx)
+		w.pool.Release()
+		if ctx.Err() != nil {
+			// The worker is stopped, the result of an interrupted check is meaningless
+			return
+		}
+
+		w.Observe(response, err)
+		if w.stats != nil {
+			w.stats.Observe(w.monitor.UniqueID, response, err)
+		}
+
+		if err != nil {
+			log.Error().Err(err).Str("UniqueID", w.monitor.UniqueID).Msg("failed to run check")
+		} else if w.processor != nil {
+			// Insert the response to the database
+			go w.processor.ProcessResponse(response)
+		}
 
 		// Sleep for the interval
-		time.Sleep(time.Duration(w.monitor.Interval) * time.Second)
+		timer := time.NewTimer(w.NextDelay(false))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+	}
+}
+
+// Observe records the result of a check, so the following checks back off while the monitor keeps failing.
+// A non-nil err means the check couldn't be made, which counts as a failure.
+func (w *Worker) Observe(response Response, err error) {
+	if err != nil || !response.Success {
+		w.consecutiveFailures++
+	} else {
+		w.consecutiveFailures = 0
+	}
+}
+
+// NextDelay returns how long the worker waits before the next check. The first check is delayed by up to the
+// jitter, the following checks wait for the interval moved by up to half of the jitter in either direction.
+func (w *Worker) NextDelay(first bool) time.Duration {
+	if w.schedule != nil {
+		// Every check waits for the next activation of the cron schedule, including the first one
+		now := time.Now()
+		return w.schedule.Next(now).Sub(now)
+	}
+
+	interval := time.Duration(w.monitor.Interval) * time.Second
+	if !first {
+		interval = w.backoff(interval)
+	}
+	jitter := interval * time.Duration(w.monitor.Jitter) / 100
+	if jitter <= 0 {
+		if first {
+			return 0
+		}
+
+		return interval
+	}
+
+	offset := time.Duration(w.random.Int63n(int64(jitter)))
+	if first {
+		return offset
+	}
+
+	return interval - jitter/2 + offset
+}
+
+// backoff doubles the interval for every failed check after the failure is confirmed by AlertAfter checks,
+// so the down alert isn't delayed, up to BackoffMaxInterval.
+func (w *Worker) backoff(interval time.Duration) time.Duration {
+	maxInterval := time.Duration(w.monitor.BackoffMaxInterval) * time.Second
+	for i := w.monitor.AlertAfter; i < w.consecutiveFailures && interval < maxInterval; i++ {
+		interval *= 2
+	}
+
+	return min(interval, max(maxInterval, time.Duration(w.monitor.Interval)*time.Second))
+}
+
+// Check runs a single check against the monitor, depending on the monitor type. A failed check is retried
+// up to the retries of the monitor. Every attempt is bounded by the monitor timeout.
+func (w *Worker) Check(ctx context.Context) (response Response, err error) {
+	ctx, span := startCheckSpan(ctx, w.tracer, w.monitor)
+	defer func() {
+		endCheckSpan(span, response, err)
+	}()
+
+	response, err = w.check(ctx)
+	for attempt := 1; attempt <= w.monitor.Retries && (err != nil || !response.Success); attempt++ {
+		timer := time.NewTimer(time.Duration(w.monitor.RetryDelay) * time.Millisecond)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return response, err
+		case <-timer.C:
+		}
+
+		response, err = w.check(ctx)
+		response.Retries = attempt
+	}
+
+	if err != nil {
+		return response, err
+	}
+
+	if response.Success && !response.Degraded && w.monitor.DegradedLatencyThreshold > 0 && response.RequestDuration > w.monitor.DegradedLatencyThreshold {
+		response.Degraded = true
+		response.Error = fmt.Sprintf("latency (%dms) is above degraded_latency_threshold (%dms)", response.RequestDuration, w.monitor.DegradedLatencyThreshold)
+	}
+
+	return response, nil
+}
+
+func (w *Worker) check(ctx context.Context) (Response, error) {
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(w.monitor.Timeout)*time.Second)
+	defer cancel()
+
+	switch w.monitor.Type {
+	case MonitorTypeHTTP, MonitorTypeGraphQL:
+		response, err := w.makeHttpRequest(ctx, w.monitor.HttpEndpoint)
+		if err != nil {
+			return Response{}, fmt.Errorf("failed to make http request: %w", err)
+		}
+
+		return response, nil
+	case MonitorTypeMultiHTTP:
+		return w.makeMultiHttpRequest(ctx), nil
+	case MonitorTypeTransaction:
+		return w.makeTransactionRequest(ctx), nil
+	case MonitorTypePing:
+		response, err := w.makeIcmpRequest(ctx)
+		if err != nil {
+			return Response{}, fmt.Errorf("failed to make icmp request: %w", err)
+		}
+
+		return response, nil
+	case MonitorTypeTCP:
+		return w.makeTcpRequest(ctx), nil
+	case MonitorTypeDNS:
+		return w.makeDnsRequest(ctx), nil
+	case MonitorTypeGRPC:
+		return w.makeGrpcRequest(ctx), nil
+	default:
+		return Response{}, fmt.Errorf("unknown monitor type: %s", w.monitor.Type)
+	}
+}
+
+func (w *Worker) isExpectedStatusCode(got int) bool {
+	if len(w.monitor.HttpExpectedStatusCodes) > 0 {
+		return slices.Contains(w.monitor.HttpExpectedStatusCodes, got)
 	}
+
+	if w.monitor.HttpExpectedStatusCode != "" {
+		return w.parseExpectedStatusCode(got)
+	}
+
+	return got >= 200 && got < 400
 }
 
 func (w *Worker) parseExpectedStatusCode(got int) bool {
@@ -133,64 +599,330 @@ func (w *Worker) parseExpectedStatusCode(got int) bool {
 		}
 	}
 
-	return false
+	return ok
 }
 
-func (w *Worker) makeHttpRequest(ctx context.Context) (Response, error) {
+func (w *Worker) makeHttpRequest(checkCtx context.Context, endpoint string) (Response, error) {
 	timeStart := time.Now().UnixMilli()
 
-	req, err := http.NewRequestWithContext(ctx, w.monitor.HttpMethod, w.monitor.HttpEndpoint, nil)
-	if err != nil && !errors.Is(err, context.DeadlineExceeded) {
+	// The phase that timed out is told by whether the connection was established before
+	var connected atomic.Bool
+	ctx, cancel := context.WithCancelCause(httptrace.WithClientTrace(checkCtx, &httptrace.ClientTrace{
+		GotConn: func(httptrace.GotConnInfo) {
+			connected.Store(true)
+		},
+	}))
+	defer cancel(nil)
+
+	var body io.Reader
+	if w.monitor.HttpBody != "" {
+		body = strings.NewReader(w.monitor.HttpBody)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, w.monitor.HttpMethod, endpoint, body)
+	if err != nil {
 		return Response{}, fmt.Errorf("failed to create request: %w", err)
 	}
 
+	if w.monitor.Type == MonitorTypeGraphQL {
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Accept", "application/json")
+	}
+
+	req.Header.Set("User-Agent", w.userAgent())
+
 	if len(w.monitor.HttpHeaders) > 0 {
 		for key, value := range w.monitor.HttpHeaders {
-			req.Header.Add(key, value)
+			req.Header.Set(key, value)
 		}
 	}
 
-	client := &http.Client{
-		Timeout: time.Duration(w.monitor.Timeout) * time.Second,
+	// Let the monitored service continue the trace of the check
+	tracePropagator.Inject(ctx, propagation.HeaderCarrier(req.Header))
+
+	if w.monitor.HttpHostHeader != "" {
+		// The Host entry of the headers is ignored by net/http, it has to be set on the request itself
+		req.Host = w.monitor.HttpHostHeader
+	}
+
+	if w.authorization != "" {
+		req.Header.Set("Authorization", w.authorization)
+	}
+
+	if w.monitor.HttpDisableKeepAlives {
+		req.Close = true
+	}
+
+	client := w.httpClient
+	if w.monitor.HttpFollowRedirects != nil && !*w.monitor.HttpFollowRedirects {
+		// The copy still shares the transport, and with it the connection pool
+		noRedirectClient := *client
+		noRedirectClient.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		}
+		client = &noRedirectClient
 	}
 
 	resp, err := client.Do(req)
-	if err != nil && !errors.Is(err, context.DeadlineExceeded) {
-		return Response{}, fmt.Errorf("failed to make request: %w", err)
+	if err != nil {
+		// An unreachable or hung endpoint is down, rather than a failure of the check itself
+		category := ClassifyError(err)
+		if category == ErrorCategoryUnknown {
+			return Response{}, fmt.Errorf("failed to make request: %w", err)
+		}
+
+		reason := err.Error()
+		var phase TimeoutPhase
+		if category == ErrorCategoryTimeout {
+			reason = ResponseErrorTimeout
+			if phase = w.timeoutPhase(checkCtx, connected.Load()); phase != "" {
+				reason = string(phase) + " " + ResponseErrorTimeout
+			}
+		}
+
+		return Response{
+			Success:         false,
+			RequestDuration: time.Now().UnixMilli() - timeStart,
+			Timestamp:       time.Now(),
+			Error:           reason,
+			ErrorCategory:   category,
+			TimeoutPhase:    phase,
+			Monitor:         w.monitor,
+		}, nil
 	}
 
+	if w.monitor.ReadTimeout > 0 {
+		resp.Body = newReadTimeoutBody(resp.Body, time.Duration(w.monitor.ReadTimeout)*time.Second, cancel)
+	}
+	defer func() {
+		// The connection can only be reused once the body is fully read
+		_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, maxResponseBodySize))
+
+		err := resp.Body.Close()
+		if err != nil {
+			log.Warn().Err(err).Msg("failed to close response body")
+		}
+	}()
+
 	timeEnd := time.Now().UnixMilli()
-	return Response{
-		Success:         w.parseExpectedStatusCode(resp.StatusCode),
+	response := Response{
+		Success:         w.isExpectedStatusCode(resp.StatusCode),
 		StatusCode:      resp.StatusCode,
 		RequestDuration: timeEnd - timeStart,
 		Timestamp:       time.Now(),
+		Protocol:        resp.Proto,
 		Monitor:         w.monitor,
-	}, nil
+	}
+
+	if resp.TLS != nil {
+		response.TlsVersion = tls.VersionName(resp.TLS.Version)
+	}
+
+	// Only keep a bounded amount of the body for the assertions, the rest is only counted.
+	var responseBody []byte
+	if response.Success && (w.monitor.HttpExpectedBodyContains != "" || w.expectedBodyRegex != nil || len(w.jsonAssertions) > 0 || w.monitor.Type == MonitorTypeGraphQL) {
+		responseBody, err = io.ReadAll(io.LimitReader(resp.Body, maxResponseBodySize))
+	}
+
+	if err == nil {
+		var counted int64
+		counted, err = io.Copy(io.Discard, io.LimitReader(resp.Body, maxCountedResponseSize-int64(len(responseBody))))
+		response.ResponseSize = int64(len(responseBody)) + counted
+	}
+
+	if !response.Success {
+		response.Error = fmt.Sprintf("unexpected status code %d", resp.StatusCode)
+		response.ErrorCategory = ErrorCategoryHttpStatus
+	} else if err != nil && errors.Is(context.Cause(ctx), errReadTimeout) && checkCtx.Err() == nil {
+		response.Success = false
+		response.Error = string(TimeoutPhaseRead) + " " + ResponseErrorTimeout
+		response.ErrorCategory = ErrorCategoryTimeout
+		response.TimeoutPhase = TimeoutPhaseRead
+	} else if err != nil {
+		response.Success = false
+		response.Error = fmt.Sprintf("failed to read response body: %s", err.Error())
+		response.ErrorCategory = ClassifyError(err)
+	} else if reason := protocolMismatch(w.monitor.HttpVersion, resp); reason != "" {
+		response.Success = false
+		response.Error = reason
+		response.ErrorCategory = ErrorCategoryProtocolMismatch
+	} else if reason := w.tlsConfig.versionMismatch(resp.TLS); reason != "" {
+		response.Success = false
+		response.Error = reason
+		response.ErrorCategory = ErrorCategoryTlsError
+	} else if reason := headerMismatch(w.expectedHeaders, resp.Header); reason != "" {
+		response.Success = false
+		response.Error = reason
+		response.ErrorCategory = ErrorCategoryHeaderMismatch
+	} else if w.monitor.HttpMinBytes > 0 && response.ResponseSize < w.monitor.HttpMinBytes {
+		response.Success = false
+		response.Error = fmt.Sprintf("response body size (%d bytes) is smaller than min_bytes (%d bytes)", response.ResponseSize, w.monitor.HttpMinBytes)
+		response.ErrorCategory = ErrorCategoryBodyMismatch
+	} else if w.monitor.HttpMaxBytes > 0 && response.ResponseSize > w.monitor.HttpMaxBytes {
+		response.Success = false
+		response.Error = fmt.Sprintf("response body size (%d bytes) is larger than max_bytes (%d bytes)", response.ResponseSize, w.monitor.HttpMaxBytes)
+		response.ErrorCategory = ErrorCategoryBodyMismatch
+	} else if reason := graphqlErrors(w.monitor.Type, responseBody); reason != "" {
+		response.Success = false
+		response.Error = reason
+		response.ErrorCategory = ErrorCategoryBodyMismatch
+	} else if reason := jsonAssertionFailure(w.jsonAssertions, responseBody); reason != "" {
+		response.Success = false
+		response.Error = reason
+		response.ErrorCategory = ErrorCategoryBodyMismatch
+	} else if w.monitor.HttpExpectedBodyContains != "" && !bytes.Contains(responseBody, []byte(w.monitor.HttpExpectedBodyContains)) {
+		w.bodyMismatch(&response, "response body does not contain the expected value")
+	} else if w.expectedBodyRegex != nil && !w.expectedBodyRegex.Match(responseBody) {
+		w.bodyMismatch(&response, "response body does not match the expected pattern")
+	}
+
+	if w.monitor.CheckTlsExpiry && resp.TLS != nil && len(resp.TLS.PeerCertificates) > 0 {
+		response.TlsExpiryDays = int(time.Until(resp.TLS.PeerCertificates[0].NotAfter).Hours() / 24)
+		// A check that already failed keeps its own error, the expiry only degrades a successful check
+		if response.Success && response.TlsExpiryDays <= w.monitor.TlsExpiryThresholdDays {
+			response.Degraded = true
+			if response.Error == "" {
+				response.Error = fmt.Sprintf("tls certificate expires in %d days", response.TlsExpiryDays)
+			}
+		}
+	}
+
+	return response, nil
+}
+
+// bodyMismatch marks the response as failed because of a body assertion, or as degraded if the monitor
+// considers body mismatches a partial outage.
+func (w *Worker) bodyMismatch(response *Response, reason string) {
+	if w.monitor.HttpDegradeOnBodyMismatch {
+		response.Degraded = true
+	} else {
+		response.Success = false
+	}
+
+	response.Error = reason
+	response.ErrorCategory = ErrorCategoryBodyMismatch
+}
+
+// graphqlRequest is the body of the request of a graphql monitor.
+type graphqlRequest struct {
+	Query     string         `json:"query"`
+	Variables map[string]any `json:"variables,omitempty"`
+}
+
+// graphqlErrors returns why the response body of a graphql monitor is a failure, or an empty string if it's
+// a successful response or the monitor is not a graphql monitor. GraphQL servers usually respond with 200 even
+// when the query failed, the errors are only listed in the body.
+func graphqlErrors(monitorType MonitorType, body []byte) string {
+	if monitorType != MonitorTypeGraphQL {
+		return ""
+	}
+
+	var response struct {
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return "response body is not a valid graphql response"
+	}
+
+	if len(response.Errors) == 0 {
+		return ""
+	}
+
+	return fmt.Sprintf("graphql response has %d errors: %s", len(response.Errors), response.Errors[0].Message)
 }
 
 func (w *Worker) makeIcmpRequest(ctx context.Context) (Response, error) {
+	// Raw ICMP sockets require elevated privileges on most platforms, try it first and fall back
+	// to unprivileged UDP ping if we're not allowed to.
+	stats, err := w.runPinger(ctx, true)
+	if err != nil {
+		var unprivilegedErr error
+		stats, unprivilegedErr = w.runPinger(ctx, false)
+		if unprivilegedErr != nil {
+			return Response{}, fmt.Errorf("failed to run pinger with raw socket (%s) and with unprivileged udp socket: %w", err.Error(), unprivilegedErr)
+		}
+	}
+
+	response := Response{
+		Success:         stats.PacketsRecv > 0,
+		StatusCode:      0,
+		RequestDuration: int64(stats.AvgRtt / time.Millisecond),
+		Timestamp:       time.Now(),
+		PacketLoss:      stats.PacketLoss,
+		Monitor:         w.monitor,
+	}
+
+	if !response.Success {
+		// Every packet went unanswered within the timeout
+		response.Error = ResponseErrorTimeout
+		response.ErrorCategory = ErrorCategoryTimeout
+	}
+
+	return response, nil
+}
+
+func (w *Worker) runPinger(ctx context.Context, privileged bool) (*probing.Statistics, error) {
 	pinger, err := probing.NewPinger(w.monitor.IcmpHostname)
 	if err != nil {
-		return Response{}, fmt.Errorf("failed to create pinger: %w", err)
+		return nil, fmt.Errorf("failed to create pinger: %w", err)
 	}
 
-	pinger.Count = 1
+	pinger.SetPrivileged(privileged)
+	pinger.Count = w.monitor.IcmpCount
 	pinger.Size = w.monitor.IcmpPacketSize
 	pinger.Timeout = time.Duration(w.monitor.Timeout) * time.Second
 
-	err = pinger.Run()
+	err = pinger.RunWithContext(ctx)
 	if err != nil {
-		return Response{}, fmt.Errorf("failed to run pinger: %w", err)
+		return nil, fmt.Errorf("failed to run pinger: %w", err)
 	}
 
-	stats := pinger.Statistics()
+	return pinger.Statistics(), nil
+}
+
+func (w *Worker) makeTcpRequest(ctx context.Context) Response {
+	timeStart := time.Now()
+
+	dialer := &net.Dialer{
+		Timeout: time.Duration(w.monitor.Timeout) * time.Second,
+	}
+
+	dial := dialer.DialContext
+	if w.dohResolver != nil {
+		dial = w.dohResolver.DialContext(dialer)
+	}
+
+	conn, err := dial(ctx, "tcp", w.monitor.TcpAddress)
+	requestDuration := time.Since(timeStart).Milliseconds()
+	if err != nil {
+		// A refused or timed out connection is a failed check, not an error of the worker itself.
+		reason := err.Error()
+		category := ClassifyError(err)
+		if category == ErrorCategoryTimeout {
+			reason = ResponseErrorTimeout
+		}
+
+		return Response{
+			Success:         false,
+			RequestDuration: requestDuration,
+			Timestamp:       time.Now(),
+			Error:           reason,
+			ErrorCategory:   category,
+			Monitor:         w.monitor,
+		}
+	}
+
+	err = conn.Close()
+	if err != nil {
+		log.Warn().Err(err).Msg("failed to close tcp connection")
+	}
 
 	return Response{
-		Success:         stats.PacketsRecv > 0,
-		StatusCode:      0,
-		RequestDuration: int64(stats.AvgRtt / time.Millisecond),
+		Success:         true,
+		RequestDuration: requestDuration,
 		Timestamp:       time.Now(),
 		Monitor:         w.monitor,
-	}, nil
+	}
 }
@@ -0,0 +1,110 @@
+package main_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	main "semyi"
+)
+
+func TestWorker_Backoff(t *testing.T) {
+	newWorker := func(t *testing.T, backoffMaxInterval int) *main.Worker {
+		worker, err := main.NewWorker(main.Monitor{
+			UniqueID:           "backoff-test",
+			Name:               "Backoff Test",
+			Type:               main.MonitorTypeHTTP,
+			HttpEndpoint:       "http://localhost",
+			Interval:           10,
+			Timeout:            5,
+			AlertAfter:         2,
+			BackoffMaxInterval: backoffMaxInterval,
+		}, nil)
+		if err != nil {
+			t.Fatalf("unexpected error creating worker: %v", err)
+		}
+
+		return worker
+	}
+
+	failure := main.Response{Success: false}
+	success := main.Response{Success: true}
+
+	t.Run("Should grow the interval on repeated failures and reset on success", func(t *testing.T) {
+		worker := newWorker(t, 60)
+
+		steps := []struct {
+			response main.Response
+			err      error
+			want     time.Duration
+		}{
+			// The failure is confirmed at the base interval
+			{response: failure, want: time.Second * 10},
+			{response: failure, want: time.Second * 10},
+			{response: failure, want: time.Second * 20},
+			// A check that couldn't be made counts as a failure
+			{err: errors.New("failed to make request"), want: time.Second * 40},
+			{response: failure, want: time.Second * 60},
+			{response: failure, want: time.Second * 60},
+			{response: success, want: time.Second * 10},
+			{response: failure, want: time.Second * 10},
+		}
+
+		for i, step := range steps {
+			worker.Observe(step.response, step.err)
+			if got := worker.NextDelay(false); got != step.want {
+				t.Errorf("step %d: expected a delay of %s, got %s", i, step.want, got)
+			}
+		}
+
+		// The first check of a restarted worker is never delayed by the backoff
+		if got := worker.NextDelay(true); got != 0 {
+			t.Errorf("expected the first check right away, got %s", got)
+		}
+	})
+
+	t.Run("Should keep the interval when backoff is disabled", func(t *testing.T) {
+		worker := newWorker(t, 0)
+		for i := 0; i < 5; i++ {
+			worker.Observe(failure, nil)
+		}
+
+		if got := worker.NextDelay(false); got != time.Second*10 {
+			t.Errorf("expected a delay of 10s, got %s", got)
+		}
+	})
+}
+
+func TestMonitor_Validate_Backoff(t *testing.T) {
+	tests := []struct {
+		name               string
+		interval           int
+		cron               string
+		backoffMaxInterval int
+		valid              bool
+	}{
+		{name: "valid cap", interval: 10, backoffMaxInterval: 300, valid: true},
+		{name: "cap below interval", interval: 60, backoffMaxInterval: 30, valid: false},
+		{name: "cap above an hour", interval: 60, backoffMaxInterval: 7200, valid: false},
+		{name: "with cron", cron: "* * * * *", backoffMaxInterval: 300, valid: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			monitor := main.Monitor{
+				UniqueID:           "backoff-validate-test",
+				Name:               "Backoff Validate Test",
+				Type:               main.MonitorTypeHTTP,
+				HttpEndpoint:       "http://localhost",
+				Interval:           tt.interval,
+				Cron:               tt.cron,
+				BackoffMaxInterval: tt.backoffMaxInterval,
+			}
+
+			valid, err := monitor.Validate()
+			if valid != tt.valid {
+				t.Errorf("expected valid to be %v, got %v (%v)", tt.valid, valid, err)
+			}
+		})
+	}
+}
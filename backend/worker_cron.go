@@ -0,0 +1,21 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/robfig/cron/v3"
+)
+
+// cronParser accepts the standard five fields with an optional leading seconds field, and the descriptors
+// such as "@hourly". The expression can be prefixed with CRON_TZ=<zone> to be evaluated in another time zone.
+var cronParser = cron.NewParser(cron.SecondOptional | cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow | cron.Descriptor)
+
+// ParseCron parses the cron expression of a monitor.
+func ParseCron(expression string) (cron.Schedule, error) {
+	schedule, err := cronParser.Parse(expression)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cron expression %q: %w", expression, err)
+	}
+
+	return schedule, nil
+}
@@ -0,0 +1,133 @@
+package main_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	main "semyi"
+)
+
+func TestWorker_CronNextDelay(t *testing.T) {
+	worker, err := main.NewWorker(main.Monitor{
+		UniqueID:     "cron-delay-test",
+		Name:         "Cron Delay Test",
+		Type:         main.MonitorTypeHTTP,
+		HttpEndpoint: "http://localhost",
+		Cron:         "* * * * *",
+		Timeout:      10,
+	}, nil)
+	if err != nil {
+		t.Fatalf("unexpected error creating worker: %v", err)
+	}
+
+	for _, first := range []bool{true, false} {
+		before := time.Now()
+		delay := worker.NextDelay(first)
+		if delay <= 0 || delay > time.Minute {
+			t.Fatalf("expected a delay of at most a minute, got %s", delay)
+		}
+
+		// Every minute fires at the start of the next minute
+		next := before.Add(delay).Round(time.Second)
+		if next.Second() != 0 || next.Sub(before) > time.Minute+time.Second {
+			t.Errorf("expected the next check at the start of the next minute, got %s", next)
+		}
+	}
+}
+
+func TestWorkerManager_Cron(t *testing.T) {
+	var mu sync.Mutex
+	var checkedAt []time.Time
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		checkedAt = append(checkedAt, time.Now())
+		mu.Unlock()
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer testServer.Close()
+
+	manager := main.NewWorkerManager(nil, nil)
+	defer manager.Stop()
+
+	// Every second, so the test doesn't have to wait for minutes
+	_, err := manager.Apply([]main.Monitor{{
+		UniqueID:     "cron-manager-test",
+		Name:         "Cron Manager Test",
+		Type:         main.MonitorTypeHTTP,
+		HttpEndpoint: testServer.URL,
+		Cron:         "* * * * * *",
+		Timeout:      1,
+	}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	time.Sleep(time.Millisecond * 3500)
+	manager.Stop()
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if len(checkedAt) < 2 || len(checkedAt) > 4 {
+		t.Fatalf("expected a check every second, got %d checks in 3.5s", len(checkedAt))
+	}
+
+	for i, at := range checkedAt {
+		// The checks start right after the second ticks
+		if offset := at.Sub(at.Truncate(time.Second)); offset > time.Millisecond*500 {
+			t.Errorf("expected check %d to run at the start of a second, got %s", i, at.Format(time.StampMilli))
+		}
+	}
+}
+
+func TestMonitor_ValidateCron(t *testing.T) {
+	tests := []struct {
+		name    string
+		monitor main.Monitor
+		wantErr string
+	}{
+		{
+			name:    "valid cron",
+			monitor: main.Monitor{Cron: "*/5 9-17 * * 1-5"},
+		},
+		{
+			name:    "valid cron with time zone",
+			monitor: main.Monitor{Cron: "CRON_TZ=Asia/Jakarta 0 9 * * *"},
+		},
+		{
+			name:    "invalid cron",
+			monitor: main.Monitor{Cron: "every minute"},
+			wantErr: "invalid cron expression",
+		},
+		{
+			name:    "cron and interval",
+			monitor: main.Monitor{Cron: "* * * * *", Interval: 60},
+			wantErr: "interval and cron are mutually exclusive",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.monitor.UniqueID = "cron-validate-test"
+			tt.monitor.Name = "Cron Validate Test"
+			tt.monitor.Type = main.MonitorTypeHTTP
+			tt.monitor.HttpEndpoint = "http://localhost"
+
+			_, err := tt.monitor.Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
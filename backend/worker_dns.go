@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"slices"
+	"strings"
+	"time"
+)
+
+func (w *Worker) makeDnsRequest(ctx context.Context) Response {
+	resolver := net.DefaultResolver
+	if w.monitor.DnsResolver != "" {
+		resolver = &net.Resolver{
+			PreferGo: true,
+			Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+				// Ignore the system configured nameserver, always dial the configured resolver.
+				dialer := &net.Dialer{Timeout: time.Duration(w.monitor.Timeout) * time.Second}
+				return dialer.DialContext(ctx, network, w.monitor.DnsResolver)
+			},
+		}
+	}
+
+	timeStart := time.Now()
+	records, err := lookupDnsRecords(ctx, resolver, w.monitor.DnsRecordType, w.monitor.DnsHostname)
+	requestDuration := time.Since(timeStart).Milliseconds()
+
+	response := Response{
+		Success:         true,
+		RequestDuration: requestDuration,
+		Timestamp:       time.Now(),
+		Monitor:         w.monitor,
+	}
+
+	switch {
+	case err != nil:
+		response.Success = false
+		response.Error = err.Error()
+		response.ErrorCategory = ClassifyError(err)
+		if response.ErrorCategory == ErrorCategoryUnknown {
+			response.ErrorCategory = ErrorCategoryDnsFailure
+		}
+	case len(records) == 0:
+		response.Success = false
+		response.Error = fmt.Sprintf("no %s records found for %s", w.monitor.DnsRecordType, w.monitor.DnsHostname)
+		response.ErrorCategory = ErrorCategoryDnsFailure
+	case w.monitor.DnsExpectedValue != "" && !slices.Contains(records, w.monitor.DnsExpectedValue):
+		response.Success = false
+		response.Error = fmt.Sprintf("expected %s in %s records, got %s", w.monitor.DnsExpectedValue, w.monitor.DnsRecordType, strings.Join(records, ", "))
+		response.ErrorCategory = ErrorCategoryDnsFailure
+	}
+
+	return response
+}
+
+// lookupDnsRecords resolves the hostname for the given record type. Every record is returned as a plain string,
+// hostnames are returned without the trailing dot.
+func lookupDnsRecords(ctx context.Context, resolver *net.Resolver, recordType DnsRecordType, hostname string) ([]string, error) {
+	var records []string
+
+	switch recordType {
+	case DnsRecordTypeA, DnsRecordTypeAAAA:
+		network := "ip4"
+		if recordType == DnsRecordTypeAAAA {
+			network = "ip6"
+		}
+
+		ips, err := resolver.LookupIP(ctx, network, hostname)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve %s records: %w", recordType, err)
+		}
+
+		for _, ip := range ips {
+			records = append(records, ip.String())
+		}
+	case DnsRecordTypeCNAME:
+		cname, err := resolver.LookupCNAME(ctx, hostname)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve CNAME record: %w", err)
+		}
+
+		if cname != "" {
+			records = append(records, strings.TrimSuffix(cname, "."))
+		}
+	case DnsRecordTypeMX:
+		mxs, err := resolver.LookupMX(ctx, hostname)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve MX records: %w", err)
+		}
+
+		for _, mx := range mxs {
+			records = append(records, strings.TrimSuffix(mx.Host, "."))
+		}
+	default:
+		return nil, fmt.Errorf("unknown dns record type: %s", recordType)
+	}
+
+	return records, nil
+}
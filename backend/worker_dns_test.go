@@ -0,0 +1,112 @@
+package main_test
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	main "semyi"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// startStubResolver runs a minimal DNS server that answers A queries for the given records,
+// and responds with NXDOMAIN for everything else.
+func startStubResolver(t *testing.T, records map[string][4]byte) string {
+	t.Helper()
+
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("unexpected error listening: %v", err)
+	}
+	t.Cleanup(func() {
+		_ = conn.Close()
+	})
+
+	go func() {
+		buffer := make([]byte, 512)
+		for {
+			n, addr, err := conn.ReadFrom(buffer)
+			if err != nil {
+				return
+			}
+
+			var parser dnsmessage.Parser
+			header, err := parser.Start(buffer[:n])
+			if err != nil {
+				continue
+			}
+
+			question, err := parser.Question()
+			if err != nil {
+				continue
+			}
+
+			builder := dnsmessage.NewBuilder(nil, dnsmessage.Header{ID: header.ID, Response: true, Authoritative: true})
+			ip, ok := records[question.Name.String()]
+			if !ok || question.Type != dnsmessage.TypeA {
+				builder = dnsmessage.NewBuilder(nil, dnsmessage.Header{ID: header.ID, Response: true, RCode: dnsmessage.RCodeNameError})
+			}
+
+			_ = builder.StartQuestions()
+			_ = builder.Question(question)
+			if ok && question.Type == dnsmessage.TypeA {
+				_ = builder.StartAnswers()
+				_ = builder.AResource(dnsmessage.ResourceHeader{Name: question.Name, Class: dnsmessage.ClassINET, TTL: 60}, dnsmessage.AResource{A: ip})
+			}
+
+			message, err := builder.Finish()
+			if err != nil {
+				continue
+			}
+
+			_, _ = conn.WriteTo(message, addr)
+		}
+	}()
+
+	return conn.LocalAddr().String()
+}
+
+func TestWorker_DnsCheck(t *testing.T) {
+	resolver := startStubResolver(t, map[string][4]byte{
+		"semya.test.": {10, 0, 0, 1},
+	})
+
+	tests := []struct {
+		name          string
+		hostname      string
+		expectedValue string
+		want          bool
+	}{
+		{"resolves record", "semya.test", "", true},
+		{"resolves record with expected value", "semya.test", "10.0.0.1", true},
+		{"resolves record without expected value", "semya.test", "10.0.0.2", false},
+		{"fails to resolve unknown hostname", "unknown.test", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			worker, err := main.NewWorker(main.Monitor{
+				UniqueID:         "dns-test",
+				Name:             "DNS Test",
+				Type:             main.MonitorTypeDNS,
+				DnsHostname:      tt.hostname,
+				DnsResolver:      resolver,
+				DnsExpectedValue: tt.expectedValue,
+				Timeout:          2,
+			}, nil)
+			if err != nil {
+				t.Fatalf("unexpected error creating worker: %v", err)
+			}
+
+			response, err := worker.Check(context.Background())
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if response.Success != tt.want {
+				t.Errorf("expected success to be %v, got %v (%s)", tt.want, response.Success, response.Error)
+			}
+		})
+	}
+}
@@ -0,0 +1,198 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// dohMaxCacheTTL caps how long a resolution is cached, regardless of the TTL of the records, so a changed
+// address is picked up quickly.
+const dohMaxCacheTTL = time.Minute
+
+// maxDohResponseSize specifies the maximum size of a DNS message that will be read from the DoH server.
+const maxDohResponseSize = 64 << 10
+
+// DohResolver resolves hostnames through a DNS-over-HTTPS server (RFC 8484), rather than the system resolver.
+// Resolutions are cached for the TTL of the records, up to a minute.
+type DohResolver struct {
+	endpoint string
+	client   *http.Client
+
+	mu    sync.Mutex
+	cache map[string]dohCacheEntry
+}
+
+type dohCacheEntry struct {
+	addresses []string
+	expires   time.Time
+}
+
+// NewDohResolver creates a resolver that queries the DoH server at endpoint, e.g.
+// "https://cloudflare-dns.com/dns-query". Client defaults to DefaultHttpClient.
+func NewDohResolver(endpoint string, client *http.Client) *DohResolver {
+	if client == nil {
+		client = DefaultHttpClient
+	}
+
+	return &DohResolver{
+		endpoint: endpoint,
+		client:   client,
+		cache:    make(map[string]dohCacheEntry),
+	}
+}
+
+// LookupHost returns the IPv4 and IPv6 addresses of the host. IP addresses are returned as is.
+func (r *DohResolver) LookupHost(ctx context.Context, host string) ([]string, error) {
+	if ip := net.ParseIP(host); ip != nil {
+		return []string{host}, nil
+	}
+
+	name := strings.ToLower(strings.TrimSuffix(host, ".")) + "."
+
+	r.mu.Lock()
+	entry, ok := r.cache[name]
+	r.mu.Unlock()
+	if ok && time.Now().Before(entry.expires) {
+		return entry.addresses, nil
+	}
+
+	var addresses []string
+	ttl := dohMaxCacheTTL
+	for _, recordType := range []dnsmessage.Type{dnsmessage.TypeA, dnsmessage.TypeAAAA} {
+		records, recordTtl, err := r.query(ctx, name, recordType)
+		if err != nil {
+			return nil, err
+		}
+
+		addresses = append(addresses, records...)
+		if len(records) > 0 && recordTtl < ttl {
+			ttl = recordTtl
+		}
+	}
+
+	if len(addresses) == 0 {
+		return nil, &net.DNSError{Err: "no addresses found", Name: host, Server: r.endpoint, IsNotFound: true}
+	}
+
+	r.mu.Lock()
+	r.cache[name] = dohCacheEntry{addresses: addresses, expires: time.Now().Add(ttl)}
+	r.mu.Unlock()
+
+	return addresses, nil
+}
+
+// DialContext returns a dial function that resolves the host of the address through the DoH server, and dials
+// the resolved addresses in order until one of them connects.
+func (r *DohResolver) DialContext(dialer *net.Dialer) func(ctx context.Context, network, address string) (net.Conn, error) {
+	return func(ctx context.Context, network, address string) (net.Conn, error) {
+		host, port, err := net.SplitHostPort(address)
+		if err != nil {
+			return nil, err
+		}
+
+		addresses, err := r.LookupHost(ctx, host)
+		if err != nil {
+			return nil, err
+		}
+
+		var errs []error
+		for _, ip := range addresses {
+			conn, err := dialer.DialContext(ctx, network, net.JoinHostPort(ip, port))
+			if err == nil {
+				return conn, nil
+			}
+
+			errs = append(errs, err)
+			if ctx.Err() != nil {
+				break
+			}
+		}
+
+		return nil, errors.Join(errs...)
+	}
+}
+
+// query sends a single question to the DoH server, and returns the addresses of the answers along with the
+// lowest TTL among them.
+func (r *DohResolver) query(ctx context.Context, name string, recordType dnsmessage.Type) ([]string, time.Duration, error) {
+	questionName, err := dnsmessage.NewName(name)
+	if err != nil {
+		return nil, 0, fmt.Errorf("invalid hostname %s: %w", name, err)
+	}
+
+	// The id is always zero, so the responses are cacheable by HTTP caches
+	message := dnsmessage.Message{
+		Header:    dnsmessage.Header{RecursionDesired: true},
+		Questions: []dnsmessage.Question{{Name: questionName, Type: recordType, Class: dnsmessage.ClassINET}},
+	}
+
+	packed, err := message.Pack()
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to pack dns query: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.endpoint, bytes.NewReader(packed))
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to create doh request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/dns-message")
+	req.Header.Set("Accept", "application/dns-message")
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to query doh server: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, 0, fmt.Errorf("doh server responded with status code %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxDohResponseSize))
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to read doh response: %w", err)
+	}
+
+	var answer dnsmessage.Message
+	if err := answer.Unpack(body); err != nil {
+		return nil, 0, fmt.Errorf("failed to unpack doh response: %w", err)
+	}
+
+	switch answer.Header.RCode {
+	case dnsmessage.RCodeSuccess:
+	case dnsmessage.RCodeNameError:
+		return nil, 0, &net.DNSError{Err: "no such host", Name: strings.TrimSuffix(name, "."), Server: r.endpoint, IsNotFound: true}
+	default:
+		return nil, 0, &net.DNSError{Err: "server responded with " + answer.Header.RCode.String(), Name: strings.TrimSuffix(name, "."), Server: r.endpoint}
+	}
+
+	var addresses []string
+	ttl := dohMaxCacheTTL
+	for _, resource := range answer.Answers {
+		switch record := resource.Body.(type) {
+		case *dnsmessage.AResource:
+			addresses = append(addresses, net.IP(record.A[:]).String())
+		case *dnsmessage.AAAAResource:
+			addresses = append(addresses, net.IP(record.AAAA[:]).String())
+		default:
+			// CNAME records are followed by the DoH server, only the addresses are relevant
+			continue
+		}
+
+		if recordTtl := time.Duration(resource.Header.TTL) * time.Second; recordTtl < ttl {
+			ttl = recordTtl
+		}
+	}
+
+	return addresses, ttl, nil
+}
@@ -0,0 +1,163 @@
+package main_test
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	main "semyi"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// newStubDohServer answers every A question of doh.test with 127.0.0.1, and every other question with NXDOMAIN.
+func newStubDohServer(t *testing.T, queries *atomic.Int64) *httptest.Server {
+	t.Helper()
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		queries.Add(1)
+
+		body, err := io.ReadAll(r.Body)
+		if err != nil || r.Header.Get("Content-Type") != "application/dns-message" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		var query dnsmessage.Message
+		if err := query.Unpack(body); err != nil || len(query.Questions) != 1 {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		question := query.Questions[0]
+		response := dnsmessage.Message{
+			Header:    dnsmessage.Header{ID: query.Header.ID, Response: true},
+			Questions: query.Questions,
+		}
+
+		switch {
+		case question.Name.String() != "doh.test.":
+			response.Header.RCode = dnsmessage.RCodeNameError
+		case question.Type == dnsmessage.TypeA:
+			response.Answers = []dnsmessage.Resource{{
+				Header: dnsmessage.ResourceHeader{Name: question.Name, Type: dnsmessage.TypeA, Class: dnsmessage.ClassINET, TTL: 30},
+				Body:   &dnsmessage.AResource{A: [4]byte{127, 0, 0, 1}},
+			}}
+		}
+
+		packed, err := response.Pack()
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/dns-message")
+		w.Write(packed)
+	}))
+}
+
+func TestWorker_DohResolver(t *testing.T) {
+	var queries atomic.Int64
+	dohServer := newStubDohServer(t, &queries)
+	defer dohServer.Close()
+
+	var host atomic.Value
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host.Store(r.Host)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer target.Close()
+
+	_, port, err := net.SplitHostPort(target.Listener.Addr().String())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	t.Run("HTTP monitor should connect to the resolved address", func(t *testing.T) {
+		queries.Store(0)
+		worker, err := main.NewWorker(main.Monitor{
+			UniqueID:     "doh-http-test",
+			Name:         "DoH HTTP Test",
+			Type:         main.MonitorTypeHTTP,
+			HttpEndpoint: "http://doh.test:" + port + "/",
+			DohResolver:  dohServer.URL,
+			Timeout:      5,
+		}, nil)
+		if err != nil {
+			t.Fatalf("unexpected error creating worker: %v", err)
+		}
+
+		for i := 0; i < 2; i++ {
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+			response, err := worker.Check(ctx)
+			cancel()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if !response.Success {
+				t.Fatalf("expected success, got failure (%s)", response.Error)
+			}
+		}
+
+		if got := host.Load(); got != "doh.test:"+port {
+			t.Errorf("expected the request to be made to doh.test:%s, got %v", port, got)
+		}
+
+		// A and AAAA are only queried once, the second check is served from the cache
+		if got := queries.Load(); got != 2 {
+			t.Errorf("expected 2 doh queries, got %d", got)
+		}
+	})
+
+	t.Run("TCP monitor should connect to the resolved address", func(t *testing.T) {
+		worker, err := main.NewWorker(main.Monitor{
+			UniqueID:    "doh-tcp-test",
+			Name:        "DoH TCP Test",
+			Type:        main.MonitorTypeTCP,
+			TcpAddress:  "doh.test:" + port,
+			DohResolver: dohServer.URL,
+			Timeout:     5,
+		}, nil)
+		if err != nil {
+			t.Fatalf("unexpected error creating worker: %v", err)
+		}
+
+		response, err := worker.Check(context.Background())
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if !response.Success {
+			t.Errorf("expected success, got failure (%s)", response.Error)
+		}
+	})
+
+	t.Run("Unknown host should fail the check", func(t *testing.T) {
+		worker, err := main.NewWorker(main.Monitor{
+			UniqueID:    "doh-unknown-test",
+			Name:        "DoH Unknown Test",
+			Type:        main.MonitorTypeTCP,
+			TcpAddress:  "unknown.test:" + port,
+			DohResolver: dohServer.URL,
+			Timeout:     5,
+		}, nil)
+		if err != nil {
+			t.Fatalf("unexpected error creating worker: %v", err)
+		}
+
+		response, err := worker.Check(context.Background())
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if response.Success {
+			t.Error("expected failure, got success")
+		}
+	})
+}
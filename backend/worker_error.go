@@ -0,0 +1,78 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"net"
+	"syscall"
+)
+
+// ErrorCategory classifies why a check failed, so failures can be told apart and aggregated without
+// parsing the error message.
+type ErrorCategory string
+
+const (
+	ErrorCategoryNone              ErrorCategory = ""
+	ErrorCategoryDnsFailure        ErrorCategory = "dns_failure"
+	ErrorCategoryConnectionRefused ErrorCategory = "connection_refused"
+	ErrorCategoryTimeout           ErrorCategory = "timeout"
+	ErrorCategoryTlsError          ErrorCategory = "tls_error"
+	ErrorCategoryHttpStatus        ErrorCategory = "http_status"
+	ErrorCategoryBodyMismatch      ErrorCategory = "body_mismatch"
+	ErrorCategoryGrpcStatus        ErrorCategory = "grpc_status"
+	ErrorCategoryProtocolMismatch  ErrorCategory = "protocol_mismatch"
+	ErrorCategoryHeaderMismatch    ErrorCategory = "header_mismatch"
+	// ErrorCategoryUnknown is used for the failures that don't fit any other category.
+	ErrorCategoryUnknown ErrorCategory = "unknown"
+)
+
+// ClassifyError maps an error of a check, usually a network error, to its category.
+// It returns ErrorCategoryNone for a nil error.
+func ClassifyError(err error) ErrorCategory {
+	if err == nil {
+		return ErrorCategoryNone
+	}
+
+	var dnsError *net.DNSError
+	if errors.As(err, &dnsError) {
+		if dnsError.IsTimeout {
+			return ErrorCategoryTimeout
+		}
+
+		return ErrorCategoryDnsFailure
+	}
+
+	if errors.Is(err, syscall.ECONNREFUSED) {
+		return ErrorCategoryConnectionRefused
+	}
+
+	var certificateVerificationError *tls.CertificateVerificationError
+	var recordHeaderError tls.RecordHeaderError
+	var alertError tls.AlertError
+	var unknownAuthorityError x509.UnknownAuthorityError
+	var hostnameError x509.HostnameError
+	var certificateInvalidError x509.CertificateInvalidError
+	if errors.As(err, &certificateVerificationError) ||
+		errors.As(err, &recordHeaderError) ||
+		errors.As(err, &alertError) ||
+		errors.As(err, &unknownAuthorityError) ||
+		errors.As(err, &hostnameError) ||
+		errors.As(err, &certificateInvalidError) {
+		return ErrorCategoryTlsError
+	}
+
+	// The alerts sent by the server, e.g. when it rejects the client certificate, are not exported by crypto/tls
+	var opError *net.OpError
+	if errors.As(err, &opError) && opError.Op == "remote error" {
+		return ErrorCategoryTlsError
+	}
+
+	var netError net.Error
+	if errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netError) && netError.Timeout()) {
+		return ErrorCategoryTimeout
+	}
+
+	return ErrorCategoryUnknown
+}
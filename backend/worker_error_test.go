@@ -0,0 +1,149 @@
+package main_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+
+	main "semyi"
+)
+
+func TestClassifyError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want main.ErrorCategory
+	}{
+		{"nil", nil, main.ErrorCategoryNone},
+		{"dns error", &net.DNSError{Err: "no such host", Name: "example.invalid", IsNotFound: true}, main.ErrorCategoryDnsFailure},
+		{"dns timeout", &net.DNSError{Err: "i/o timeout", Name: "example.com", IsTimeout: true}, main.ErrorCategoryTimeout},
+		{"connection refused", &net.OpError{Op: "dial", Net: "tcp", Err: syscall.ECONNREFUSED}, main.ErrorCategoryConnectionRefused},
+		{"tls alert", &net.OpError{Op: "remote error", Err: errors.New("tls: certificate required")}, main.ErrorCategoryTlsError},
+		{"deadline exceeded", fmt.Errorf("wrapped: %w", context.DeadlineExceeded), main.ErrorCategoryTimeout},
+		{"unknown", errors.New("something else"), main.ErrorCategoryUnknown},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := main.ClassifyError(tt.err); got != tt.want {
+				t.Errorf("expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestWorker_ErrorCategory(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/error":
+			w.WriteHeader(http.StatusInternalServerError)
+		case "/slow":
+			select {
+			case <-r.Context().Done():
+			case <-time.After(time.Second * 5):
+			}
+		default:
+			w.Write([]byte("ok"))
+		}
+	}))
+	defer server.Close()
+
+	tlsServer := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer tlsServer.Close()
+
+	var queries atomic.Int64
+	dohServer := newStubDohServer(t, &queries)
+	defer dohServer.Close()
+
+	// Nothing listens on a port that was just released
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("unexpected error listening: %v", err)
+	}
+	closedAddress := listener.Addr().String()
+	listener.Close()
+
+	tests := []struct {
+		name    string
+		monitor main.Monitor
+		want    main.ErrorCategory
+	}{
+		{
+			name:    "dns failure",
+			monitor: main.Monitor{Type: main.MonitorTypeHTTP, HttpEndpoint: "http://unknown.test/", DohResolver: dohServer.URL},
+			want:    main.ErrorCategoryDnsFailure,
+		},
+		{
+			name:    "http connection refused",
+			monitor: main.Monitor{Type: main.MonitorTypeHTTP, HttpEndpoint: "http://" + closedAddress},
+			want:    main.ErrorCategoryConnectionRefused,
+		},
+		{
+			name:    "tcp connection refused",
+			monitor: main.Monitor{Type: main.MonitorTypeTCP, TcpAddress: closedAddress},
+			want:    main.ErrorCategoryConnectionRefused,
+		},
+		{
+			name:    "timeout",
+			monitor: main.Monitor{Type: main.MonitorTypeHTTP, HttpEndpoint: server.URL + "/slow", Timeout: 1},
+			want:    main.ErrorCategoryTimeout,
+		},
+		{
+			name:    "tls error",
+			monitor: main.Monitor{Type: main.MonitorTypeHTTP, HttpEndpoint: tlsServer.URL},
+			want:    main.ErrorCategoryTlsError,
+		},
+		{
+			name:    "http status",
+			monitor: main.Monitor{Type: main.MonitorTypeHTTP, HttpEndpoint: server.URL + "/error"},
+			want:    main.ErrorCategoryHttpStatus,
+		},
+		{
+			name:    "body mismatch",
+			monitor: main.Monitor{Type: main.MonitorTypeHTTP, HttpEndpoint: server.URL, HttpExpectedBodyContains: "healthy"},
+			want:    main.ErrorCategoryBodyMismatch,
+		},
+		{
+			name:    "success",
+			monitor: main.Monitor{Type: main.MonitorTypeHTTP, HttpEndpoint: server.URL},
+			want:    main.ErrorCategoryNone,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.monitor.UniqueID = "error-category-test"
+			tt.monitor.Name = "Error Category Test"
+			if tt.monitor.Timeout == 0 {
+				tt.monitor.Timeout = 5
+			}
+
+			worker, err := main.NewWorker(tt.monitor, nil)
+			if err != nil {
+				t.Fatalf("unexpected error creating worker: %v", err)
+			}
+
+			response, err := worker.Check(context.Background())
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if response.ErrorCategory != tt.want {
+				t.Errorf("expected category %q, got %q (%s)", tt.want, response.ErrorCategory, response.Error)
+			}
+
+			if response.Success != (tt.want == main.ErrorCategoryNone) {
+				t.Errorf("expected success to be %v, got %v", tt.want == main.ErrorCategoryNone, response.Success)
+			}
+		})
+	}
+}
@@ -0,0 +1,106 @@
+package main_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	main "semyi"
+)
+
+func TestWorker_GraphQL(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var request struct {
+			Query     string         `json:"query"`
+			Variables map[string]any `json:"variables"`
+		}
+		if r.Method != http.MethodPost || r.Header.Get("Content-Type") != "application/json" {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case strings.Contains(request.Query, "health"):
+			json.NewEncoder(w).Encode(map[string]any{"data": map[string]any{"health": request.Variables["service"]}})
+		case strings.Contains(request.Query, "broken"):
+			// The errors are reported with a successful status code
+			w.Write([]byte(`{"data": null, "errors": [{"message": "Cannot query field \"broken\""}]}`))
+		case strings.Contains(request.Query, "plain"):
+			w.Write([]byte("OK"))
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name      string
+		query     string
+		variables map[string]any
+		success   bool
+		error     string
+	}{
+		{name: "successful response", query: "query Health($service: String!) { health(service: $service) }", variables: map[string]any{"service": "api"}, success: true},
+		{name: "errored response", query: "{ broken }", success: false, error: `graphql response has 1 errors: Cannot query field "broken"`},
+		{name: "invalid response", query: "{ plain }", success: false, error: "response body is not a valid graphql response"},
+		{name: "unexpected status code", query: "{ unknown }", success: false, error: "unexpected status code 500"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			worker, err := main.NewWorker(main.Monitor{
+				UniqueID:         "graphql-test",
+				Name:             "GraphQL Test",
+				Type:             main.MonitorTypeGraphQL,
+				HttpEndpoint:     server.URL,
+				GraphqlQuery:     tt.query,
+				GraphqlVariables: tt.variables,
+				Timeout:          5,
+			}, nil)
+			if err != nil {
+				t.Fatalf("unexpected error creating worker: %v", err)
+			}
+
+			response, err := worker.Check(context.Background())
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if response.Success != tt.success {
+				t.Errorf("expected success to be %v, got %v (%s)", tt.success, response.Success, response.Error)
+			}
+
+			if response.Error != tt.error {
+				t.Errorf("expected error %q, got %q", tt.error, response.Error)
+			}
+
+			if !tt.success && response.StatusCode == http.StatusOK && response.ErrorCategory != main.ErrorCategoryBodyMismatch {
+				t.Errorf("expected category %q, got %q", main.ErrorCategoryBodyMismatch, response.ErrorCategory)
+			}
+
+			if response.RequestDuration < 0 {
+				t.Errorf("expected the latency to be recorded, got %d", response.RequestDuration)
+			}
+		})
+	}
+
+	t.Run("Should require a query", func(t *testing.T) {
+		_, err := main.NewWorker(main.Monitor{
+			UniqueID:     "graphql-test",
+			Name:         "GraphQL Test",
+			Type:         main.MonitorTypeGraphQL,
+			HttpEndpoint: server.URL,
+		}, nil)
+		if err == nil || !strings.Contains(err.Error(), "graphql_query is required") {
+			t.Errorf("expected a graphql_query error, got %v", err)
+		}
+	})
+}
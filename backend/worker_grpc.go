@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+func (w *Worker) makeGrpcRequest(ctx context.Context) Response {
+	transportCredentials := insecure.NewCredentials()
+	if w.monitor.GrpcTls {
+		tlsConfig := &tls.Config{}
+		if w.tlsConfig != nil {
+			tlsConfig = w.tlsConfig.TLSConfig()
+		}
+
+		transportCredentials = credentials.NewTLS(tlsConfig)
+	}
+
+	response := Response{
+		Success: true,
+		Monitor: w.monitor,
+	}
+
+	timeStart := time.Now()
+	// The dial doesn't block, the connection is established by the health check call itself
+	conn, err := grpc.DialContext(ctx, w.monitor.GrpcAddress, grpc.WithTransportCredentials(transportCredentials), grpc.WithUserAgent(w.userAgent()))
+	if err == nil {
+		var result *grpc_health_v1.HealthCheckResponse
+		result, err = grpc_health_v1.NewHealthClient(conn).Check(ctx, &grpc_health_v1.HealthCheckRequest{Service: w.monitor.GrpcService})
+		if err == nil && result.GetStatus() != grpc_health_v1.HealthCheckResponse_SERVING {
+			response.Success = false
+			response.Error = fmt.Sprintf("health status is %s", result.GetStatus())
+			response.ErrorCategory = ErrorCategoryGrpcStatus
+		}
+
+		if closeErr := conn.Close(); closeErr != nil {
+			log.Warn().Err(closeErr).Msg("failed to close grpc connection")
+		}
+	}
+	response.RequestDuration = time.Since(timeStart).Milliseconds()
+	response.Timestamp = time.Now()
+
+	if err != nil {
+		response.Success = false
+		response.Error = err.Error()
+		response.ErrorCategory = classifyGrpcError(err)
+		if response.ErrorCategory == ErrorCategoryTimeout {
+			response.Error = ResponseErrorTimeout
+		}
+	}
+
+	return response
+}
+
+// classifyGrpcError maps the error of a gRPC call to its category. The status errors don't wrap the network
+// error that caused them, so they are classified by their code.
+func classifyGrpcError(err error) ErrorCategory {
+	if category := ClassifyError(err); category != ErrorCategoryUnknown {
+		return category
+	}
+
+	switch status.Code(err) {
+	case codes.DeadlineExceeded:
+		return ErrorCategoryTimeout
+	case codes.Unavailable:
+		return ErrorCategoryConnectionRefused
+	default:
+		// e.g. NotFound when the service is not registered on the health server
+		return ErrorCategoryGrpcStatus
+	}
+}
@@ -0,0 +1,114 @@
+package main_test
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	main "semyi"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	"google.golang.org/grpc/health/grpc_health_v1"
+)
+
+func TestWorker_GrpcCheck(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("unexpected error creating listener: %v", err)
+	}
+
+	healthServer := health.NewServer()
+	grpcServer := grpc.NewServer()
+	grpc_health_v1.RegisterHealthServer(grpcServer, healthServer)
+	go grpcServer.Serve(listener)
+	defer grpcServer.Stop()
+
+	// A listener that is closed right away, so dialing it is refused
+	closedListener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("unexpected error creating listener: %v", err)
+	}
+	closedAddress := closedListener.Addr().String()
+	closedListener.Close()
+
+	tests := []struct {
+		name          string
+		address       string
+		service       string
+		status        grpc_health_v1.HealthCheckResponse_ServingStatus
+		success       bool
+		errorCategory main.ErrorCategory
+	}{
+		{name: "serving server", address: listener.Addr().String(), status: grpc_health_v1.HealthCheckResponse_SERVING, success: true},
+		{name: "serving service", address: listener.Addr().String(), service: "semya.Api", status: grpc_health_v1.HealthCheckResponse_SERVING, success: true},
+		{name: "not serving service", address: listener.Addr().String(), service: "semya.Api", status: grpc_health_v1.HealthCheckResponse_NOT_SERVING, success: false, errorCategory: main.ErrorCategoryGrpcStatus},
+		{name: "unknown service", address: listener.Addr().String(), service: "semya.Unknown", success: false, errorCategory: main.ErrorCategoryGrpcStatus},
+		{name: "refused connection", address: closedAddress, success: false, errorCategory: main.ErrorCategoryConnectionRefused},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.service != "semya.Unknown" {
+				healthServer.SetServingStatus(tt.service, tt.status)
+			}
+
+			worker, err := main.NewWorker(main.Monitor{
+				UniqueID:    "grpc-test",
+				Name:        "gRPC Test",
+				Type:        main.MonitorTypeGRPC,
+				GrpcAddress: tt.address,
+				GrpcService: tt.service,
+				Timeout:     5,
+			}, nil)
+			if err != nil {
+				t.Fatalf("unexpected error creating worker: %v", err)
+			}
+
+			response, err := worker.Check(context.Background())
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if response.Success != tt.success {
+				t.Errorf("expected success to be %v, got %v (%s)", tt.success, response.Success, response.Error)
+			}
+
+			if response.ErrorCategory != tt.errorCategory {
+				t.Errorf("expected error category %q, got %q", tt.errorCategory, response.ErrorCategory)
+			}
+
+			if response.RequestDuration < 0 || response.Timestamp.IsZero() {
+				t.Errorf("expected the latency and timestamp to be recorded, got %+v", response)
+			}
+		})
+	}
+}
+
+func TestMonitor_Validate_Grpc(t *testing.T) {
+	tests := []struct {
+		name    string
+		address string
+		valid   bool
+	}{
+		{name: "valid address", address: "localhost:50051", valid: true},
+		{name: "missing address", address: "", valid: false},
+		{name: "missing port", address: "localhost", valid: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			monitor := main.Monitor{
+				UniqueID:    "grpc-validate-test",
+				Name:        "gRPC Validate Test",
+				Type:        main.MonitorTypeGRPC,
+				GrpcAddress: tt.address,
+			}
+
+			valid, err := monitor.Validate()
+			if valid != tt.valid {
+				t.Errorf("expected valid to be %v, got %v (%v)", tt.valid, valid, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// headerAssertion is a response header that must be present with the expected value.
+type headerAssertion struct {
+	name  string
+	value string
+	// pattern is set when the expected value is a regular expression, it takes precedence over value.
+	pattern *regexp.Regexp
+}
+
+// compileHeaderAssertions parses the expected headers of a monitor. A value enclosed in slashes, e.g.
+// "/^max-age=\d+$/", is a regular expression, any other value is compared as is. The assertions are sorted
+// by the header name, so a failed check always reports the same header.
+func compileHeaderAssertions(expectedHeaders map[string]string) ([]headerAssertion, error) {
+	assertions := make([]headerAssertion, 0, len(expectedHeaders))
+	for name, value := range expectedHeaders {
+		if strings.TrimSpace(name) == "" {
+			return nil, fmt.Errorf("expected_headers can't contain an empty header name")
+		}
+
+		assertion := headerAssertion{name: http.CanonicalHeaderKey(name), value: value}
+		if len(value) >= 2 && strings.HasPrefix(value, "/") && strings.HasSuffix(value, "/") {
+			pattern, err := regexp.Compile(value[1 : len(value)-1])
+			if err != nil {
+				return nil, fmt.Errorf("invalid expected_headers pattern of %s: %w", name, err)
+			}
+
+			assertion.pattern = pattern
+		}
+
+		assertions = append(assertions, assertion)
+	}
+
+	sort.Slice(assertions, func(i, j int) bool {
+		return assertions[i].name < assertions[j].name
+	})
+
+	return assertions, nil
+}
+
+// headerMismatch returns the reason of a failed check when a response header is absent or doesn't have the
+// expected value, and an empty string otherwise. A header that is sent multiple times matches if any of its
+// values matches.
+func headerMismatch(assertions []headerAssertion, header http.Header) string {
+	for _, assertion := range assertions {
+		values := header.Values(assertion.name)
+		if len(values) == 0 {
+			return fmt.Sprintf("response header %s is missing", assertion.name)
+		}
+
+		matched := false
+		for _, value := range values {
+			if assertion.pattern != nil && assertion.pattern.MatchString(value) ||
+				assertion.pattern == nil && value == assertion.value {
+				matched = true
+				break
+			}
+		}
+
+		if !matched {
+			return fmt.Sprintf("response header %s has an unexpected value %q", assertion.name, values[0])
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,114 @@
+package main_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	main "semyi"
+)
+
+func TestWorker_ExpectedHeaders(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "public, max-age=3600")
+		w.Header().Set("X-Cache", "HIT")
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name     string
+		expected map[string]string
+		success  bool
+		reason   string
+	}{
+		{
+			name:     "present",
+			expected: map[string]string{"x-cache": "HIT", "Cache-Control": `/max-age=\d+/`},
+			success:  true,
+		},
+		{
+			name:     "absent",
+			expected: map[string]string{"X-Cache": "HIT", "X-Served-By": "/.+/"},
+			reason:   "response header X-Served-By is missing",
+		},
+		{
+			name:     "mismatched value",
+			expected: map[string]string{"X-Cache": "MISS"},
+			reason:   `response header X-Cache has an unexpected value "HIT"`,
+		},
+		{
+			name:     "mismatched pattern",
+			expected: map[string]string{"Cache-Control": "/no-store/"},
+			reason:   `response header Cache-Control has an unexpected value "public, max-age=3600"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			worker, err := main.NewWorker(main.Monitor{
+				UniqueID:            "expected-headers-test",
+				Name:                "Expected Headers Test",
+				Type:                main.MonitorTypeHTTP,
+				HttpEndpoint:        server.URL,
+				HttpExpectedHeaders: tt.expected,
+				Timeout:             5,
+			}, nil)
+			if err != nil {
+				t.Fatalf("unexpected error creating worker: %v", err)
+			}
+
+			response, err := worker.Check(context.Background())
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if response.Success != tt.success {
+				t.Errorf("expected success to be %v, got %v (%s)", tt.success, response.Success, response.Error)
+			}
+
+			if tt.success {
+				return
+			}
+
+			if response.Error != tt.reason {
+				t.Errorf("expected error %q, got %q", tt.reason, response.Error)
+			}
+
+			if response.ErrorCategory != main.ErrorCategoryHeaderMismatch {
+				t.Errorf("expected error category %q, got %q", main.ErrorCategoryHeaderMismatch, response.ErrorCategory)
+			}
+		})
+	}
+}
+
+func TestMonitor_Validate_ExpectedHeaders(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected map[string]string
+		valid    bool
+	}{
+		{name: "exact value", expected: map[string]string{"X-Cache": "HIT"}, valid: true},
+		{name: "pattern", expected: map[string]string{"Cache-Control": `/max-age=\d+/`}, valid: true},
+		{name: "invalid pattern", expected: map[string]string{"Cache-Control": "/max-age=(/"}, valid: false},
+		{name: "empty header name", expected: map[string]string{" ": "HIT"}, valid: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			monitor := main.Monitor{
+				UniqueID:            "expected-headers-validate-test",
+				Name:                "Expected Headers Validate Test",
+				Type:                main.MonitorTypeHTTP,
+				HttpEndpoint:        "https://example.com",
+				HttpExpectedHeaders: tt.expected,
+			}
+
+			valid, err := monitor.Validate()
+			if valid != tt.valid {
+				t.Errorf("expected valid to be %v, got %v (%v)", tt.valid, valid, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net/http"
+
+	"github.com/quic-go/quic-go/http3"
+)
+
+// http3Client returns a copy of the client that sends the requests over QUIC. QUIC has its own transport,
+// so the connection pool and the dialer of the shared client don't apply.
+func http3Client(client *http.Client, tlsConfig *tlsClientConfig) *http.Client {
+	roundTripper := &http3.RoundTripper{}
+	if tlsConfig != nil {
+		roundTripper.TLSClientConfig = tlsConfig.TLSConfig()
+	}
+
+	quicClient := *client
+	quicClient.Transport = roundTripper
+	return &quicClient
+}
+
+// configureHttpVersion restricts the protocols that the transport negotiates to the given version.
+func configureHttpVersion(transport *http.Transport, version HttpVersion) {
+	switch version {
+	case HttpVersion11:
+		// The configuration of a cloned transport may still offer h2, so it's removed from ALPN as well
+		if transport.TLSClientConfig == nil {
+			transport.TLSClientConfig = &tls.Config{}
+		}
+
+		transport.ForceAttemptHTTP2 = false
+		transport.TLSClientConfig.NextProtos = []string{"http/1.1"}
+		transport.TLSNextProto = map[string]func(string, *tls.Conn) http.RoundTripper{}
+	case HttpVersion2:
+		transport.ForceAttemptHTTP2 = true
+	}
+}
+
+// protocolMismatch returns the reason of a failed check when the server responded with a lower version than
+// the required one, and an empty string otherwise.
+func protocolMismatch(version HttpVersion, resp *http.Response) string {
+	var major int
+	switch version {
+	case HttpVersion2:
+		major = 2
+	case HttpVersion3:
+		major = 3
+	default:
+		return ""
+	}
+
+	if resp.ProtoMajor < major {
+		return fmt.Sprintf("expected HTTP/%s, negotiated %s", version, resp.Proto)
+	}
+
+	return ""
+}
@@ -0,0 +1,119 @@
+package main_test
+
+import (
+	"context"
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	main "semyi"
+
+	"github.com/quic-go/quic-go"
+	"github.com/quic-go/quic-go/http3"
+)
+
+func TestWorker_HttpVersion(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	h2Server := httptest.NewUnstartedServer(handler)
+	h2Server.EnableHTTP2 = true
+	h2Server.StartTLS()
+	defer h2Server.Close()
+
+	h1Server := httptest.NewTLSServer(handler)
+	defer h1Server.Close()
+
+	// The HTTP/3 server reuses the certificate of the HTTP/2 server
+	listener, err := quic.ListenAddrEarly("127.0.0.1:0", http3.ConfigureTLSConfig(&tls.Config{Certificates: h2Server.TLS.Certificates}), nil)
+	if err != nil {
+		t.Fatalf("unexpected error creating quic listener: %v", err)
+	}
+	h3Server := &http3.Server{Handler: handler}
+	go h3Server.ServeListener(listener)
+	defer h3Server.Close()
+
+	tests := []struct {
+		name     string
+		endpoint string
+		version  main.HttpVersion
+		success  bool
+		protocol string
+	}{
+		{name: "negotiates h2 by default", endpoint: h2Server.URL, success: true, protocol: "HTTP/2.0"},
+		{name: "forces h2", endpoint: h2Server.URL, version: main.HttpVersion2, success: true, protocol: "HTTP/2.0"},
+		{name: "forces http/1.1", endpoint: h2Server.URL, version: main.HttpVersion11, success: true, protocol: "HTTP/1.1"},
+		{name: "falls back to http/1.1", endpoint: h1Server.URL, version: main.HttpVersion2, success: false, protocol: "HTTP/1.1"},
+		{name: "forces h3", endpoint: "https://" + listener.Addr().String(), version: main.HttpVersion3, success: true, protocol: "HTTP/3.0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			worker, err := main.NewWorker(main.Monitor{
+				UniqueID:              "http-version-test",
+				Name:                  "HTTP Version Test",
+				Type:                  main.MonitorTypeHTTP,
+				HttpEndpoint:          tt.endpoint,
+				HttpVersion:           tt.version,
+				TlsInsecureSkipVerify: true,
+				Timeout:               5,
+			}, nil)
+			if err != nil {
+				t.Fatalf("unexpected error creating worker: %v", err)
+			}
+
+			response, err := worker.Check(context.Background())
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if response.Success != tt.success {
+				t.Errorf("expected success to be %v, got %v (%s)", tt.success, response.Success, response.Error)
+			}
+
+			if response.Protocol != tt.protocol {
+				t.Errorf("expected protocol %s, got %s", tt.protocol, response.Protocol)
+			}
+
+			if !tt.success && response.ErrorCategory != main.ErrorCategoryProtocolMismatch {
+				t.Errorf("expected error category %q, got %q", main.ErrorCategoryProtocolMismatch, response.ErrorCategory)
+			}
+		})
+	}
+}
+
+func TestMonitor_Validate_HttpVersion(t *testing.T) {
+	tests := []struct {
+		name     string
+		endpoint string
+		version  main.HttpVersion
+		proxy    string
+		valid    bool
+	}{
+		{name: "http/1.1 over http", endpoint: "http://example.com", version: main.HttpVersion11, valid: true},
+		{name: "h2 over https", endpoint: "https://example.com", version: main.HttpVersion2, valid: true},
+		{name: "h2 over http", endpoint: "http://example.com", version: main.HttpVersion2, valid: false},
+		{name: "h3 with proxy", endpoint: "https://example.com", version: main.HttpVersion3, proxy: "http://proxy.internal:3128", valid: false},
+		{name: "unknown version", endpoint: "https://example.com", version: "4", valid: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			monitor := main.Monitor{
+				UniqueID:     "http-version-validate-test",
+				Name:         "HTTP Version Validate Test",
+				Type:         main.MonitorTypeHTTP,
+				HttpEndpoint: tt.endpoint,
+				HttpVersion:  tt.version,
+				HttpProxy:    tt.proxy,
+			}
+
+			valid, err := monitor.Validate()
+			if valid != tt.valid {
+				t.Errorf("expected valid to be %v, got %v (%v)", tt.valid, valid, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	"github.com/ohler55/ojg/jp"
+)
+
+type JsonAssertionOperator string
+
+const (
+	JsonAssertionOperatorEqual          JsonAssertionOperator = "=="
+	JsonAssertionOperatorNotEqual       JsonAssertionOperator = "!="
+	JsonAssertionOperatorExists         JsonAssertionOperator = "exists"
+	JsonAssertionOperatorGreater        JsonAssertionOperator = ">"
+	JsonAssertionOperatorGreaterOrEqual JsonAssertionOperator = ">="
+	JsonAssertionOperatorLess           JsonAssertionOperator = "<"
+	JsonAssertionOperatorLessOrEqual    JsonAssertionOperator = "<="
+)
+
+// JsonAssertion is a condition on a value of a JSON response body, e.g. that $.status is "ok".
+type JsonAssertion struct {
+	// Path specifies the JSONPath of the value, e.g. "$.status" or "$.checks[0].latency_ms".
+	Path string `json:"path" yaml:"path" toml:"path"`
+	// Operator specifies how the value is compared to Value. It can be either "==", "!=", "exists", ">", ">=",
+	// "<", or "<=". The numeric comparisons require both sides to be numbers. Defaults to "==".
+	Operator JsonAssertionOperator `json:"operator" yaml:"operator" toml:"operator"`
+	// Value specifies the expected value, it can be any JSON value. It's ignored by the exists operator.
+	Value any `json:"value" yaml:"value" toml:"value"`
+}
+
+// jsonAssertion is a compiled JsonAssertion.
+type jsonAssertion struct {
+	path     jp.Expr
+	operator JsonAssertionOperator
+	// value is the expected value in the same types as a decoded body, so the numbers of YAML and TOML
+	// configurations compare equal to the numbers of the body.
+	value any
+}
+
+func compileJsonAssertions(assertions []JsonAssertion) ([]jsonAssertion, error) {
+	compiled := make([]jsonAssertion, 0, len(assertions))
+	for i, assertion := range assertions {
+		path, err := jp.ParseString(assertion.Path)
+		if err != nil || assertion.Path == "" {
+			return nil, fmt.Errorf("invalid json_assertions[%d]: invalid path %q", i, assertion.Path)
+		}
+
+		operator := assertion.Operator
+		if operator == "" {
+			operator = JsonAssertionOperatorEqual
+		}
+
+		encoded, err := json.Marshal(assertion.Value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid json_assertions[%d]: value must be a json value: %w", i, err)
+		}
+
+		var value any
+		if err := json.Unmarshal(encoded, &value); err != nil {
+			return nil, fmt.Errorf("invalid json_assertions[%d]: value must be a json value: %w", i, err)
+		}
+
+		switch operator {
+		case JsonAssertionOperatorEqual, JsonAssertionOperatorNotEqual, JsonAssertionOperatorExists:
+		case JsonAssertionOperatorGreater, JsonAssertionOperatorGreaterOrEqual, JsonAssertionOperatorLess, JsonAssertionOperatorLessOrEqual:
+			if _, ok := value.(float64); !ok {
+				return nil, fmt.Errorf("invalid json_assertions[%d]: value must be a number for the %s operator", i, operator)
+			}
+		default:
+			return nil, fmt.Errorf("invalid json_assertions[%d]: unknown operator %q", i, operator)
+		}
+
+		compiled = append(compiled, jsonAssertion{path: path, operator: operator, value: value})
+	}
+
+	return compiled, nil
+}
+
+// jsonAssertionFailure returns the reason of a failed check when the body is not JSON, or doesn't satisfy
+// one of the assertions, and an empty string otherwise. Paths that match multiple values are compared with
+// the first one.
+func jsonAssertionFailure(assertions []jsonAssertion, body []byte) string {
+	if len(assertions) == 0 {
+		return ""
+	}
+
+	var document any
+	if err := json.Unmarshal(body, &document); err != nil {
+		return "response body is not valid json"
+	}
+
+	for _, assertion := range assertions {
+		results := assertion.path.Get(document)
+		if len(results) == 0 {
+			return fmt.Sprintf("json assertion %s failed: no value at the path", assertion)
+		}
+
+		if !assertion.satisfied(results[0]) {
+			actual, _ := json.Marshal(results[0])
+			return fmt.Sprintf("json assertion %s failed: got %s", assertion, actual)
+		}
+	}
+
+	return ""
+}
+
+func (a jsonAssertion) satisfied(actual any) bool {
+	switch a.operator {
+	case JsonAssertionOperatorExists:
+		return true
+	case JsonAssertionOperatorEqual:
+		return reflect.DeepEqual(actual, a.value)
+	case JsonAssertionOperatorNotEqual:
+		return !reflect.DeepEqual(actual, a.value)
+	}
+
+	number, ok := actual.(float64)
+	if !ok {
+		return false
+	}
+
+	expected := a.value.(float64)
+	switch a.operator {
+	case JsonAssertionOperatorGreater:
+		return number > expected
+	case JsonAssertionOperatorGreaterOrEqual:
+		return number >= expected
+	case JsonAssertionOperatorLess:
+		return number < expected
+	case JsonAssertionOperatorLessOrEqual:
+		return number <= expected
+	}
+
+	return false
+}
+
+func (a jsonAssertion) String() string {
+	if a.operator == JsonAssertionOperatorExists {
+		return fmt.Sprintf("%s exists", a.path)
+	}
+
+	value, _ := json.Marshal(a.value)
+	return fmt.Sprintf("%s %s %s", a.path, a.operator, value)
+}
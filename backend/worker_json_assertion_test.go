@@ -0,0 +1,157 @@
+package main_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	main "semyi"
+)
+
+func TestWorker_JsonAssertions(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		if r.URL.Path == "/text" {
+			w.Write([]byte("ok"))
+			return
+		}
+
+		w.Write([]byte(`{"status": "ok", "version": {"major": 2}, "checks": [{"name": "database", "latency_ms": 12.5}], "maintenance": null}`))
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name       string
+		path       string
+		assertions []main.JsonAssertion
+		success    bool
+		reason     string
+	}{
+		{
+			name:       "equal",
+			assertions: []main.JsonAssertion{{Path: "$.status", Value: "ok"}},
+			success:    true,
+		},
+		{
+			name:       "not equal",
+			assertions: []main.JsonAssertion{{Path: "$.status", Value: "degraded"}},
+			reason:     `json assertion $.status == "degraded" failed: got "ok"`,
+		},
+		{
+			// Numbers of YAML and TOML configurations are decoded as integers
+			name:       "equal number",
+			assertions: []main.JsonAssertion{{Path: "$.version.major", Value: 2}, {Path: "$.version", Value: map[string]any{"major": int64(2)}}},
+			success:    true,
+		},
+		{
+			name:       "different",
+			assertions: []main.JsonAssertion{{Path: "$.status", Operator: main.JsonAssertionOperatorNotEqual, Value: "down"}},
+			success:    true,
+		},
+		{
+			name:       "exists",
+			assertions: []main.JsonAssertion{{Path: "$.maintenance", Operator: main.JsonAssertionOperatorExists}, {Path: "$.checks[0].name", Operator: main.JsonAssertionOperatorExists}},
+			success:    true,
+		},
+		{
+			name:       "missing",
+			assertions: []main.JsonAssertion{{Path: "$.checks[1].name", Operator: main.JsonAssertionOperatorExists}},
+			reason:     "json assertion $.checks[1].name exists failed: no value at the path",
+		},
+		{
+			name: "numeric comparison",
+			assertions: []main.JsonAssertion{
+				{Path: "$.checks[0].latency_ms", Operator: main.JsonAssertionOperatorLess, Value: 100},
+				{Path: "$.checks[0].latency_ms", Operator: main.JsonAssertionOperatorGreaterOrEqual, Value: 12.5},
+				{Path: "$.version.major", Operator: main.JsonAssertionOperatorGreater, Value: 1},
+			},
+			success: true,
+		},
+		{
+			name:       "numeric comparison fails",
+			assertions: []main.JsonAssertion{{Path: "$.checks[0].latency_ms", Operator: main.JsonAssertionOperatorLessOrEqual, Value: 10}},
+			reason:     "json assertion $.checks[0].latency_ms <= 10 failed: got 12.5",
+		},
+		{
+			name:       "numeric comparison of a string",
+			assertions: []main.JsonAssertion{{Path: "$.status", Operator: main.JsonAssertionOperatorGreater, Value: 1}},
+			reason:     `json assertion $.status > 1 failed: got "ok"`,
+		},
+		{
+			name:       "not json",
+			path:       "/text",
+			assertions: []main.JsonAssertion{{Path: "$.status", Value: "ok"}},
+			reason:     "response body is not valid json",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			worker, err := main.NewWorker(main.Monitor{
+				UniqueID:           "json-assertions-test",
+				Name:               "JSON Assertions Test",
+				Type:               main.MonitorTypeHTTP,
+				HttpEndpoint:       server.URL + tt.path,
+				HttpJsonAssertions: tt.assertions,
+				Timeout:            5,
+			}, nil)
+			if err != nil {
+				t.Fatalf("unexpected error creating worker: %v", err)
+			}
+
+			response, err := worker.Check(context.Background())
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if response.Success != tt.success {
+				t.Errorf("expected success to be %v, got %v (%s)", tt.success, response.Success, response.Error)
+			}
+
+			if tt.success {
+				return
+			}
+
+			if response.Error != tt.reason {
+				t.Errorf("expected error %q, got %q", tt.reason, response.Error)
+			}
+
+			if response.ErrorCategory != main.ErrorCategoryBodyMismatch {
+				t.Errorf("expected error category %q, got %q", main.ErrorCategoryBodyMismatch, response.ErrorCategory)
+			}
+		})
+	}
+}
+
+func TestMonitor_Validate_JsonAssertions(t *testing.T) {
+	tests := []struct {
+		name       string
+		assertions []main.JsonAssertion
+		valid      bool
+	}{
+		{name: "equal", assertions: []main.JsonAssertion{{Path: "$.status", Value: "ok"}}, valid: true},
+		{name: "empty path", assertions: []main.JsonAssertion{{Value: "ok"}}, valid: false},
+		{name: "invalid path", assertions: []main.JsonAssertion{{Path: "$.[status", Value: "ok"}}, valid: false},
+		{name: "unknown operator", assertions: []main.JsonAssertion{{Path: "$.status", Operator: "contains", Value: "ok"}}, valid: false},
+		{name: "numeric comparison of a string", assertions: []main.JsonAssertion{{Path: "$.latency", Operator: main.JsonAssertionOperatorLess, Value: "100"}}, valid: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			monitor := main.Monitor{
+				UniqueID:           "json-assertions-validate-test",
+				Name:               "JSON Assertions Validate Test",
+				Type:               main.MonitorTypeHTTP,
+				HttpEndpoint:       "https://example.com",
+				HttpJsonAssertions: tt.assertions,
+			}
+
+			valid, err := monitor.Validate()
+			if valid != tt.valid {
+				t.Errorf("expected valid to be %v, got %v (%v)", tt.valid, valid, err)
+			}
+		})
+	}
+}
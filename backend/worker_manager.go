@@ -0,0 +1,291 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sort"
+	"sync"
+
+	"github.com/rs/zerolog/log"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+// WorkerManager runs a worker for each configured monitor, and applies changes of the monitor list
+// when the configuration is reloaded.
+type WorkerManager struct {
+	mu        sync.Mutex
+	processor *Processor
+	registry  *MonitorRegistry
+	options   WorkerManagerOptions
+	workers   map[string]*managedWorker
+}
+
+// managedWorker is a monitor known to the manager. Its cancel is nil when the monitor is disabled or paused,
+// and thus has no running worker.
+type managedWorker struct {
+	monitor Monitor
+	cancel  context.CancelFunc
+}
+
+func (w *managedWorker) stop() {
+	if w.cancel != nil {
+		w.cancel()
+	}
+}
+
+// ReloadResult lists the unique IDs of the monitors that were changed by Apply.
+type ReloadResult struct {
+	Added   []string
+	Removed []string
+	Updated []string
+}
+
+// WorkerManagerOptions specifies the resources that are shared by every worker of a manager.
+type WorkerManagerOptions struct {
+	// CheckPool limits how many checks run at the same time. The checks are not limited if it's nil.
+	CheckPool *CheckPool
+	// HttpClient is used by every HTTP monitor, so connections are reused between checks.
+	// Defaults to a client created by NewHttpClient with the default configuration.
+	HttpClient *http.Client
+	// TracerProvider records the spans of the checks. Defaults to a no-op provider.
+	TracerProvider trace.TracerProvider
+	// CheckStats records the result of every check for the diagnostics endpoint. This is optional.
+	CheckStats *CheckStats
+	// PauseStore holds the monitors that are paused at runtime. Defaults to a store that is only kept in memory.
+	PauseStore *PauseStore
+}
+
+// ErrMonitorNotFound is returned when pausing or resuming a monitor that is not in the monitor list.
+var ErrMonitorNotFound = errors.New("monitor not found")
+
+func NewWorkerManager(processor *Processor, registry *MonitorRegistry) *WorkerManager {
+	return NewWorkerManagerWithOptions(processor, registry, WorkerManagerOptions{})
+}
+
+// NewWorkerManagerWithOptions creates a manager whose workers share the resources of the options.
+func NewWorkerManagerWithOptions(processor *Processor, registry *MonitorRegistry, options WorkerManagerOptions) *WorkerManager {
+	if registry == nil {
+		registry = NewMonitorRegistry(nil)
+	}
+
+	if options.HttpClient == nil {
+		options.HttpClient = DefaultHttpClient
+	}
+
+	if options.TracerProvider == nil {
+		options.TracerProvider = noop.NewTracerProvider()
+	}
+
+	if options.PauseStore == nil {
+		options.PauseStore = NewPauseStore(nil)
+	}
+
+	return &WorkerManager{
+		processor: processor,
+		registry:  registry,
+		options:   options,
+		workers:   make(map[string]*managedWorker),
+	}
+}
+
+// Apply makes the running workers match the given monitors: workers are started for new monitors, stopped for
+// removed monitors, and restarted for changed monitors. Every monitor is validated before anything is changed,
+// so an invalid monitor list leaves the running workers intact. Disabled and paused monitors are validated as
+// well, but their workers are not started.
+func (m *WorkerManager) Apply(monitors []Monitor) (ReloadResult, error) {
+	workers := make(map[string]*Worker, len(monitors))
+	for _, monitor := range monitors {
+		if _, ok := workers[monitor.UniqueID]; ok {
+			return ReloadResult{}, fmt.Errorf("duplicate monitor unique_id: %s", monitor.UniqueID)
+		}
+
+		worker, err := m.newWorker(monitor)
+		if err != nil {
+			return ReloadResult{}, fmt.Errorf("invalid monitor %s: %w", monitor.UniqueID, err)
+		}
+
+		workers[monitor.UniqueID] = worker
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var result ReloadResult
+	for id, running := range m.workers {
+		if _, ok := workers[id]; !ok {
+			running.stop()
+			delete(m.workers, id)
+			result.Removed = append(result.Removed, id)
+		}
+	}
+
+	for _, monitor := range monitors {
+		running, ok := m.workers[monitor.UniqueID]
+		if ok && reflect.DeepEqual(running.monitor, monitor) {
+			continue
+		}
+
+		if ok {
+			running.stop()
+			result.Updated = append(result.Updated, monitor.UniqueID)
+		} else {
+			result.Added = append(result.Added, monitor.UniqueID)
+		}
+
+		if !monitor.IsEnabled() {
+			log.Info().Str("UniqueID", monitor.UniqueID).Str("Name", monitor.Name).Msg("Monitor is disabled")
+			m.workers[monitor.UniqueID] = &managedWorker{monitor: monitor}
+			continue
+		}
+
+		if m.options.PauseStore.Paused(monitor.UniqueID) {
+			log.Info().Str("UniqueID", monitor.UniqueID).Str("Name", monitor.Name).Msg("Monitor is paused")
+			m.workers[monitor.UniqueID] = &managedWorker{monitor: monitor}
+			continue
+		}
+
+		m.workers[monitor.UniqueID] = m.start(monitor, workers[monitor.UniqueID])
+	}
+
+	m.registry.Replace(monitors)
+
+	sort.Strings(result.Added)
+	sort.Strings(result.Removed)
+	sort.Strings(result.Updated)
+	return result, nil
+}
+
+// Reload re-reads the configuration file and applies its monitors. The running workers are left intact
+// if the configuration file is malformed. Webhook destinations are not reloaded.
+func (m *WorkerManager) Reload(configPath string) (ReloadResult, error) {
+	config, err := ReadConfigurationFile(configPath)
+	if err != nil {
+		return ReloadResult{}, err
+	}
+
+	if err := ValidateConfig(config); err != nil {
+		return ReloadResult{}, err
+	}
+
+	return m.Apply(config.Monitors)
+}
+
+// Pause stops the worker of the monitor until it's resumed, even across restarts. The monitor stays in the
+// monitor list, so its historical data is still served. It returns ErrMonitorNotFound for unknown monitors.
+func (m *WorkerManager) Pause(ctx context.Context, monitorId string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	running, ok := m.workers[monitorId]
+	if !ok {
+		return ErrMonitorNotFound
+	}
+
+	if err := m.options.PauseStore.Pause(ctx, monitorId); err != nil {
+		return err
+	}
+
+	if running.cancel != nil {
+		running.stop()
+		m.workers[monitorId] = &managedWorker{monitor: running.monitor}
+		log.Info().Str("UniqueID", monitorId).Str("Name", running.monitor.Name).Msg("Paused monitor")
+	}
+
+	return nil
+}
+
+// Resume starts the worker of a paused monitor again. A monitor that is disabled in the configuration stays
+// disabled. It returns ErrMonitorNotFound for unknown monitors.
+func (m *WorkerManager) Resume(ctx context.Context, monitorId string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	running, ok := m.workers[monitorId]
+	if !ok {
+		return ErrMonitorNotFound
+	}
+
+	if err := m.options.PauseStore.Resume(ctx, monitorId); err != nil {
+		return err
+	}
+
+	if running.cancel != nil || !running.monitor.IsEnabled() {
+		return nil
+	}
+
+	worker, err := m.newWorker(running.monitor)
+	if err != nil {
+		return fmt.Errorf("invalid monitor %s: %w", monitorId, err)
+	}
+
+	m.workers[monitorId] = m.start(running.monitor, worker)
+	return nil
+}
+
+// Paused reports whether the monitor is paused at runtime. Monitors that are disabled in the configuration
+// are not reported as paused by it.
+func (m *WorkerManager) Paused(monitorId string) bool {
+	return m.options.PauseStore.Paused(monitorId)
+}
+
+// Running returns the sorted unique IDs of the monitors that have a running worker.
+func (m *WorkerManager) Running() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	ids := make([]string, 0, len(m.workers))
+	for id, running := range m.workers {
+		if running.cancel != nil {
+			ids = append(ids, id)
+		}
+	}
+
+	sort.Strings(ids)
+	return ids
+}
+
+// Stop stops every running worker.
+func (m *WorkerManager) Stop() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for id, running := range m.workers {
+		running.stop()
+		delete(m.workers, id)
+	}
+}
+
+func (m *WorkerManager) newWorker(monitor Monitor) (*Worker, error) {
+	worker, err := NewWorker(monitor, m.processor)
+	if err != nil {
+		return nil, err
+	}
+	worker.pool = m.options.CheckPool
+	worker.useHttpClient(m.options.HttpClient)
+	worker.tracer = m.options.TracerProvider.Tracer(tracerName)
+	worker.stats = m.options.CheckStats
+
+	return worker, nil
+}
+
+func (m *WorkerManager) start(monitor Monitor, worker *Worker) *managedWorker {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				log.Warn().Msgf("[Running worker] Recovered from panic: %v", r)
+			}
+		}()
+
+		worker.Run(ctx)
+	}()
+
+	log.Info().Str("UniqueID", monitor.UniqueID).Str("Name", monitor.Name).Msg("Registered monitor")
+
+	return &managedWorker{monitor: monitor, cancel: cancel}
+}
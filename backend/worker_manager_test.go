@@ -0,0 +1,195 @@
+package main_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	main "semyi"
+)
+
+func TestWorkerManager_Reload(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.json")
+	writeConfig := func(content string) {
+		if err := os.WriteFile(configPath, []byte(content), 0o644); err != nil {
+			t.Fatalf("unexpected error writing config: %v", err)
+		}
+	}
+
+	registry := main.NewMonitorRegistry(nil)
+	manager := main.NewWorkerManager(nil, registry)
+	defer manager.Stop()
+
+	server, err := main.NewServer(main.ServerConfig{
+		MonitorHistoricalReader: main.NewMonitorHistoricalReader(database),
+		CentralBroker:           main.NewBroker[main.MonitorHistorical](),
+		MonitorRegistry:         registry,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error creating server: %v", err)
+	}
+
+	writeConfig(`{"monitors": [
+		{"unique_id": "reload-a", "name": "A", "type": "tcp", "tcp_address": "127.0.0.1:1", "interval": 3600},
+		{"unique_id": "reload-b", "name": "B", "type": "tcp", "tcp_address": "127.0.0.1:1", "interval": 3600}
+	]}`)
+	if _, err := manager.Reload(configPath); err != nil {
+		t.Fatalf("unexpected error on initial load: %v", err)
+	}
+
+	if running := manager.Running(); !slices.Equal(running, []string{"reload-a", "reload-b"}) {
+		t.Fatalf("expected reload-a and reload-b to be running, got %v", running)
+	}
+
+	t.Run("Should add, remove, and update monitors", func(t *testing.T) {
+		writeConfig(`{"monitors": [
+			{"unique_id": "reload-a", "name": "A", "type": "tcp", "tcp_address": "127.0.0.1:1", "interval": 1800},
+			{"unique_id": "reload-c", "name": "C", "type": "tcp", "tcp_address": "127.0.0.1:1", "interval": 3600}
+		]}`)
+
+		result, err := manager.Reload(configPath)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if !slices.Equal(result.Added, []string{"reload-c"}) || !slices.Equal(result.Removed, []string{"reload-b"}) || !slices.Equal(result.Updated, []string{"reload-a"}) {
+			t.Errorf("unexpected reload result: %+v", result)
+		}
+
+		if running := manager.Running(); !slices.Equal(running, []string{"reload-a", "reload-c"}) {
+			t.Errorf("expected reload-a and reload-c to be running, got %v", running)
+		}
+
+		recorder := httptest.NewRecorder()
+		server.Handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/api/static?id=reload-c&interval=raw", nil))
+		if recorder.Code != http.StatusOK {
+			t.Errorf("expected the server to know the added monitor, got status code %d", recorder.Code)
+		}
+	})
+
+	t.Run("Should keep the running config on malformed config", func(t *testing.T) {
+		writeConfig(`{"monitors": [`)
+		if _, err := manager.Reload(configPath); err == nil {
+			t.Fatal("expected an error, got nil")
+		}
+
+		writeConfig(`{"monitors": [{"unique_id": "reload-d", "name": "D", "type": "unknown"}]}`)
+		if _, err := manager.Reload(configPath); err == nil {
+			t.Fatal("expected an error, got nil")
+		}
+
+		if running := manager.Running(); !slices.Equal(running, []string{"reload-a", "reload-c"}) {
+			t.Errorf("expected reload-a and reload-c to still be running, got %v", running)
+		}
+
+		if ids := registry.IDs(); !slices.Equal(ids, []string{"reload-a", "reload-c"}) {
+			t.Errorf("expected registry to be intact, got %v", ids)
+		}
+	})
+}
+
+func TestWorkerManager_DisabledMonitor(t *testing.T) {
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer target.Close()
+
+	disabled := false
+	monitors := []main.Monitor{
+		{UniqueID: "disabled-control", Name: "Control", Type: main.MonitorTypeTCP, TcpAddress: target.Listener.Addr().String(), Interval: 3600},
+		{UniqueID: "disabled-test", Name: "Disabled", Type: main.MonitorTypeTCP, TcpAddress: target.Listener.Addr().String(), Interval: 3600, Enabled: &disabled},
+	}
+
+	broker := main.NewBroker[main.MonitorHistorical]()
+	processor := main.NewProcessor(main.ProcessorConfig{
+		HistoricalWriter: main.NewMonitorHistoricalWriter(database),
+		HistoricalReader: main.NewMonitorHistoricalReader(database),
+		CentralBroker:    broker,
+		AlertDebouncer:   main.NewAlertDebouncer(),
+	})
+
+	registry := main.NewMonitorRegistry(nil)
+	manager := main.NewWorkerManager(processor, registry)
+	defer manager.Stop()
+
+	result, err := manager.Apply(monitors)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !slices.Equal(result.Added, []string{"disabled-control", "disabled-test"}) {
+		t.Errorf("expected both monitors to be added, got %+v", result)
+	}
+
+	if running := manager.Running(); !slices.Equal(running, []string{"disabled-control"}) {
+		t.Errorf("expected only disabled-control to be running, got %v", running)
+	}
+
+	// The control monitor is checked right away, which is enough time for a check of the disabled monitor
+	deadline := time.Now().Add(time.Second * 5)
+	for {
+		if _, ok := broker.Latest("disabled-control"); ok {
+			break
+		}
+
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for the control snapshot")
+		}
+
+		time.Sleep(time.Millisecond * 10)
+	}
+
+	time.Sleep(time.Millisecond * 200)
+	if message, ok := broker.Latest("disabled-test"); ok {
+		t.Errorf("expected no snapshot of the disabled monitor, got %+v", message)
+	}
+
+	server, err := main.NewServer(main.ServerConfig{
+		MonitorHistoricalReader: main.NewMonitorHistoricalReader(database),
+		CentralBroker:           broker,
+		MonitorRegistry:         registry,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error creating server: %v", err)
+	}
+
+	recorder := httptest.NewRecorder()
+	server.Handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/api/monitors", nil))
+
+	var metadata []main.MonitorMetadata
+	if err := json.NewDecoder(recorder.Body).Decode(&metadata); err != nil {
+		t.Fatalf("unexpected error decoding body: %v", err)
+	}
+
+	paused := make(map[string]bool)
+	for _, monitor := range metadata {
+		paused[monitor.ID] = monitor.Paused
+	}
+
+	if value, ok := paused["disabled-test"]; !ok || !value {
+		t.Errorf("expected the disabled monitor to be listed as paused, got %+v", metadata)
+	}
+
+	if value, ok := paused["disabled-control"]; !ok || value {
+		t.Errorf("expected the control monitor to be listed as not paused, got %+v", metadata)
+	}
+
+	recorder = httptest.NewRecorder()
+	server.Handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/api/status", nil))
+
+	var snapshots []main.MonitorStatusSnapshot
+	if err := json.NewDecoder(recorder.Body).Decode(&snapshots); err != nil {
+		t.Fatalf("unexpected error decoding body: %v", err)
+	}
+
+	for _, snapshot := range snapshots {
+		if snapshot.ID == "disabled-test" && (snapshot.Status == nil || *snapshot.Status != "paused" || !snapshot.Paused) {
+			t.Errorf("expected the disabled monitor to be reported as paused, got %+v", snapshot)
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// makeMultiHttpRequest checks every endpoint of a multi_http monitor concurrently. The monitor is up when at
+// least Quorum of the endpoints succeed, the failed endpoints are recorded either way. The response takes the
+// status code, latency, and protocol of the first successful endpoint, or of the first failed one when the quorum
+// is not reached.
+func (w *Worker) makeMultiHttpRequest(ctx context.Context) Response {
+	endpoints := w.monitor.HttpEndpoints
+	responses := make([]Response, len(endpoints))
+
+	var wg sync.WaitGroup
+	for i, endpoint := range endpoints {
+		wg.Add(1)
+		go func(i int, endpoint string) {
+			defer wg.Done()
+
+			response, err := w.makeHttpRequest(ctx, endpoint)
+			if err != nil {
+				response = Response{
+					Success:       false,
+					Timestamp:     time.Now(),
+					Error:         err.Error(),
+					ErrorCategory: ClassifyError(err),
+				}
+			}
+
+			responses[i] = response
+		}(i, endpoint)
+	}
+	wg.Wait()
+
+	var succeeded, failed []int
+	var reasons []string
+	for i, response := range responses {
+		if response.Success {
+			succeeded = append(succeeded, i)
+			continue
+		}
+
+		failed = append(failed, i)
+		reasons = append(reasons, endpoints[i]+": "+response.Error)
+	}
+
+	var response Response
+	if len(succeeded) >= w.monitor.Quorum {
+		response = responses[succeeded[0]]
+		// A degraded endpoint, e.g. with an expiring certificate, still needs attention
+		for _, i := range succeeded {
+			if responses[i].Degraded {
+				response.Degraded = true
+				response.Error = endpoints[i] + ": " + responses[i].Error
+				break
+			}
+		}
+	} else {
+		response = responses[failed[0]]
+		response.Error = fmt.Sprintf("%d of %d endpoints succeeded, below the quorum of %d: %s",
+			len(succeeded), len(endpoints), w.monitor.Quorum, strings.Join(reasons, "; "))
+	}
+
+	for _, i := range failed {
+		response.FailedEndpoints = append(response.FailedEndpoints, endpoints[i])
+	}
+
+	response.Timestamp = time.Now()
+	response.Monitor = w.monitor
+	return response
+}
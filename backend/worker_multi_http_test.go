@@ -0,0 +1,143 @@
+package main_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	main "semyi"
+)
+
+func TestWorker_MultiHttp(t *testing.T) {
+	healthy := func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}
+	failing := func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}
+
+	asia := httptest.NewServer(http.HandlerFunc(healthy))
+	defer asia.Close()
+	europe := httptest.NewServer(http.HandlerFunc(healthy))
+	defer europe.Close()
+	america := httptest.NewServer(http.HandlerFunc(failing))
+	defer america.Close()
+	africa := httptest.NewServer(http.HandlerFunc(failing))
+	defer africa.Close()
+
+	// An endpoint that can't be reached at all fails as well
+	closed := httptest.NewServer(http.HandlerFunc(healthy))
+	closed.Close()
+
+	tests := []struct {
+		name      string
+		endpoints []string
+		quorum    int
+		success   bool
+		failed    []string
+	}{
+		{name: "all succeed", endpoints: []string{asia.URL, europe.URL}, success: true},
+		{name: "any succeeds by default", endpoints: []string{america.URL, asia.URL, closed.URL}, success: true, failed: []string{america.URL, closed.URL}},
+		{name: "quorum reached", endpoints: []string{asia.URL, america.URL, europe.URL}, quorum: 2, success: true, failed: []string{america.URL}},
+		{name: "quorum not reached", endpoints: []string{asia.URL, america.URL, africa.URL}, quorum: 2, success: false, failed: []string{america.URL, africa.URL}},
+		{name: "all fail", endpoints: []string{america.URL, closed.URL}, success: false, failed: []string{america.URL, closed.URL}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			worker, err := main.NewWorker(main.Monitor{
+				UniqueID:      "multi-http-test",
+				Name:          "Multi HTTP Test",
+				Type:          main.MonitorTypeMultiHTTP,
+				HttpEndpoints: tt.endpoints,
+				Quorum:        tt.quorum,
+				Timeout:       5,
+			}, nil)
+			if err != nil {
+				t.Fatalf("unexpected error creating worker: %v", err)
+			}
+
+			response, err := worker.Check(context.Background())
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if response.Success != tt.success {
+				t.Errorf("expected success to be %v, got %v (%s)", tt.success, response.Success, response.Error)
+			}
+
+			if !slices.Equal(response.FailedEndpoints, tt.failed) {
+				t.Errorf("expected failed endpoints %v, got %v", tt.failed, response.FailedEndpoints)
+			}
+
+			if tt.success {
+				if response.StatusCode != http.StatusOK {
+					t.Errorf("expected status code %d, got %d", http.StatusOK, response.StatusCode)
+				}
+
+				return
+			}
+
+			for _, endpoint := range tt.failed {
+				if !strings.Contains(response.Error, endpoint) {
+					t.Errorf("expected the error to name %s, got %q", endpoint, response.Error)
+				}
+			}
+		})
+	}
+}
+
+func TestMonitor_Validate_MultiHttp(t *testing.T) {
+	tests := []struct {
+		name    string
+		monitor main.Monitor
+		valid   bool
+	}{
+		{
+			name:    "valid",
+			monitor: main.Monitor{HttpEndpoints: []string{"https://asia.example.com", "https://europe.example.com"}, Quorum: 2},
+			valid:   true,
+		},
+		{
+			name:    "without endpoints",
+			monitor: main.Monitor{},
+			valid:   false,
+		},
+		{
+			name:    "quorum above the endpoints",
+			monitor: main.Monitor{HttpEndpoints: []string{"https://asia.example.com"}, Quorum: 2},
+			valid:   false,
+		},
+		{
+			name:    "negative quorum",
+			monitor: main.Monitor{HttpEndpoints: []string{"https://asia.example.com"}, Quorum: -1},
+			valid:   false,
+		},
+		{
+			name:    "with http_endpoint",
+			monitor: main.Monitor{HttpEndpoint: "https://example.com", HttpEndpoints: []string{"https://asia.example.com"}},
+			valid:   false,
+		},
+		{
+			name:    "h2 over http",
+			monitor: main.Monitor{HttpEndpoints: []string{"https://asia.example.com", "http://europe.example.com"}, HttpVersion: main.HttpVersion2},
+			valid:   false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.monitor.UniqueID = "multi-http-validate-test"
+			tt.monitor.Name = "Multi HTTP Validate Test"
+			tt.monitor.Type = main.MonitorTypeMultiHTTP
+
+			valid, err := tt.monitor.Validate()
+			if valid != tt.valid {
+				t.Errorf("expected valid to be %v, got %v (%v)", tt.valid, valid, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"container/list"
+	"context"
+	"sync"
+)
+
+// CheckPool limits how many checks run at the same time. Checks wait for a free slot in the order they were
+// scheduled, so a monitor with a long interval is not starved by monitors that are checked more often.
+// A nil pool doesn't limit anything.
+type CheckPool struct {
+	mu      sync.Mutex
+	size    int
+	running int
+	// waiters holds a channel for every check waiting for a slot, the channel is closed once it's given one
+	waiters list.List
+}
+
+// NewCheckPool creates a pool that runs at most size checks at the same time. It returns nil if size is
+// not positive, which means the checks are not limited.
+func NewCheckPool(size int) *CheckPool {
+	if size <= 0 {
+		return nil
+	}
+
+	return &CheckPool{size: size}
+}
+
+// Acquire blocks until a slot is free, or returns the context error if the context is done first.
+// Every successful Acquire must be followed by a Release.
+func (p *CheckPool) Acquire(ctx context.Context) error {
+	if p == nil {
+		return nil
+	}
+
+	p.mu.Lock()
+	if p.running < p.size && p.waiters.Len() == 0 {
+		p.running++
+		p.mu.Unlock()
+		return nil
+	}
+
+	ready := make(chan struct{})
+	element := p.waiters.PushBack(ready)
+	p.mu.Unlock()
+
+	select {
+	case <-ready:
+		return nil
+	case <-ctx.Done():
+		p.mu.Lock()
+		select {
+		case <-ready:
+			// The slot was handed over right before the context was done, pass it on
+			p.mu.Unlock()
+			p.Release()
+		default:
+			p.waiters.Remove(element)
+			p.mu.Unlock()
+		}
+
+		return ctx.Err()
+	}
+}
+
+// Release frees the slot taken by Acquire, handing it over to the check that has waited the longest.
+func (p *CheckPool) Release() {
+	if p == nil {
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if front := p.waiters.Front(); front != nil {
+		// The slot is handed over as is, so running stays the same
+		p.waiters.Remove(front)
+		close(front.Value.(chan struct{}))
+		return
+	}
+
+	p.running--
+}
+
+// Running returns the amount of checks that are holding a slot.
+func (p *CheckPool) Running() int {
+	if p == nil {
+		return 0
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.running
+}
+
+// Waiting returns the amount of checks that are waiting for a slot.
+func (p *CheckPool) Waiting() int {
+	if p == nil {
+		return 0
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.waiters.Len()
+}
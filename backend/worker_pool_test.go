@@ -0,0 +1,174 @@
+package main_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	main "semyi"
+)
+
+func TestCheckPool(t *testing.T) {
+	t.Run("Should never exceed the size", func(t *testing.T) {
+		pool := main.NewCheckPool(3)
+
+		var running, peak atomic.Int64
+		var wg sync.WaitGroup
+		for i := 0; i < 50; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if err := pool.Acquire(context.Background()); err != nil {
+					return
+				}
+				defer pool.Release()
+
+				current := running.Add(1)
+				for {
+					old := peak.Load()
+					if current <= old || peak.CompareAndSwap(old, current) {
+						break
+					}
+				}
+
+				time.Sleep(time.Millisecond)
+				running.Add(-1)
+			}()
+		}
+		wg.Wait()
+
+		if peak.Load() > 3 {
+			t.Errorf("expected at most 3 concurrent checks, got %d", peak.Load())
+		}
+
+		if pool.Running() != 0 || pool.Waiting() != 0 {
+			t.Errorf("expected the pool to be empty, got %d running and %d waiting", pool.Running(), pool.Waiting())
+		}
+	})
+
+	t.Run("Should grant slots in the order they were requested", func(t *testing.T) {
+		pool := main.NewCheckPool(1)
+		if err := pool.Acquire(context.Background()); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		order := make(chan int, 5)
+		for i := 0; i < 5; i++ {
+			go func(i int) {
+				if err := pool.Acquire(context.Background()); err != nil {
+					return
+				}
+				order <- i
+				pool.Release()
+			}(i)
+
+			// Wait for the check to be queued, so the order is deterministic
+			deadline := time.Now().Add(time.Second * 5)
+			for pool.Waiting() != i+1 && time.Now().Before(deadline) {
+				time.Sleep(time.Millisecond)
+			}
+		}
+
+		pool.Release()
+		for want := 0; want < 5; want++ {
+			if got := <-order; got != want {
+				t.Fatalf("expected check %d to run, got %d", want, got)
+			}
+		}
+	})
+
+	t.Run("Should stop waiting when the context is done", func(t *testing.T) {
+		pool := main.NewCheckPool(1)
+		if err := pool.Acquire(context.Background()); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*10)
+		defer cancel()
+
+		if err := pool.Acquire(ctx); !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("expected context.DeadlineExceeded, got %v", err)
+		}
+
+		if pool.Waiting() != 0 {
+			t.Errorf("expected the cancelled check to leave the queue, got %d waiting", pool.Waiting())
+		}
+
+		pool.Release()
+		if pool.Running() != 0 {
+			t.Errorf("expected no running checks, got %d", pool.Running())
+		}
+	})
+
+	t.Run("Nil pool should not limit", func(t *testing.T) {
+		pool := main.NewCheckPool(0)
+		if pool != nil {
+			t.Fatalf("expected a nil pool, got %v", pool)
+		}
+
+		for i := 0; i < 10; i++ {
+			if err := pool.Acquire(context.Background()); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		}
+		pool.Release()
+	})
+}
+
+func TestWorkerManager_CheckPool(t *testing.T) {
+	var running, peak, served atomic.Int64
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		current := running.Add(1)
+		defer running.Add(-1)
+		for {
+			old := peak.Load()
+			if current <= old || peak.CompareAndSwap(old, current) {
+				break
+			}
+		}
+
+		time.Sleep(time.Millisecond * 20)
+		served.Add(1)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer testServer.Close()
+
+	// Every monitor is due at the same time
+	var monitors []main.Monitor
+	for i := 0; i < 20; i++ {
+		monitors = append(monitors, main.Monitor{
+			UniqueID:     fmt.Sprintf("pool-%d", i),
+			Name:         fmt.Sprintf("Pool %d", i),
+			Type:         main.MonitorTypeHTTP,
+			HttpEndpoint: testServer.URL,
+			Interval:     3600,
+			Timeout:      10,
+		})
+	}
+
+	manager := main.NewWorkerManagerWithOptions(nil, nil, main.WorkerManagerOptions{CheckPool: main.NewCheckPool(4)})
+	defer manager.Stop()
+
+	if _, err := manager.Apply(monitors); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	deadline := time.Now().Add(time.Second * 10)
+	for served.Load() < 20 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond * 10)
+	}
+
+	if served.Load() != 20 {
+		t.Fatalf("expected every monitor to be checked, got %d checks", served.Load())
+	}
+
+	if peak.Load() > 4 {
+		t.Errorf("expected at most 4 concurrent checks, got %d", peak.Load())
+	}
+}
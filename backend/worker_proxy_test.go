@@ -0,0 +1,208 @@
+package main_test
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+
+	main "semyi"
+)
+
+// serveSocks5 accepts SOCKS5 connections that authenticate with the given credentials (RFC 1928 and RFC 1929),
+// and relays them to the requested address. It records the requested address of the last connection.
+func serveSocks5(listener net.Listener, username, password string, requested *atomic.Value) {
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+
+		go func() {
+			defer conn.Close()
+
+			// Greeting: version, method count, methods. Only username/password is accepted.
+			header := make([]byte, 2)
+			if _, err := io.ReadFull(conn, header); err != nil {
+				return
+			}
+			methods := make([]byte, header[1])
+			if _, err := io.ReadFull(conn, methods); err != nil {
+				return
+			}
+			conn.Write([]byte{5, 2})
+
+			// Authentication: version, username, password
+			if _, err := io.ReadFull(conn, header[:2]); err != nil {
+				return
+			}
+			user := make([]byte, header[1])
+			if _, err := io.ReadFull(conn, user); err != nil {
+				return
+			}
+			if _, err := io.ReadFull(conn, header[:1]); err != nil {
+				return
+			}
+			pass := make([]byte, header[0])
+			if _, err := io.ReadFull(conn, pass); err != nil {
+				return
+			}
+			if string(user) != username || string(pass) != password {
+				conn.Write([]byte{1, 1})
+				return
+			}
+			conn.Write([]byte{1, 0})
+
+			// Request: version, command, reserved, address type, address, port
+			request := make([]byte, 4)
+			if _, err := io.ReadFull(conn, request); err != nil {
+				return
+			}
+
+			var host string
+			switch request[3] {
+			case 1:
+				ip := make([]byte, 4)
+				if _, err := io.ReadFull(conn, ip); err != nil {
+					return
+				}
+				host = net.IP(ip).String()
+			case 3:
+				if _, err := io.ReadFull(conn, header[:1]); err != nil {
+					return
+				}
+				name := make([]byte, header[0])
+				if _, err := io.ReadFull(conn, name); err != nil {
+					return
+				}
+				host = string(name)
+			default:
+				return
+			}
+
+			port := make([]byte, 2)
+			if _, err := io.ReadFull(conn, port); err != nil {
+				return
+			}
+
+			address := net.JoinHostPort(host, strconv.Itoa(int(binary.BigEndian.Uint16(port))))
+			requested.Store(address)
+
+			upstream, err := net.Dial("tcp", address)
+			if err != nil {
+				conn.Write([]byte{5, 5, 0, 1, 0, 0, 0, 0, 0, 0})
+				return
+			}
+			defer upstream.Close()
+
+			conn.Write([]byte{5, 0, 0, 1, 0, 0, 0, 0, 0, 0})
+			go io.Copy(upstream, conn)
+			io.Copy(conn, upstream)
+		}()
+	}
+}
+
+func TestWorker_Proxy(t *testing.T) {
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer target.Close()
+
+	t.Run("HTTP proxy", func(t *testing.T) {
+		var proxied atomic.Value
+		proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("Proxy-Authorization") != "Basic "+base64.StdEncoding.EncodeToString([]byte("user:secret")) {
+				w.WriteHeader(http.StatusProxyAuthRequired)
+				return
+			}
+
+			// A forward proxy receives the absolute url of the target
+			proxied.Store(r.URL.String())
+			w.WriteHeader(http.StatusOK)
+		}))
+		defer proxy.Close()
+
+		t.Setenv("PROXY_PASSWORD", "secret")
+		worker, err := main.NewWorker(main.Monitor{
+			UniqueID:               "http-proxy-test",
+			Name:                   "HTTP Proxy Test",
+			Type:                   main.MonitorTypeHTTP,
+			HttpEndpoint:           "http://internal.example.com/health",
+			HttpExpectedStatusCode: "200",
+			HttpProxy:              "http://user:${PROXY_PASSWORD}@" + proxy.Listener.Addr().String(),
+			Timeout:                5,
+		}, nil)
+		if err != nil {
+			t.Fatalf("unexpected error creating worker: %v", err)
+		}
+
+		response, err := worker.Check(context.Background())
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if !response.Success {
+			t.Fatalf("expected success, got failure (status code %d)", response.StatusCode)
+		}
+
+		if got := proxied.Load(); got != "http://internal.example.com/health" {
+			t.Errorf("expected the request to be proxied to http://internal.example.com/health, got %v", got)
+		}
+	})
+
+	t.Run("SOCKS5 proxy", func(t *testing.T) {
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatalf("unexpected error listening: %v", err)
+		}
+		defer listener.Close()
+
+		var requested atomic.Value
+		go serveSocks5(listener, "user", "secret", &requested)
+
+		worker, err := main.NewWorker(main.Monitor{
+			UniqueID:               "socks5-proxy-test",
+			Name:                   "SOCKS5 Proxy Test",
+			Type:                   main.MonitorTypeHTTP,
+			HttpEndpoint:           target.URL,
+			HttpExpectedStatusCode: "200",
+			HttpProxy:              "socks5://user:secret@" + listener.Addr().String(),
+			Timeout:                5,
+		}, nil)
+		if err != nil {
+			t.Fatalf("unexpected error creating worker: %v", err)
+		}
+
+		response, err := worker.Check(context.Background())
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if !response.Success {
+			t.Fatalf("expected success, got failure (status code %d)", response.StatusCode)
+		}
+
+		if got := requested.Load(); got != target.Listener.Addr().String() {
+			t.Errorf("expected the proxy to connect to %s, got %v", target.Listener.Addr().String(), got)
+		}
+	})
+
+	t.Run("Unsupported proxy scheme", func(t *testing.T) {
+		_, err := main.NewWorker(main.Monitor{
+			UniqueID:     "ftp-proxy-test",
+			Name:         "FTP Proxy Test",
+			Type:         main.MonitorTypeHTTP,
+			HttpEndpoint: target.URL,
+			HttpProxy:    "ftp://proxy.example.com",
+		}, nil)
+		if err == nil {
+			t.Error("expected an error, got nil")
+		}
+	})
+}
@@ -0,0 +1,97 @@
+package main_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	main "semyi"
+)
+
+func TestWorker_Retries(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Only the first request of every check fails
+		if requests.Add(1)%2 == 1 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	t.Run("Should record the retried check as up", func(t *testing.T) {
+		requests.Store(0)
+		monitor := main.Monitor{
+			UniqueID:     "retry-test",
+			Name:         "Retry Test",
+			Type:         main.MonitorTypeHTTP,
+			HttpEndpoint: server.URL,
+			Timeout:      5,
+			Retries:      2,
+			RetryDelay:   10,
+		}
+
+		worker, err := main.NewWorker(monitor, nil)
+		if err != nil {
+			t.Fatalf("unexpected error creating worker: %v", err)
+		}
+
+		response, err := worker.Check(context.Background())
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if !response.Success || response.Retries != 1 {
+			t.Fatalf("expected a successful check after 1 retry, got success %v after %d retries (%s)", response.Success, response.Retries, response.Error)
+		}
+
+		if got := requests.Load(); got != 2 {
+			t.Errorf("expected 2 requests, got %d", got)
+		}
+
+		processor := main.NewProcessor(main.ProcessorConfig{
+			HistoricalWriter: main.NewMonitorHistoricalWriter(database),
+			HistoricalReader: main.NewMonitorHistoricalReader(database),
+			CentralBroker:    main.NewBroker[main.MonitorHistorical](),
+			AlertDebouncer:   main.NewAlertDebouncer(),
+			MonitorRegistry:  main.NewMonitorRegistry([]main.Monitor{monitor}),
+		})
+		processor.ProcessResponse(response)
+
+		snapshot, err := main.NewMonitorHistoricalReader(database).ReadRawLatest(context.Background(), "retry-test")
+		if err != nil {
+			t.Fatalf("unexpected error reading the snapshot: %v", err)
+		}
+
+		if snapshot.Status != main.MonitorStatusSuccess || snapshot.Retries != 1 {
+			t.Errorf("expected an up snapshot with 1 retry, got %s with %d retries", snapshot.Status, snapshot.Retries)
+		}
+	})
+
+	t.Run("Should not retry without retries", func(t *testing.T) {
+		requests.Store(0)
+		worker, err := main.NewWorker(main.Monitor{
+			UniqueID:     "no-retry-test",
+			Name:         "No Retry Test",
+			Type:         main.MonitorTypeHTTP,
+			HttpEndpoint: server.URL,
+			Timeout:      5,
+		}, nil)
+		if err != nil {
+			t.Fatalf("unexpected error creating worker: %v", err)
+		}
+
+		response, err := worker.Check(context.Background())
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if response.Success || response.Retries != 0 || requests.Load() != 1 {
+			t.Errorf("expected a single failed attempt, got success %v after %d retries and %d requests", response.Success, response.Retries, requests.Load())
+		}
+	})
+}
@@ -1,9 +1,23 @@
 package main_test
 
 import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
+
+	main "semyi"
 )
 
 func parseExpectedStatusCode(expected string, got int) bool {
@@ -79,3 +93,820 @@ func TestParseExpectedStatusCode(t *testing.T) {
 		})
 	}
 }
+
+func TestWorker_TcpCheck(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("unexpected error listening: %v", err)
+	}
+
+	address := listener.Addr().String()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			_ = conn.Close()
+		}
+	}()
+
+	worker, err := main.NewWorker(main.Monitor{
+		UniqueID:   "tcp-test",
+		Name:       "TCP Test",
+		Type:       main.MonitorTypeTCP,
+		TcpAddress: address,
+		Timeout:    1,
+	}, nil)
+	if err != nil {
+		t.Fatalf("unexpected error creating worker: %v", err)
+	}
+
+	t.Run("Should succeed when the port accepts connections", func(t *testing.T) {
+		response, err := worker.Check(context.Background())
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if !response.Success {
+			t.Errorf("expected success, got failure: %s", response.Error)
+		}
+
+		if response.RequestDuration < 0 {
+			t.Errorf("expected non-negative latency, got %d", response.RequestDuration)
+		}
+	})
+
+	t.Run("Should fail when the port stops accepting connections", func(t *testing.T) {
+		if err := listener.Close(); err != nil {
+			t.Fatalf("unexpected error closing listener: %v", err)
+		}
+
+		response, err := worker.Check(context.Background())
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if response.Success {
+			t.Error("expected failure, got success")
+		}
+
+		if response.Error == "" {
+			t.Error("expected error message to be preserved, got empty string")
+		}
+	})
+}
+
+func TestWorker_IcmpCheck(t *testing.T) {
+	worker, err := main.NewWorker(main.Monitor{
+		UniqueID:     "icmp-test",
+		Name:         "ICMP Test",
+		Type:         main.MonitorTypePing,
+		IcmpHostname: "127.0.0.1",
+		Timeout:      5,
+	}, nil)
+	if err != nil {
+		t.Fatalf("unexpected error creating worker: %v", err)
+	}
+
+	response, err := worker.Check(context.Background())
+	if err != nil {
+		// Neither raw nor unprivileged ICMP sockets are allowed on this machine
+		t.Skipf("icmp is not available: %v", err)
+	}
+
+	if !response.Success {
+		t.Error("expected success, got failure")
+	}
+
+	if response.PacketLoss != 0 {
+		t.Errorf("expected zero packet loss, got %f", response.PacketLoss)
+	}
+}
+
+func TestWorker_TlsExpiryCheck(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/error" {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	// Trust the test server certificate on the default client that the worker uses
+	certPool := x509.NewCertPool()
+	certPool.AddCert(server.Certificate())
+	transport := main.DefaultHttpClient.Transport.(*http.Transport)
+	previousTlsConfig := transport.TLSClientConfig
+	transport.TLSClientConfig = &tls.Config{RootCAs: certPool}
+	t.Cleanup(func() {
+		transport.TLSClientConfig = previousTlsConfig
+	})
+
+	expectedDays := int(time.Until(server.Certificate().NotAfter).Hours() / 24)
+
+	tests := []struct {
+		name          string
+		path          string
+		thresholdDays int
+		wantDegraded  bool
+		wantError     string
+	}{
+		{"certificate outside threshold", "", 14, false, ""},
+		{"certificate within threshold", "", expectedDays + 1, true, fmt.Sprintf("tls certificate expires in %d days", expectedDays)},
+		{"failed check within threshold", "/error", expectedDays + 1, false, "unexpected status code 500"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			worker, err := main.NewWorker(main.Monitor{
+				UniqueID:               "tls-test",
+				Name:                   "TLS Test",
+				Type:                   main.MonitorTypeHTTP,
+				HttpEndpoint:           server.URL + tt.path,
+				HttpExpectedStatusCode: "200",
+				CheckTlsExpiry:         true,
+				TlsExpiryThresholdDays: tt.thresholdDays,
+				Timeout:                5,
+			}, nil)
+			if err != nil {
+				t.Fatalf("unexpected error creating worker: %v", err)
+			}
+
+			response, err := worker.Check(context.Background())
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if response.TlsExpiryDays != expectedDays {
+				t.Errorf("expected %d days until expiry, got %d", expectedDays, response.TlsExpiryDays)
+			}
+
+			if response.Degraded != tt.wantDegraded {
+				t.Errorf("expected degraded to be %v, got %v", tt.wantDegraded, response.Degraded)
+			}
+
+			if response.Error != tt.wantError {
+				t.Errorf("expected error %q, got %q", tt.wantError, response.Error)
+			}
+		})
+	}
+}
+
+func TestWorker_HttpRequest(t *testing.T) {
+	type receivedRequest struct {
+		method string
+		body   string
+		header string
+	}
+
+	received := make(chan receivedRequest, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		received <- receivedRequest{
+			method: r.Method,
+			body:   string(body),
+			header: r.Header.Get("X-Readiness-Token"),
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	worker, err := main.NewWorker(main.Monitor{
+		UniqueID:               "http-post-test",
+		Name:                   "HTTP POST Test",
+		Type:                   main.MonitorTypeHTTP,
+		HttpEndpoint:           server.URL,
+		HttpMethod:             http.MethodPost,
+		HttpBody:               `{"ping":true}`,
+		HttpHeaders:            map[string]string{"X-Readiness-Token": "secret"},
+		HttpExpectedStatusCode: "200",
+		Timeout:                5,
+	}, nil)
+	if err != nil {
+		t.Fatalf("unexpected error creating worker: %v", err)
+	}
+
+	response, err := worker.Check(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !response.Success {
+		t.Errorf("expected success, got failure")
+	}
+
+	request := <-received
+	if request.method != http.MethodPost {
+		t.Errorf("expected method POST, got %s", request.method)
+	}
+
+	if request.body != `{"ping":true}` {
+		t.Errorf("expected body to be sent, got %q", request.body)
+	}
+
+	if request.header != "secret" {
+		t.Errorf("expected custom header to be sent, got %q", request.header)
+	}
+}
+
+func TestWorker_HttpBodyAssertion(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		if r.URL.Path == "/large" {
+			// The expected value is placed beyond the read limit
+			_, _ = w.Write([]byte(strings.Repeat("a", 2<<20)))
+			_, _ = w.Write([]byte(`{"status":"ok"}`))
+			return
+		}
+
+		_, _ = w.Write([]byte(`{"status":"degraded"}`))
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name     string
+		path     string
+		contains string
+		regex    string
+		want     bool
+	}{
+		{"substring matches", "/", `"status":"degraded"`, "", true},
+		{"substring does not match", "/", `"status":"ok"`, "", false},
+		{"regex matches", "/", "", `"status":\s*"degraded"`, true},
+		{"regex does not match", "/", "", `"status":\s*"ok"`, false},
+		{"body beyond the limit is ignored", "/large", `"status":"ok"`, "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			worker, err := main.NewWorker(main.Monitor{
+				UniqueID:                 "http-body-test",
+				Name:                     "HTTP Body Test",
+				Type:                     main.MonitorTypeHTTP,
+				HttpEndpoint:             server.URL + tt.path,
+				HttpExpectedStatusCode:   "200",
+				HttpExpectedBodyContains: tt.contains,
+				HttpExpectedBodyRegex:    tt.regex,
+				Timeout:                  5,
+			}, nil)
+			if err != nil {
+				t.Fatalf("unexpected error creating worker: %v", err)
+			}
+
+			response, err := worker.Check(context.Background())
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if response.Success != tt.want {
+				t.Errorf("expected success to be %v, got %v (%s)", tt.want, response.Success, response.Error)
+			}
+		})
+	}
+}
+
+func TestWorker_HttpResponseSize(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(strings.Repeat("a", 100)))
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name     string
+		minBytes int64
+		maxBytes int64
+		want     bool
+	}{
+		{"no bounds", 0, 0, true},
+		{"under the minimum", 101, 0, false},
+		{"within the bounds", 100, 100, true},
+		{"over the maximum", 10, 99, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			worker, err := main.NewWorker(main.Monitor{
+				UniqueID:               "http-size-test",
+				Name:                   "HTTP Size Test",
+				Type:                   main.MonitorTypeHTTP,
+				HttpEndpoint:           server.URL,
+				HttpExpectedStatusCode: "200",
+				HttpMinBytes:           tt.minBytes,
+				HttpMaxBytes:           tt.maxBytes,
+				Timeout:                5,
+			}, nil)
+			if err != nil {
+				t.Fatalf("unexpected error creating worker: %v", err)
+			}
+
+			response, err := worker.Check(context.Background())
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if response.ResponseSize != 100 {
+				t.Errorf("expected a response size of 100 bytes, got %d", response.ResponseSize)
+			}
+
+			if response.Success != tt.want {
+				t.Errorf("expected success to be %v, got %v (%s)", tt.want, response.Success, response.Error)
+			}
+		})
+	}
+
+	t.Run("min_bytes greater than max_bytes", func(t *testing.T) {
+		_, err := main.NewWorker(main.Monitor{
+			UniqueID:     "http-size-test",
+			Name:         "HTTP Size Test",
+			Type:         main.MonitorTypeHTTP,
+			HttpEndpoint: server.URL,
+			HttpMinBytes: 100,
+			HttpMaxBytes: 10,
+		}, nil)
+		if err == nil {
+			t.Error("expected an error, got nil")
+		}
+	})
+}
+
+func TestWorker_ExpectedStatusCodes(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name                string
+		expectedStatusCodes []int
+		want                bool
+	}{
+		{"401 is healthy when configured", []int{200, 301, 401}, true},
+		{"401 is unhealthy when not configured", []int{200, 301}, false},
+		{"401 is unhealthy by default", nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			worker, err := main.NewWorker(main.Monitor{
+				UniqueID:                "status-code-test",
+				Name:                    "Status Code Test",
+				Type:                    main.MonitorTypeHTTP,
+				HttpEndpoint:            server.URL,
+				HttpExpectedStatusCodes: tt.expectedStatusCodes,
+				Timeout:                 5,
+			}, nil)
+			if err != nil {
+				t.Fatalf("unexpected error creating worker: %v", err)
+			}
+
+			response, err := worker.Check(context.Background())
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if response.Success != tt.want {
+				t.Errorf("expected success to be %v, got %v", tt.want, response.Success)
+			}
+		})
+	}
+}
+
+func TestWorker_HttpAuthorization(t *testing.T) {
+	received := make(chan string, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received <- r.Header.Get("Authorization")
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	t.Setenv("SEMYA_TEST_HEALTH_PASSWORD", "hunter2")
+	t.Setenv("SEMYA_TEST_HEALTH_TOKEN", "token-from-env")
+
+	tests := []struct {
+		name        string
+		basicAuth   *main.BasicAuth
+		bearerToken string
+		want        string
+	}{
+		{
+			name:      "basic auth",
+			basicAuth: &main.BasicAuth{Username: "semya", Password: "${SEMYA_TEST_HEALTH_PASSWORD}"},
+			want:      "Basic c2VteWE6aHVudGVyMg==",
+		},
+		{
+			name:        "bearer token",
+			bearerToken: "${SEMYA_TEST_HEALTH_TOKEN}",
+			want:        "Bearer token-from-env",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			worker, err := main.NewWorker(main.Monitor{
+				UniqueID:        "http-auth-test",
+				Name:            "HTTP Auth Test",
+				Type:            main.MonitorTypeHTTP,
+				HttpEndpoint:    server.URL,
+				HttpBasicAuth:   tt.basicAuth,
+				HttpBearerToken: tt.bearerToken,
+				Timeout:         5,
+			}, nil)
+			if err != nil {
+				t.Fatalf("unexpected error creating worker: %v", err)
+			}
+
+			_, err = worker.Check(context.Background())
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if got := <-received; got != tt.want {
+				t.Errorf("expected Authorization header %q, got %q", tt.want, got)
+			}
+		})
+	}
+
+	t.Run("Should fail on unset environment variable", func(t *testing.T) {
+		_, err := main.NewWorker(main.Monitor{
+			UniqueID:        "http-auth-test",
+			Name:            "HTTP Auth Test",
+			Type:            main.MonitorTypeHTTP,
+			HttpEndpoint:    server.URL,
+			HttpBearerToken: "${SEMYA_TEST_UNSET_TOKEN}",
+		}, nil)
+		if err == nil {
+			t.Error("expected error, got nil")
+		}
+	})
+}
+
+func TestWorker_UserAgent(t *testing.T) {
+	received := make(chan string, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received <- r.Header.Get("User-Agent")
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	configPath := filepath.Join(t.TempDir(), "config.json")
+	err := os.WriteFile(configPath, []byte(`{
+		"user_agent": "semya-global/1.0",
+		"monitors": [
+			{"unique_id": "inherited", "name": "Inherited", "type": "http", "http_endpoint": "`+server.URL+`"},
+			{"unique_id": "own", "name": "Own", "type": "http", "http_endpoint": "`+server.URL+`", "user_agent": "semya-monitor/2.0"}
+		]
+	}`), 0o644)
+	if err != nil {
+		t.Fatalf("unexpected error writing config: %v", err)
+	}
+
+	config, err := main.ReadConfigurationFile(configPath)
+	if err != nil {
+		t.Fatalf("unexpected error reading config: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		monitor main.Monitor
+		want    string
+	}{
+		{
+			name:    "default",
+			monitor: main.Monitor{UniqueID: "default", Name: "Default", Type: main.MonitorTypeHTTP, HttpEndpoint: server.URL},
+			want:    main.DefaultUserAgent(),
+		},
+		{
+			name:    "global",
+			monitor: config.Monitors[0],
+			want:    "semya-global/1.0",
+		},
+		{
+			name:    "per monitor",
+			monitor: config.Monitors[1],
+			want:    "semya-monitor/2.0",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.monitor.Timeout = 5
+			worker, err := main.NewWorker(tt.monitor, nil)
+			if err != nil {
+				t.Fatalf("unexpected error creating worker: %v", err)
+			}
+
+			_, err = worker.Check(context.Background())
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if got := <-received; got != tt.want {
+				t.Errorf("expected User-Agent header %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestWorker_HttpTimeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(time.Second * 5):
+		}
+	}))
+	defer server.Close()
+
+	worker, err := main.NewWorker(main.Monitor{
+		UniqueID:     "http-timeout-test",
+		Name:         "HTTP Timeout Test",
+		Type:         main.MonitorTypeHTTP,
+		HttpEndpoint: server.URL,
+		Timeout:      1,
+	}, nil)
+	if err != nil {
+		t.Fatalf("unexpected error creating worker: %v", err)
+	}
+
+	start := time.Now()
+	response, err := worker.Check(context.Background())
+	elapsed := time.Since(start)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if response.Success {
+		t.Error("expected a failed check")
+	}
+
+	if response.Error != main.ResponseErrorTimeout {
+		t.Errorf("expected error reason %q, got %q", main.ResponseErrorTimeout, response.Error)
+	}
+
+	if elapsed < time.Second || elapsed > time.Second+time.Millisecond*500 {
+		t.Errorf("expected the check to time out after 1s, took %s", elapsed)
+	}
+}
+
+func TestWorker_FollowRedirects(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/login", http.StatusFound)
+	})
+	mux.HandleFunc("/login", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	followRedirects := true
+	doNotFollowRedirects := false
+
+	tests := []struct {
+		name            string
+		followRedirects *bool
+		wantStatusCode  int
+		wantSuccess     bool
+	}{
+		{name: "default", followRedirects: nil, wantStatusCode: http.StatusOK, wantSuccess: true},
+		{name: "follow", followRedirects: &followRedirects, wantStatusCode: http.StatusOK, wantSuccess: true},
+		{name: "do not follow", followRedirects: &doNotFollowRedirects, wantStatusCode: http.StatusFound, wantSuccess: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			worker, err := main.NewWorker(main.Monitor{
+				UniqueID:               "follow-redirects-test",
+				Name:                   "Follow Redirects Test",
+				Type:                   main.MonitorTypeHTTP,
+				HttpEndpoint:           server.URL + "/health",
+				HttpExpectedStatusCode: "2xx",
+				HttpFollowRedirects:    tt.followRedirects,
+				Timeout:                5,
+			}, nil)
+			if err != nil {
+				t.Fatalf("unexpected error creating worker: %v", err)
+			}
+
+			response, err := worker.Check(context.Background())
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if response.StatusCode != tt.wantStatusCode {
+				t.Errorf("expected status code %d, got %d", tt.wantStatusCode, response.StatusCode)
+			}
+
+			if response.Success != tt.wantSuccess {
+				t.Errorf("expected success %t, got %t", tt.wantSuccess, response.Success)
+			}
+		})
+	}
+}
+
+func TestWorker_Jitter(t *testing.T) {
+	newWorker := func(t *testing.T, uniqueId string, jitter int, seed int64) *main.Worker {
+		worker, err := main.NewWorker(main.Monitor{
+			UniqueID:   uniqueId,
+			Name:       uniqueId,
+			Type:       main.MonitorTypeTCP,
+			TcpAddress: "localhost:80",
+			Interval:   60,
+			Timeout:    10,
+			Jitter:     jitter,
+			JitterSeed: seed,
+		}, nil)
+		if err != nil {
+			t.Fatalf("unexpected error creating worker: %v", err)
+		}
+
+		return worker
+	}
+
+	t.Run("Should spread the first checks across the interval", func(t *testing.T) {
+		// Split the interval into 6 buckets of 10 seconds, 60 monitors should land in most of them
+		buckets := map[time.Duration]int{}
+		for i := 0; i < 60; i++ {
+			delay := newWorker(t, fmt.Sprintf("jitter-%d", i), 100, 42).NextDelay(true)
+			if delay < 0 || delay >= time.Minute {
+				t.Fatalf("expected the first delay within the interval, got %s", delay)
+			}
+
+			buckets[delay/(time.Second*10)]++
+		}
+
+		if len(buckets) < 5 {
+			t.Errorf("expected the first checks to be spread across the interval, got %v", buckets)
+		}
+
+		for bucket, count := range buckets {
+			if count > 30 {
+				t.Errorf("expected no bucket to hold half of the checks, bucket %d has %d", bucket, count)
+			}
+		}
+	})
+
+	t.Run("Should keep the following checks around the interval", func(t *testing.T) {
+		worker := newWorker(t, "jitter-interval", 20, 42)
+		for i := 0; i < 100; i++ {
+			delay := worker.NextDelay(false)
+			if delay < time.Second*54 || delay >= time.Second*66 {
+				t.Fatalf("expected the delay within 54s and 66s, got %s", delay)
+			}
+		}
+	})
+
+	t.Run("Should be deterministic with a seed", func(t *testing.T) {
+		first := newWorker(t, "jitter-seed", 50, 7)
+		second := newWorker(t, "jitter-seed", 50, 7)
+		for i := 0; i < 10; i++ {
+			if a, b := first.NextDelay(i == 0), second.NextDelay(i == 0); a != b {
+				t.Fatalf("expected the same delays with the same seed, got %s and %s", a, b)
+			}
+		}
+	})
+
+	t.Run("Should not delay without jitter", func(t *testing.T) {
+		worker := newWorker(t, "jitter-none", 0, 0)
+		if delay := worker.NextDelay(true); delay != 0 {
+			t.Errorf("expected no initial delay, got %s", delay)
+		}
+
+		if delay := worker.NextDelay(false); delay != time.Minute {
+			t.Errorf("expected the exact interval, got %s", delay)
+		}
+	})
+}
+
+func TestWorker_ResolveOverride(t *testing.T) {
+	var host, localAddress atomic.Value
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host.Store(r.Host)
+		localAddress.Store(r.Context().Value(http.LocalAddrContextKey).(net.Addr).String())
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name       string
+		hostHeader string
+		wantHost   string
+	}{
+		{"endpoint host", "", "production.example.com"},
+		{"overridden host header", "www.example.com", "www.example.com"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			worker, err := main.NewWorker(main.Monitor{
+				UniqueID:            "resolve-override-test",
+				Name:                "Resolve Override Test",
+				Type:                main.MonitorTypeHTTP,
+				HttpEndpoint:        "http://production.example.com/",
+				HttpResolveOverride: server.Listener.Addr().String(),
+				HttpHostHeader:      tt.hostHeader,
+				Timeout:             5,
+			}, nil)
+			if err != nil {
+				t.Fatalf("unexpected error creating worker: %v", err)
+			}
+
+			response, err := worker.Check(context.Background())
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if !response.Success {
+				t.Fatalf("expected success, got failure (%s)", response.Error)
+			}
+
+			if got := host.Load(); got != tt.wantHost {
+				t.Errorf("expected host %s, got %v", tt.wantHost, got)
+			}
+
+			if got := localAddress.Load(); got != server.Listener.Addr().String() {
+				t.Errorf("expected the connection on %s, got %v", server.Listener.Addr().String(), got)
+			}
+		})
+	}
+
+	t.Run("invalid resolve override", func(t *testing.T) {
+		_, err := main.NewWorker(main.Monitor{
+			UniqueID:            "resolve-override-test",
+			Name:                "Resolve Override Test",
+			Type:                main.MonitorTypeHTTP,
+			HttpEndpoint:        "http://production.example.com/",
+			HttpResolveOverride: "backend.example.com:8080",
+		}, nil)
+		if err == nil {
+			t.Error("expected an error, got nil")
+		}
+	})
+}
+
+func TestWorker_Degraded(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slow" {
+			time.Sleep(time.Millisecond * 100)
+		}
+
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(`{"database":"down"}`))
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name         string
+		monitor      main.Monitor
+		wantSuccess  bool
+		wantDegraded bool
+	}{
+		{
+			name:         "slow response above the threshold",
+			monitor:      main.Monitor{HttpEndpoint: server.URL + "/slow", DegradedLatencyThreshold: 50},
+			wantSuccess:  true,
+			wantDegraded: true,
+		},
+		{
+			name:        "fast response below the threshold",
+			monitor:     main.Monitor{HttpEndpoint: server.URL, DegradedLatencyThreshold: 5000},
+			wantSuccess: true,
+		},
+		{
+			name:         "body mismatch degrades",
+			monitor:      main.Monitor{HttpEndpoint: server.URL, HttpExpectedBodyContains: `"database":"up"`, HttpDegradeOnBodyMismatch: true},
+			wantSuccess:  true,
+			wantDegraded: true,
+		},
+		{
+			name:    "body mismatch fails by default",
+			monitor: main.Monitor{HttpEndpoint: server.URL, HttpExpectedBodyContains: `"database":"up"`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.monitor.UniqueID = "degraded-test"
+			tt.monitor.Name = "Degraded Test"
+			tt.monitor.Type = main.MonitorTypeHTTP
+			tt.monitor.Timeout = 5
+
+			worker, err := main.NewWorker(tt.monitor, nil)
+			if err != nil {
+				t.Fatalf("unexpected error creating worker: %v", err)
+			}
+
+			response, err := worker.Check(context.Background())
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if response.Success != tt.wantSuccess || response.Degraded != tt.wantDegraded {
+				t.Errorf("expected success %v and degraded %v, got %v and %v (%s)", tt.wantSuccess, tt.wantDegraded, response.Success, response.Degraded, response.Error)
+			}
+		})
+	}
+}
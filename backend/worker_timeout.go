@@ -0,0 +1,63 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"time"
+)
+
+// TimeoutPhase specifies the part of an HTTP check that didn't complete within its own timeout.
+type TimeoutPhase string
+
+const (
+	// TimeoutPhaseConnect is the TCP handshake along with the TLS handshake, bound by ConnectTimeout.
+	TimeoutPhaseConnect TimeoutPhase = "connect"
+	// TimeoutPhaseRead is the wait for the response headers, and for each read of the body, bound by ReadTimeout.
+	TimeoutPhaseRead TimeoutPhase = "read"
+)
+
+// errReadTimeout is the cause of the cancelled request when the response body stalls for longer than ReadTimeout.
+var errReadTimeout = errors.New("read timeout")
+
+// timeoutPhase returns the phase that timed out, or an empty phase if the whole check ran out of its timeout
+// first. The context is the one of the check, which is only done when the monitor timeout is reached.
+func (w *Worker) timeoutPhase(ctx context.Context, connected bool) TimeoutPhase {
+	if ctx.Err() != nil {
+		return ""
+	}
+
+	if !connected && w.monitor.ConnectTimeout > 0 {
+		return TimeoutPhaseConnect
+	}
+
+	if connected && w.monitor.ReadTimeout > 0 {
+		return TimeoutPhaseRead
+	}
+
+	return ""
+}
+
+// readTimeoutBody cancels the request once a read of the body takes longer than the timeout, so a response
+// that stalls halfway is told apart from one that is slow to complete.
+type readTimeoutBody struct {
+	io.ReadCloser
+	timeout time.Duration
+	timer   *time.Timer
+}
+
+func newReadTimeoutBody(body io.ReadCloser, timeout time.Duration, cancel context.CancelCauseFunc) *readTimeoutBody {
+	timer := time.AfterFunc(timeout, func() {
+		cancel(errReadTimeout)
+	})
+	timer.Stop()
+
+	return &readTimeoutBody{ReadCloser: body, timeout: timeout, timer: timer}
+}
+
+func (b *readTimeoutBody) Read(p []byte) (int, error) {
+	b.timer.Reset(b.timeout)
+	defer b.timer.Stop()
+
+	return b.ReadCloser.Read(p)
+}
@@ -0,0 +1,161 @@
+package main_test
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	main "semyi"
+)
+
+func TestWorker_PhaseTimeouts(t *testing.T) {
+	// The listener accepts the TCP connection, but never answers the TLS handshake
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("unexpected error listening: %v", err)
+	}
+	defer listener.Close()
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+		}
+	}()
+
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/stall-headers":
+			<-release
+		case "/stall-body":
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte("partial"))
+			w.(http.Flusher).Flush()
+			<-release
+		}
+	}))
+	defer server.Close()
+	defer close(release)
+
+	tests := []struct {
+		name           string
+		endpoint       string
+		connectTimeout int
+		readTimeout    int
+		wantError      string
+		wantPhase      main.TimeoutPhase
+	}{
+		{
+			name:           "stalled at connect",
+			endpoint:       "https://" + listener.Addr().String(),
+			connectTimeout: 1,
+			readTimeout:    1,
+			wantError:      "connect timeout",
+			wantPhase:      main.TimeoutPhaseConnect,
+		},
+		{
+			name:           "stalled before the headers",
+			endpoint:       server.URL + "/stall-headers",
+			connectTimeout: 1,
+			readTimeout:    1,
+			wantError:      "read timeout",
+			wantPhase:      main.TimeoutPhaseRead,
+		},
+		{
+			name:        "stalled at body read",
+			endpoint:    server.URL + "/stall-body",
+			readTimeout: 1,
+			wantError:   "read timeout",
+			wantPhase:   main.TimeoutPhaseRead,
+		},
+		{
+			name:      "monitor timeout without phase timeouts",
+			endpoint:  server.URL + "/stall-headers",
+			wantError: main.ResponseErrorTimeout,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			timeout := 3
+			if tt.connectTimeout == 0 && tt.readTimeout == 0 {
+				timeout = 1
+			}
+
+			worker, err := main.NewWorker(main.Monitor{
+				UniqueID:       "phase-timeout-test",
+				Name:           "Phase Timeout Test",
+				Type:           main.MonitorTypeHTTP,
+				HttpEndpoint:   tt.endpoint,
+				Timeout:        timeout,
+				ConnectTimeout: tt.connectTimeout,
+				ReadTimeout:    tt.readTimeout,
+			}, nil)
+			if err != nil {
+				t.Fatalf("unexpected error creating worker: %v", err)
+			}
+
+			start := time.Now()
+			response, err := worker.Check(context.Background())
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if response.Success {
+				t.Fatal("expected the check to fail")
+			}
+
+			if response.Error != tt.wantError || response.TimeoutPhase != tt.wantPhase || response.ErrorCategory != main.ErrorCategoryTimeout {
+				t.Errorf("expected a %s timeout (%q), got %q %q (%s)", tt.wantPhase, tt.wantError, response.TimeoutPhase, response.Error, response.ErrorCategory)
+			}
+
+			if elapsed := time.Since(start); elapsed > time.Second*2+time.Millisecond*500 && tt.wantPhase != "" {
+				t.Errorf("expected the phase timeout to end the check early, took %s", elapsed)
+			}
+		})
+	}
+}
+
+func TestMonitor_Validate_PhaseTimeouts(t *testing.T) {
+	tests := []struct {
+		name           string
+		timeout        int
+		connectTimeout int
+		readTimeout    int
+		httpVersion    main.HttpVersion
+		valid          bool
+	}{
+		{name: "within the timeout", timeout: 10, connectTimeout: 2, readTimeout: 5, valid: true},
+		{name: "within the default timeout", connectTimeout: 2, valid: true},
+		{name: "negative", timeout: 10, connectTimeout: -1, valid: false},
+		{name: "greater than the timeout", timeout: 5, readTimeout: 10, valid: false},
+		{name: "with http 3", timeout: 10, connectTimeout: 2, httpVersion: main.HttpVersion3, valid: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			monitor := main.Monitor{
+				UniqueID:       "phase-timeout-validate-test",
+				Name:           "Phase Timeout Validate Test",
+				Type:           main.MonitorTypeHTTP,
+				HttpEndpoint:   "https://localhost",
+				Timeout:        tt.timeout,
+				ConnectTimeout: tt.connectTimeout,
+				ReadTimeout:    tt.readTimeout,
+				HttpVersion:    tt.httpVersion,
+			}
+
+			valid, err := monitor.Validate()
+			if valid != tt.valid {
+				t.Errorf("expected valid to be %v, got %v (%v)", tt.valid, valid, err)
+			}
+		})
+	}
+}
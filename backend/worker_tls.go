@@ -0,0 +1,276 @@
+package main
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// tlsFile is a PEM encoded file of the monitor, given either as a path or as the contents themselves, e.g.
+// through an environment variable reference. Files on disk are read again whenever they change, so rotated
+// certificates are picked up without restarting the worker.
+type tlsFile struct {
+	path     string
+	contents []byte
+
+	mu      sync.Mutex
+	modTime time.Time
+	size    int64
+}
+
+// newTlsFile resolves the environment variable references of the value. The value is used as the contents
+// when it's PEM encoded, and as a path otherwise.
+func newTlsFile(value string) (*tlsFile, error) {
+	expanded, err := expandEnvironment(value)
+	if err != nil {
+		return nil, err
+	}
+
+	if bytes.HasPrefix(bytes.TrimSpace([]byte(expanded)), []byte("-----BEGIN")) {
+		return &tlsFile{contents: []byte(expanded)}, nil
+	}
+
+	return &tlsFile{path: expanded}, nil
+}
+
+// read returns the contents of the file, and whether they have changed since the last call. Contents that
+// are not read from disk never change.
+func (f *tlsFile) read() ([]byte, bool, error) {
+	if f.path == "" {
+		return f.contents, false, nil
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	info, err := os.Stat(f.path)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if f.contents != nil && info.ModTime().Equal(f.modTime) && info.Size() == f.size {
+		return f.contents, false, nil
+	}
+
+	contents, err := os.ReadFile(f.path)
+	if err != nil {
+		return nil, false, err
+	}
+
+	f.contents, f.modTime, f.size = contents, info.ModTime(), info.Size()
+	return contents, true, nil
+}
+
+// tlsVersions maps the TLS versions of the configuration to the versions of crypto/tls.
+var tlsVersions = map[TlsVersion]uint16{
+	TlsVersion10: tls.VersionTLS10,
+	TlsVersion11: tls.VersionTLS11,
+	TlsVersion12: tls.VersionTLS12,
+	TlsVersion13: tls.VersionTLS13,
+}
+
+// tlsVersionRange returns the crypto/tls versions of the range, which are zero when they're not set. The minimum
+// is lowered to the maximum when only the maximum is set below the default minimum of crypto/tls.
+func tlsVersionRange(minVersion, maxVersion TlsVersion) (uint16, uint16, error) {
+	var minId, maxId uint16
+	if minVersion != "" {
+		var ok bool
+		if minId, ok = tlsVersions[minVersion]; !ok {
+			return 0, 0, fmt.Errorf("min_tls_version must be 1.0, 1.1, 1.2, or 1.3")
+		}
+	}
+
+	if maxVersion != "" {
+		var ok bool
+		if maxId, ok = tlsVersions[maxVersion]; !ok {
+			return 0, 0, fmt.Errorf("max_tls_version must be 1.0, 1.1, 1.2, or 1.3")
+		}
+	}
+
+	if minId != 0 && maxId != 0 && minId > maxId {
+		return 0, 0, fmt.Errorf("min_tls_version must not be greater than max_tls_version")
+	}
+
+	if minId == 0 && maxId != 0 && maxId < tls.VersionTLS12 {
+		minId = maxId
+	}
+
+	return minId, maxId, nil
+}
+
+// tlsClientConfig holds the client certificate, the certificate authority, and the allowed TLS versions of
+// a monitor, and builds the TLS configuration of its checks from them.
+type tlsClientConfig struct {
+	clientCert         *tlsFile
+	clientKey          *tlsFile
+	caCert             *tlsFile
+	insecureSkipVerify bool
+	minVersion         uint16
+	maxVersion         uint16
+
+	mu          sync.Mutex
+	certificate *tls.Certificate
+	rootCAs     *x509.CertPool
+}
+
+// newTlsClientConfig returns nil when the monitor has neither a client certificate, a certificate authority,
+// a TLS version range, nor skips the verification. The files are loaded right away, so a misconfigured monitor
+// fails to start.
+func newTlsClientConfig(monitor Monitor) (*tlsClientConfig, error) {
+	if monitor.TlsClientCert == "" && monitor.TlsCaCert == "" && !monitor.TlsInsecureSkipVerify && monitor.TlsMinVersion == "" && monitor.TlsMaxVersion == "" {
+		return nil, nil
+	}
+
+	minVersion, maxVersion, err := tlsVersionRange(monitor.TlsMinVersion, monitor.TlsMaxVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	config := &tlsClientConfig{
+		insecureSkipVerify: monitor.TlsInsecureSkipVerify,
+		minVersion:         minVersion,
+		maxVersion:         maxVersion,
+	}
+	if monitor.TlsClientCert != "" {
+		var err error
+		config.clientCert, err = newTlsFile(monitor.TlsClientCert)
+		if err != nil {
+			return nil, fmt.Errorf("invalid client_cert: %w", err)
+		}
+
+		config.clientKey, err = newTlsFile(monitor.TlsClientKey)
+		if err != nil {
+			return nil, fmt.Errorf("invalid client_key: %w", err)
+		}
+
+		if _, err := config.clientCertificate(); err != nil {
+			return nil, err
+		}
+	}
+
+	if monitor.TlsCaCert != "" {
+		var err error
+		config.caCert, err = newTlsFile(monitor.TlsCaCert)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ca_cert: %w", err)
+		}
+
+		if _, err := config.certificateAuthority(); err != nil {
+			return nil, err
+		}
+	}
+
+	return config, nil
+}
+
+// clientCertificate returns the client certificate, reloading it if either file has changed.
+func (c *tlsClientConfig) clientCertificate() (*tls.Certificate, error) {
+	certPEM, certChanged, err := c.clientCert.read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read client_cert: %w", err)
+	}
+
+	keyPEM, keyChanged, err := c.clientKey.read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read client_key: %w", err)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.certificate == nil || certChanged || keyChanged {
+		certificate, err := tls.X509KeyPair(certPEM, keyPEM)
+		if err != nil {
+			return nil, fmt.Errorf("invalid client_cert or client_key: %w", err)
+		}
+
+		c.certificate = &certificate
+	}
+
+	return c.certificate, nil
+}
+
+// certificateAuthority returns the pool of the certificate authority, reloading it if the file has changed.
+func (c *tlsClientConfig) certificateAuthority() (*x509.CertPool, error) {
+	caPEM, changed, err := c.caCert.read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read ca_cert: %w", err)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.rootCAs == nil || changed {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caPEM) {
+			return nil, errors.New("invalid ca_cert: no certificates found")
+		}
+
+		c.rootCAs = pool
+	}
+
+	return c.rootCAs, nil
+}
+
+// TLSConfig returns the configuration of the checks. The certificates are looked up on every handshake rather
+// than set once, so they can be reloaded.
+func (c *tlsClientConfig) TLSConfig() *tls.Config {
+	config := &tls.Config{MinVersion: c.minVersion, MaxVersion: c.maxVersion}
+
+	if c.clientCert != nil {
+		config.GetClientCertificate = func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			return c.clientCertificate()
+		}
+	}
+
+	if c.insecureSkipVerify {
+		config.InsecureSkipVerify = true
+	} else if c.caCert != nil {
+		// RootCAs can't be swapped on a live configuration, so the server is verified by hand instead
+		config.InsecureSkipVerify = true
+		config.VerifyConnection = func(state tls.ConnectionState) error {
+			rootCAs, err := c.certificateAuthority()
+			if err != nil {
+				return err
+			}
+
+			if len(state.PeerCertificates) == 0 {
+				return errors.New("server did not present a certificate")
+			}
+
+			intermediates := x509.NewCertPool()
+			for _, certificate := range state.PeerCertificates[1:] {
+				intermediates.AddCert(certificate)
+			}
+
+			_, err = state.PeerCertificates[0].Verify(x509.VerifyOptions{
+				DNSName:       state.ServerName,
+				Roots:         rootCAs,
+				Intermediates: intermediates,
+			})
+			return err
+		}
+	}
+
+	return config
+}
+
+// versionMismatch returns the reason of a failed check when the connection negotiated a TLS version outside
+// of the range, and an empty string otherwise. The handshake is already constrained to the range, so it only
+// catches transports that don't honor the configuration.
+func (c *tlsClientConfig) versionMismatch(state *tls.ConnectionState) string {
+	if c == nil || state == nil {
+		return ""
+	}
+
+	if (c.minVersion != 0 && state.Version < c.minVersion) || (c.maxVersion != 0 && state.Version > c.maxVersion) {
+		return fmt.Sprintf("negotiated %s, which is outside of the allowed tls versions", tls.VersionName(state.Version))
+	}
+
+	return ""
+}
@@ -0,0 +1,379 @@
+package main_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	main "semyi"
+)
+
+// createClientCertificate creates a self-signed client certificate, and returns it with its PEM encoded
+// certificate and key.
+func createClientCertificate(t *testing.T, name string) (*x509.Certificate, []byte, []byte) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("unexpected error generating key: %v", err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("unexpected error creating certificate: %v", err)
+	}
+
+	certificate, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("unexpected error parsing certificate: %v", err)
+	}
+
+	keyDer, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatalf("unexpected error marshaling key: %v", err)
+	}
+
+	return certificate,
+		pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDer})
+}
+
+func writeFile(t *testing.T, path string, contents []byte) {
+	t.Helper()
+
+	if err := os.WriteFile(path, contents, 0o600); err != nil {
+		t.Fatalf("unexpected error writing %s: %v", path, err)
+	}
+}
+
+func TestWorker_MutualTls(t *testing.T) {
+	trusted, trustedCert, trustedKey := createClientCertificate(t, "trusted")
+	_, untrustedCert, untrustedKey := createClientCertificate(t, "untrusted")
+
+	clientCAs := x509.NewCertPool()
+	clientCAs.AddCert(trusted)
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	server.TLS = &tls.Config{ClientAuth: tls.RequireAndVerifyClientCert, ClientCAs: clientCAs}
+	server.StartTLS()
+	defer server.Close()
+
+	serverCA := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+
+	dir := t.TempDir()
+	caPath := filepath.Join(dir, "ca.pem")
+	certPath := filepath.Join(dir, "client.pem")
+	keyPath := filepath.Join(dir, "client-key.pem")
+	writeFile(t, caPath, serverCA)
+	writeFile(t, certPath, trustedCert)
+	writeFile(t, keyPath, trustedKey)
+
+	t.Setenv("SEMYA_TEST_CA_CERT", string(serverCA))
+
+	check := func(t *testing.T, monitor main.Monitor) main.Response {
+		t.Helper()
+
+		monitor.UniqueID = "mtls-test"
+		monitor.Name = "mTLS Test"
+		monitor.Type = main.MonitorTypeHTTP
+		monitor.HttpEndpoint = server.URL
+		monitor.Timeout = 5
+
+		worker, err := main.NewWorker(monitor, nil)
+		if err != nil {
+			t.Fatalf("unexpected error creating worker: %v", err)
+		}
+
+		response, err := worker.Check(context.Background())
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		return response
+	}
+
+	tests := []struct {
+		name    string
+		monitor main.Monitor
+		success bool
+	}{
+		{name: "with client certificate", monitor: main.Monitor{TlsClientCert: certPath, TlsClientKey: keyPath, TlsCaCert: caPath}, success: true},
+		{name: "with certificate authority from environment", monitor: main.Monitor{TlsClientCert: certPath, TlsClientKey: keyPath, TlsCaCert: "${SEMYA_TEST_CA_CERT}"}, success: true},
+		{name: "without client certificate", monitor: main.Monitor{TlsCaCert: caPath}, success: false},
+		{name: "without certificate authority", monitor: main.Monitor{TlsClientCert: certPath, TlsClientKey: keyPath}, success: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			response := check(t, tt.monitor)
+			if response.Success != tt.success {
+				t.Errorf("expected success to be %v, got %v (%s)", tt.success, response.Success, response.Error)
+			}
+
+			if !tt.success && response.ErrorCategory != main.ErrorCategoryTlsError {
+				t.Errorf("expected error category %q, got %q", main.ErrorCategoryTlsError, response.ErrorCategory)
+			}
+		})
+	}
+
+	t.Run("reloads changed certificate", func(t *testing.T) {
+		rotatedCertPath := filepath.Join(dir, "rotated.pem")
+		rotatedKeyPath := filepath.Join(dir, "rotated-key.pem")
+		writeFile(t, rotatedCertPath, untrustedCert)
+		writeFile(t, rotatedKeyPath, untrustedKey)
+
+		worker, err := main.NewWorker(main.Monitor{
+			UniqueID:      "mtls-reload-test",
+			Name:          "mTLS Reload Test",
+			Type:          main.MonitorTypeHTTP,
+			HttpEndpoint:  server.URL,
+			Timeout:       5,
+			TlsClientCert: rotatedCertPath,
+			TlsClientKey:  rotatedKeyPath,
+			TlsCaCert:     caPath,
+		}, nil)
+		if err != nil {
+			t.Fatalf("unexpected error creating worker: %v", err)
+		}
+
+		response, err := worker.Check(context.Background())
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if response.Success {
+			t.Fatal("expected the untrusted certificate to be rejected")
+		}
+
+		writeFile(t, rotatedCertPath, trustedCert)
+		writeFile(t, rotatedKeyPath, trustedKey)
+		// The modification time might not have changed within the resolution of the file system
+		modTime := time.Now().Add(time.Minute)
+		for _, path := range []string{rotatedCertPath, rotatedKeyPath} {
+			if err := os.Chtimes(path, modTime, modTime); err != nil {
+				t.Fatalf("unexpected error changing modification time: %v", err)
+			}
+		}
+
+		response, err = worker.Check(context.Background())
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if !response.Success {
+			t.Errorf("expected the rotated certificate to be presented, got %s", response.Error)
+		}
+	})
+
+	t.Run("rejects missing key", func(t *testing.T) {
+		monitor := main.Monitor{
+			UniqueID:      "mtls-validate-test",
+			Name:          "mTLS Validate Test",
+			Type:          main.MonitorTypeHTTP,
+			HttpEndpoint:  server.URL,
+			TlsClientCert: certPath,
+		}
+
+		if valid, _ := monitor.Validate(); valid {
+			t.Error("expected a client_cert without client_key to be invalid")
+		}
+	})
+}
+
+func TestWorker_InsecureSkipVerify(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name               string
+		insecureSkipVerify bool
+		success            bool
+	}{
+		{name: "verifies by default", insecureSkipVerify: false, success: false},
+		{name: "skips verification", insecureSkipVerify: true, success: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			worker, err := main.NewWorker(main.Monitor{
+				UniqueID:              "insecure-skip-verify-test",
+				Name:                  "Insecure Skip Verify Test",
+				Type:                  main.MonitorTypeHTTP,
+				HttpEndpoint:          server.URL,
+				Timeout:               5,
+				TlsInsecureSkipVerify: tt.insecureSkipVerify,
+			}, nil)
+			if err != nil {
+				t.Fatalf("unexpected error creating worker: %v", err)
+			}
+
+			response, err := worker.Check(context.Background())
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if response.Success != tt.success {
+				t.Errorf("expected success to be %v, got %v (%s)", tt.success, response.Success, response.Error)
+			}
+		})
+	}
+
+	// Other monitors still verify the certificate of the same server
+	worker, err := main.NewWorker(main.Monitor{
+		UniqueID:     "insecure-skip-verify-other-test",
+		Name:         "Insecure Skip Verify Other Test",
+		Type:         main.MonitorTypeHTTP,
+		HttpEndpoint: server.URL,
+		Timeout:      5,
+	}, nil)
+	if err != nil {
+		t.Fatalf("unexpected error creating worker: %v", err)
+	}
+
+	response, err := worker.Check(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if response.Success || response.ErrorCategory != main.ErrorCategoryTlsError {
+		t.Errorf("expected the other monitor to fail with a tls error, got %+v", response)
+	}
+}
+
+func TestWorker_TlsVersions(t *testing.T) {
+	// pinnedServer starts a server that only accepts the TLS versions from minVersion to maxVersion
+	pinnedServer := func(minVersion, maxVersion uint16) *httptest.Server {
+		server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		}))
+		server.TLS = &tls.Config{MinVersion: minVersion, MaxVersion: maxVersion}
+		server.StartTLS()
+		return server
+	}
+
+	legacy := pinnedServer(tls.VersionTLS10, tls.VersionTLS11)
+	defer legacy.Close()
+	tls10 := pinnedServer(tls.VersionTLS10, tls.VersionTLS10)
+	defer tls10.Close()
+	tls12 := pinnedServer(tls.VersionTLS12, tls.VersionTLS12)
+	defer tls12.Close()
+	tls13 := pinnedServer(tls.VersionTLS13, tls.VersionTLS13)
+	defer tls13.Close()
+	modern := pinnedServer(tls.VersionTLS10, tls.VersionTLS13)
+	defer modern.Close()
+
+	tests := []struct {
+		name       string
+		server     *httptest.Server
+		minVersion main.TlsVersion
+		maxVersion main.TlsVersion
+		success    bool
+		negotiated string
+	}{
+		{name: "legacy server is rejected", server: legacy, minVersion: main.TlsVersion12, success: false},
+		{name: "tls 1.2 is accepted", server: tls12, minVersion: main.TlsVersion12, success: true, negotiated: "TLS 1.2"},
+		{name: "tls 1.3 is accepted", server: tls13, minVersion: main.TlsVersion12, success: true, negotiated: "TLS 1.3"},
+		{name: "tls 1.3 above the maximum", server: tls13, maxVersion: main.TlsVersion12, success: false},
+		{name: "maximum is negotiated", server: modern, minVersion: main.TlsVersion11, maxVersion: main.TlsVersion11, success: true, negotiated: "TLS 1.1"},
+		{name: "minimum follows a lower maximum", server: tls10, maxVersion: main.TlsVersion10, success: true, negotiated: "TLS 1.0"},
+		{name: "default range", server: modern, success: true, negotiated: "TLS 1.3"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			worker, err := main.NewWorker(main.Monitor{
+				UniqueID:              "tls-versions-test",
+				Name:                  "TLS Versions Test",
+				Type:                  main.MonitorTypeHTTP,
+				HttpEndpoint:          tt.server.URL,
+				Timeout:               5,
+				TlsInsecureSkipVerify: true,
+				TlsMinVersion:         tt.minVersion,
+				TlsMaxVersion:         tt.maxVersion,
+			}, nil)
+			if err != nil {
+				t.Fatalf("unexpected error creating worker: %v", err)
+			}
+
+			response, err := worker.Check(context.Background())
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if response.Success != tt.success {
+				t.Errorf("expected success to be %v, got %v (%s)", tt.success, response.Success, response.Error)
+			}
+
+			if !tt.success && response.ErrorCategory != main.ErrorCategoryTlsError {
+				t.Errorf("expected error category %q, got %q", main.ErrorCategoryTlsError, response.ErrorCategory)
+			}
+
+			if response.TlsVersion != tt.negotiated {
+				t.Errorf("expected the negotiated version to be %q, got %q", tt.negotiated, response.TlsVersion)
+			}
+		})
+	}
+}
+
+func TestMonitor_Validate_TlsVersions(t *testing.T) {
+	tests := []struct {
+		name        string
+		minVersion  main.TlsVersion
+		maxVersion  main.TlsVersion
+		httpVersion main.HttpVersion
+		valid       bool
+	}{
+		{name: "range", minVersion: main.TlsVersion12, maxVersion: main.TlsVersion13, valid: true},
+		{name: "unknown minimum", minVersion: "1.4", valid: false},
+		{name: "unknown maximum", maxVersion: "TLS 1.3", valid: false},
+		{name: "minimum above the maximum", minVersion: main.TlsVersion13, maxVersion: main.TlsVersion12, valid: false},
+		{name: "http/3 with tls 1.3", maxVersion: main.TlsVersion13, httpVersion: main.HttpVersion3, valid: true},
+		{name: "http/3 below tls 1.3", maxVersion: main.TlsVersion12, httpVersion: main.HttpVersion3, valid: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			monitor := main.Monitor{
+				UniqueID:      "tls-versions-validate-test",
+				Name:          "TLS Versions Validate Test",
+				Type:          main.MonitorTypeHTTP,
+				HttpEndpoint:  "https://example.com",
+				HttpVersion:   tt.httpVersion,
+				TlsMinVersion: tt.minVersion,
+				TlsMaxVersion: tt.maxVersion,
+			}
+
+			valid, err := monitor.Validate()
+			if valid != tt.valid {
+				t.Errorf("expected valid to be %v, got %v (%v)", tt.valid, valid, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,318 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/cookiejar"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/ohler55/ojg/jp"
+	"github.com/rs/zerolog/log"
+)
+
+// TransactionStep is a single request of a transaction monitor. The url, the headers, and the body can refer
+// to the values extracted by the previous steps as {{name}}.
+type TransactionStep struct {
+	// Name specifies the name of the step in the results. Defaults to "step N".
+	Name string `json:"name" yaml:"name" toml:"name"`
+	// Method specifies the HTTP method of the request. Defaults to GET.
+	Method  string            `json:"method" yaml:"method" toml:"method"`
+	Url     string            `json:"url" yaml:"url" toml:"url"`
+	Headers map[string]string `json:"headers" yaml:"headers" toml:"headers"`
+	Body    string            `json:"body" yaml:"body" toml:"body"`
+	// ExpectedStatusCodes specifies the status codes of a successful step. Defaults to any 2xx or 3xx status code.
+	ExpectedStatusCodes []int `json:"expected_status_codes" yaml:"expected_status_codes" toml:"expected_status_codes"`
+	// Extract specifies the values that are extracted from the response body for the next steps. The step fails
+	// if any of them can't be extracted.
+	Extract []TransactionExtraction `json:"extract" yaml:"extract" toml:"extract"`
+}
+
+// TransactionExtraction extracts a value from the response body of a step, with either Regex or JsonPath.
+type TransactionExtraction struct {
+	// Name specifies the variable that the value is stored in, which the next steps refer to as {{name}}.
+	Name string `json:"name" yaml:"name" toml:"name"`
+	// Regex specifies a regular expression that is matched against the body. The value is the first capturing
+	// group, or the whole match if the expression has none.
+	Regex string `json:"regex" yaml:"regex" toml:"regex"`
+	// JsonPath specifies the JSONPath of the value in a JSON body, e.g. "$.data.token". Values that aren't
+	// strings are stored as their JSON encoding.
+	JsonPath string `json:"json_path" yaml:"json_path" toml:"json_path"`
+}
+
+// TransactionStepResult is the outcome of a step of a transaction check.
+type TransactionStepResult struct {
+	Name            string `json:"name"`
+	StatusCode      int    `json:"statusCode"`
+	RequestDuration int64  `json:"requestDuration"`
+	Error           string `json:"error,omitempty"`
+}
+
+var transactionVariablePattern = regexp.MustCompile(`\{\{\s*([A-Za-z_][A-Za-z0-9_]*)\s*\}\}`)
+
+// Validate checks that the step has a url and that its extractions can be compiled.
+func (s TransactionStep) Validate() error {
+	if s.Url == "" {
+		return fmt.Errorf("url is required")
+	}
+
+	_, err := compileTransactionExtractors(s.Extract)
+	return err
+}
+
+// variables returns the names of the variables that the step refers to.
+func (s TransactionStep) variables() []string {
+	sources := []string{s.Url, s.Body}
+	for _, value := range s.Headers {
+		sources = append(sources, value)
+	}
+
+	var names []string
+	for _, source := range sources {
+		for _, match := range transactionVariablePattern.FindAllStringSubmatch(source, -1) {
+			names = append(names, match[1])
+		}
+	}
+
+	return names
+}
+
+// validateTransactionSteps checks every step, and that the steps only refer to the variables extracted by
+// the steps before them.
+func validateTransactionSteps(steps []TransactionStep) error {
+	if len(steps) == 0 {
+		return fmt.Errorf("steps is required for transaction monitors")
+	}
+
+	extracted := make(map[string]bool)
+	for i, step := range steps {
+		if err := step.Validate(); err != nil {
+			return fmt.Errorf("invalid steps[%d]: %w", i, err)
+		}
+
+		for _, name := range step.variables() {
+			if !extracted[name] {
+				return fmt.Errorf("invalid steps[%d]: {{%s}} is not extracted by a previous step", i, name)
+			}
+		}
+
+		for _, extraction := range step.Extract {
+			extracted[extraction.Name] = true
+		}
+	}
+
+	return nil
+}
+
+// transactionExtractor is a compiled TransactionExtraction.
+type transactionExtractor struct {
+	name    string
+	pattern *regexp.Regexp
+	path    jp.Expr
+}
+
+func compileTransactionExtractors(extractions []TransactionExtraction) ([]transactionExtractor, error) {
+	extractors := make([]transactionExtractor, 0, len(extractions))
+	for i, extraction := range extractions {
+		if extraction.Name == "" {
+			return nil, fmt.Errorf("extract[%d]: name is required", i)
+		}
+
+		if (extraction.Regex == "") == (extraction.JsonPath == "") {
+			return nil, fmt.Errorf("extract[%d]: either regex or json_path is required", i)
+		}
+
+		extractor := transactionExtractor{name: extraction.Name}
+		if extraction.Regex != "" {
+			pattern, err := regexp.Compile(extraction.Regex)
+			if err != nil {
+				return nil, fmt.Errorf("extract[%d]: invalid regex: %w", i, err)
+			}
+
+			extractor.pattern = pattern
+		} else {
+			path, err := jp.ParseString(extraction.JsonPath)
+			if err != nil {
+				return nil, fmt.Errorf("extract[%d]: invalid json_path: %w", i, err)
+			}
+
+			extractor.path = path
+		}
+
+		extractors = append(extractors, extractor)
+	}
+
+	return extractors, nil
+}
+
+// extract returns the value of the extractor in the body.
+func (e transactionExtractor) extract(body []byte, document func() (any, error)) (string, error) {
+	if e.pattern != nil {
+		match := e.pattern.FindSubmatch(body)
+		if match == nil {
+			return "", fmt.Errorf("failed to extract %s: the body doesn't match the regex", e.name)
+		}
+
+		if len(match) > 1 {
+			return string(match[1]), nil
+		}
+
+		return string(match[0]), nil
+	}
+
+	data, err := document()
+	if err != nil {
+		return "", fmt.Errorf("failed to extract %s: the body is not valid json", e.name)
+	}
+
+	results := e.path.Get(data)
+	if len(results) == 0 || results[0] == nil {
+		return "", fmt.Errorf("failed to extract %s: no value at %s", e.name, e.path)
+	}
+
+	if value, ok := results[0].(string); ok {
+		return value, nil
+	}
+
+	value, err := json.Marshal(results[0])
+	if err != nil {
+		return "", fmt.Errorf("failed to extract %s: %w", e.name, err)
+	}
+
+	return string(value), nil
+}
+
+// makeTransactionRequest runs the steps of a transaction monitor in order, stopping at the first failed step.
+// The monitor is only up when every step succeeds. The cookies set by a step are sent on the next steps, so
+// session based logins work as well.
+func (w *Worker) makeTransactionRequest(ctx context.Context) Response {
+	jar, _ := cookiejar.New(nil)
+	client := *w.httpClient
+	client.Jar = jar
+
+	timeStart := time.Now()
+	response := Response{Success: true, Monitor: w.monitor}
+	variables := make(map[string]string)
+	for i := range w.monitor.TransactionSteps {
+		result, category := w.makeTransactionStep(ctx, &client, i, variables)
+		response.Steps = append(response.Steps, result)
+		response.StatusCode = result.StatusCode
+		if result.Error != "" {
+			response.Success = false
+			response.Error = fmt.Sprintf("step %q failed: %s", result.Name, result.Error)
+			response.ErrorCategory = category
+			break
+		}
+	}
+
+	response.RequestDuration = time.Since(timeStart).Milliseconds()
+	response.Timestamp = time.Now()
+	return response
+}
+
+// makeTransactionStep makes the request of the step, and stores its extracted values into the variables.
+// The error of the result is set when the step failed, along with its category.
+func (w *Worker) makeTransactionStep(ctx context.Context, client *http.Client, index int, variables map[string]string) (TransactionStepResult, ErrorCategory) {
+	step := w.monitor.TransactionSteps[index]
+	result := TransactionStepResult{Name: step.Name}
+	if result.Name == "" {
+		result.Name = fmt.Sprintf("step %d", index+1)
+	}
+
+	expand := func(value string) string {
+		return transactionVariablePattern.ReplaceAllStringFunc(value, func(match string) string {
+			return variables[transactionVariablePattern.FindStringSubmatch(match)[1]]
+		})
+	}
+
+	var body io.Reader
+	if step.Body != "" {
+		body = strings.NewReader(expand(step.Body))
+	}
+
+	method := step.Method
+	if method == "" {
+		method = http.MethodGet
+	}
+
+	timeStart := time.Now()
+	req, err := http.NewRequestWithContext(ctx, method, expand(step.Url), body)
+	if err != nil {
+		result.Error = fmt.Sprintf("failed to create request: %s", err.Error())
+		return result, ErrorCategoryUnknown
+	}
+
+	req.Header.Set("User-Agent", w.userAgent())
+	for key, value := range step.Headers {
+		req.Header.Set(key, expand(value))
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		result.RequestDuration = time.Since(timeStart).Milliseconds()
+		category := ClassifyError(err)
+		result.Error = err.Error()
+		if category == ErrorCategoryTimeout {
+			result.Error = ResponseErrorTimeout
+		}
+
+		return result, category
+	}
+	defer func() {
+		// The connection can only be reused once the body is fully read
+		_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, maxResponseBodySize))
+
+		err := resp.Body.Close()
+		if err != nil {
+			log.Warn().Err(err).Msg("failed to close response body")
+		}
+	}()
+
+	result.StatusCode = resp.StatusCode
+	responseBody, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseBodySize))
+	result.RequestDuration = time.Since(timeStart).Milliseconds()
+	if err != nil {
+		result.Error = fmt.Sprintf("failed to read response body: %s", err.Error())
+		return result, ClassifyError(err)
+	}
+
+	expected := resp.StatusCode >= 200 && resp.StatusCode < 400
+	if len(step.ExpectedStatusCodes) > 0 {
+		expected = slices.Contains(step.ExpectedStatusCodes, resp.StatusCode)
+	}
+
+	if !expected {
+		result.Error = fmt.Sprintf("unexpected status code %d", resp.StatusCode)
+		return result, ErrorCategoryHttpStatus
+	}
+
+	// The body is only decoded once, and only if a json_path needs it
+	var document any
+	var documentErr error
+	decoded := false
+	decode := func() (any, error) {
+		if !decoded {
+			decoded = true
+			documentErr = json.Unmarshal(responseBody, &document)
+		}
+
+		return document, documentErr
+	}
+
+	for _, extractor := range w.transactionExtractors[index] {
+		value, err := extractor.extract(responseBody, decode)
+		if err != nil {
+			result.Error = err.Error()
+			return result, ErrorCategoryBodyMismatch
+		}
+
+		variables[extractor.name] = value
+	}
+
+	return result, ErrorCategoryNone
+}
@@ -0,0 +1,218 @@
+package main_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	main "semyi"
+)
+
+func TestWorker_Transaction(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/login", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"data": {"token": "secret-token", "expires_in": 3600}}`))
+	})
+	mux.HandleFunc("/me", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"user": "semya"}`))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	newWorker := func(t *testing.T, me main.TransactionStep) *main.Worker {
+		worker, err := main.NewWorker(main.Monitor{
+			UniqueID: "transaction-test",
+			Name:     "Transaction Test",
+			Type:     main.MonitorTypeTransaction,
+			TransactionSteps: []main.TransactionStep{
+				{
+					Name:   "login",
+					Method: http.MethodPost,
+					Url:    server.URL + "/login",
+					Body:   `{"username": "semya"}`,
+					Extract: []main.TransactionExtraction{
+						{Name: "token", JsonPath: "$.data.token"},
+						{Name: "expires_in", Regex: `"expires_in": (\d+)`},
+					},
+				},
+				me,
+			},
+			Timeout: 5,
+		}, nil)
+		if err != nil {
+			t.Fatalf("unexpected error creating worker: %v", err)
+		}
+
+		return worker
+	}
+
+	t.Run("passes", func(t *testing.T) {
+		worker := newWorker(t, main.TransactionStep{
+			Name:    "me",
+			Url:     server.URL + "/me?expires_in={{expires_in}}",
+			Headers: map[string]string{"Authorization": "Bearer {{token}}"},
+		})
+
+		response, err := worker.Check(context.Background())
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if !response.Success {
+			t.Fatalf("expected the transaction to succeed, got %s", response.Error)
+		}
+
+		if len(response.Steps) != 2 {
+			t.Fatalf("expected 2 steps, got %d", len(response.Steps))
+		}
+
+		var total int64
+		for i, name := range []string{"login", "me"} {
+			step := response.Steps[i]
+			if step.Name != name || step.StatusCode != http.StatusOK || step.Error != "" {
+				t.Errorf("expected step %s to succeed, got %+v", name, step)
+			}
+
+			total += step.RequestDuration
+		}
+
+		if response.RequestDuration < total {
+			t.Errorf("expected the total latency to be at least %dms, got %dms", total, response.RequestDuration)
+		}
+	})
+
+	t.Run("second step fails", func(t *testing.T) {
+		// The token is not sent, so the second step is unauthorized
+		worker := newWorker(t, main.TransactionStep{
+			Name: "me",
+			Url:  server.URL + "/me",
+		})
+
+		response, err := worker.Check(context.Background())
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if response.Success {
+			t.Fatal("expected the transaction to fail")
+		}
+
+		if len(response.Steps) != 2 {
+			t.Fatalf("expected 2 steps, got %d", len(response.Steps))
+		}
+
+		if response.Steps[0].Error != "" {
+			t.Errorf("expected the first step to succeed, got %s", response.Steps[0].Error)
+		}
+
+		if response.Steps[1].StatusCode != http.StatusUnauthorized {
+			t.Errorf("expected status code %d, got %d", http.StatusUnauthorized, response.Steps[1].StatusCode)
+		}
+
+		if !strings.Contains(response.Error, `step "me" failed`) {
+			t.Errorf("expected the error to name the failed step, got %q", response.Error)
+		}
+
+		if response.ErrorCategory != main.ErrorCategoryHttpStatus {
+			t.Errorf("expected error category %q, got %q", main.ErrorCategoryHttpStatus, response.ErrorCategory)
+		}
+	})
+
+	t.Run("extraction fails", func(t *testing.T) {
+		worker, err := main.NewWorker(main.Monitor{
+			UniqueID: "transaction-extract-test",
+			Name:     "Transaction Extract Test",
+			Type:     main.MonitorTypeTransaction,
+			TransactionSteps: []main.TransactionStep{
+				{Method: http.MethodPost, Url: server.URL + "/login", Extract: []main.TransactionExtraction{{Name: "token", JsonPath: "$.data.session"}}},
+				{Url: server.URL + "/me", Headers: map[string]string{"Authorization": "Bearer {{token}}"}},
+			},
+			Timeout: 5,
+		}, nil)
+		if err != nil {
+			t.Fatalf("unexpected error creating worker: %v", err)
+		}
+
+		response, err := worker.Check(context.Background())
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if response.Success || len(response.Steps) != 1 {
+			t.Fatalf("expected the transaction to stop at the first step, got %+v", response.Steps)
+		}
+
+		if response.ErrorCategory != main.ErrorCategoryBodyMismatch {
+			t.Errorf("expected error category %q, got %q", main.ErrorCategoryBodyMismatch, response.ErrorCategory)
+		}
+	})
+}
+
+func TestMonitor_Validate_Transaction(t *testing.T) {
+	tests := []struct {
+		name  string
+		steps []main.TransactionStep
+		valid bool
+	}{
+		{
+			name: "valid",
+			steps: []main.TransactionStep{
+				{Url: "https://example.com/login", Extract: []main.TransactionExtraction{{Name: "token", JsonPath: "$.token"}}},
+				{Url: "https://example.com/me", Headers: map[string]string{"Authorization": "Bearer {{token}}"}},
+			},
+			valid: true,
+		},
+		{name: "without steps", valid: false},
+		{name: "without url", steps: []main.TransactionStep{{Name: "login"}}, valid: false},
+		{
+			name: "undefined variable",
+			steps: []main.TransactionStep{
+				{Url: "https://example.com/me", Headers: map[string]string{"Authorization": "Bearer {{token}}"}},
+				{Url: "https://example.com/login", Extract: []main.TransactionExtraction{{Name: "token", JsonPath: "$.token"}}},
+			},
+			valid: false,
+		},
+		{
+			name:  "both regex and json_path",
+			steps: []main.TransactionStep{{Url: "https://example.com", Extract: []main.TransactionExtraction{{Name: "token", JsonPath: "$.token", Regex: "token"}}}},
+			valid: false,
+		},
+		{
+			name:  "invalid json_path",
+			steps: []main.TransactionStep{{Url: "https://example.com", Extract: []main.TransactionExtraction{{Name: "token", JsonPath: "$.[token"}}}},
+			valid: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			monitor := main.Monitor{
+				UniqueID:         "transaction-validate-test",
+				Name:             "Transaction Validate Test",
+				Type:             main.MonitorTypeTransaction,
+				TransactionSteps: tt.steps,
+			}
+
+			valid, err := monitor.Validate()
+			if valid != tt.valid {
+				t.Errorf("expected valid to be %v, got %v (%v)", tt.valid, valid, err)
+			}
+		})
+	}
+}
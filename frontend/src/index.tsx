@@ -10,7 +10,7 @@ import { Route, Router } from "@solidjs/router";
 
 render(
   () => (
-    <Router>
+    <Router base={import.meta.env.BASE_URL.replace(/\/$/, "")}>
       <Route path="/" component={OverviewPage} />
       <Route path="/by" component={DetailPage} />
     </Router>
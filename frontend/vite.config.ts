@@ -6,6 +6,8 @@ export default ({ mode }: { mode: string }) => {
   const env = loadEnv(mode, import.meta.url, "");
 
   return defineConfig({
+    // Assets are requested under the BASE_PATH of the backend, e.g. "/status/"
+    base: env.VITE_BASE_PATH ? env.VITE_BASE_PATH.replace(/\/?$/, "/") : "/",
     plugins: [solidPlugin()],
     resolve: {
       alias: {
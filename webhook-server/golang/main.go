@@ -1,9 +1,15 @@
 package main
 
 import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"io"
 	"log"
 	"net/http"
+	"os"
 )
 
 type WebhookRequest struct {
@@ -23,8 +29,26 @@ func main() {
 			return
 		}
 
+		raw, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		// Verify the signature if a secret is configured
+		if secret := os.Getenv("WEBHOOK_SECRET"); secret != "" {
+			mac := hmac.New(sha256.New, []byte(secret))
+			mac.Write(raw)
+			expected := hex.EncodeToString(mac.Sum(nil))
+
+			if !hmac.Equal([]byte(expected), []byte(r.Header.Get("X-Semya-Signature"))) {
+				http.Error(w, "invalid signature", http.StatusUnauthorized)
+				return
+			}
+		}
+
 		var body WebhookRequest
-		err := json.NewDecoder(r.Body).Decode(&body)
+		err = json.NewDecoder(bytes.NewReader(raw)).Decode(&body)
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusBadRequest)
 		}